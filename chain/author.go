@@ -0,0 +1,127 @@
+package chain
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"submarine/crypto"
+	"submarine/rpc"
+	"submarine/scale"
+)
+
+// ErrNoSlotClaim is returned for blocks without a BABE or AURA pre-runtime digest, like the genesis block.
+var ErrNoSlotClaim = errors.New("no BABE or AURA pre-runtime digest")
+
+// SlotClaim is the slot a block was authored in, as claimed by its pre-runtime digest.
+type SlotClaim struct {
+	Engine ConsensusEngineId
+	Slot   uint64
+	// Babe is set for BABE blocks.
+	Babe *BabePreDigest
+}
+
+// FindSlotClaim returns the slot claim of a block from its digest.
+func FindSlotClaim(items []DigestItem) (*SlotClaim, error) {
+	if data, ok := FindPreRuntime(items, EngineBabe); ok {
+		digest, err := DecodeBabePreDigest(data)
+		if err != nil {
+			return nil, err
+		}
+		return &SlotClaim{Engine: EngineBabe, Slot: digest.Slot, Babe: digest}, nil
+	}
+	if data, ok := FindPreRuntime(items, EngineAura); ok {
+		slot, err := DecodeAuraPreDigest(data)
+		if err != nil {
+			return nil, err
+		}
+		return &SlotClaim{Engine: EngineAura, Slot: slot}, nil
+	}
+	return nil, ErrNoSlotClaim
+}
+
+// AuthorIndex returns the index of the block author in the validator set: the authority index of
+// the BABE pre-digest, or the slot modulo the number of validators for AURA's round robin.
+func (c *SlotClaim) AuthorIndex(validatorCount int) (int, error) {
+	if validatorCount == 0 {
+		return 0, errors.New("empty validator set")
+	}
+
+	var index int
+	if c.Babe != nil {
+		index = int(c.Babe.AuthorityIndex)
+	} else {
+		index = int(c.Slot % uint64(validatorCount))
+	}
+	if index >= validatorCount {
+		return 0, fmt.Errorf("authority index %d out of range for %d validators", index, validatorCount)
+	}
+	return index, nil
+}
+
+// BlockAuthor returns the account of the validator that authored a block, given the block's digest
+// and the session validators it was authored by, those at its parent block.
+func BlockAuthor(items []DigestItem, validators [][32]byte) ([32]byte, error) {
+	claim, err := FindSlotClaim(items)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	index, err := claim.AuthorIndex(len(validators))
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return validators[index], nil
+}
+
+// SessionValidatorsKey is the storage key of Session.Validators.
+var SessionValidatorsKey = "0x" + hex.EncodeToString(crypto.StoragePrefix("Session", "Validators"))
+
+// FetchValidators returns the session validators at the given block.
+func FetchValidators(client *rpc.RPC, blockHash string) ([][32]byte, error) {
+	value, err := client.GetStorage(SessionValidatorsKey, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, fmt.Errorf("no session validators at block %s", blockHash)
+	}
+
+	r := scale.NewReader(value)
+	validators, err := scale.DecodeVec(r, 32, func(r *scale.Reader) ([32]byte, error) {
+		account, err := r.ReadBytes(32)
+		if err != nil {
+			return [32]byte{}, err
+		}
+		return [32]byte(account), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("decode session validators: %w", err)
+	}
+	return validators, nil
+}
+
+// FetchBlockAuthor returns the author of the block with the given hash. The validators are read at
+// the parent block: the first block of a session already holds the validators of the next one in
+// its state, but was authored by one of the session before.
+func FetchBlockAuthor(client *rpc.RPC, blockHash string) ([32]byte, error) {
+	header, err := client.GetHeader(blockHash)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	items, err := DecodeDigestLogs(header.Digest.Logs)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	claim, err := FindSlotClaim(items)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	validators, err := FetchValidators(client, header.ParentHash)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	index, err := claim.AuthorIndex(len(validators))
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return validators[index], nil
+}
@@ -0,0 +1,188 @@
+package chain
+
+import (
+	"fmt"
+	"submarine/scale"
+)
+
+// BabePreDigestKind is the variant index of a BABE PreDigest.
+type BabePreDigestKind int
+
+const (
+	KindBabePrimary        BabePreDigestKind = 1
+	KindBabeSecondaryPlain BabePreDigestKind = 2
+	KindBabeSecondaryVRF   BabePreDigestKind = 3
+)
+
+// BabePreDigest is the PreRuntime payload of BABE, claiming a slot for the block author.
+type BabePreDigest struct {
+	Kind           BabePreDigestKind
+	AuthorityIndex uint32
+	Slot           uint64
+	// VRF output and proof, for primary and secondary VRF slots.
+	VrfPreOutput *[32]byte
+	VrfProof     *[64]byte
+}
+
+// IsPrimary reports whether the author won the slot through the VRF lottery, rather than
+// being assigned it as a secondary author.
+func (d *BabePreDigest) IsPrimary() bool {
+	return d.Kind == KindBabePrimary
+}
+
+func DecodeBabePreDigest(data []byte) (*BabePreDigest, error) {
+	r := scale.NewReader(data)
+	variant, err := r.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read BABE PreDigest variant: %w", err)
+	}
+
+	digest := BabePreDigest{Kind: BabePreDigestKind(variant)}
+	switch digest.Kind {
+	case KindBabePrimary, KindBabeSecondaryPlain, KindBabeSecondaryVRF:
+	default:
+		return nil, fmt.Errorf("unknown BABE PreDigest variant %d", variant)
+	}
+
+	if digest.AuthorityIndex, err = scale.DecodeU32(r); err != nil {
+		return nil, fmt.Errorf("failed to decode BABE authority index: %w", err)
+	}
+	if digest.Slot, err = scale.DecodeU64(r); err != nil {
+		return nil, fmt.Errorf("failed to decode BABE slot: %w", err)
+	}
+
+	if digest.Kind != KindBabeSecondaryPlain {
+		output, err := r.ReadBytes(32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode BABE VRF output: %w", err)
+		}
+		proof, err := r.ReadBytes(64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode BABE VRF proof: %w", err)
+		}
+		digest.VrfPreOutput = (*[32]byte)(output)
+		digest.VrfProof = (*[64]byte)(proof)
+	}
+
+	if r.Pos() != len(data) {
+		return nil, fmt.Errorf("BABE PreDigest: %d trailing bytes", len(data)-r.Pos())
+	}
+	return &digest, nil
+}
+
+// DecodeAuraPreDigest decodes the PreRuntime payload of AURA, which is the slot of the block.
+func DecodeAuraPreDigest(data []byte) (uint64, error) {
+	r := scale.NewReader(data)
+	slot, err := scale.DecodeU64(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode AURA slot: %w", err)
+	}
+	if r.Pos() != len(data) {
+		return 0, fmt.Errorf("AURA PreDigest: %d trailing bytes", len(data)-r.Pos())
+	}
+	return slot, nil
+}
+
+// GrandpaAuthority is a GRANDPA voter with its ed25519 public key and voting weight.
+type GrandpaAuthority struct {
+	PublicKey [32]byte
+	Weight    uint64
+}
+
+func DecodeGrandpaAuthority(r *scale.Reader) (GrandpaAuthority, error) {
+	var authority GrandpaAuthority
+	key, err := r.ReadBytes(32)
+	if err != nil {
+		return authority, fmt.Errorf("failed to decode GRANDPA authority key: %w", err)
+	}
+	authority.PublicKey = [32]byte(key)
+	if authority.Weight, err = scale.DecodeU64(r); err != nil {
+		return authority, fmt.Errorf("failed to decode GRANDPA authority weight: %w", err)
+	}
+	return authority, nil
+}
+
+// GrandpaScheduledChange announces the next GRANDPA authority set, enacted Delay blocks
+// after the block carrying it.
+type GrandpaScheduledChange struct {
+	NextAuthorities []GrandpaAuthority
+	Delay           uint32
+}
+
+func DecodeGrandpaScheduledChange(r *scale.Reader) (GrandpaScheduledChange, error) {
+	var change GrandpaScheduledChange
+	var err error
+	if change.NextAuthorities, err = scale.DecodeVec(r, 40, DecodeGrandpaAuthority); err != nil {
+		return change, fmt.Errorf("failed to decode next authorities: %w", err)
+	}
+	if change.Delay, err = scale.DecodeU32(r); err != nil {
+		return change, fmt.Errorf("failed to decode delay: %w", err)
+	}
+	return change, nil
+}
+
+// GrandpaLogKind is the variant index of a GRANDPA ConsensusLog.
+type GrandpaLogKind int
+
+const (
+	KindGrandpaScheduledChange GrandpaLogKind = 1
+	KindGrandpaForcedChange    GrandpaLogKind = 2
+	KindGrandpaOnDisabled      GrandpaLogKind = 3
+	KindGrandpaPause           GrandpaLogKind = 4
+	KindGrandpaResume          GrandpaLogKind = 5
+)
+
+// GrandpaConsensusLog is the Consensus payload of GRANDPA.
+type GrandpaConsensusLog struct {
+	Kind GrandpaLogKind
+	// Change is set for ScheduledChange and ForcedChange.
+	Change *GrandpaScheduledChange
+	// MedianLastFinalized is the block number a ForcedChange is based on.
+	MedianLastFinalized uint32
+	// DisabledAuthority is the index of the authority disabled by OnDisabled.
+	DisabledAuthority uint64
+	// Delay is the number of blocks until a Pause or Resume takes effect.
+	Delay uint32
+}
+
+func DecodeGrandpaConsensusLog(data []byte) (*GrandpaConsensusLog, error) {
+	r := scale.NewReader(data)
+	variant, err := r.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GRANDPA ConsensusLog variant: %w", err)
+	}
+
+	log := GrandpaConsensusLog{Kind: GrandpaLogKind(variant)}
+	switch log.Kind {
+	case KindGrandpaScheduledChange:
+		change, err := DecodeGrandpaScheduledChange(r)
+		if err != nil {
+			return nil, fmt.Errorf("GRANDPA ScheduledChange: %w", err)
+		}
+		log.Change = &change
+	case KindGrandpaForcedChange:
+		if log.MedianLastFinalized, err = scale.DecodeU32(r); err != nil {
+			return nil, fmt.Errorf("GRANDPA ForcedChange: failed to decode median last finalized: %w", err)
+		}
+		change, err := DecodeGrandpaScheduledChange(r)
+		if err != nil {
+			return nil, fmt.Errorf("GRANDPA ForcedChange: %w", err)
+		}
+		log.Change = &change
+	case KindGrandpaOnDisabled:
+		if log.DisabledAuthority, err = scale.DecodeU64(r); err != nil {
+			return nil, fmt.Errorf("GRANDPA OnDisabled: %w", err)
+		}
+	case KindGrandpaPause, KindGrandpaResume:
+		if log.Delay, err = scale.DecodeU32(r); err != nil {
+			return nil, fmt.Errorf("GRANDPA Pause/Resume: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown GRANDPA ConsensusLog variant %d", variant)
+	}
+
+	if r.Pos() != len(data) {
+		return nil, fmt.Errorf("GRANDPA ConsensusLog: %d trailing bytes", len(data)-r.Pos())
+	}
+	return &log, nil
+}
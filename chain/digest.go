@@ -0,0 +1,117 @@
+// Package chain decodes block-level structures: header digests, consensus payloads and block authorship.
+package chain
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"submarine/scale"
+)
+
+// ConsensusEngineId identifies the consensus engine a digest item belongs to, e.g. "BABE".
+type ConsensusEngineId [4]byte
+
+var (
+	EngineBabe    = ConsensusEngineId{'B', 'A', 'B', 'E'}
+	EngineAura    = ConsensusEngineId{'a', 'u', 'r', 'a'}
+	EngineGrandpa = ConsensusEngineId{'F', 'R', 'N', 'K'}
+	EngineBeefy   = ConsensusEngineId{'B', 'E', 'E', 'F'}
+)
+
+func (id ConsensusEngineId) String() string {
+	return string(id[:])
+}
+
+// DigestItemKind is the variant index of a sp_runtime::generic::DigestItem.
+type DigestItemKind int
+
+const (
+	KindDigestOther                     DigestItemKind = 0
+	KindDigestConsensus                 DigestItemKind = 4
+	KindDigestSeal                      DigestItemKind = 5
+	KindDigestPreRuntime                DigestItemKind = 6
+	KindDigestRuntimeEnvironmentUpdated DigestItemKind = 8
+)
+
+func (k DigestItemKind) String() string {
+	switch k {
+	case KindDigestOther:
+		return "Other"
+	case KindDigestConsensus:
+		return "Consensus"
+	case KindDigestSeal:
+		return "Seal"
+	case KindDigestPreRuntime:
+		return "PreRuntime"
+	case KindDigestRuntimeEnvironmentUpdated:
+		return "RuntimeEnvironmentUpdated"
+	default:
+		return fmt.Sprintf("DigestItemKind(%d)", int(k))
+	}
+}
+
+// DigestItem is a log entry of a block header digest.
+type DigestItem struct {
+	Kind DigestItemKind
+	// Engine is set for PreRuntime, Consensus and Seal items.
+	Engine ConsensusEngineId
+	// Data is the engine-specific payload, or the opaque payload of Other.
+	Data []byte
+}
+
+func DecodeDigestItem(r *scale.Reader) (DigestItem, error) {
+	variant, err := r.ReadByte()
+	if err != nil {
+		return DigestItem{}, fmt.Errorf("failed to read DigestItem variant: %w", err)
+	}
+
+	item := DigestItem{Kind: DigestItemKind(variant)}
+	switch item.Kind {
+	case KindDigestPreRuntime, KindDigestConsensus, KindDigestSeal:
+		engine, err := r.ReadBytes(4)
+		if err != nil {
+			return DigestItem{}, fmt.Errorf("failed to decode %s engine id: %w", item.Kind, err)
+		}
+		item.Engine = ConsensusEngineId(engine)
+		if item.Data, err = scale.DecodeBytes(r); err != nil {
+			return DigestItem{}, fmt.Errorf("failed to decode %s data: %w", item.Kind, err)
+		}
+	case KindDigestOther:
+		if item.Data, err = scale.DecodeBytes(r); err != nil {
+			return DigestItem{}, fmt.Errorf("failed to decode Other data: %w", err)
+		}
+	case KindDigestRuntimeEnvironmentUpdated:
+	default:
+		return DigestItem{}, fmt.Errorf("unknown DigestItem variant %d", variant)
+	}
+	return item, nil
+}
+
+// DecodeDigestLogs decodes the hex-encoded digest logs of an rpc.BlockHeader.
+func DecodeDigestLogs(logs []string) ([]DigestItem, error) {
+	items := make([]DigestItem, len(logs))
+	for i, log := range logs {
+		data, err := hex.DecodeString(strings.TrimPrefix(log, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("digest log #%d: invalid hex: %w", i, err)
+		}
+		r := scale.NewReader(data)
+		if items[i], err = DecodeDigestItem(r); err != nil {
+			return nil, fmt.Errorf("digest log #%d: %w", i, err)
+		}
+		if r.Pos() != len(data) {
+			return nil, fmt.Errorf("digest log #%d: %d trailing bytes", i, len(data)-r.Pos())
+		}
+	}
+	return items, nil
+}
+
+// FindPreRuntime returns the data of the first PreRuntime item of the given engine.
+func FindPreRuntime(items []DigestItem, engine ConsensusEngineId) ([]byte, bool) {
+	for _, item := range items {
+		if item.Kind == KindDigestPreRuntime && item.Engine == engine {
+			return item.Data, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,225 @@
+package chain_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	. "submarine/chain"
+	"submarine/rpc"
+	"submarine/rpc/rpctest"
+	"testing"
+)
+
+// digestLog encodes a digest item with an engine id and a length-prefixed payload (< 64 bytes).
+func digestLog(kind byte, engine string, payload []byte) string {
+	out := append([]byte{kind}, engine...)
+	out = append(out, byte(len(payload)<<2))
+	return "0x" + hex.EncodeToString(append(out, payload...))
+}
+
+func babePreDigest(kind byte, authorityIndex uint32, slot uint64) []byte {
+	out := binary.LittleEndian.AppendUint32([]byte{kind}, authorityIndex)
+	out = binary.LittleEndian.AppendUint64(out, slot)
+	if kind != 2 {
+		out = append(out, bytes.Repeat([]byte{0x11}, 32)...)
+		out = append(out, bytes.Repeat([]byte{0x22}, 64)...)
+	}
+	return out
+}
+
+func TestDecodeDigestLogs(t *testing.T) {
+	seal := "0x05" + hex.EncodeToString([]byte("BABE")) + "0101" + hex.EncodeToString(bytes.Repeat([]byte{0x33}, 64))
+	logs := []string{
+		digestLog(6, "BABE", babePreDigest(2, 5, 300_000_000)),
+		"0x0008aabb",
+		"0x08",
+		seal,
+	}
+
+	items, err := DecodeDigestLogs(logs)
+	if err != nil {
+		t.Fatalf("decode logs: %v", err)
+	}
+
+	tests := []struct {
+		kind    DigestItemKind
+		engine  ConsensusEngineId
+		dataLen int
+	}{
+		{KindDigestPreRuntime, EngineBabe, 13},
+		{KindDigestOther, ConsensusEngineId{}, 2},
+		{KindDigestRuntimeEnvironmentUpdated, ConsensusEngineId{}, 0},
+		{KindDigestSeal, EngineBabe, 64},
+	}
+	if len(items) != len(tests) {
+		t.Fatalf("got %d items, want %d", len(items), len(tests))
+	}
+	for i, tt := range tests {
+		if items[i].Kind != tt.kind || items[i].Engine != tt.engine || len(items[i].Data) != tt.dataLen {
+			t.Errorf("item %d = %s %s (%d bytes), want %s %s (%d bytes)",
+				i, items[i].Kind, items[i].Engine, len(items[i].Data), tt.kind, tt.engine, tt.dataLen)
+		}
+	}
+
+	for _, invalid := range []string{"0x03", "0x06424142", "0x0008aa", "0x0800"} {
+		if _, err := DecodeDigestLogs([]string{invalid}); err == nil {
+			t.Errorf("expected an error for %s", invalid)
+		}
+	}
+}
+
+func TestDecodeBabePreDigest(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    BabePreDigestKind
+		primary bool
+		hasVrf  bool
+	}{
+		{"primary", KindBabePrimary, true, true},
+		{"secondary plain", KindBabeSecondaryPlain, false, false},
+		{"secondary vrf", KindBabeSecondaryVRF, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			digest, err := DecodeBabePreDigest(babePreDigest(byte(tt.kind), 7, 123456))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if digest.Kind != tt.kind || digest.AuthorityIndex != 7 || digest.Slot != 123456 || digest.IsPrimary() != tt.primary {
+				t.Errorf("got %+v", digest)
+			}
+			if (digest.VrfPreOutput != nil) != tt.hasVrf || (digest.VrfProof != nil) != tt.hasVrf {
+				t.Errorf("vrf output %v, proof %v, want present: %v", digest.VrfPreOutput, digest.VrfProof, tt.hasVrf)
+			}
+			if tt.hasVrf && (digest.VrfPreOutput[0] != 0x11 || digest.VrfProof[63] != 0x22) {
+				t.Errorf("vrf output %x, proof %x", digest.VrfPreOutput, digest.VrfProof)
+			}
+		})
+	}
+
+	if _, err := DecodeBabePreDigest(babePreDigest(4, 0, 0)); err == nil {
+		t.Error("expected an error for an unknown variant")
+	}
+	if _, err := DecodeBabePreDigest(babePreDigest(1, 0, 0)[:20]); err == nil {
+		t.Error("expected an error for a truncated primary pre-digest")
+	}
+}
+
+func TestDecodeGrandpaConsensusLog(t *testing.T) {
+	key := bytes.Repeat([]byte{0x44}, 32)
+	change := append([]byte{0x04}, key...)                // one authority
+	change = binary.LittleEndian.AppendUint64(change, 1)  // weight
+	change = binary.LittleEndian.AppendUint32(change, 10) // delay
+
+	scheduled, err := DecodeGrandpaConsensusLog(append([]byte{1}, change...))
+	if err != nil {
+		t.Fatalf("decode scheduled change: %v", err)
+	}
+	if scheduled.Kind != KindGrandpaScheduledChange || scheduled.Change == nil || scheduled.Change.Delay != 10 ||
+		len(scheduled.Change.NextAuthorities) != 1 || scheduled.Change.NextAuthorities[0].PublicKey != [32]byte(key) ||
+		scheduled.Change.NextAuthorities[0].Weight != 1 {
+		t.Errorf("scheduled change = %+v", scheduled)
+	}
+
+	forced, err := DecodeGrandpaConsensusLog(append(binary.LittleEndian.AppendUint32([]byte{2}, 99), change...))
+	if err != nil {
+		t.Fatalf("decode forced change: %v", err)
+	}
+	if forced.Kind != KindGrandpaForcedChange || forced.MedianLastFinalized != 99 || forced.Change == nil {
+		t.Errorf("forced change = %+v", forced)
+	}
+
+	disabled, err := DecodeGrandpaConsensusLog(binary.LittleEndian.AppendUint64([]byte{3}, 4))
+	if err != nil || disabled.DisabledAuthority != 4 {
+		t.Errorf("on disabled = %+v, %v", disabled, err)
+	}
+
+	pause, err := DecodeGrandpaConsensusLog(binary.LittleEndian.AppendUint32([]byte{4}, 5))
+	if err != nil || pause.Kind != KindGrandpaPause || pause.Delay != 5 {
+		t.Errorf("pause = %+v, %v", pause, err)
+	}
+}
+
+func TestBlockAuthor(t *testing.T) {
+	validators := [][32]byte{{0xa0}, {0xa1}, {0xa2}}
+	aura := binary.LittleEndian.AppendUint64(nil, 301)
+
+	tests := []struct {
+		name string
+		logs []string
+		want [32]byte
+		err  error
+	}{
+		{"babe", []string{digestLog(6, "BABE", babePreDigest(2, 1, 1000))}, validators[1], nil},
+		{"aura", []string{digestLog(6, "aura", aura)}, validators[301%3], nil},
+		{"no claim", []string{"0x08"}, [32]byte{}, ErrNoSlotClaim},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := DecodeDigestLogs(tt.logs)
+			if err != nil {
+				t.Fatalf("decode logs: %v", err)
+			}
+			author, err := BlockAuthor(items, validators)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if author != tt.want {
+				t.Errorf("author = %x, want %x", author, tt.want)
+			}
+		})
+	}
+
+	items, _ := DecodeDigestLogs([]string{digestLog(6, "BABE", babePreDigest(2, 3, 1000))})
+	if _, err := BlockAuthor(items, validators); err == nil {
+		t.Error("expected an error for an authority index out of range")
+	}
+}
+
+func TestFetchBlockAuthor(t *testing.T) {
+	const blockHash, parentHash = "0xb10c", "0x0a7e"
+	if SessionValidatorsKey != "0xcec5070d609dd3497f72bde07fc96ba088dcde934c658227ee1dfafcd6e16903" {
+		t.Fatalf("unexpected Session.Validators key %s", SessionValidatorsKey)
+	}
+
+	node := rpctest.NewNode()
+	defer node.Close()
+
+	var header rpc.BlockHeader
+	header.ParentHash = parentHash
+	header.Digest.Logs = []string{digestLog(6, "BABE", babePreDigest(2, 1, 1000))}
+	node.HandleResult("chain_getHeader", header)
+	// The block starts a new session: its state holds the validators of the new session, but it
+	// was authored by one of the session before, in the state of its parent.
+	validators := map[string]string{
+		parentHash: "0x08" + hex.EncodeToString(bytes.Repeat([]byte{0xa0}, 32)) + hex.EncodeToString(bytes.Repeat([]byte{0xa1}, 32)),
+		blockHash:  "0x08" + hex.EncodeToString(bytes.Repeat([]byte{0xb0}, 32)) + hex.EncodeToString(bytes.Repeat([]byte{0xb1}, 32)),
+	}
+	node.Handle("state_getStorage", func(params []json.RawMessage) (any, error) {
+		var key, at string
+		json.Unmarshal(params[0], &key)
+		json.Unmarshal(params[1], &at)
+		if key != SessionValidatorsKey {
+			return nil, nil
+		}
+		return validators[at], nil
+	})
+
+	client, err := rpc.NewRPC(node.URL())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer client.Close()
+
+	author, err := FetchBlockAuthor(client, blockHash)
+	if err != nil {
+		t.Fatalf("fetch author: %v", err)
+	}
+	if author != [32]byte(bytes.Repeat([]byte{0xa1}, 32)) {
+		t.Errorf("author = %x, want the validator at the parent block", author)
+	}
+}
@@ -0,0 +1,34 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"submarine/rpc"
+	"submarine/trie"
+)
+
+// ErrExtrinsicsRootMismatch is returned when a block body doesn't match the extrinsics root of its header.
+var ErrExtrinsicsRootMismatch = errors.New("extrinsics root mismatch")
+
+// ExtrinsicsRoot returns the root committing to a block body: the ordered trie root of the
+// encoded extrinsics, length prefix included.
+func ExtrinsicsRoot(extrinsics [][]byte, layout trie.Layout) [32]byte {
+	return trie.OrderedRoot(extrinsics, layout)
+}
+
+// VerifyExtrinsicsRoot checks that extrinsics are the body committed to by header.
+//
+// Runtimes build the root with LayoutV1 from system_version 2 and LayoutV0 before. Both are
+// accepted, which is as strong as knowing the version: a different body can't match either root.
+func VerifyExtrinsicsRoot(header *rpc.BlockHeader, extrinsics [][]byte) error {
+	expected, err := ParseHash(header.ExtrinsicsRoot)
+	if err != nil {
+		return fmt.Errorf("extrinsics root: %w", err)
+	}
+	for _, layout := range []trie.Layout{trie.LayoutV0, trie.LayoutV1} {
+		if ExtrinsicsRoot(extrinsics, layout) == expected {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: header commits to 0x%x", ErrExtrinsicsRootMismatch, expected)
+}
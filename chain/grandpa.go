@@ -0,0 +1,230 @@
+package chain
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"submarine/rpc"
+	"submarine/scale"
+)
+
+// ErrInvalidJustification is returned when a GRANDPA justification doesn't prove finality of its target.
+var ErrInvalidJustification = errors.New("invalid GRANDPA justification")
+
+// GrandpaPrecommit is a vote for a block in the precommit phase of a GRANDPA round.
+type GrandpaPrecommit struct {
+	TargetHash   [32]byte
+	TargetNumber uint32
+}
+
+// GrandpaSignedPrecommit is a precommit with the ed25519 signature of the authority that cast it.
+type GrandpaSignedPrecommit struct {
+	Precommit   GrandpaPrecommit
+	Signature   [64]byte
+	AuthorityId [32]byte
+}
+
+// GrandpaCommit is the block finalized by a round and the precommits supporting it.
+type GrandpaCommit struct {
+	TargetHash   [32]byte
+	TargetNumber uint32
+	Precommits   []GrandpaSignedPrecommit
+}
+
+// GrandpaJustification proves the finality of the commit target. VotesAncestries are the headers
+// linking precommit targets back to the commit target.
+type GrandpaJustification struct {
+	Round           uint64
+	Commit          GrandpaCommit
+	VotesAncestries []rpc.BlockHeader
+}
+
+func DecodeGrandpaPrecommit(r *scale.Reader) (GrandpaPrecommit, error) {
+	var precommit GrandpaPrecommit
+	hash, err := r.ReadBytes(32)
+	if err != nil {
+		return precommit, fmt.Errorf("failed to decode target hash: %w", err)
+	}
+	precommit.TargetHash = [32]byte(hash)
+	if precommit.TargetNumber, err = scale.DecodeU32(r); err != nil {
+		return precommit, fmt.Errorf("failed to decode target number: %w", err)
+	}
+	return precommit, nil
+}
+
+func DecodeGrandpaSignedPrecommit(r *scale.Reader) (GrandpaSignedPrecommit, error) {
+	var signed GrandpaSignedPrecommit
+	var err error
+	if signed.Precommit, err = DecodeGrandpaPrecommit(r); err != nil {
+		return signed, err
+	}
+	signature, err := r.ReadBytes(64)
+	if err != nil {
+		return signed, fmt.Errorf("failed to decode signature: %w", err)
+	}
+	signed.Signature = [64]byte(signature)
+	id, err := r.ReadBytes(32)
+	if err != nil {
+		return signed, fmt.Errorf("failed to decode authority id: %w", err)
+	}
+	signed.AuthorityId = [32]byte(id)
+	return signed, nil
+}
+
+func DecodeGrandpaJustification(data []byte) (*GrandpaJustification, error) {
+	r := scale.NewReader(data)
+	var j GrandpaJustification
+	var err error
+	if j.Round, err = scale.DecodeU64(r); err != nil {
+		return nil, fmt.Errorf("failed to decode GRANDPA round: %w", err)
+	}
+	target, err := DecodeGrandpaPrecommit(r)
+	if err != nil {
+		return nil, fmt.Errorf("GRANDPA commit: %w", err)
+	}
+	j.Commit.TargetHash, j.Commit.TargetNumber = target.TargetHash, target.TargetNumber
+	if j.Commit.Precommits, err = scale.DecodeVec(r, 132, DecodeGrandpaSignedPrecommit); err != nil {
+		return nil, fmt.Errorf("GRANDPA precommits: %w", err)
+	}
+	if j.VotesAncestries, err = scale.DecodeVec(r, 98, DecodeHeader); err != nil {
+		return nil, fmt.Errorf("GRANDPA votes ancestries: %w", err)
+	}
+
+	if r.Pos() != len(data) {
+		return nil, fmt.Errorf("GRANDPA justification: %d trailing bytes", len(data)-r.Pos())
+	}
+	return &j, nil
+}
+
+// FindJustification returns the justification of the given engine in a block, if it has one.
+func FindJustification(block *rpc.SignedBlock, engine ConsensusEngineId) ([]byte, bool) {
+	for _, justification := range block.Justifications {
+		if justification.EngineId == engine {
+			return justification.Data, true
+		}
+	}
+	return nil, false
+}
+
+// GrandpaThreshold returns the weight of precommits needed to finalize a block: more than two
+// thirds of the total weight of the authority set.
+func GrandpaThreshold(totalWeight uint64) uint64 {
+	if totalWeight == 0 {
+		return 0
+	}
+	return totalWeight - (totalWeight-1)/3
+}
+
+// GrandpaPrecommitPayload returns the message an authority signs for a precommit: the encoded
+// Precommit variant of a GRANDPA message, followed by the round and the authority set id.
+func GrandpaPrecommitPayload(precommit GrandpaPrecommit, round, setId uint64) []byte {
+	out := append([]byte{1}, precommit.TargetHash[:]...)
+	out = binary.LittleEndian.AppendUint32(out, precommit.TargetNumber)
+	out = binary.LittleEndian.AppendUint64(out, round)
+	return binary.LittleEndian.AppendUint64(out, setId)
+}
+
+// Verify checks that the justification finalizes its commit target under the authority set with
+// the given id: every precommit is signed by an authority of the set and votes for the target or
+// one of its descendants, those authorities weigh at least GrandpaThreshold, and the votes
+// ancestries are exactly the headers linking the precommit targets to the commit target.
+func (j *GrandpaJustification) Verify(setId uint64, authorities []GrandpaAuthority) error {
+	weights := make(map[[32]byte]uint64, len(authorities))
+	var totalWeight uint64
+	for _, authority := range authorities {
+		weights[authority.PublicKey] = authority.Weight
+		totalWeight += authority.Weight
+	}
+
+	ancestries := make(map[[32]byte]*rpc.BlockHeader, len(j.VotesAncestries))
+	for i := range j.VotesAncestries {
+		hash, err := HeaderHash(&j.VotesAncestries[i])
+		if err != nil {
+			return fmt.Errorf("votes ancestry #%d: %w", i, err)
+		}
+		ancestries[hash] = &j.VotesAncestries[i]
+	}
+
+	visited := make(map[[32]byte]bool)
+	voted := make(map[[32]byte]bool)
+	var weight uint64
+	for i, signed := range j.Commit.Precommits {
+		authorityWeight, ok := weights[signed.AuthorityId]
+		if !ok {
+			return fmt.Errorf("%w: precommit #%d by 0x%x, which is not in authority set %d",
+				ErrInvalidJustification, i, signed.AuthorityId, setId)
+		}
+		payload := GrandpaPrecommitPayload(signed.Precommit, j.Round, setId)
+		if !ed25519.Verify(signed.AuthorityId[:], payload, signed.Signature[:]) {
+			return fmt.Errorf("%w: bad signature on precommit #%d by 0x%x", ErrInvalidJustification, i, signed.AuthorityId)
+		}
+		if err := j.walkAncestry(signed.Precommit.TargetHash, ancestries, visited); err != nil {
+			return fmt.Errorf("%w: precommit #%d: %w", ErrInvalidJustification, i, err)
+		}
+
+		// Equivocating authorities count once.
+		if !voted[signed.AuthorityId] {
+			voted[signed.AuthorityId] = true
+			weight += authorityWeight
+		}
+	}
+
+	if threshold := GrandpaThreshold(totalWeight); weight < threshold || weight == 0 {
+		return fmt.Errorf("%w: precommits weigh %d of %d, need %d", ErrInvalidJustification, weight, totalWeight, threshold)
+	}
+	if len(visited) != len(ancestries) {
+		return fmt.Errorf("%w: %d of %d votes ancestries are not on the path of a precommit",
+			ErrInvalidJustification, len(ancestries)-len(visited), len(ancestries))
+	}
+	return nil
+}
+
+// walkAncestry follows parent hashes from a precommit target down to the commit target, marking
+// the headers it goes through as visited.
+func (j *GrandpaJustification) walkAncestry(hash [32]byte, ancestries map[[32]byte]*rpc.BlockHeader, visited map[[32]byte]bool) error {
+	for hash != j.Commit.TargetHash {
+		header, ok := ancestries[hash]
+		if !ok {
+			return fmt.Errorf("target 0x%x doesn't descend from the commit target 0x%x", hash, j.Commit.TargetHash)
+		}
+		if visited[hash] {
+			// The rest of the path was walked for an earlier precommit.
+			return nil
+		}
+		visited[hash] = true
+
+		parent, err := ParseHash(header.ParentHash)
+		if err != nil {
+			return fmt.Errorf("votes ancestry 0x%x: %w", hash, err)
+		}
+		hash = parent
+	}
+	return nil
+}
+
+// VerifyGrandpaJustification checks that a block carries a GRANDPA justification finalizing it
+// under the given authority set.
+func VerifyGrandpaJustification(block *rpc.SignedBlock, setId uint64, authorities []GrandpaAuthority) (*GrandpaJustification, error) {
+	data, ok := FindJustification(block, EngineGrandpa)
+	if !ok {
+		return nil, fmt.Errorf("%w: block has no GRANDPA justification", ErrInvalidJustification)
+	}
+	justification, err := DecodeGrandpaJustification(data)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := HeaderHash(&block.Block.Header)
+	if err != nil {
+		return nil, err
+	}
+	if justification.Commit.TargetHash != hash {
+		return nil, fmt.Errorf("%w: commit target 0x%x is not the block 0x%x",
+			ErrInvalidJustification, justification.Commit.TargetHash, hash)
+	}
+	if err := justification.Verify(setId, authorities); err != nil {
+		return nil, err
+	}
+	return justification, nil
+}
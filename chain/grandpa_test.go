@@ -0,0 +1,183 @@
+package chain_test
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	. "submarine/chain"
+	"submarine/rpc"
+	"submarine/scale"
+	"testing"
+)
+
+type voter struct {
+	key ed25519.PrivateKey
+	id  [32]byte
+}
+
+func voters(n int) ([]voter, []GrandpaAuthority) {
+	var vs []voter
+	var authorities []GrandpaAuthority
+	for i := range n {
+		seed := make([]byte, ed25519.SeedSize)
+		seed[0] = byte(i + 1)
+		key := ed25519.NewKeyFromSeed(seed)
+		id := [32]byte(key.Public().(ed25519.PublicKey))
+		vs = append(vs, voter{key, id})
+		authorities = append(authorities, GrandpaAuthority{PublicKey: id, Weight: 1})
+	}
+	return vs, authorities
+}
+
+func (v voter) precommit(target GrandpaPrecommit, round, setId uint64) GrandpaSignedPrecommit {
+	signature := ed25519.Sign(v.key, GrandpaPrecommitPayload(target, round, setId))
+	return GrandpaSignedPrecommit{Precommit: target, Signature: [64]byte(signature), AuthorityId: v.id}
+}
+
+func encodeJustification(t *testing.T, j *GrandpaJustification) []byte {
+	t.Helper()
+	out := binary.LittleEndian.AppendUint64(nil, j.Round)
+	out = append(out, j.Commit.TargetHash[:]...)
+	out = binary.LittleEndian.AppendUint32(out, j.Commit.TargetNumber)
+	out = append(out, scale.EncodeCompactU64(uint64(len(j.Commit.Precommits)))...)
+	for _, p := range j.Commit.Precommits {
+		out = append(out, p.Precommit.TargetHash[:]...)
+		out = binary.LittleEndian.AppendUint32(out, p.Precommit.TargetNumber)
+		out = append(out, p.Signature[:]...)
+		out = append(out, p.AuthorityId[:]...)
+	}
+	out = append(out, scale.EncodeCompactU64(uint64(len(j.VotesAncestries)))...)
+	for i := range j.VotesAncestries {
+		header, err := EncodeHeader(&j.VotesAncestries[i])
+		if err != nil {
+			t.Fatalf("encode ancestry: %v", err)
+		}
+		out = append(out, header...)
+	}
+	return out
+}
+
+func TestGrandpaThreshold(t *testing.T) {
+	tests := []struct{ total, want uint64 }{{1, 1}, {3, 3}, {4, 3}, {7, 5}, {10, 7}, {297, 199}}
+	for _, tt := range tests {
+		if got := GrandpaThreshold(tt.total); got != tt.want {
+			t.Errorf("GrandpaThreshold(%d) = %d, want %d", tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestGrandpaJustificationVerify(t *testing.T) {
+	const round, setId = 42, 7
+	vs, authorities := voters(4)
+
+	// The commit targets block 1; one voter precommits block 3, a descendant.
+	headers := chainFrom(t, polkadotGenesis(), 3)
+	hashes := make([]GrandpaPrecommit, len(headers))
+	for i := range headers {
+		hash, _ := HeaderHash(&headers[i])
+		hashes[i] = GrandpaPrecommit{TargetHash: hash, TargetNumber: uint32(i)}
+	}
+	target := hashes[1]
+	justification := func(precommits ...GrandpaSignedPrecommit) *GrandpaJustification {
+		return &GrandpaJustification{
+			Round:  round,
+			Commit: GrandpaCommit{TargetHash: target.TargetHash, TargetNumber: target.TargetNumber, Precommits: precommits},
+		}
+	}
+
+	valid := justification(vs[0].precommit(target, round, setId), vs[1].precommit(target, round, setId),
+		vs[2].precommit(hashes[3], round, setId))
+	valid.VotesAncestries = headers[2:4]
+
+	forged := vs[1].precommit(target, round, setId)
+	forged.AuthorityId = vs[3].id
+	outsider, _ := voters(5)
+
+	tests := []struct {
+		name          string
+		justification *GrandpaJustification
+		setId         uint64
+		ok            bool
+	}{
+		{"threshold met", valid, setId, true},
+		{"below threshold", justification(vs[0].precommit(target, round, setId), vs[1].precommit(target, round, setId)), setId, false},
+		{"equivocation counts once", justification(vs[0].precommit(target, round, setId), vs[0].precommit(hashes[1], round, setId),
+			vs[1].precommit(target, round, setId)), setId, false},
+		{"wrong set id", valid, setId + 1, false},
+		{"forged signature", justification(vs[0].precommit(target, round, setId), vs[1].precommit(target, round, setId), forged), setId, false},
+		{"unknown authority", justification(vs[0].precommit(target, round, setId), vs[1].precommit(target, round, setId),
+			outsider[4].precommit(target, round, setId)), setId, false},
+		{"missing ancestry", justification(vs[0].precommit(target, round, setId), vs[1].precommit(target, round, setId),
+			vs[2].precommit(hashes[3], round, setId)), setId, false},
+		{"precommit for an ancestor", justification(vs[0].precommit(target, round, setId), vs[1].precommit(target, round, setId),
+			vs[2].precommit(hashes[0], round, setId)), setId, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Round-trip through the SCALE encoding, as received from a node.
+			decoded, err := DecodeGrandpaJustification(encodeJustification(t, tt.justification))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			err = decoded.Verify(tt.setId, authorities)
+			if tt.ok && err != nil {
+				t.Errorf("verify: %v", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalidJustification) {
+				t.Errorf("error = %v, want %v", err, ErrInvalidJustification)
+			}
+		})
+	}
+
+	t.Run("unused ancestry", func(t *testing.T) {
+		extra := *valid
+		extra.VotesAncestries = append(headers[2:4:4], headers[0])
+		if err := extra.Verify(setId, authorities); !errors.Is(err, ErrInvalidJustification) {
+			t.Errorf("error = %v, want %v", err, ErrInvalidJustification)
+		}
+	})
+}
+
+func TestVerifyGrandpaJustification(t *testing.T) {
+	const round, setId = 1, 0
+	vs, authorities := voters(1)
+	header := polkadotGenesis()
+	hash, _ := HeaderHash(&header)
+	target := GrandpaPrecommit{TargetHash: hash}
+	j := &GrandpaJustification{Round: round, Commit: GrandpaCommit{
+		TargetHash: hash, Precommits: []GrandpaSignedPrecommit{vs[0].precommit(target, round, setId)},
+	}}
+
+	// Nodes send justifications as arrays of numbers.
+	var numbers []int
+	for _, b := range encodeJustification(t, j) {
+		numbers = append(numbers, int(b))
+	}
+	data, _ := json.Marshal(numbers)
+	blockJSON := fmt.Sprintf(`{"block":{"header":{"parentHash":%q,"number":"0x0","stateRoot":%q,"extrinsicsRoot":%q,"digest":{"logs":[]}},"extrinsics":[]},
+		"justifications":[[[66,69,69,70],"0x0102"],[[70,82,78,75],%s]]}`, header.ParentHash, header.StateRoot, header.ExtrinsicsRoot, data)
+
+	var block rpc.SignedBlock
+	if err := json.Unmarshal([]byte(blockJSON), &block); err != nil {
+		t.Fatalf("unmarshal block: %v", err)
+	}
+	if len(block.Justifications) != 2 || block.Justifications[0].EngineId != EngineBeefy || len(block.Justifications[0].Data) != 2 {
+		t.Fatalf("justifications = %+v", block.Justifications)
+	}
+
+	verified, err := VerifyGrandpaJustification(&block, setId, authorities)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if verified.Round != round || len(verified.Commit.Precommits) != 1 {
+		t.Errorf("justification = %+v", verified)
+	}
+
+	block.Block.Header.Number = "0x1"
+	if _, err := VerifyGrandpaJustification(&block, setId, authorities); !errors.Is(err, ErrInvalidJustification) {
+		t.Errorf("error = %v, want %v", err, ErrInvalidJustification)
+	}
+}
@@ -0,0 +1,171 @@
+package chain
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"submarine/crypto"
+	"submarine/rpc"
+	"submarine/scale"
+)
+
+// ErrBrokenChain is returned when a header's parent hash doesn't match the hash of the header before it.
+var ErrBrokenChain = errors.New("headers do not chain")
+
+// ParseHash decodes a 0x-prefixed 32-byte hash, as used by the RPC.
+func ParseHash(s string) ([32]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("invalid hash %q: %w", s, err)
+	}
+	if len(b) != 32 {
+		return [32]byte{}, fmt.Errorf("invalid hash %q: expected 32 bytes, got %d", s, len(b))
+	}
+	return [32]byte(b), nil
+}
+
+// HeaderNumber returns the block number of a header, which the RPC sends as a hex string.
+func HeaderNumber(header *rpc.BlockHeader) (uint64, error) {
+	n, ok := new(big.Int).SetString(strings.TrimPrefix(header.Number, "0x"), 16)
+	if !ok || !n.IsUint64() {
+		return 0, fmt.Errorf("invalid block number %q", header.Number)
+	}
+	return n.Uint64(), nil
+}
+
+// EncodeHeader returns the SCALE encoding of a header: parent hash, compact number, state root,
+// extrinsics root and digest. The digest logs are already SCALE-encoded digest items.
+func EncodeHeader(header *rpc.BlockHeader) ([]byte, error) {
+	var out []byte
+
+	parentHash, err := ParseHash(header.ParentHash)
+	if err != nil {
+		return nil, fmt.Errorf("parent hash: %w", err)
+	}
+	out = append(out, parentHash[:]...)
+
+	number, err := HeaderNumber(header)
+	if err != nil {
+		return nil, err
+	}
+	out = append(out, scale.EncodeCompactU64(number)...)
+
+	stateRoot, err := ParseHash(header.StateRoot)
+	if err != nil {
+		return nil, fmt.Errorf("state root: %w", err)
+	}
+	out = append(out, stateRoot[:]...)
+
+	extrinsicsRoot, err := ParseHash(header.ExtrinsicsRoot)
+	if err != nil {
+		return nil, fmt.Errorf("extrinsics root: %w", err)
+	}
+	out = append(out, extrinsicsRoot[:]...)
+
+	out = append(out, scale.EncodeCompactU64(uint64(len(header.Digest.Logs)))...)
+	for i, log := range header.Digest.Logs {
+		item, err := hex.DecodeString(strings.TrimPrefix(log, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("digest log #%d: invalid hex: %w", i, err)
+		}
+		out = append(out, item...)
+	}
+
+	return out, nil
+}
+
+// HeaderHash returns the block hash of a header, the Blake2-256 hash of its encoding.
+func HeaderHash(header *rpc.BlockHeader) ([32]byte, error) {
+	encoded, err := EncodeHeader(header)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return crypto.Blake2_256(encoded), nil
+}
+
+// ExtrinsicHash returns the hash of an extrinsic from its raw bytes, including the length prefix,
+// as returned by author_submitExtrinsic.
+func ExtrinsicHash(extrinsic []byte) [32]byte {
+	return crypto.Blake2_256(extrinsic)
+}
+
+// VerifyChain checks that headers are consecutive blocks in ascending order, each one's parent hash
+// being the hash of the header before it. It returns the hash of the last header.
+func VerifyChain(headers []rpc.BlockHeader) ([32]byte, error) {
+	var prevHash [32]byte
+	var prevNumber uint64
+	for i := range headers {
+		header := &headers[i]
+		number, err := HeaderNumber(header)
+		if err != nil {
+			return [32]byte{}, fmt.Errorf("header #%d: %w", i, err)
+		}
+
+		if i > 0 {
+			if number != prevNumber+1 {
+				return [32]byte{}, fmt.Errorf("%w: block %d follows block %d", ErrBrokenChain, number, prevNumber)
+			}
+			parentHash, err := ParseHash(header.ParentHash)
+			if err != nil {
+				return [32]byte{}, fmt.Errorf("block %d: parent hash: %w", number, err)
+			}
+			if parentHash != prevHash {
+				return [32]byte{}, fmt.Errorf("%w: block %d has parent 0x%x, but block %d hashes to 0x%x",
+					ErrBrokenChain, number, parentHash, prevNumber, prevHash)
+			}
+		}
+
+		if prevHash, err = HeaderHash(header); err != nil {
+			return [32]byte{}, fmt.Errorf("block %d: %w", number, err)
+		}
+		prevNumber = number
+	}
+	return prevHash, nil
+}
+
+// DecodeHeader decodes a SCALE-encoded header, the reverse of EncodeHeader.
+func DecodeHeader(r *scale.Reader) (rpc.BlockHeader, error) {
+	var header rpc.BlockHeader
+	readHash := func(name string) (string, error) {
+		hash, err := r.ReadBytes(32)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode %s: %w", name, err)
+		}
+		return "0x" + hex.EncodeToString(hash), nil
+	}
+
+	var err error
+	if header.ParentHash, err = readHash("parent hash"); err != nil {
+		return header, err
+	}
+	number, err := scale.DecodeCompact(r)
+	if err != nil {
+		return header, fmt.Errorf("failed to decode block number: %w", err)
+	}
+	header.Number = "0x" + number.Text(16)
+	if header.StateRoot, err = readHash("state root"); err != nil {
+		return header, err
+	}
+	if header.ExtrinsicsRoot, err = readHash("extrinsics root"); err != nil {
+		return header, err
+	}
+
+	count, err := scale.DecodeCompact(r)
+	if err != nil {
+		return header, fmt.Errorf("failed to decode digest length: %w", err)
+	}
+	if !count.IsUint64() || count.Uint64() > uint64(r.Remaining()) {
+		return header, fmt.Errorf("digest length %s exceeds the %d bytes left", count, r.Remaining())
+	}
+	header.Digest.Logs = make([]string, 0, count.Uint64())
+	for i := uint64(0); i < count.Uint64(); i++ {
+		start := r.Pos()
+		if _, err := DecodeDigestItem(r); err != nil {
+			return header, fmt.Errorf("digest log #%d: %w", i, err)
+		}
+		header.Digest.Logs = append(header.Digest.Logs, "0x"+hex.EncodeToString(r.BytesSince(start)))
+	}
+	return header, nil
+}
@@ -0,0 +1,125 @@
+package chain_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	. "submarine/chain"
+	"submarine/rpc"
+	"testing"
+)
+
+// polkadotGenesis is the header of Polkadot's genesis block.
+func polkadotGenesis() rpc.BlockHeader {
+	return rpc.BlockHeader{
+		ParentHash:     "0x0000000000000000000000000000000000000000000000000000000000000000",
+		Number:         "0x0",
+		StateRoot:      "0x29d0d972cd27cbc511e9589fcb7a4506d5eb6a9e8df205f00472e5ab354a4e17",
+		ExtrinsicsRoot: "0x03170a2e7597b7b7e3d84c05391d139a62b157e78786d8c082f29dcf4c111314",
+	}
+}
+
+func TestHeaderHash(t *testing.T) {
+	genesis := polkadotGenesis()
+	hash, err := HeaderHash(&genesis)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	if got := hex.EncodeToString(hash[:]); got != "91b171bb158e2d3848fa23a9f1c25182fb8e20313b2c1eb49219da7a70ce90c3" {
+		t.Errorf("genesis hash = %s", got)
+	}
+}
+
+// chainFrom builds count headers following parent, each with a BABE pre-runtime digest.
+func chainFrom(t *testing.T, parent rpc.BlockHeader, count int) []rpc.BlockHeader {
+	t.Helper()
+	headers := []rpc.BlockHeader{parent}
+	for i := 1; i <= count; i++ {
+		prev := headers[len(headers)-1]
+		prevHash, err := HeaderHash(&prev)
+		if err != nil {
+			t.Fatalf("hash: %v", err)
+		}
+		prevNumber, _ := HeaderNumber(&prev)
+
+		var header rpc.BlockHeader
+		header.ParentHash = "0x" + hex.EncodeToString(prevHash[:])
+		header.Number = "0x" + strconv.FormatUint(prevNumber+1, 16)
+		header.StateRoot = prev.StateRoot
+		header.ExtrinsicsRoot = prev.ExtrinsicsRoot
+		header.Digest.Logs = []string{digestLog(6, "BABE", babePreDigest(2, uint32(i), uint64(1000+i)))}
+		headers = append(headers, header)
+	}
+	return headers
+}
+
+func TestVerifyChain(t *testing.T) {
+	headers := chainFrom(t, polkadotGenesis(), 3)
+
+	last, err := VerifyChain(headers)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if want, _ := HeaderHash(&headers[3]); last != want {
+		t.Errorf("last hash = %x, want %x", last, want)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(headers []rpc.BlockHeader) []rpc.BlockHeader
+	}{
+		{"tampered state root", func(h []rpc.BlockHeader) []rpc.BlockHeader {
+			h[1].StateRoot = "0x" + strings.Repeat("ab", 32)
+			return h
+		}},
+		{"tampered digest", func(h []rpc.BlockHeader) []rpc.BlockHeader {
+			h[2].Digest.Logs = nil
+			return h
+		}},
+		{"missing block", func(h []rpc.BlockHeader) []rpc.BlockHeader {
+			return append(h[:1], h[2:]...)
+		}},
+		{"reordered", func(h []rpc.BlockHeader) []rpc.BlockHeader {
+			h[1], h[2] = h[2], h[1]
+			return h
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := tt.tamper(chainFrom(t, polkadotGenesis(), 3))
+			if _, err := VerifyChain(headers); !errors.Is(err, ErrBrokenChain) {
+				t.Errorf("expected ErrBrokenChain, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEncodeHeaderInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(h *rpc.BlockHeader)
+	}{
+		{"short parent hash", func(h *rpc.BlockHeader) { h.ParentHash = "0x00" }},
+		{"invalid number", func(h *rpc.BlockHeader) { h.Number = "0xzz" }},
+		{"invalid digest", func(h *rpc.BlockHeader) { h.Digest.Logs = []string{"0xg"} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := polkadotGenesis()
+			tt.modify(&header)
+			if _, err := EncodeHeader(&header); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestExtrinsicHash(t *testing.T) {
+	hash := ExtrinsicHash([]byte{0x00})
+	if got := hex.EncodeToString(hash[:]); got != "03170a2e7597b7b7e3d84c05391d139a62b157e78786d8c082f29dcf4c111314" {
+		t.Errorf("hash = %s", got)
+	}
+}
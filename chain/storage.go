@@ -0,0 +1,55 @@
+package chain
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"submarine/rpc"
+	"submarine/trie"
+)
+
+// ErrHeaderMismatch is returned when the header served for a block doesn't hash to the block hash.
+var ErrHeaderMismatch = errors.New("header does not match block hash")
+
+// FetchVerifiedStorage reads the values under keys at the given block from an untrusted node. The
+// header is checked against the block hash, and the values against its state root with a read
+// proof. A nil value means the key is proven absent.
+func FetchVerifiedStorage(client *rpc.RPC, blockHash string, keys [][]byte) ([][]byte, error) {
+	expected, err := ParseHash(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	header, err := client.GetHeader(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := HeaderHash(header)
+	if err != nil {
+		return nil, err
+	}
+	if hash != expected {
+		return nil, fmt.Errorf("%w: got 0x%x, want 0x%x", ErrHeaderMismatch, hash, expected)
+	}
+	stateRoot, err := ParseHash(header.StateRoot)
+	if err != nil {
+		return nil, fmt.Errorf("state root: %w", err)
+	}
+
+	hexKeys := make([]string, len(keys))
+	for i, key := range keys {
+		hexKeys[i] = "0x" + hex.EncodeToString(key)
+	}
+	readProof, err := client.GetReadProof(hexKeys, blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := make([][]byte, len(readProof.Proof))
+	for i, node := range readProof.Proof {
+		if proof[i], err = hex.DecodeString(strings.TrimPrefix(node, "0x")); err != nil {
+			return nil, fmt.Errorf("proof node #%d: invalid hex: %w", i, err)
+		}
+	}
+	return trie.VerifyProof(stateRoot, proof, keys)
+}
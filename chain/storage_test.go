@@ -0,0 +1,69 @@
+package chain_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	. "submarine/chain"
+	"submarine/crypto"
+	"submarine/rpc"
+	"submarine/rpc/rpctest"
+	"submarine/trie"
+	"testing"
+)
+
+func TestFetchVerifiedStorage(t *testing.T) {
+	validatorsKey := crypto.StoragePrefix("Session", "Validators")
+	missingKey := crypto.StoragePrefix("Session", "QueuedKeys")
+	entries := []trie.KeyValue{
+		{Key: validatorsKey, Value: append([]byte{0x04}, bytes.Repeat([]byte{0xa0}, 32)...)},
+		{Key: crypto.StoragePrefix("System", "Number"), Value: []byte{0x2a, 0, 0, 0}},
+	}
+	keys := [][]byte{validatorsKey, missingKey}
+	proof, err := trie.Prove(entries, trie.LayoutV1, keys)
+	if err != nil {
+		t.Fatalf("prove: %v", err)
+	}
+
+	root := trie.Root(entries, trie.LayoutV1)
+	header := polkadotGenesis()
+	header.StateRoot = "0x" + hex.EncodeToString(root[:])
+	hash, _ := HeaderHash(&header)
+	blockHash := "0x" + hex.EncodeToString(hash[:])
+
+	readProof := rpc.ReadProof{At: blockHash}
+	for _, node := range proof {
+		readProof.Proof = append(readProof.Proof, "0x"+hex.EncodeToString(node))
+	}
+
+	node := rpctest.NewNode()
+	defer node.Close()
+	node.HandleResult("chain_getHeader", header)
+	node.HandleResult("state_getReadProof", readProof)
+
+	client, err := rpc.NewRPC(node.URL())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer client.Close()
+
+	values, err := FetchVerifiedStorage(client, blockHash, keys)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if !bytes.Equal(values[0], entries[0].Value) || values[1] != nil {
+		t.Errorf("values = %x", values)
+	}
+
+	// A header that doesn't hash to the requested block is rejected.
+	otherHash := "0x" + hex.EncodeToString(bytes.Repeat([]byte{0x01}, 32))
+	if _, err := FetchVerifiedStorage(client, otherHash, keys); !errors.Is(err, ErrHeaderMismatch) {
+		t.Errorf("error = %v, want %v", err, ErrHeaderMismatch)
+	}
+
+	// So is a proof that doesn't cover the keys.
+	node.HandleResult("state_getReadProof", rpc.ReadProof{At: blockHash, Proof: readProof.Proof[:1]})
+	if _, err := FetchVerifiedStorage(client, blockHash, keys); !errors.Is(err, trie.ErrIncompleteProof) {
+		t.Errorf("error = %v, want %v", err, trie.ErrIncompleteProof)
+	}
+}
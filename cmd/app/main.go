@@ -1,13 +1,13 @@
 package main
 
 import (
-	// "encoding/hex"
-	// "fmt"
+	"fmt"
 	"log"
 	"strconv"
-	// "strings"
-	// decoder_models "submarine/decoder/models"
+	"submarine/decoder/models"
+	v14_decoder "submarine/decoder/v14"
 	"submarine/metadata/decoder"
+	"submarine/metadata/generated/v14"
 	. "submarine/rpc"
 	"submarine/scale"
 )
@@ -97,48 +97,65 @@ func main() {
 	log.Printf("Metadata Version: %d", metadataRaw.Version)
 
 	metadataReader := scale.NewReader(metadataRaw.Data)
-	_, err = decoder.DecodeMetadata(metadataRaw.Version, metadataReader)
+	metadata, err := decoder.DecodeMetadata(metadataRaw.Version, metadataReader)
 	if err != nil {
 		log.Fatalf("Failed to decode metadata: %s", err)
 	}
+	metadataV14, ok := metadata.(*v14.Metadata)
+	if !ok {
+		log.Fatalf("Block decoding needs v14 metadata, got v%d", metadataRaw.Version)
+	}
+
+	eventsBytes := client.GetEvents(blockHash)
+
+	block, err := v14_decoder.DecodeBlock(metadataV14, &signedBlock, eventsBytes)
+	if err != nil {
+		log.Fatalf("Failed to decode block: %s", err)
+	}
 
-	// exts := make([]decoder_models.DecodedExtrinsic, 0, 10)
-	//
-	// for _, ext := range signedBlock.Block.Extrinsics {
-	// 	ext = strings.TrimPrefix(ext, "0x")
-	// 	extBytes, err := hex.DecodeString(ext)
-	// 	if err != nil {
-	// 		log.Fatal(err)
-	// 	}
-	// 	ext_, err := decoder.DecodeExtrinsic(metadata, extBytes)
-	// 	if err != nil {
-	// 		log.Fatal(err)
-	// 	}
-	// 	exts = append(exts, *ext_)
-	// 	fmt.Printf("extrinsic: %s: %s\n", ext_.Call.PalletName, ext_.Call.VariantName)
-	// }
-	//
-	// eventsBytes := client.GetEvents(blockHash)
-	//
-	// events, err := decoder.DecodeEvents(metadata, eventsBytes)
-	// if err != nil {
-	// 	log.Fatalf("failed to decode event: %s", err)
-	// }
-	//
-	// for _, event := range events {
-	// 	var ctx string
-	// 	if event.Phase.IsApplyExtrinsic {
-	// 		ext := exts[event.Phase.AsApplyExtrinsic]
-	// 		ctx = fmt.Sprintf("(ext) %s: %s", ext.Call.PalletName, ext.Call.VariantName)
-	// 	} else if event.Phase.IsInitialization {
-	// 		ctx = "init"
-	// 	} else if event.Phase.IsFinalization {
-	// 		ctx = "fin"
-	// 	} else {
-	// 		ctx = "unknown"
-	// 	}
-	// 	fmt.Printf("event (%s): %s: %s\n", ctx, event.Event.PalletName, event.Event.EventName)
-	// }
+	jsonOptions := models.JSONOptions{Format: models.FormatToHuman}
+	if constants, err := v14_decoder.DecodeConstants(metadataV14); err == nil {
+		jsonOptions.SS58Prefix, _ = constants.Uint16("System", "SS58Prefix")
+	}
+	var properties struct {
+		TokenDecimals any `json:"tokenDecimals"`
+		TokenSymbol   any `json:"tokenSymbol"`
+	}
+	if err := client.Send("system_properties", nil).As(&properties); err == nil {
+		// Chains with several tokens list them, the native one first.
+		if decimals, ok := firstProperty(properties.TokenDecimals).(float64); ok {
+			jsonOptions.Decimals = int(decimals)
+		}
+		jsonOptions.Symbol, _ = firstProperty(properties.TokenSymbol).(string)
+	}
+
+	for _, event := range block.InitializationEvents {
+		fmt.Printf("event (init): %s: %s\n", event.Event.PalletName, event.Event.EventName)
+	}
+	for _, ext := range block.Extrinsics {
+		status := "ok"
+		if !ext.Success {
+			status = fmt.Sprintf("failed: %v", ext.DispatchError)
+		}
+		fmt.Printf("extrinsic #%d: %s: %s (%s, fee %v)\n", ext.Index, ext.Extrinsic.Call.PalletName, ext.Extrinsic.Call.VariantName, status, ext.Fee)
+		for _, event := range ext.Events {
+			fmt.Printf("  event: %s: %s\n", event.Event.PalletName, event.Event.EventName)
+		}
+		if data, err := jsonOptions.JSON(ext.Extrinsic); err == nil {
+			fmt.Printf("  %s\n", data)
+		}
+	}
+	for _, event := range block.FinalizationEvents {
+		fmt.Printf("event (fin): %s: %s\n", event.Event.PalletName, event.Event.EventName)
+	}
+}
+
+// firstProperty returns the first element of a system_properties value that lists one per token.
+func firstProperty(value any) any {
+	if list, ok := value.([]any); ok && len(list) > 0 {
+		return list[0]
+	}
+	return value
 }
 
 // hexToDecimal converts a hex string (e.g., "0x123") to a decimal number.
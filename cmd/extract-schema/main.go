@@ -0,0 +1,64 @@
+// extract-schema regenerates polkadot_scale_schema/schema.json from the definitions.ts files of a
+// checkout of @polkadot/types, and reports how it differs from the embedded schema:
+//
+//	go run cmd/extract-schema/main.go -types ../polkadot-js-api/packages/types
+//
+// With -check, nothing is written and the exit status is 1 if the schemas differ.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"submarine/polkadot_scale_schema"
+	"submarine/polkadot_scale_schema/extractor"
+)
+
+func main() {
+	typesDir := flag.String("types", "", "path to a checkout of @polkadot/types or polkadot-js/api")
+	out := flag.String("out", "polkadot_scale_schema/schema.json", "file to write the schema to")
+	check := flag.Bool("check", false, "only report the differences, exiting with status 1 if there are any")
+	flag.Parse()
+
+	if *typesDir == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	schema, err := extractor.Extract(*typesDir)
+	if err != nil {
+		log.Fatalf("extract: %v", err)
+	}
+
+	embedded, err := polkadot_scale_schema.ParseJSON(polkadot_scale_schema.EmbeddedSchema())
+	if err != nil {
+		log.Fatalf("parse embedded schema.json: %v", err)
+	}
+	embeddedSchema, ok := embedded.(*polkadot_scale_schema.Object)
+	if !ok {
+		log.Fatalf("embedded schema.json must be an object, got %T", embedded)
+	}
+
+	report := extractor.Diff(embeddedSchema, schema)
+	for _, line := range report {
+		fmt.Println(line)
+	}
+	log.Printf("%d modules, %d differences from the embedded schema.json\n", len(schema.Keys), len(report))
+
+	if *check {
+		if len(report) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	data, err := polkadot_scale_schema.MarshalIndentJSON(schema, "  ")
+	if err != nil {
+		log.Fatalf("encode schema: %v", err)
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		log.Fatalf("write %s: %v", *out, err)
+	}
+	log.Printf("Wrote %s\n", *out)
+}
@@ -0,0 +1,23 @@
+package crypto
+
+import (
+	"golang.org/x/crypto/blake2b"
+)
+
+// Blake2_256 returns the 32-byte BLAKE2b hash of data, the default hasher of Substrate chains.
+func Blake2_256(data []byte) [32]byte {
+	return blake2b.Sum256(data)
+}
+
+// Blake2_128 returns the 16-byte BLAKE2b hash of data.
+func Blake2_128(data []byte) [16]byte {
+	h, err := blake2b.New(16, nil)
+	if err != nil {
+		panic(err) // only fails for invalid sizes or keys
+	}
+	h.Write(data)
+
+	var out [16]byte
+	copy(out[:], h.Sum(nil))
+	return out
+}
@@ -0,0 +1,80 @@
+package crypto_test
+
+import (
+	"encoding/hex"
+	"submarine/crypto"
+	"testing"
+)
+
+func TestBlake2(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+		hash     func([]byte) []byte
+	}{
+		{"blake2_256 empty", []byte{}, "0e5751c026e543b2e8ab2eb06099daa1d1e5df47778f7787faab45cdf12fe3a8", blake2_256},
+		{"blake2_256 empty trie node", []byte{0x00}, "03170a2e7597b7b7e3d84c05391d139a62b157e78786d8c082f29dcf4c111314", blake2_256},
+		{"blake2_128 empty", []byte{}, "cae66941d9efbd404e4d88758ea67670", blake2_128},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := hex.EncodeToString(tt.hash(tt.data))
+			if result != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func blake2_256(data []byte) []byte {
+	h := crypto.Blake2_256(data)
+	return h[:]
+}
+
+func blake2_128(data []byte) []byte {
+	h := crypto.Blake2_128(data)
+	return h[:]
+}
+
+func TestTwox(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+		hash     func([]byte) []byte
+	}{
+		{"twox64 empty", []byte{}, "99e9d85137db46ef", twox64},
+		{"twox64 long", []byte("Nobody inspects the spammish repetition"), "f18b378a3ca8cefb", twox64},
+		{"twox128 System", []byte("System"), "26aa394eea5630e07c48ae0c9558cef7", twox128},
+		{"twox128 Events", []byte("Events"), "80d41e5e16056765bc8461851072c9d7", twox128},
+		{"twox128 Balances", []byte("Balances"), "c2261276cc9d1f8598ea4b6a74b15c2f", twox128},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := hex.EncodeToString(tt.hash(tt.data))
+			if result != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestStoragePrefix(t *testing.T) {
+	expected := "26aa394eea5630e07c48ae0c9558cef780d41e5e16056765bc8461851072c9d7"
+	if result := hex.EncodeToString(crypto.StoragePrefix("System", "Events")); result != expected {
+		t.Errorf("expected %s, got %s", expected, result)
+	}
+}
+
+func twox64(data []byte) []byte {
+	h := crypto.Twox64(data)
+	return h[:]
+}
+
+func twox128(data []byte) []byte {
+	h := crypto.Twox128(data)
+	return h[:]
+}
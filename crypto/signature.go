@@ -0,0 +1,68 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/ChainSafe/go-schnorrkel"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// The signing context used by Substrate for all sr25519 signatures.
+var sr25519SigningContext = []byte("substrate")
+
+// VerifyEd25519 checks an ed25519 signature of message against a 32-byte public key.
+func VerifyEd25519(publicKey [32]byte, message []byte, signature [64]byte) bool {
+	return ed25519.Verify(publicKey[:], message, signature[:])
+}
+
+// VerifySr25519 checks a schnorrkel sr25519 signature of message against a 32-byte public key.
+func VerifySr25519(publicKey [32]byte, message []byte, signature [64]byte) bool {
+	pk, err := schnorrkel.NewPublicKey(publicKey)
+	if err != nil {
+		return false
+	}
+
+	var sig schnorrkel.Signature
+	if err := sig.Decode(signature); err != nil {
+		return false
+	}
+
+	ok, err := pk.Verify(&sig, schnorrkel.NewSigningContext(sr25519SigningContext, message))
+	return err == nil && ok
+}
+
+// RecoverEcdsa recovers the 33-byte compressed secp256k1 public key that produced signature over
+// message. Substrate signs the Blake2-256 hash of the message, and encodes signatures as R || S || V.
+func RecoverEcdsa(message []byte, signature [65]byte) ([33]byte, error) {
+	var pub [33]byte
+
+	recoveryId := signature[64]
+	if recoveryId >= 27 {
+		recoveryId -= 27 // Ethereum-style recovery ids
+	}
+	if recoveryId > 3 {
+		return pub, fmt.Errorf("ecdsa: invalid recovery id %d", signature[64])
+	}
+
+	// Convert into the compact format expected by the library: V || R || S,
+	// where V = 27 + recovery id + 4 for compressed keys.
+	compact := make([]byte, 65)
+	compact[0] = 27 + 4 + recoveryId
+	copy(compact[1:], signature[:64])
+
+	hash := Blake2_256(message)
+	key, _, err := ecdsa.RecoverCompact(compact, hash[:])
+	if err != nil {
+		return pub, fmt.Errorf("ecdsa: %w", err)
+	}
+
+	copy(pub[:], key.SerializeCompressed())
+	return pub, nil
+}
+
+// VerifyEcdsa checks an ecdsa signature of message against a 33-byte compressed public key.
+func VerifyEcdsa(publicKey [33]byte, message []byte, signature [65]byte) bool {
+	recovered, err := RecoverEcdsa(message, signature)
+	return err == nil && recovered == publicKey
+}
@@ -0,0 +1,58 @@
+package crypto_test
+
+import (
+	"encoding/hex"
+	"submarine/crypto"
+	"testing"
+)
+
+// TestVerifyEd25519 checks the test vectors of RFC 8032, section 7.1.
+func TestVerifyEd25519(t *testing.T) {
+	tests := []struct {
+		name      string
+		publicKey string
+		message   string
+		signature string
+	}{
+		{
+			"test 1",
+			"d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a",
+			"",
+			"e5564300c360ac729086e2cc806e828a84877f1eb8e5d974d873e065224901555fb8821590a33bacc61e39701cf9b46bd25bf5f0595bbe24655141438e7a100b",
+		},
+		{
+			"test 2",
+			"3d4017c3e843895a92b70aa74d1b7ebc9c982ccf2ec4968cc0cd55f12af4660c",
+			"72",
+			"92a009a9f0d4cab8720e820b5f642540a2b27b5416503f8fb3762223ebdb69da085ac1e43e15996e458f3613d0f11d8c387b2eaeb4302aeeb00d291612bb0c00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publicKey := [32]byte(mustHex(t, tt.publicKey, 32))
+			signature := [64]byte(mustHex(t, tt.signature, 64))
+			message := mustHex(t, tt.message, -1)
+
+			if !crypto.VerifyEd25519(publicKey, message, signature) {
+				t.Error("expected the signature to verify")
+			}
+			if crypto.VerifyEd25519(publicKey, append(message, 0), signature) {
+				t.Error("expected the signature of another message to fail")
+			}
+		})
+	}
+}
+
+// mustHex decodes a hex vector of size bytes, or of any size if size is negative.
+func mustHex(t *testing.T, s string, size int) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex %q: %v", s, err)
+	}
+	if size >= 0 && len(b) != size {
+		t.Fatalf("hex %q is %d bytes, want %d", s, len(b), size)
+	}
+	return b
+}
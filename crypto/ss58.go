@@ -0,0 +1,130 @@
+package crypto
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var ss58Context = []byte("SS58PRE")
+
+// SS58Encode returns the SS58 address of a public key, e.g. 5GrwvaEF... for Alice on a chain with
+// prefix 42. Prefixes go up to 16383; keys are 1, 2, 4, 8, 32 or 33 bytes long.
+func SS58Encode(publicKey []byte, prefix uint16) (string, error) {
+	checksumLen, err := ss58ChecksumLen(len(publicKey))
+	if err != nil {
+		return "", err
+	}
+
+	var data []byte
+	switch {
+	case prefix < 64:
+		data = []byte{byte(prefix)}
+	case prefix < 16384:
+		data = []byte{
+			byte((prefix&0b1111_1100)>>2) | 0b0100_0000,
+			byte(prefix>>8) | byte(prefix&0b11)<<6,
+		}
+	default:
+		return "", fmt.Errorf("ss58: prefix %d out of range", prefix)
+	}
+	data = append(data, publicKey...)
+	checksum := ss58Checksum(data)
+	return base58Encode(append(data, checksum[:checksumLen]...)), nil
+}
+
+// SS58Decode returns the public key and prefix of an SS58 address, verifying its checksum.
+func SS58Decode(address string) ([]byte, uint16, error) {
+	data, err := base58Decode(address)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ss58: %w", err)
+	}
+	if len(data) < 2 {
+		return nil, 0, fmt.Errorf("ss58: address too short")
+	}
+
+	var prefix uint16
+	prefixLen := 1
+	switch {
+	case data[0] < 64:
+		prefix = uint16(data[0])
+	case data[0] < 128:
+		prefix = uint16(data[0]&0b0011_1111)<<2 | uint16(data[1]>>6) | uint16(data[1]&0b0011_1111)<<8
+		prefixLen = 2
+	default:
+		return nil, 0, fmt.Errorf("ss58: invalid prefix byte %#x", data[0])
+	}
+
+	for _, keyLen := range []int{33, 32, 8, 4, 2, 1} {
+		checksumLen, _ := ss58ChecksumLen(keyLen)
+		if prefixLen+keyLen+checksumLen != len(data) {
+			continue
+		}
+		payload := data[:prefixLen+keyLen]
+		checksum := ss58Checksum(payload)
+		if !bytes.Equal(checksum[:checksumLen], data[prefixLen+keyLen:]) {
+			return nil, 0, fmt.Errorf("ss58: invalid checksum")
+		}
+		return bytes.Clone(payload[prefixLen:]), prefix, nil
+	}
+	return nil, 0, fmt.Errorf("ss58: invalid length %d", len(data))
+}
+
+func ss58ChecksumLen(keyLen int) (int, error) {
+	switch keyLen {
+	case 1, 2, 4, 8:
+		return 1, nil
+	case 32, 33:
+		return 2, nil
+	default:
+		return 0, fmt.Errorf("ss58: invalid public key length %d", keyLen)
+	}
+}
+
+func ss58Checksum(data []byte) [64]byte {
+	return blake2b.Sum512(append(bytes.Clone(ss58Context), data...))
+}
+
+func base58Encode(data []byte) string {
+	n := new(big.Int).SetBytes(data)
+	base, mod := big.NewInt(58), new(big.Int)
+
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, base, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	// Leading zero bytes are written as the first digit.
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+func base58Decode(s string) ([]byte, error) {
+	n, base := new(big.Int), big.NewInt(58)
+	for _, c := range s {
+		digit := strings.IndexRune(base58Alphabet, c)
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", c)
+		}
+		n.Mul(n, base).Add(n, big.NewInt(int64(digit)))
+	}
+
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
@@ -0,0 +1,64 @@
+package crypto_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"submarine/crypto"
+	"testing"
+)
+
+func TestSS58(t *testing.T) {
+	alice, _ := hex.DecodeString("d43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d")
+
+	tests := []struct {
+		name      string
+		publicKey []byte
+		prefix    uint16
+		expected  string
+	}{
+		{"substrate", alice, 42, "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY"},
+		{"polkadot", alice, 0, "15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp5"},
+		{"kusama", alice, 2, "HNZata7iMYWmk5RvZRTiAsSDhV8366zq2YGb3tLH5Upf74F"},
+		{"two-byte prefix", alice, 1284, ""},
+		{"account index", []byte{1, 0, 0, 0}, 42, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, err := crypto.SS58Encode(tt.publicKey, tt.prefix)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			if tt.expected != "" && address != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, address)
+			}
+
+			publicKey, prefix, err := crypto.SS58Decode(address)
+			if err != nil {
+				t.Fatalf("decode %s: %v", address, err)
+			}
+			if !bytes.Equal(publicKey, tt.publicKey) || prefix != tt.prefix {
+				t.Errorf("round trip: expected %x/%d, got %x/%d", tt.publicKey, tt.prefix, publicKey, prefix)
+			}
+		})
+	}
+}
+
+func TestSS58Errors(t *testing.T) {
+	if _, err := crypto.SS58Encode(make([]byte, 31), 42); err == nil {
+		t.Error("expected an error for a 31-byte key")
+	}
+	if _, err := crypto.SS58Encode(make([]byte, 32), 16384); err == nil {
+		t.Error("expected an error for prefix 16384")
+	}
+
+	for _, address := range []string{
+		"5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQZ", // checksum
+		"5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKut0Y", // not base58
+		"5Grwva",
+	} {
+		if _, _, err := crypto.SS58Decode(address); err == nil {
+			t.Errorf("expected an error for %s", address)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package crypto
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Twox64 returns the 8-byte xxHash64 of data with seed 0, little-endian.
+func Twox64(data []byte) [8]byte {
+	var out [8]byte
+	binary.LittleEndian.PutUint64(out[:], xxhash64(data, 0))
+	return out
+}
+
+// Twox128 returns the 16-byte twox hash of data: xxHash64 with seeds 0 and 1, concatenated.
+// Storage prefixes are the Twox128 hashes of the pallet and item names.
+func Twox128(data []byte) [16]byte {
+	var out [16]byte
+	binary.LittleEndian.PutUint64(out[0:], xxhash64(data, 0))
+	binary.LittleEndian.PutUint64(out[8:], xxhash64(data, 1))
+	return out
+}
+
+// Twox256 returns the 32-byte twox hash of data: xxHash64 with seeds 0 to 3, concatenated.
+func Twox256(data []byte) [32]byte {
+	var out [32]byte
+	for seed := range uint64(4) {
+		binary.LittleEndian.PutUint64(out[seed*8:], xxhash64(data, seed))
+	}
+	return out
+}
+
+// StoragePrefix returns the storage key of a plain storage item, or the prefix of a map's keys.
+func StoragePrefix(pallet, item string) []byte {
+	palletHash := Twox128([]byte(pallet))
+	itemHash := Twox128([]byte(item))
+	return append(palletHash[:], itemHash[:]...)
+}
+
+const (
+	prime64_1 uint64 = 11400714785074694791
+	prime64_2 uint64 = 14029467366897019727
+	prime64_3 uint64 = 1609587929392839161
+	prime64_4 uint64 = 9650029242287828579
+	prime64_5 uint64 = 2870177450012600261
+)
+
+func xxhash64(data []byte, seed uint64) uint64 {
+	n := len(data)
+	var h uint64
+
+	if n >= 32 {
+		v1 := seed + prime64_1 + prime64_2
+		v2 := seed + prime64_2
+		v3 := seed
+		v4 := seed - prime64_1
+		for len(data) >= 32 {
+			v1 = xxhRound(v1, binary.LittleEndian.Uint64(data[0:]))
+			v2 = xxhRound(v2, binary.LittleEndian.Uint64(data[8:]))
+			v3 = xxhRound(v3, binary.LittleEndian.Uint64(data[16:]))
+			v4 = xxhRound(v4, binary.LittleEndian.Uint64(data[24:]))
+			data = data[32:]
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxhMergeRound(h, v1)
+		h = xxhMergeRound(h, v2)
+		h = xxhMergeRound(h, v3)
+		h = xxhMergeRound(h, v4)
+	} else {
+		h = seed + prime64_5
+	}
+
+	h += uint64(n)
+
+	for ; len(data) >= 8; data = data[8:] {
+		h ^= xxhRound(0, binary.LittleEndian.Uint64(data))
+		h = bits.RotateLeft64(h, 27)*prime64_1 + prime64_4
+	}
+	if len(data) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(data)) * prime64_1
+		h = bits.RotateLeft64(h, 23)*prime64_2 + prime64_3
+		data = data[4:]
+	}
+	for _, b := range data {
+		h ^= uint64(b) * prime64_5
+		h = bits.RotateLeft64(h, 11) * prime64_1
+	}
+
+	h ^= h >> 33
+	h *= prime64_2
+	h ^= h >> 29
+	h *= prime64_3
+	h ^= h >> 32
+	return h
+}
+
+func xxhRound(acc, input uint64) uint64 {
+	acc += input * prime64_2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * prime64_1
+}
+
+func xxhMergeRound(acc, val uint64) uint64 {
+	val = xxhRound(0, val)
+	acc ^= val
+	return acc*prime64_1 + prime64_4
+}
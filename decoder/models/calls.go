@@ -0,0 +1,101 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CallPathElement is one step from a call to a call nested in its arguments.
+type CallPathElement struct {
+	PalletName string
+	CallName   string
+	Arg        string // argument of the wrapping call that holds the nested call
+	Index      int    // position of the nested call in a Vec<RuntimeCall> argument, -1 otherwise
+}
+
+// CallPath leads from the call of an extrinsic to a nested call, outermost call first.
+// It is empty for the extrinsic's own call.
+type CallPath []CallPathElement
+
+func (p CallPath) String() string {
+	parts := make([]string, len(p))
+	for i, elem := range p {
+		parts[i] = elem.PalletName + "." + elem.CallName + "." + elem.Arg
+		if elem.Index >= 0 {
+			parts[i] += fmt.Sprintf("[%d]", elem.Index)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
+
+// NestedCall is a call found by Flatten, together with the path leading to it.
+type NestedCall struct {
+	Path CallPath
+	Call *DecodedPalletVariant
+}
+
+// Walk visits the call and every call nested in its arguments, depth first and in argument order.
+// Returning false from fn skips the calls nested in the visited call.
+func (v *DecodedPalletVariant) Walk(fn func(path CallPath, call *DecodedPalletVariant) bool) {
+	v.walk(nil, fn)
+}
+
+func (v *DecodedPalletVariant) walk(path CallPath, fn func(CallPath, *DecodedPalletVariant) bool) {
+	if !fn(path, v) {
+		return
+	}
+	for _, arg := range v.Args {
+		elem := CallPathElement{PalletName: v.PalletName, CallName: v.VariantName, Arg: arg.Name, Index: -1}
+		walkValue(arg.Value, path, elem, fn)
+	}
+}
+
+// walkValue finds the calls in a decoded argument value, looking through sequences, structs and enums.
+func walkValue(value any, path CallPath, elem CallPathElement, fn func(CallPath, *DecodedPalletVariant) bool) {
+	switch value := value.(type) {
+	case *DecodedPalletVariant:
+		// Copy the path, so that sibling calls don't share its backing array.
+		nested := append(append(CallPath(nil), path...), elem)
+		value.walk(nested, fn)
+	case []any:
+		for i, item := range value {
+			itemElem := elem
+			itemElem.Index = i
+			walkValue(item, path, itemElem, fn)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walkValue(value[key], path, elem, fn)
+		}
+	}
+}
+
+// Flatten returns the calls that are effectively dispatched: the calls without nested calls,
+// such as the individual calls of a batch. A call without nested calls flattens to itself.
+func (v *DecodedPalletVariant) Flatten() []NestedCall {
+	var calls []NestedCall
+	v.Walk(func(path CallPath, call *DecodedPalletVariant) bool {
+		if !call.hasNestedCalls() {
+			calls = append(calls, NestedCall{Path: path, Call: call})
+		}
+		return true
+	})
+	return calls
+}
+
+func (v *DecodedPalletVariant) hasNestedCalls() bool {
+	found := false
+	for _, arg := range v.Args {
+		walkValue(arg.Value, nil, CallPathElement{}, func(CallPath, *DecodedPalletVariant) bool {
+			found = true
+			return false
+		})
+	}
+	return found
+}
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// ErrConstantNotFound is returned by the Constants getters for a pallet or constant missing from the metadata.
+var ErrConstantNotFound = errors.New("constant not found")
+
+// DecodedConstant is a pallet constant with its value decoded from the metadata.
+type DecodedConstant struct {
+	PalletName string
+	Name       string
+	// TypeName is the path of the type in v14 metadata, e.g. "sp_weights::weight_v2::Weight",
+	// or the type name of legacy metadata, e.g. "BalanceOf<T>".
+	TypeName string
+	Value    any
+	Raw      []byte // SCALE encoding of Value, as stored in the metadata
+	Docs     []string
+}
+
+// Constants are the constants of all pallets, in metadata order.
+type Constants []DecodedConstant
+
+// Get returns the constant with the given pallet and name.
+func (c Constants) Get(pallet, name string) (*DecodedConstant, error) {
+	for i := range c {
+		if c[i].PalletName == pallet && c[i].Name == name {
+			return &c[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s.%s", ErrConstantNotFound, pallet, name)
+}
+
+// Pallet returns the constants of a pallet.
+func (c Constants) Pallet(pallet string) Constants {
+	var out Constants
+	for _, constant := range c {
+		if constant.PalletName == pallet {
+			out = append(out, constant)
+		}
+	}
+	return out
+}
+
+// BigInt returns an integer constant, e.g. Balances.ExistentialDeposit.
+func (c Constants) BigInt(pallet, name string) (*big.Int, error) {
+	constant, err := c.Get(pallet, name)
+	if err != nil {
+		return nil, err
+	}
+	n, err := constantInt(constant.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%s.%s: %w", pallet, name, err)
+	}
+	return n, nil
+}
+
+// Uint64 returns an integer constant that fits in a uint64, e.g. Babe.EpochDuration.
+func (c Constants) Uint64(pallet, name string) (uint64, error) {
+	return c.uint(pallet, name, math.MaxUint64)
+}
+
+// Uint32 returns an integer constant that fits in a uint32, e.g. System.BlockHashCount.
+func (c Constants) Uint32(pallet, name string) (uint32, error) {
+	n, err := c.uint(pallet, name, math.MaxUint32)
+	return uint32(n), err
+}
+
+// Uint16 returns an integer constant that fits in a uint16, e.g. System.SS58Prefix.
+func (c Constants) Uint16(pallet, name string) (uint16, error) {
+	n, err := c.uint(pallet, name, math.MaxUint16)
+	return uint16(n), err
+}
+
+func (c Constants) uint(pallet, name string, max uint64) (uint64, error) {
+	n, err := c.BigInt(pallet, name)
+	if err != nil {
+		return 0, err
+	}
+	if n.Sign() < 0 || !n.IsUint64() || n.Uint64() > max {
+		return 0, fmt.Errorf("%s.%s: %s out of range", pallet, name, n)
+	}
+	return n.Uint64(), nil
+}
+
+// Bytes returns a byte-string or byte-array constant, e.g. Treasury.PalletId.
+func (c Constants) Bytes(pallet, name string) ([]byte, error) {
+	constant, err := c.Get(pallet, name)
+	if err != nil {
+		return nil, err
+	}
+	if b, ok := constantBytes(unwrapNewtype(constant.Value)); ok {
+		return b, nil
+	}
+	return nil, fmt.Errorf("%s.%s: expected bytes, got %T", pallet, name, constant.Value)
+}
+
+// Struct returns the fields of a struct constant, e.g. System.BlockWeights.
+func (c Constants) Struct(pallet, name string) (map[string]any, error) {
+	constant, err := c.Get(pallet, name)
+	if err != nil {
+		return nil, err
+	}
+	fields, ok := constant.Value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s.%s: expected a struct, got %T", pallet, name, constant.Value)
+	}
+	return fields, nil
+}
+
+func constantInt(value any) (*big.Int, error) {
+	switch v := unwrapNewtype(value).(type) {
+	case *big.Int:
+		return v, nil
+	case uint8:
+		return new(big.Int).SetUint64(uint64(v)), nil
+	case uint16:
+		return new(big.Int).SetUint64(uint64(v)), nil
+	case uint32:
+		return new(big.Int).SetUint64(uint64(v)), nil
+	case uint64:
+		return new(big.Int).SetUint64(v), nil
+	case int8:
+		return big.NewInt(int64(v)), nil
+	case int16:
+		return big.NewInt(int64(v)), nil
+	case int32:
+		return big.NewInt(int64(v)), nil
+	case int64:
+		return big.NewInt(v), nil
+	default:
+		return nil, fmt.Errorf("expected an integer, got %T", value)
+	}
+}
+
+// constantBytes accepts bytes, and arrays of u8 as decoded from v14 metadata.
+func constantBytes(value any) ([]byte, bool) {
+	switch v := value.(type) {
+	case []byte:
+		return v, true
+	case []any:
+		out := make([]byte, len(v))
+		for i, elem := range v {
+			b, ok := elem.(uint8)
+			if !ok {
+				return nil, false
+			}
+			out[i] = b
+		}
+		return out, true
+	default:
+		return nil, false
+	}
+}
+
+// unwrapNewtype returns the field of a single-field tuple struct like Perbill(u32) or PalletId([u8; 8]).
+func unwrapNewtype(value any) any {
+	if fields, ok := value.(map[string]any); ok && len(fields) == 1 {
+		if inner, ok := fields["unnamed"]; ok {
+			return inner
+		}
+	}
+	return value
+}
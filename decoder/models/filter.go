@@ -0,0 +1,79 @@
+package models
+
+// EventFilter selects events by pallet, event name and topic. Empty criteria match any event.
+type EventFilter struct {
+	Pallet string
+	Name   string
+	Topic  *[32]byte
+}
+
+// Match reports whether record satisfies all criteria of the filter.
+func (f EventFilter) Match(record EventRecord) bool {
+	if f.Pallet != "" && record.Event.PalletName != f.Pallet {
+		return false
+	}
+	if f.Name != "" && record.Event.EventName != f.Name {
+		return false
+	}
+	if f.Topic != nil && !record.HasTopic(*f.Topic) {
+		return false
+	}
+	return true
+}
+
+// CallFilter selects calls by pallet and call name. Empty criteria match any call.
+type CallFilter struct {
+	Pallet string
+	Name   string
+}
+
+// Match reports whether the call palletName.callName satisfies all criteria of the filter.
+func (f CallFilter) Match(palletName, callName string) bool {
+	return (f.Pallet == "" || palletName == f.Pallet) && (f.Name == "" || callName == f.Name)
+}
+
+// BlockFilter selects the extrinsics and events of a block worth decoding, for scanning many
+// blocks for a few of them. Calls match the call of an extrinsic, not the calls nested in it:
+// select Utility or Proxy calls to find those. An extrinsic or event matching any of the filters
+// is selected.
+type BlockFilter struct {
+	Calls  []CallFilter
+	Events []EventFilter
+}
+
+// HasTopic reports whether the event was deposited with the given topic.
+func (record EventRecord) HasTopic(topic [32]byte) bool {
+	for _, t := range record.Topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterEvents returns the records that match the filter, in order.
+func FilterEvents(records []EventRecord, filter EventFilter) []EventRecord {
+	var matched []EventRecord
+	for _, record := range records {
+		if filter.Match(record) {
+			matched = append(matched, record)
+		}
+	}
+	return matched
+}
+
+// Events returns all events of the block in the order they were deposited: initialization,
+// then each extrinsic's events, then finalization.
+func (b *DecodedBlock) Events() []EventRecord {
+	var events []EventRecord
+	events = append(events, b.InitializationEvents...)
+	for _, ext := range b.Extrinsics {
+		events = append(events, ext.Events...)
+	}
+	return append(events, b.FinalizationEvents...)
+}
+
+// FilterEvents returns the events of the block that match the filter, in order.
+func (b *DecodedBlock) FilterEvents(filter EventFilter) []EventRecord {
+	return FilterEvents(b.Events(), filter)
+}
@@ -0,0 +1,743 @@
+package models
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+	"submarine/crypto"
+	"submarine/metadata/base"
+	"submarine/scale"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/goccy/go-yaml"
+)
+
+// JSONFormat selects how JSONOptions renders decoded values.
+type JSONFormat int
+
+const (
+	// FormatLossless keeps everything that was decoded: names as in the metadata, integers written
+	// out in full, bytes in hex and scale.Values in their typed form. It is the format of MarshalJSON.
+	FormatLossless JSONFormat = iota
+	// FormatToJSON follows the toJSON() of polkadot.js: camelCase names, calls and events by their
+	// indices, extrinsics in hex, integers too wide for a JavaScript number as hex strings of the
+	// width of their type, and addresses in SS58.
+	FormatToJSON
+	// FormatToHuman follows the toHuman() of polkadot.js: integers as strings with thousands
+	// separators, balances in units of the native token, and text bytes as strings.
+	FormatToHuman
+)
+
+// JSONOptions renders decoded blocks, extrinsics, events and values as JSON or YAML.
+type JSONOptions struct {
+	Format JSONFormat
+	// SS58Prefix is the address format of the chain, e.g. 0 for Polkadot or 42 for a dev chain.
+	SS58Prefix uint16
+	// Decimals and Symbol are those of the native token, e.g. 10 and DOT, for the balances of
+	// FormatToHuman. Without a Symbol, balances are formatted like other integers.
+	Decimals int
+	Symbol   string
+}
+
+// JSON renders v, see Render.
+func (o JSONOptions) JSON(v any) ([]byte, error) {
+	return json.Marshal(o.Render(v))
+}
+
+// YAML renders v like JSON, as YAML.
+func (o JSONOptions) YAML(v any) ([]byte, error) {
+	data, err := o.JSON(v)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.JSONToYAML(data)
+}
+
+// Render converts a DecodedBlock, BlockExtrinsic, DecodedExtrinsic, EventRecord, call, event or
+// decoded value to a tree that encoding/json marshals in the format of the options.
+func (o JSONOptions) Render(v any) any {
+	switch v := v.(type) {
+	case *DecodedBlock:
+		return o.block(v)
+	case DecodedBlock:
+		return o.block(&v)
+	case *BlockExtrinsic:
+		return o.blockExtrinsic(v)
+	case BlockExtrinsic:
+		return o.blockExtrinsic(&v)
+	case *DecodedExtrinsic:
+		return o.extrinsic(v)
+	case DecodedExtrinsic:
+		return o.extrinsic(&v)
+	case *EventRecord:
+		return o.eventRecord(v)
+	case EventRecord:
+		return o.eventRecord(&v)
+	default:
+		return o.value(v, "")
+	}
+}
+
+// MarshalJSON implements json.Marshaler with FormatLossless.
+func (e DecodedExtrinsic) MarshalJSON() ([]byte, error) {
+	return JSONOptions{}.JSON(e)
+}
+
+// MarshalJSON implements json.Marshaler with FormatLossless.
+func (record EventRecord) MarshalJSON() ([]byte, error) {
+	return JSONOptions{}.JSON(record)
+}
+
+// MarshalJSON implements json.Marshaler with FormatLossless.
+func (v DecodedPalletVariant) MarshalJSON() ([]byte, error) {
+	return JSONOptions{}.JSON(&v)
+}
+
+// object is a JSON object that keeps the order of its keys.
+type object []member
+
+type member struct {
+	key   string
+	value any
+}
+
+func (obj object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range obj {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(m.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.key, err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// name returns a field or variant name as the format writes it.
+func (o JSONOptions) name(name string) string {
+	if o.Format == FormatLossless {
+		return name
+	}
+	return camelCase(name)
+}
+
+// variantName returns the name of an enum variant as the format writes it: toHuman keeps it.
+func (o JSONOptions) variantName(name string) string {
+	if o.Format == FormatToHuman {
+		return name
+	}
+	return o.name(name)
+}
+
+func (o JSONOptions) block(b *DecodedBlock) any {
+	extrinsics := make([]any, len(b.Extrinsics))
+	for i := range b.Extrinsics {
+		extrinsics[i] = o.blockExtrinsic(&b.Extrinsics[i])
+	}
+	return object{
+		{"extrinsics", extrinsics},
+		{"initializationEvents", o.eventRecords(b.InitializationEvents)},
+		{"finalizationEvents", o.eventRecords(b.FinalizationEvents)},
+	}
+}
+
+func (o JSONOptions) blockExtrinsic(ext *BlockExtrinsic) any {
+	obj := object{
+		{"index", o.integer(big.NewInt(int64(ext.Index)), 32, "")},
+		{"extrinsic", o.extrinsic(&ext.Extrinsic)},
+		{"events", o.eventRecords(ext.Events)},
+		{"success", ext.Success},
+	}
+	if ext.DispatchError != nil {
+		obj = append(obj, member{"dispatchError", ext.DispatchError.Error()})
+	}
+	if info := ext.DispatchInfo; info != nil {
+		obj = append(obj, member{"dispatchInfo", object{
+			{"weight", object{{"refTime", o.integer(info.RefTime, 64, "")}, {"proofSize", o.integer(info.ProofSize, 64, "")}}},
+			{"class", info.Class},
+			{"paysFee", info.PaysFee},
+		}})
+	}
+	if ext.Fee != nil {
+		obj = append(obj, member{"fee", o.integer(ext.Fee, 128, "Balance")})
+	}
+	if ext.Tip != nil {
+		obj = append(obj, member{"tip", o.integer(ext.Tip, 128, "Balance")})
+	}
+	return obj
+}
+
+func (o JSONOptions) extrinsic(e *DecodedExtrinsic) any {
+	if o.Format == FormatLossless {
+		obj := object{{"version", e.Version}, {"isSigned", e.IsSigned}}
+		if e.IsSigned {
+			extensions := make([]any, len(e.Extensions))
+			for i, ext := range e.Extensions {
+				extensions[i] = object{{"identifier", ext.Identifier}, {"value", o.value(ext.Value, "")}, {"raw", hexString(ext.Raw)}}
+			}
+			obj = append(obj,
+				member{"address", o.address(e.Address)},
+				member{"signature", signature(e.Signature)},
+				member{"extensions", extensions})
+		}
+		return append(obj, member{"call", o.call(&e.Call)}, member{"callBytes", hexString(e.CallBytes)})
+	}
+	if o.Format == FormatToJSON {
+		return hexString(e.Raw)
+	}
+
+	// The fields of the toHuman() of polkadot.js, in its order.
+	obj := object{{"isSigned", e.IsSigned}, {"method", o.call(&e.Call)}}
+	if !e.IsSigned {
+		return obj
+	}
+	var era, nonce, tip any
+	for _, ext := range e.Extensions {
+		switch ext.Identifier {
+		case "CheckMortality", "CheckEra":
+			era = o.era(ext.Raw)
+		case "CheckNonce":
+			nonce = o.value(ext.Value, "")
+		case "ChargeTransactionPayment":
+			tip = o.value(ext.Value, "Balance")
+		case "ChargeAssetTxPayment":
+			if fields, ok := ext.Value.(map[string]any); ok {
+				tip = o.value(fields["tip"], "Balance")
+			}
+		}
+	}
+	return append(obj,
+		member{"era", era},
+		member{"nonce", nonce},
+		member{"signature", signature(e.Signature).(object)[0].value},
+		member{"signer", o.address(e.Address)},
+		member{"tip", tip})
+}
+
+// era renders the mortality of an extrinsic, from the raw encoding of its CheckMortality.
+func (o JSONOptions) era(raw []byte) any {
+	era, err := base.DecodeEra(scale.NewReader(raw))
+	if err != nil {
+		return hexString(raw)
+	}
+	switch {
+	case era.IsImmortal && o.Format == FormatToHuman:
+		return object{{"ImmortalEra", hexString(raw)}}
+	case era.IsImmortal:
+		return object{{"immortalEra", hexString(raw)}}
+	case o.Format == FormatToHuman:
+		return object{{"MortalEra", object{
+			{"period", o.integer(new(big.Int).SetUint64(era.Period), 64, "")},
+			{"phase", o.integer(new(big.Int).SetUint64(era.Phase), 64, "")},
+		}}}
+	default:
+		return object{{"mortalEra", hexString(raw)}}
+	}
+}
+
+func (o JSONOptions) address(addr base.Address) any {
+	var key string
+	var value any
+	switch addr.Kind {
+	case base.KindAddressId:
+		key, value = "Id", o.account(addr.Id[:])
+	case base.KindAddressIndex:
+		key, value = "Index", o.integer(new(big.Int).SetUint64(uint64(*addr.Index)), 32, "")
+	case base.KindAddressRaw:
+		key, value = "Raw", hexString(*addr.Raw)
+	case base.KindAddress32:
+		key, value = "Address32", o.account(addr.Addr32[:])
+	case base.KindAddress20:
+		key, value = "Address20", hexString(addr.Addr20[:])
+	default:
+		return nil
+	}
+	return object{{o.variantName(key), value}}
+}
+
+func signature(sig base.Signature) any {
+	switch sig.Kind {
+	case base.KindSignatureEd25519:
+		return object{{"ed25519", hexString(sig.Ed25519[:])}}
+	case base.KindSignatureSr25519:
+		return object{{"sr25519", hexString(sig.Sr25519[:])}}
+	case base.KindSignatureEcdsa:
+		return object{{"ecdsa", hexString(sig.Ecdsa[:])}}
+	default:
+		return object{{"unknown", nil}}
+	}
+}
+
+// account renders a public key as an SS58 address, or in hex in FormatLossless.
+func (o JSONOptions) account(publicKey []byte) any {
+	if o.Format == FormatLossless {
+		return hexString(publicKey)
+	}
+	address, err := crypto.SS58Encode(publicKey, o.SS58Prefix)
+	if err != nil {
+		return hexString(publicKey)
+	}
+	return address
+}
+
+// call renders a call, or a call nested in the arguments of another.
+func (o JSONOptions) call(call *DecodedPalletVariant) any {
+	switch o.Format {
+	case FormatLossless:
+		return object{{"pallet", call.PalletName}, {"name", call.VariantName}, {"args", o.losslessArgs(call.Args)}}
+	case FormatToJSON:
+		return object{{"callIndex", hexString([]byte{call.PalletIndex, call.VariantIndex})}, {"args", o.namedArgs(call.Args)}}
+	}
+	return object{
+		{"args", o.namedArgs(call.Args)},
+		{"method", camelCase(call.VariantName)},
+		{"section", camelCase(call.PalletName)},
+	}
+}
+
+func (o JSONOptions) losslessArgs(args []DecodedArg) []any {
+	out := make([]any, len(args))
+	for i, arg := range args {
+		out[i] = object{{"name", arg.Name}, {"typeName", arg.TypeName}, {"value", o.value(arg.Value, arg.TypeName)}}
+	}
+	return out
+}
+
+func (o JSONOptions) namedArgs(args []DecodedArg) object {
+	obj := make(object, len(args))
+	for i, arg := range args {
+		obj[i] = member{o.name(arg.Name), o.value(arg.Value, arg.TypeName)}
+	}
+	return obj
+}
+
+func (o JSONOptions) eventRecords(records []EventRecord) []any {
+	out := make([]any, len(records))
+	for i := range records {
+		out[i] = o.eventRecord(&records[i])
+	}
+	return out
+}
+
+func (o JSONOptions) eventRecord(record *EventRecord) any {
+	var phase any
+	switch {
+	case record.Phase.IsApplyExtrinsic:
+		phase = object{{o.variantName("ApplyExtrinsic"), o.integer(big.NewInt(int64(record.Phase.AsApplyExtrinsic)), 32, "")}}
+	case o.Format == FormatLossless && record.Phase.IsFinalization:
+		phase = "Finalization"
+	case o.Format == FormatLossless:
+		phase = "Initialization"
+	// Phase has a variant with a value, so polkadot.js renders its unit variants as objects too.
+	case record.Phase.IsFinalization:
+		phase = object{{o.variantName("Finalization"), nil}}
+	default:
+		phase = object{{o.variantName("Initialization"), nil}}
+	}
+
+	topics := make([]any, len(record.Topics))
+	for i, topic := range record.Topics {
+		topics[i] = hexString(topic[:])
+	}
+
+	event := &record.Event
+	var rendered any
+	switch o.Format {
+	case FormatLossless:
+		rendered = object{{"pallet", event.PalletName}, {"name", event.EventName}, {"args", o.losslessArgs(event.Args)}}
+	case FormatToHuman:
+		rendered = object{{"method", event.EventName}, {"section", camelCase(event.PalletName)}, {"data", o.namedArgs(event.Args)}}
+	default:
+		data := make([]any, len(event.Args))
+		for i, arg := range event.Args {
+			data[i] = o.value(arg.Value, arg.TypeName)
+		}
+		rendered = object{{"index", hexString([]byte{event.PalletIndex, event.EventIndex})}, {"data", data}}
+	}
+
+	return object{{"phase", phase}, {"event", rendered}, {"topics", topics}}
+}
+
+var (
+	// genericArgsPattern matches the generic arguments of a type name, e.g. <T, I> in BalanceOf<T, I>.
+	genericArgsPattern = regexp.MustCompile(`<[^<>]*>`)
+	balanceTypes       = map[string]bool{"Balance": true, "BalanceOf": true}
+	accountTypes       = map[string]bool{"AccountId": true, "AccountId32": true, "AccountIdOf": true}
+	lookupTypes        = map[string]bool{"AccountIdLookupOf": true, "MultiAddress": true, "Address": true, "LookupSource": true, "Source": true}
+)
+
+// baseTypeName returns the last segment of a type name without generic arguments, looking through
+// Compact, e.g. Balance for Compact<T::Balance> and BalanceOf for BalanceOf<T, I>.
+func baseTypeName(typeName string) string {
+	if inner, ok := strings.CutPrefix(typeName, "Compact<"); ok {
+		typeName = strings.TrimSuffix(inner, ">")
+	}
+	for genericArgsPattern.MatchString(typeName) {
+		typeName = genericArgsPattern.ReplaceAllString(typeName, "")
+	}
+	if i := strings.LastIndex(typeName, "::"); i >= 0 {
+		typeName = typeName[i+2:]
+	}
+	return typeName
+}
+
+// accountBytes returns the public key of an AccountId32, which the v14 decoder decodes to a
+// newtype around [u8; 32], nested in another newtype in the variants of MultiAddress.
+func accountBytes(value any) ([]byte, bool) {
+	for {
+		if b, ok := constantBytes(value); ok {
+			return b, len(b) == 32
+		}
+		fields, ok := value.(map[string]any)
+		if !ok || len(fields) != 1 {
+			return nil, false
+		}
+		if value, ok = fields["unnamed"]; !ok {
+			return nil, false
+		}
+	}
+}
+
+// value renders a decoded value, of the v14 decoder or a scale.Value. typeName is the name of its
+// type in the metadata, if known, which tells balances and accounts apart from other values.
+func (o JSONOptions) value(value any, typeName string) any {
+	baseType := baseTypeName(typeName)
+	bits := intTypeBits[baseType]
+	if accountTypes[baseType] && o.Format != FormatLossless {
+		if b, ok := accountBytes(value); ok {
+			return o.account(b)
+		}
+	}
+	if lookupTypes[baseType] && o.Format != FormatLossless {
+		if fields, ok := value.(map[string]any); ok && len(fields) == 1 {
+			if b, ok := accountBytes(fields["Id"]); ok {
+				return object{{o.variantName("Id"), o.account(b)}}
+			}
+		}
+	}
+	if !balanceTypes[baseType] {
+		typeName = ""
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case scale.Value:
+		return o.scaleValue(v, typeName)
+	case *DecodedPalletVariant:
+		return o.call(v)
+	case *big.Int:
+		return o.integer(v, bits, typeName)
+	case uint8, int8:
+		n, _ := constantInt(v)
+		return o.integer(n, 8, typeName)
+	case uint16, int16:
+		n, _ := constantInt(v)
+		return o.integer(n, 16, typeName)
+	case uint32, int32:
+		n, _ := constantInt(v)
+		return o.integer(n, 32, typeName)
+	case uint64, int64:
+		n, _ := constantInt(v)
+		return o.integer(n, 64, typeName)
+	case []byte:
+		return o.bytes(v)
+	case []any:
+		if b, ok := constantBytes(v); ok && len(v) > 0 && o.Format != FormatLossless {
+			return o.bytes(b)
+		}
+		list := make([]any, len(v))
+		for i, elem := range v {
+			list[i] = o.value(elem, "")
+		}
+		return list
+	case map[string]any:
+		return o.composite(v)
+	default:
+		return v
+	}
+}
+
+// composite renders a struct or an enum of the v14 decoder, which decodes both to maps: an enum
+// to a map with its variant name as the only key, a struct to its fields, "unnamed" for those of
+// tuple structs.
+func (o JSONOptions) composite(fields map[string]any) any {
+	if o.Format == FormatLossless {
+		return o.fields(fields)
+	}
+
+	if len(fields) == 1 {
+		for name, inner := range fields {
+			if name == "unnamed" {
+				return o.value(inner, "")
+			}
+			if first, _ := utf8.DecodeRuneInString(name); !unicode.IsUpper(first) {
+				break
+			}
+
+			// A variant.
+			payload, _ := inner.(map[string]any)
+			switch {
+			case name == "None" && len(payload) == 0:
+				return nil
+			case name == "Some":
+				return o.value(inner, "")
+			case inner == nil || (payload != nil && len(payload) == 0):
+				return name
+			}
+			return object{{o.variantName(name), o.value(inner, "")}}
+		}
+	}
+	return o.fields(fields)
+}
+
+func (o JSONOptions) fields(fields map[string]any) object {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	obj := make(object, len(names))
+	for i, name := range names {
+		obj[i] = member{o.name(name), o.value(fields[name], "")}
+	}
+	return obj
+}
+
+// scaleValue renders a scale.Value, as decoded with a legacy type registry.
+func (o JSONOptions) scaleValue(v scale.Value, typeName string) any {
+	if o.Format == FormatLossless {
+		return v
+	}
+
+	switch v.Kind {
+	case scale.ValueKindInt, scale.ValueKindCompact:
+		return o.integer(v.Int, 0, typeName)
+	case scale.ValueKindBool:
+		return v.Bool
+	case scale.ValueKindBytes:
+		return o.bytes(v.Bytes)
+	case scale.ValueKindText:
+		return v.Text
+	case scale.ValueKindList:
+		list := make([]any, len(v.List))
+		for i, elem := range v.List {
+			list[i] = o.scaleValue(elem, "")
+		}
+		return list
+	case scale.ValueKindStruct:
+		return o.scaleFields(v.Fields)
+	case scale.ValueKindVariant:
+		if v.Variant.Fields == nil {
+			return v.Variant.Name
+		}
+		var payload any
+		switch fields := v.Variant.Fields; {
+		case len(fields) == 0:
+		case fields[0].Name != "":
+			payload = o.scaleFields(fields)
+		case len(fields) == 1:
+			payload = o.scaleValue(fields[0].Value, "")
+		default:
+			list := make([]any, len(fields))
+			for i, field := range fields {
+				list[i] = o.scaleValue(field.Value, "")
+			}
+			payload = list
+		}
+		return object{{o.variantName(v.Variant.Name), payload}}
+	case scale.ValueKindOption:
+		if v.Option == nil {
+			return nil
+		}
+		return o.scaleValue(*v.Option, typeName)
+	case scale.ValueKindBitSequence:
+		return hexString(scale.PackBits(v.Bits))
+	default:
+		return nil
+	}
+}
+
+func (o JSONOptions) scaleFields(fields []scale.Field) object {
+	obj := make(object, len(fields))
+	for i, field := range fields {
+		obj[i] = member{o.name(field.Name), o.scaleValue(field.Value, "")}
+	}
+	return obj
+}
+
+var (
+	maxSafeInteger = big.NewInt(1<<53 - 1)
+	u128Max        = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+	// intTypeBits are the bit widths of the integer types a type name may name. The v14 decoder
+	// decodes u128, u256, i128, i256 and compact integers to *big.Int, which only the type name
+	// tells apart.
+	intTypeBits = map[string]int{
+		"u8": 8, "u16": 16, "u32": 32, "u64": 64, "u128": 128, "u256": 256,
+		"i8": 8, "i16": 16, "i32": 32, "i64": 64, "i128": 128, "i256": 256,
+	}
+)
+
+// integer renders an integer of a type of the given bit width, a balance if typeName is not
+// empty. A width of 0 is unknown, and taken as 128 bits, the width of balances, or 256 bits for
+// integers that need more.
+func (o JSONOptions) integer(n *big.Int, bits int, typeName string) any {
+	if n == nil {
+		return nil
+	}
+	switch o.Format {
+	case FormatLossless:
+		return n
+	case FormatToHuman:
+		if typeName != "" && o.Symbol != "" {
+			if n.Cmp(u128Max) == 0 {
+				return "everything"
+			}
+			return formatBalance(n, o.Decimals, o.Symbol)
+		}
+		return formatNumber(n.String())
+	default:
+		if bits == 0 {
+			bits = 128
+			if n.BitLen() > 128 {
+				bits = 256
+			}
+		}
+		// Like AbstractInt.toJSON, which checks the bit length for types up to 128 bits.
+		abs := new(big.Int).Abs(n)
+		if (bits <= 128 && abs.BitLen() > 52) || abs.Cmp(maxSafeInteger) > 0 {
+			return hexInteger(n, bits)
+		}
+		return n.Int64()
+	}
+}
+
+// hexInteger writes an integer of a type of the given bit width like the toHex() of polkadot.js:
+// zero-padded to the width, in two's complement if negative.
+func hexInteger(n *big.Int, bits int) string {
+	if n.Sign() < 0 {
+		n = new(big.Int).Add(n, new(big.Int).Lsh(big.NewInt(1), uint(bits)))
+	}
+	digits := n.Text(16)
+	if pad := bits/4 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	return "0x" + digits
+}
+
+// bytes renders bytes in hex, or as text in FormatToHuman if they are printable ASCII.
+func (o JSONOptions) bytes(b []byte) any {
+	if o.Format == FormatToHuman && len(b) > 0 && isPrintableASCII(b) {
+		return string(b)
+	}
+	return hexString(b)
+}
+
+func isPrintableASCII(b []byte) bool {
+	for _, c := range b {
+		if (c < 0x20 || c > 0x7e) && c != '\t' && c != '\n' && c != '\r' {
+			return false
+		}
+	}
+	return true
+}
+
+func hexString(b []byte) string {
+	return "0x" + hex.EncodeToString(b)
+}
+
+// camelCase converts a name to the camelCase of polkadot.js, e.g. transfer_keep_alive to
+// transferKeepAlive and Balances to balances.
+func camelCase(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' || r == ' ' })
+	var out strings.Builder
+	for i, word := range words {
+		if word == strings.ToUpper(word) {
+			// An acronym, e.g. XCM.
+			word = strings.ToLower(word)
+		}
+		first, size := utf8.DecodeRuneInString(word)
+		if i == 0 {
+			out.WriteRune(unicode.ToLower(first))
+		} else {
+			out.WriteRune(unicode.ToUpper(first))
+		}
+		out.WriteString(word[size:])
+	}
+	return out.String()
+}
+
+// formatNumber writes an integer with thousands separators, e.g. 1,234,567.
+func formatNumber(digits string) string {
+	sign := ""
+	if rest, ok := strings.CutPrefix(digits, "-"); ok {
+		sign, digits = "-", rest
+	}
+	var out strings.Builder
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			out.WriteByte(',')
+		}
+		out.WriteRune(c)
+	}
+	return sign + out.String()
+}
+
+// siPrefixes are the SI prefixes formatBalance scales balances by, from 10^-15 to 10^24.
+var siPrefixes = []string{"f", "p", "n", "µ", "m", "", "k", "M", "B", "T", "P", "E", "Z", "Y"}
+
+const siUnit = 5 // the index of the empty prefix in siPrefixes
+
+// formatBalance writes a balance like the formatBalance of polkadot.js, with four decimals and an
+// SI prefix, e.g. 1.2345 kDOT for 12345 * 10^(decimals-1).
+func formatBalance(n *big.Int, decimals int, symbol string) string {
+	text := n.String()
+	if text == "0" {
+		return "0"
+	}
+	sign := ""
+	if rest, ok := strings.CutPrefix(text, "-"); ok {
+		sign, text = "-", rest
+	}
+
+	// Pick the prefix from the number of digits of the integer part, like calcSi.
+	excess := len(text) - decimals
+	si := siUnit - 1 + (excess+2)/3
+	if excess <= 0 {
+		si = siUnit - 1 - (-excess)/3
+	}
+	si = max(0, min(si, len(siPrefixes)-1))
+	power := (si - siUnit) * 3
+
+	mid := len(text) - (decimals + power)
+	pre := "0"
+	if mid > 0 {
+		pre = text[:mid]
+	}
+	post := text
+	if mid < 0 {
+		post = strings.Repeat("0", -mid) + text
+	} else {
+		post = text[mid:]
+	}
+	post = (post + "0000")[:4]
+
+	return fmt.Sprintf("%s%s.%s %s%s", sign, formatNumber(pre), post, siPrefixes[si], symbol)
+}
@@ -1,13 +1,18 @@
 package models
 
 import (
+	"fmt"
+	"math/big"
 	"submarine/metadata/base"
 )
 
 // DecodedArg holds the name and decoded value of a single extrinsic argument.
 type DecodedArg struct {
-	Name  string
-	Value any // Using `any` to hold various decoded types.
+	Name string
+	// TypeName is the name of the argument's type in the metadata, e.g. "T::Balance" or
+	// "AccountIdLookupOf<T>", empty if the metadata doesn't give one.
+	TypeName string
+	Value    any // Using `any` to hold various decoded types.
 }
 
 // DecodedCall represents the action part of an extrinsic.
@@ -19,14 +24,28 @@ type DecodedCall struct {
 
 // DecodedExtrinsic represents the full decoded extrinsic.
 type DecodedExtrinsic struct {
-	Signature base.Signature
-	Call      DecodedPalletVariant
+	Version    uint8 // extrinsic format version, without the signed bit
+	IsSigned   bool
+	Address    base.Address // only set for signed extrinsics
+	Signature  base.Signature
+	Extensions []DecodedSignedExtension
+	Call       DecodedPalletVariant
+	CallBytes  []byte // raw encoding of Call, as covered by the signature
+	Raw        []byte // the encoded extrinsic, with its length prefix
+}
+
+// DecodedSignedExtension is the value of a signed extension carried by a signed extrinsic.
+type DecodedSignedExtension struct {
+	Identifier string
+	Value      any
+	Raw        []byte // raw encoding of Value
 }
 
 type EventRecord struct {
 	Phase EventPhase
 	Event DecodedEvent
-	// Topics are skipped for this implementation but would be a [][]byte.
+	// Topics are the hashes the event was deposited with, for indexed lookups.
+	Topics [][32]byte
 }
 
 type EventPhase struct {
@@ -37,14 +56,103 @@ type EventPhase struct {
 }
 
 type DecodedEvent struct {
-	PalletName string
-	EventName  string
-	Args       []DecodedArg
+	PalletName  string
+	EventName   string
+	PalletIndex uint8
+	EventIndex  uint8
+	Args        []DecodedArg
 }
 
 // DecodedPalletVariant is a generic representation of a decoded call or event.
 type DecodedPalletVariant struct {
-	PalletName  string
-	VariantName string
-	Args        []DecodedArg
+	PalletName   string
+	VariantName  string
+	PalletIndex  uint8
+	VariantIndex uint8
+	Args         []DecodedArg
+}
+
+// DecodedBlock is a block with its extrinsics matched to the events they emitted.
+type DecodedBlock struct {
+	Extrinsics           []BlockExtrinsic
+	InitializationEvents []EventRecord
+	FinalizationEvents   []EventRecord
+}
+
+// FilteredBlock holds the extrinsics and events of a block selected by a BlockFilter.
+type FilteredBlock struct {
+	// Extrinsics are the selected extrinsics, with their outcome and all their events.
+	Extrinsics []BlockExtrinsic
+	// Events are the selected events, in the order they were deposited.
+	Events []EventRecord
+}
+
+// BlockExtrinsic is an extrinsic of a block together with the outcome of applying it.
+type BlockExtrinsic struct {
+	Index     int
+	Extrinsic DecodedExtrinsic
+	Events    []EventRecord
+	Success   bool
+	// DispatchError is the dispatch_error of System.ExtrinsicFailed, nil on success.
+	DispatchError *DispatchError
+	// DispatchInfo is taken from System.ExtrinsicSuccess or System.ExtrinsicFailed.
+	DispatchInfo *DispatchInfo
+	// Fee and Tip are taken from TransactionPayment.TransactionFeePaid, nil if it wasn't emitted
+	// (unsigned extrinsics, or runtimes predating the event).
+	Fee *big.Int
+	Tip *big.Int
+}
+
+// DispatchInfo describes the weight and fee class of a dispatched call.
+type DispatchInfo struct {
+	RefTime   *big.Int
+	ProofSize *big.Int // zero for runtimes with single-dimension weights
+	Class     string   // Normal, Operational or Mandatory
+	PaysFee   bool
+}
+
+// DispatchError is a decoded sp_runtime::DispatchError.
+type DispatchError struct {
+	// Kind is the DispatchError variant, e.g. Module, BadOrigin or Token.
+	Kind string
+	// Detail is the inner error of the Token, Arithmetic and Transactional variants,
+	// e.g. FundsUnavailable for Token(FundsUnavailable).
+	Detail string
+	// Module is set for the Module variant.
+	Module *ModuleError
+}
+
+func (e *DispatchError) Error() string {
+	switch {
+	case e.Module != nil:
+		return e.Module.Error()
+	case e.Detail != "":
+		return e.Kind + "." + e.Detail
+	default:
+		return e.Kind
+	}
+}
+
+// ModuleError is an error returned by a pallet, resolved against the pallet's error type.
+type ModuleError struct {
+	PalletIndex uint8
+	ErrorIndex  uint8
+	// ErrorBytes are the error bytes as encoded, the first of which is ErrorIndex.
+	ErrorBytes []byte
+	// PalletName, ErrorName and Docs are empty if the metadata has no such error.
+	PalletName string
+	ErrorName  string
+	Docs       []string
+}
+
+// Resolved reports whether the error was found in the metadata.
+func (e *ModuleError) Resolved() bool {
+	return e.PalletName != ""
+}
+
+func (e *ModuleError) Error() string {
+	if !e.Resolved() {
+		return fmt.Sprintf("Module(index: %d, error: 0x%x)", e.PalletIndex, e.ErrorBytes)
+	}
+	return e.PalletName + "." + e.ErrorName
 }
@@ -0,0 +1,176 @@
+package v14
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"submarine/chain"
+	. "submarine/decoder/models"
+	"submarine/metadata/generated/v14"
+	"submarine/rpc"
+)
+
+// DecodeBlock decodes the extrinsics of a block and the System.Events of the same block,
+// attributing every ApplyExtrinsic event to its extrinsic. The extrinsics are checked against
+// the extrinsics root of the block header first.
+func DecodeBlock(metadata *v14.Metadata, block *rpc.SignedBlock, eventsBytes []byte) (*DecodedBlock, error) {
+	decoded := &DecodedBlock{
+		Extrinsics: make([]BlockExtrinsic, len(block.Block.Extrinsics)),
+	}
+
+	rawExtrinsics, err := verifiedExtrinsics(block)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, extBytes := range rawExtrinsics {
+		ext, err := DecodeExtrinsic(metadata, extBytes)
+		if err != nil {
+			return nil, fmt.Errorf("extrinsic #%d: %w", i, err)
+		}
+		decoded.Extrinsics[i] = BlockExtrinsic{Index: i, Extrinsic: *ext}
+	}
+
+	events, err := DecodeEvents(metadata, eventsBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range events {
+		switch {
+		case record.Phase.IsInitialization:
+			decoded.InitializationEvents = append(decoded.InitializationEvents, record)
+		case record.Phase.IsFinalization:
+			decoded.FinalizationEvents = append(decoded.FinalizationEvents, record)
+		case record.Phase.IsApplyExtrinsic:
+			index := int(record.Phase.AsApplyExtrinsic)
+			if index >= len(decoded.Extrinsics) {
+				return nil, fmt.Errorf("event %s.%s refers to extrinsic #%d, but the block has %d extrinsics",
+					record.Event.PalletName, record.Event.EventName, index, len(decoded.Extrinsics))
+			}
+			if err := addExtrinsicEvent(metadata, &decoded.Extrinsics[index], record); err != nil {
+				return nil, fmt.Errorf("extrinsic #%d: %w", index, err)
+			}
+		}
+	}
+
+	return decoded, nil
+}
+
+// verifiedExtrinsics returns the extrinsics of the block, checked against its extrinsics root.
+func verifiedExtrinsics(block *rpc.SignedBlock) ([][]byte, error) {
+	rawExtrinsics := make([][]byte, len(block.Block.Extrinsics))
+	for i, extHex := range block.Block.Extrinsics {
+		extBytes, err := hex.DecodeString(strings.TrimPrefix(extHex, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("extrinsic #%d: invalid hex: %w", i, err)
+		}
+		rawExtrinsics[i] = extBytes
+	}
+
+	// Make sure the body is the one the header commits to before making sense of it.
+	if err := chain.VerifyExtrinsicsRoot(&block.Block.Header, rawExtrinsics); err != nil {
+		return nil, err
+	}
+	return rawExtrinsics, nil
+}
+
+// addExtrinsicEvent records an event emitted by the extrinsic, picking up its outcome and fee.
+// Event arguments are read by position, since runtimes before named event fields leave them unnamed.
+func addExtrinsicEvent(metadata *v14.Metadata, ext *BlockExtrinsic, record EventRecord) error {
+	ext.Events = append(ext.Events, record)
+	args := record.Event.Args
+
+	var err error
+	switch record.Event.PalletName + "." + record.Event.EventName {
+	case "System.ExtrinsicSuccess":
+		if len(args) != 1 {
+			return fmt.Errorf("ExtrinsicSuccess: expected 1 arg, got %d", len(args))
+		}
+		ext.Success = true
+		ext.DispatchInfo, err = decodeDispatchInfo(args[0].Value)
+
+	case "System.ExtrinsicFailed":
+		if len(args) != 2 {
+			return fmt.Errorf("ExtrinsicFailed: expected 2 args, got %d", len(args))
+		}
+		ext.Success = false
+		if ext.DispatchError, err = ResolveDispatchError(metadata, args[0].Value); err != nil {
+			return err
+		}
+		ext.DispatchInfo, err = decodeDispatchInfo(args[1].Value)
+
+	case "TransactionPayment.TransactionFeePaid":
+		if len(args) != 3 {
+			return fmt.Errorf("TransactionFeePaid: expected 3 args, got %d", len(args))
+		}
+		if ext.Fee, err = toBigInt(args[1].Value); err != nil {
+			return fmt.Errorf("TransactionFeePaid: actual_fee: %w", err)
+		}
+		if ext.Tip, err = toBigInt(args[2].Value); err != nil {
+			return fmt.Errorf("TransactionFeePaid: tip: %w", err)
+		}
+	}
+	return err
+}
+
+// decodeDispatchInfo converts a DispatchInfo decoded by DecodeArg. The weight is either a
+// Weight { ref_time, proof_size }, a Weight { ref_time } on the runtimes between the u64 weight
+// and WeightV2, or, on older runtimes, a plain u64.
+func decodeDispatchInfo(value any) (*DispatchInfo, error) {
+	fields, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("dispatch info: expected a struct, got %T", value)
+	}
+
+	var info DispatchInfo
+	var err error
+	switch weight := fields["weight"].(type) {
+	case map[string]any:
+		if info.RefTime, err = toBigInt(weight["ref_time"]); err != nil {
+			return nil, fmt.Errorf("dispatch info: ref_time: %w", err)
+		}
+		info.ProofSize = new(big.Int)
+		if proofSize, ok := weight["proof_size"]; ok {
+			if info.ProofSize, err = toBigInt(proofSize); err != nil {
+				return nil, fmt.Errorf("dispatch info: proof_size: %w", err)
+			}
+		}
+	default:
+		if info.RefTime, err = toBigInt(weight); err != nil {
+			return nil, fmt.Errorf("dispatch info: weight: %w", err)
+		}
+		info.ProofSize = new(big.Int)
+	}
+
+	if info.Class, err = variantName(fields["class"]); err != nil {
+		return nil, fmt.Errorf("dispatch info: class: %w", err)
+	}
+	paysFee, err := variantName(fields["pays_fee"])
+	if err != nil {
+		return nil, fmt.Errorf("dispatch info: pays_fee: %w", err)
+	}
+	info.PaysFee = paysFee == "Yes"
+
+	return &info, nil
+}
+
+// variantName returns the name of an enum value decoded by DecodeArg.
+func variantName(value any) (string, error) {
+	name, _, err := variantValue(value)
+	return name, err
+}
+
+func toBigInt(value any) (*big.Int, error) {
+	switch v := value.(type) {
+	case *big.Int:
+		return v, nil
+	case uint64:
+		return new(big.Int).SetUint64(v), nil
+	case uint32:
+		return big.NewInt(int64(v)), nil
+	default:
+		return nil, fmt.Errorf("expected an integer, got %T", value)
+	}
+}
@@ -0,0 +1,223 @@
+package v14_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"submarine/chain"
+	. "submarine/decoder/models"
+	. "submarine/decoder/v14"
+	"submarine/decoder/v14/v14test"
+	"submarine/rpc"
+	"submarine/trie"
+	"testing"
+)
+
+// unsignedRemark is System.remark(0x) as an unsigned extrinsic.
+const unsignedRemark = "0x1004000000"
+
+// testBlock returns a block with the given extrinsics and a header committing to them.
+func testBlock(extrinsics ...string) *rpc.SignedBlock {
+	return testBlockWithLayout(trie.LayoutV0, extrinsics...)
+}
+
+func testBlockWithLayout(layout trie.Layout, extrinsics ...string) *rpc.SignedBlock {
+	raw := make([][]byte, len(extrinsics))
+	for i, ext := range extrinsics {
+		raw[i], _ = hex.DecodeString(strings.TrimPrefix(ext, "0x"))
+	}
+	root := chain.ExtrinsicsRoot(raw, layout)
+
+	var block rpc.SignedBlock
+	block.Block.Header.ExtrinsicsRoot = "0x" + hex.EncodeToString(root[:])
+	block.Block.Extrinsics = extrinsics
+	return &block
+}
+
+func eventNames(records []EventRecord) []string {
+	var names []string
+	for _, record := range records {
+		names = append(names, record.Event.PalletName+"."+record.Event.EventName)
+	}
+	return names
+}
+
+func equalBigInt(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
+
+func TestDecodeBlock(t *testing.T) {
+	account := make([]byte, 32)
+	// DispatchError::Arithmetic(Overflow)
+	dispatchError := []byte{8, 1}
+
+	block := testBlock(unsignedRemark, "0x"+signedTransferSr25519, "0x"+signedTransferSr25519)
+	events := v14test.Events(
+		v14test.EventRecord(v14test.Initialization(), v14test.BalancesIndex, 7, account, v14test.U128(1)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(0), v14test.SystemIndex, 0, v14test.DispatchInfo(100, 0, 2, 0)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(1), v14test.BalancesIndex, 7, account, v14test.U128(1500)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(1), v14test.BalancesIndex, 2, account, account, v14test.U128(12345)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(1), v14test.TransactionPaymentIndex, 0, account, v14test.U128(1500), v14test.U128(7)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(1), v14test.SystemIndex, 0, v14test.DispatchInfo(200, 3593, 0, 0)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(2), v14test.BalancesIndex, 7, account, v14test.U128(900)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(2), v14test.TransactionPaymentIndex, 0, account, v14test.U128(900), v14test.U128(0)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(2), v14test.SystemIndex, 1, dispatchError, v14test.DispatchInfo(300, 10, 1, 1)),
+		v14test.EventRecord(v14test.Finalization(), v14test.BalancesIndex, 7, account, v14test.U128(2)),
+	)
+
+	decoded, err := DecodeBlock(v14test.NewMetadata(), block, events)
+	if err != nil {
+		t.Fatalf("decode block: %v", err)
+	}
+
+	if got := eventNames(decoded.InitializationEvents); !reflect.DeepEqual(got, []string{"Balances.Withdraw"}) {
+		t.Errorf("initialization events = %v", got)
+	}
+	if got := eventNames(decoded.FinalizationEvents); !reflect.DeepEqual(got, []string{"Balances.Withdraw"}) {
+		t.Errorf("finalization events = %v", got)
+	}
+	if len(decoded.Extrinsics) != 3 {
+		t.Fatalf("got %d extrinsics, want 3", len(decoded.Extrinsics))
+	}
+
+	tests := []struct {
+		name         string
+		call         string
+		events       []string
+		success      bool
+		dispatchInfo DispatchInfo
+		fee, tip     *big.Int
+	}{
+		{
+			name:         "inherent",
+			call:         "System.remark",
+			events:       []string{"System.ExtrinsicSuccess"},
+			success:      true,
+			dispatchInfo: DispatchInfo{RefTime: big.NewInt(100), ProofSize: big.NewInt(0), Class: "Mandatory", PaysFee: true},
+		},
+		{
+			name:         "transfer",
+			call:         "Balances.transfer_keep_alive",
+			events:       []string{"Balances.Withdraw", "Balances.Transfer", "TransactionPayment.TransactionFeePaid", "System.ExtrinsicSuccess"},
+			success:      true,
+			dispatchInfo: DispatchInfo{RefTime: big.NewInt(200), ProofSize: big.NewInt(3593), Class: "Normal", PaysFee: true},
+			fee:          big.NewInt(1500),
+			tip:          big.NewInt(7),
+		},
+		{
+			name:         "failed",
+			call:         "Balances.transfer_keep_alive",
+			events:       []string{"Balances.Withdraw", "TransactionPayment.TransactionFeePaid", "System.ExtrinsicFailed"},
+			success:      false,
+			dispatchInfo: DispatchInfo{RefTime: big.NewInt(300), ProofSize: big.NewInt(10), Class: "Operational", PaysFee: false},
+			fee:          big.NewInt(900),
+			tip:          big.NewInt(0),
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext := decoded.Extrinsics[i]
+			if ext.Index != i {
+				t.Errorf("index = %d, want %d", ext.Index, i)
+			}
+			if call := ext.Extrinsic.Call.PalletName + "." + ext.Extrinsic.Call.VariantName; call != tt.call {
+				t.Errorf("call = %s, want %s", call, tt.call)
+			}
+			if got := eventNames(ext.Events); !reflect.DeepEqual(got, tt.events) {
+				t.Errorf("events = %v, want %v", got, tt.events)
+			}
+			if ext.Success != tt.success {
+				t.Errorf("success = %v, want %v", ext.Success, tt.success)
+			}
+			if (ext.DispatchError == nil) != tt.success {
+				t.Errorf("dispatch error = %v", ext.DispatchError)
+			}
+			if info := ext.DispatchInfo; info == nil ||
+				!equalBigInt(info.RefTime, tt.dispatchInfo.RefTime) || !equalBigInt(info.ProofSize, tt.dispatchInfo.ProofSize) ||
+				info.Class != tt.dispatchInfo.Class || info.PaysFee != tt.dispatchInfo.PaysFee {
+				t.Errorf("dispatch info = %+v, want %+v", ext.DispatchInfo, tt.dispatchInfo)
+			}
+			if !equalBigInt(ext.Fee, tt.fee) || !equalBigInt(ext.Tip, tt.tip) {
+				t.Errorf("fee, tip = %v, %v, want %v, %v", ext.Fee, ext.Tip, tt.fee, tt.tip)
+			}
+		})
+	}
+}
+
+func TestDecodeBlockRefTimeWeight(t *testing.T) {
+	block := testBlock(unsignedRemark)
+	events := v14test.Events(
+		v14test.EventRecord(v14test.ApplyExtrinsic(0), v14test.SystemIndex, 0, v14test.DispatchInfoRefTime(100, 2, 0)),
+	)
+	decoded, err := DecodeBlock(v14test.NewRefTimeWeightMetadata(), block, events)
+	if err != nil {
+		t.Fatalf("decode block: %v", err)
+	}
+	want := DispatchInfo{RefTime: big.NewInt(100), ProofSize: big.NewInt(0), Class: "Mandatory", PaysFee: true}
+	if info := decoded.Extrinsics[0].DispatchInfo; info == nil || !reflect.DeepEqual(*info, want) {
+		t.Errorf("dispatch info = %+v, want %+v", info, want)
+	}
+}
+
+func TestDecodeBlockEventOutOfRange(t *testing.T) {
+	events := v14test.Events(
+		v14test.EventRecord(v14test.ApplyExtrinsic(1), v14test.SystemIndex, 0, v14test.DispatchInfo(1, 1, 0, 0)),
+	)
+	if _, err := DecodeBlock(v14test.NewMetadata(), testBlock(unsignedRemark), events); err == nil {
+		t.Fatal("expected an error for an event of a missing extrinsic")
+	}
+}
+
+func TestDecodeBlockUnknownModuleError(t *testing.T) {
+	// DispatchError::Module of a pallet the metadata doesn't have, as after a runtime upgrade.
+	dispatchError := []byte{3, 99, 4, 0, 0, 0}
+	events := v14test.Events(
+		v14test.EventRecord(v14test.ApplyExtrinsic(0), v14test.SystemIndex, 1, dispatchError, v14test.DispatchInfo(100, 0, 0, 0)),
+	)
+
+	decoded, err := DecodeBlock(v14test.NewMetadata(), testBlock(unsignedRemark), events)
+	if err != nil {
+		t.Fatalf("decode block: %v", err)
+	}
+	ext := decoded.Extrinsics[0]
+	want := &ModuleError{PalletIndex: 99, ErrorIndex: 4, ErrorBytes: []byte{4, 0, 0, 0}}
+	if ext.Success || ext.DispatchError == nil || !reflect.DeepEqual(ext.DispatchError.Module, want) {
+		t.Errorf("success = %v, dispatch error = %+v, want module error %+v", ext.Success, ext.DispatchError, want)
+	}
+}
+
+func TestDecodeBlockInvalidExtrinsic(t *testing.T) {
+	if _, err := DecodeBlock(v14test.NewMetadata(), testBlock("0x"+hex.EncodeToString([]byte{0x08, 0x04, 0x63})), v14test.Events()); err == nil {
+		t.Fatal("expected an error for an extrinsic of an unknown pallet")
+	}
+}
+
+func TestDecodeBlockExtrinsicsRoot(t *testing.T) {
+	events := v14test.Events()
+	extrinsics := []string{unsignedRemark, "0x" + signedTransferSr25519}
+
+	// signedTransferSr25519 is long enough to be stored by hash with LayoutV1, so the roots differ.
+	for _, layout := range []trie.Layout{trie.LayoutV0, trie.LayoutV1} {
+		if _, err := DecodeBlock(v14test.NewMetadata(), testBlockWithLayout(layout, extrinsics...), events); err != nil {
+			t.Errorf("%s: decode block: %v", layout, err)
+		}
+	}
+
+	tampered := testBlock(extrinsics...)
+	tampered.Block.Extrinsics = []string{unsignedRemark, unsignedRemark}
+	if _, err := DecodeBlock(v14test.NewMetadata(), tampered, events); !errors.Is(err, chain.ErrExtrinsicsRootMismatch) {
+		t.Errorf("expected ErrExtrinsicsRootMismatch for a tampered body, got %v", err)
+	}
+
+	missing := testBlock(extrinsics...)
+	missing.Block.Extrinsics = missing.Block.Extrinsics[:1]
+	if _, err := DecodeBlock(v14test.NewMetadata(), missing, events); !errors.Is(err, chain.ErrExtrinsicsRootMismatch) {
+		t.Errorf("expected ErrExtrinsicsRootMismatch for a missing extrinsic, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package v14_test
+
+import (
+	"reflect"
+	. "submarine/decoder/models"
+	. "submarine/decoder/v14"
+	"submarine/decoder/v14/v14test"
+	"testing"
+)
+
+func TestDecodeNestedCalls(t *testing.T) {
+	alice := make([]byte, 32)
+	alice[0] = 0xa1
+	bob := make([]byte, 32)
+	bob[0] = 0xb0
+
+	transfer := v14test.Concat([]byte{v14test.BalancesIndex, 3, 0}, bob, v14test.Compact(1000))
+	remark := v14test.Concat([]byte{v14test.SystemIndex, 0}, v14test.Compact(1), []byte{0x01})
+	sudo := v14test.Concat([]byte{v14test.SudoIndex, 0}, remark)
+	proxy := v14test.Concat([]byte{v14test.ProxyIndex, 0, 0}, alice, []byte{0}, sudo)
+	multisig := v14test.Concat([]byte{v14test.MultisigIndex, 0}, v14test.Compact(1), bob, transfer)
+	batch := v14test.Concat([]byte{v14test.UtilityIndex, 2}, v14test.Compact(3), transfer, proxy, multisig)
+
+	body := v14test.Concat([]byte{0x04}, batch)
+	ext, err := DecodeExtrinsic(v14test.NewMetadata(), v14test.Concat(v14test.Compact(uint64(len(body))), body))
+	if err != nil {
+		t.Fatalf("decode extrinsic: %v", err)
+	}
+
+	calls, ok := ext.Call.Args[0].Value.([]any)
+	if !ok || len(calls) != 3 {
+		t.Fatalf("batch_all calls = %#v, want 3 decoded calls", ext.Call.Args[0].Value)
+	}
+	inner, ok := calls[1].(*DecodedPalletVariant)
+	if !ok || inner.PalletName != "Proxy" || inner.VariantName != "proxy" {
+		t.Fatalf("calls[1] = %#v, want Proxy.proxy", calls[1])
+	}
+
+	var visited []string
+	ext.Call.Walk(func(path CallPath, call *DecodedPalletVariant) bool {
+		visited = append(visited, call.PalletName+"."+call.VariantName)
+		return call.PalletName != "Proxy"
+	})
+	wantVisited := []string{"Utility.batch_all", "Balances.transfer_keep_alive", "Proxy.proxy", "Multisig.as_multi_threshold_1", "Balances.transfer_keep_alive"}
+	if !reflect.DeepEqual(visited, wantVisited) {
+		t.Errorf("walk visited %v, want %v", visited, wantVisited)
+	}
+
+	type flatCall struct{ path, call string }
+	var flat []flatCall
+	for _, nested := range ext.Call.Flatten() {
+		flat = append(flat, flatCall{nested.Path.String(), nested.Call.PalletName + "." + nested.Call.VariantName})
+	}
+	wantFlat := []flatCall{
+		{"Utility.batch_all.calls[0]", "Balances.transfer_keep_alive"},
+		{"Utility.batch_all.calls[1] > Proxy.proxy.call > Sudo.sudo.call", "System.remark"},
+		{"Utility.batch_all.calls[2] > Multisig.as_multi_threshold_1.call", "Balances.transfer_keep_alive"},
+	}
+	if !reflect.DeepEqual(flat, wantFlat) {
+		t.Errorf("flatten:\n got %v\nwant %v", flat, wantFlat)
+	}
+}
+
+func TestFlattenPlainCall(t *testing.T) {
+	ext, err := DecodeExtrinsic(v14test.NewMetadata(), mustDecodeHex(t, signedTransferSr25519))
+	if err != nil {
+		t.Fatalf("decode extrinsic: %v", err)
+	}
+
+	flat := ext.Call.Flatten()
+	if len(flat) != 1 || flat[0].Call != &ext.Call || len(flat[0].Path) != 0 {
+		t.Errorf("flatten = %+v, want the call itself", flat)
+	}
+}
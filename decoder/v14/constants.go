@@ -0,0 +1,89 @@
+package v14
+
+import (
+	"fmt"
+	. "submarine/decoder/models"
+	"submarine/metadata/generated/scaleInfo"
+	"submarine/metadata/generated/v14"
+	. "submarine/scale"
+)
+
+// DecodeConstants decodes the constants of every pallet from the metadata alone.
+func DecodeConstants(metadata *v14.Metadata) (Constants, error) {
+	var constants Constants
+	for _, pallet := range metadata.Pallets {
+		for _, constant := range pallet.Constants {
+			r := NewReader(constant.Value)
+			value, err := DecodeArg(metadata, r, constant.Type)
+			if err != nil {
+				return nil, fmt.Errorf("constant %s.%s: %w", pallet.Name, constant.Name, err)
+			}
+			if r.Remaining() != 0 {
+				return nil, fmt.Errorf("constant %s.%s: %d trailing bytes", pallet.Name, constant.Name, r.Remaining())
+			}
+
+			constants = append(constants, DecodedConstant{
+				PalletName: pallet.Name,
+				Name:       constant.Name,
+				TypeName:   typeName(metadata, constant.Type),
+				Value:      value,
+				Raw:        constant.Value,
+				Docs:       constant.Docs,
+			})
+		}
+	}
+	return constants, nil
+}
+
+var primitiveNames = map[scaleInfo.Si0TypeDefPrimitive]string{
+	scaleInfo.Si0TypeDefPrimitiveBool: "bool",
+	scaleInfo.Si0TypeDefPrimitiveChar: "char",
+	scaleInfo.Si0TypeDefPrimitiveStr:  "str",
+	scaleInfo.Si0TypeDefPrimitiveU8:   "u8",
+	scaleInfo.Si0TypeDefPrimitiveU16:  "u16",
+	scaleInfo.Si0TypeDefPrimitiveU32:  "u32",
+	scaleInfo.Si0TypeDefPrimitiveU64:  "u64",
+	scaleInfo.Si0TypeDefPrimitiveU128: "u128",
+	scaleInfo.Si0TypeDefPrimitiveU256: "u256",
+	scaleInfo.Si0TypeDefPrimitiveI8:   "i8",
+	scaleInfo.Si0TypeDefPrimitiveI16:  "i16",
+	scaleInfo.Si0TypeDefPrimitiveI32:  "i32",
+	scaleInfo.Si0TypeDefPrimitiveI64:  "i64",
+	scaleInfo.Si0TypeDefPrimitiveI128: "i128",
+	scaleInfo.Si0TypeDefPrimitiveI256: "i256",
+}
+
+// typeName returns a readable name for a type: its path, or its shape for anonymous types like
+// primitives, Vec<u8> or [u8; 8].
+func typeName(metadata *v14.Metadata, typeID scaleInfo.Si1LookupTypeId) string {
+	typ, ok := findType(metadata, typeID)
+	if !ok {
+		return fmt.Sprintf("<unknown type %s>", typeID)
+	}
+	if typ.Path != "" {
+		return typ.Path
+	}
+
+	def := typ.Def
+	switch def.Kind {
+	case scaleInfo.Si1TypeDefKindPrimitive:
+		return primitiveNames[*def.Primitive]
+	case scaleInfo.Si1TypeDefKindCompact:
+		return "Compact<" + typeName(metadata, def.Compact.Type) + ">"
+	case scaleInfo.Si1TypeDefKindSequence:
+		return "Vec<" + typeName(metadata, def.Sequence.Type) + ">"
+	case scaleInfo.Si1TypeDefKindArray:
+		return fmt.Sprintf("[%s; %d]", typeName(metadata, def.Array.Type), def.Array.Len)
+	case scaleInfo.Si1TypeDefKindTuple:
+		name := "("
+		for i, item := range *def.Tuple {
+			if i > 0 {
+				name += ", "
+			}
+			name += typeName(metadata, item)
+		}
+		return name + ")"
+	default:
+		return fmt.Sprintf("<type %s>", typeID)
+	}
+}
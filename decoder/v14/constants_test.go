@@ -0,0 +1,66 @@
+package v14_test
+
+import (
+	"errors"
+	"math/big"
+	. "submarine/decoder/models"
+	. "submarine/decoder/v14"
+	"submarine/decoder/v14/v14test"
+	"testing"
+)
+
+func TestDecodeConstants(t *testing.T) {
+	constants, err := DecodeConstants(v14test.NewMetadata())
+	if err != nil {
+		t.Fatalf("decode constants: %v", err)
+	}
+	if len(constants) != 4 || len(constants.Pallet("System")) != 3 {
+		t.Fatalf("got %d constants", len(constants))
+	}
+
+	ed, err := constants.BigInt("Balances", "ExistentialDeposit")
+	if err != nil || ed.Cmp(big.NewInt(v14test.ExistentialDeposit)) != 0 {
+		t.Errorf("ExistentialDeposit = %v, %v", ed, err)
+	}
+	prefix, err := constants.Uint16("System", "SS58Prefix")
+	if err != nil || prefix != 0 {
+		t.Errorf("SS58Prefix = %d, %v", prefix, err)
+	}
+	hashCount, err := constants.Uint32("System", "BlockHashCount")
+	if err != nil || hashCount != 4096 {
+		t.Errorf("BlockHashCount = %d, %v", hashCount, err)
+	}
+
+	weights, err := constants.Struct("System", "BlockWeights")
+	if err != nil {
+		t.Fatalf("BlockWeights: %v", err)
+	}
+	maxBlock, ok := weights["max_block"].(map[string]any)
+	if !ok {
+		t.Fatalf("max_block = %#v", weights["max_block"])
+	}
+	refTime, _ := maxBlock["ref_time"].(*big.Int)
+	proofSize, _ := maxBlock["proof_size"].(*big.Int)
+	if refTime == nil || refTime.Uint64() != v14test.MaxBlockRefTime || proofSize == nil || proofSize.Uint64() != v14test.MaxBlockProofSize {
+		t.Errorf("max_block = %v", maxBlock)
+	}
+
+	constant, _ := constants.Get("System", "BlockWeights")
+	if constant.TypeName != "frame_system::limits::BlockWeights" || len(constant.Docs) != 1 {
+		t.Errorf("BlockWeights type %q, docs %q", constant.TypeName, constant.Docs)
+	}
+	constant, _ = constants.Get("System", "SS58Prefix")
+	if constant.TypeName != "u16" {
+		t.Errorf("SS58Prefix type %q", constant.TypeName)
+	}
+
+	if _, err := constants.Uint16("Balances", "ExistentialDeposit"); err == nil {
+		t.Error("expected an error for ExistentialDeposit as u16")
+	}
+	if _, err := constants.BigInt("Balances", "MaxLocks"); !errors.Is(err, ErrConstantNotFound) {
+		t.Errorf("error = %v, want %v", err, ErrConstantNotFound)
+	}
+	if _, err := constants.Struct("Balances", "ExistentialDeposit"); err == nil {
+		t.Error("expected an error for ExistentialDeposit as a struct")
+	}
+}
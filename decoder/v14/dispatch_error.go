@@ -0,0 +1,124 @@
+package v14
+
+import (
+	"fmt"
+	. "submarine/decoder/models"
+	"submarine/metadata/generated/scaleInfo"
+	"submarine/metadata/generated/v14"
+)
+
+// ResolveDispatchError converts a DispatchError decoded by DecodeArg into a DispatchError,
+// looking up module errors in the metadata of the erroring pallet.
+//
+// Module errors are ModuleError { index: u8, error: [u8; 4] } on current runtimes, where only the
+// first byte of error selects the variant, and { index: u8, error: u8 } on older ones. Module
+// errors the metadata doesn't know, as of a pallet added by a later runtime upgrade, are left
+// unresolved, with only their indices and error bytes.
+func ResolveDispatchError(metadata *v14.Metadata, value any) (*DispatchError, error) {
+	kind, inner, err := variantValue(value)
+	if err != nil {
+		return nil, fmt.Errorf("dispatch error: %w", err)
+	}
+
+	dispatchError := &DispatchError{Kind: kind}
+	switch kind {
+	case "Module":
+		fields, ok := unwrapUnnamed(inner).(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("dispatch error: module error: expected a struct, got %T", inner)
+		}
+		palletIndex, ok := fields["index"].(uint8)
+		if !ok {
+			return nil, fmt.Errorf("dispatch error: module error: invalid pallet index %v", fields["index"])
+		}
+		var errorBytes []byte
+		switch errorValue := fields["error"].(type) {
+		case uint8:
+			errorBytes = []byte{errorValue}
+		case []any:
+			if len(errorValue) == 0 {
+				return nil, fmt.Errorf("dispatch error: module error: empty error index")
+			}
+			errorBytes = make([]byte, len(errorValue))
+			for i, b := range errorValue {
+				if errorBytes[i], ok = b.(uint8); !ok {
+					return nil, fmt.Errorf("dispatch error: module error: invalid error index %v", errorValue)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("dispatch error: module error: invalid error index %v", errorValue)
+		}
+
+		dispatchError.Module, err = LookupModuleError(metadata, palletIndex, errorBytes[0])
+		if err != nil {
+			dispatchError.Module = &ModuleError{PalletIndex: palletIndex, ErrorIndex: errorBytes[0]}
+		}
+		dispatchError.Module.ErrorBytes = errorBytes
+
+	case "Token", "Arithmetic", "Transactional":
+		detail, _, err := variantValue(unwrapUnnamed(inner))
+		if err != nil {
+			return nil, fmt.Errorf("dispatch error: %s: %w", kind, err)
+		}
+		dispatchError.Detail = detail
+	}
+
+	return dispatchError, nil
+}
+
+// LookupModuleError finds the error with the given index among the errors of a pallet.
+func LookupModuleError(metadata *v14.Metadata, palletIndex, errorIndex uint8) (*ModuleError, error) {
+	for _, pallet := range metadata.Pallets {
+		if pallet.Index != palletIndex {
+			continue
+		}
+		if pallet.Errors == nil {
+			return nil, fmt.Errorf("pallet '%s' has no errors defined", pallet.Name)
+		}
+
+		errorType, ok := findType(metadata, pallet.Errors.Type)
+		if !ok {
+			return nil, fmt.Errorf("error type definition for pallet '%s' not found", pallet.Name)
+		}
+		if errorType.Def.Kind != scaleInfo.Si1TypeDefKindVariant {
+			return nil, fmt.Errorf("expected error type of pallet '%s' to be a variant, but got kind %v", pallet.Name, errorType.Def.Kind)
+		}
+
+		for _, variant := range errorType.Def.Variant.Variants {
+			if variant.Index == errorIndex {
+				return &ModuleError{
+					PalletIndex: palletIndex,
+					ErrorIndex:  errorIndex,
+					PalletName:  pallet.Name,
+					ErrorName:   variant.Name,
+					Docs:        variant.Docs,
+				}, nil
+			}
+		}
+		return nil, fmt.Errorf("error with index %d not found in pallet '%s'", errorIndex, pallet.Name)
+	}
+	return nil, fmt.Errorf("pallet with index %d not found", palletIndex)
+}
+
+// variantValue splits an enum value decoded by DecodeArg into its variant name and fields.
+func variantValue(value any) (string, any, error) {
+	variant, ok := value.(map[string]any)
+	if !ok || len(variant) != 1 {
+		return "", nil, fmt.Errorf("expected an enum, got %T", value)
+	}
+	for name, inner := range variant {
+		return name, inner, nil
+	}
+	panic("unreachable")
+}
+
+// unwrapUnnamed returns the value of a single unnamed field, as in Module(ModuleError),
+// and value itself for anything else.
+func unwrapUnnamed(value any) any {
+	if fields, ok := value.(map[string]any); ok && len(fields) == 1 {
+		if inner, ok := fields["unnamed"]; ok {
+			return inner
+		}
+	}
+	return value
+}
@@ -0,0 +1,137 @@
+package v14_test
+
+import (
+	"reflect"
+	. "submarine/decoder/models"
+	. "submarine/decoder/v14"
+	"submarine/decoder/v14/v14test"
+	. "submarine/scale"
+	"testing"
+)
+
+func TestResolveDispatchError(t *testing.T) {
+	metadata := v14test.NewMetadata()
+
+	tests := []struct {
+		name    string
+		encoded []byte
+		want    DispatchError
+		message string
+	}{
+		{"other", []byte{0}, DispatchError{Kind: "Other"}, "Other"},
+		{"bad origin", []byte{2}, DispatchError{Kind: "BadOrigin"}, "BadOrigin"},
+		{
+			name:    "module",
+			encoded: []byte{3, v14test.BalancesIndex, 2, 0, 0, 0},
+			want: DispatchError{Kind: "Module", Module: &ModuleError{
+				PalletIndex: v14test.BalancesIndex,
+				ErrorIndex:  2,
+				ErrorBytes:  []byte{2, 0, 0, 0},
+				PalletName:  "Balances",
+				ErrorName:   "InsufficientBalance",
+				Docs:        []string{"Balance too low to send value."},
+			}},
+			message: "Balances.InsufficientBalance",
+		},
+		{
+			name:    "unknown module",
+			encoded: []byte{3, 99, 5, 1, 0, 0},
+			want: DispatchError{Kind: "Module", Module: &ModuleError{
+				PalletIndex: 99,
+				ErrorIndex:  5,
+				ErrorBytes:  []byte{5, 1, 0, 0},
+			}},
+			message: "Module(index: 99, error: 0x05010000)",
+		},
+		{"token", []byte{7, 0}, DispatchError{Kind: "Token", Detail: "FundsUnavailable"}, "Token.FundsUnavailable"},
+		{"arithmetic", []byte{8, 2}, DispatchError{Kind: "Arithmetic", Detail: "DivisionByZero"}, "Arithmetic.DivisionByZero"},
+		{"transactional", []byte{9, 0}, DispatchError{Kind: "Transactional", Detail: "LimitReached"}, "Transactional.LimitReached"},
+		{"exhausted", []byte{10}, DispatchError{Kind: "Exhausted"}, "Exhausted"},
+		{"root not allowed", []byte{13}, DispatchError{Kind: "RootNotAllowed"}, "RootNotAllowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Decode ExtrinsicFailed { dispatch_error, dispatch_info } to get at the raw DispatchError value.
+			encoded := append([]byte{v14test.SystemIndex, 1}, tt.encoded...)
+			encoded = append(encoded, v14test.DispatchInfo(0, 0, 0, 0)...)
+			event, err := DecodePalletVariant(metadata, NewReader(encoded), "events")
+			if err != nil {
+				t.Fatalf("decode event: %v", err)
+			}
+
+			got, err := ResolveDispatchError(metadata, event.Args[0].Value)
+			if err != nil {
+				t.Fatalf("resolve: %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+			if got.Error() != tt.message {
+				t.Errorf("message = %q, want %q", got.Error(), tt.message)
+			}
+		})
+	}
+}
+
+func TestResolveLegacyModuleError(t *testing.T) {
+	// Runtimes before ModuleError grew a 4-byte error index encode Module { index: u8, error: u8 },
+	// with the fields inline in the variant.
+	value := map[string]any{"Module": map[string]any{"index": uint8(v14test.BalancesIndex), "error": uint8(3)}}
+
+	got, err := ResolveDispatchError(v14test.NewMetadata(), value)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if got.Module == nil || got.Module.ErrorName != "ExistentialDeposit" || got.Module.PalletName != "Balances" {
+		t.Errorf("got %+v, want Balances.ExistentialDeposit", got.Module)
+	}
+}
+
+func TestResolveDispatchErrorUnknown(t *testing.T) {
+	// Module errors missing from the metadata are kept unresolved, with their indices.
+	metadata := v14test.NewMetadata()
+	tests := []struct {
+		name        string
+		palletIndex uint8
+		errorIndex  uint8
+	}{
+		{"unknown pallet", 99, 0},
+		{"pallet without errors", v14test.SystemIndex, 0},
+		{"unknown error", v14test.BalancesIndex, 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := map[string]any{"Module": map[string]any{"index": tt.palletIndex, "error": tt.errorIndex}}
+			got, err := ResolveDispatchError(metadata, value)
+			if err != nil {
+				t.Fatalf("resolve: %v", err)
+			}
+			want := &ModuleError{PalletIndex: tt.palletIndex, ErrorIndex: tt.errorIndex, ErrorBytes: []byte{tt.errorIndex}}
+			if !reflect.DeepEqual(got.Module, want) || got.Module.Resolved() {
+				t.Errorf("got %+v, want %+v", got.Module, want)
+			}
+		})
+	}
+}
+
+func TestResolveDispatchErrorInvalid(t *testing.T) {
+	metadata := v14test.NewMetadata()
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{"not an enum", "BadOrigin"},
+		{"invalid pallet index", map[string]any{"Module": map[string]any{"index": "x", "error": uint8(0)}}},
+		{"empty error", map[string]any{"Module": map[string]any{"index": uint8(1), "error": []any{}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := ResolveDispatchError(metadata, tt.value); err == nil {
+				t.Errorf("expected an error, got %+v", got)
+			}
+		})
+	}
+}
@@ -6,20 +6,30 @@ import (
 	"submarine/metadata/generated/scaleInfo"
 	"submarine/metadata/generated/v14"
 	. "submarine/scale"
+	"unsafe"
 )
 
 // DecodeEvents is the main entry point for decoding the raw bytes from System.Events.
 func DecodeEvents(metadata *v14.Metadata, eventBytes []byte) ([]EventRecord, error) {
-	r := NewReader(eventBytes)
+	return DecodeEventsWithOptions(metadata, eventBytes, DefaultDecodeOptions)
+}
+
+// DecodeEventsWithOptions decodes events like DecodeEvents, within the limits of options.
+func DecodeEventsWithOptions(metadata *v14.Metadata, eventBytes []byte, options DecodeOptions) ([]EventRecord, error) {
+	r := NewReaderWithOptions(eventBytes, options)
 
 	// The event bytes are a Vec<EventRecord>. First, decode the length.
-	numEvents, err := DecodeCompact(r)
+	// A record takes at least a byte for the phase and two for the event's indices.
+	numEvents, err := DecodeLength(r, 3)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode event vector length: %w", err)
 	}
+	if err := r.Allocate(numEvents, int(unsafe.Sizeof(EventRecord{}))); err != nil {
+		return nil, fmt.Errorf("events: %w", err)
+	}
 
-	records := make([]EventRecord, numEvents.Int64())
-	for i := int64(0); i < numEvents.Int64(); i++ {
+	records := make([]EventRecord, numEvents)
+	for i := range numEvents {
 		record, err := DecodeEventRecord(metadata, r)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decode event record #%d: %w", i, err)
@@ -55,8 +65,7 @@ func DecodeEventRecord(metadata *v14.Metadata, r *Reader) (EventRecord, error) {
 	case 2: // Initialization
 		record.Phase = EventPhase{IsInitialization: true}
 	default:
-		// Default to an empty phase for unknown/unhandled phase indices.
-		record.Phase = EventPhase{}
+		return record, fmt.Errorf("unknown phase index %d", phaseIndex)
 	}
 
 	// --- 2. Decode the Event Payload ---
@@ -66,23 +75,31 @@ func DecodeEventRecord(metadata *v14.Metadata, r *Reader) (EventRecord, error) {
 		return record, fmt.Errorf("failed to decode event payload: %w", err)
 	}
 	record.Event = DecodedEvent{
-		PalletName: decodedEvent.PalletName,
-		EventName:  decodedEvent.VariantName,
-		Args:       decodedEvent.Args,
+		PalletName:  decodedEvent.PalletName,
+		EventName:   decodedEvent.VariantName,
+		PalletIndex: decodedEvent.PalletIndex,
+		EventIndex:  decodedEvent.VariantIndex,
+		Args:        decodedEvent.Args,
 	}
 
-	// --- 3. Decode and Skip Topics ---
+	// --- 3. Decode Topics ---
 	// Topics are a Vec<Hash> (Vec<[u8; 32]>).
-	// We decode the vector but discard the contents for this example.
-	numTopics, err := DecodeCompact(r)
+	numTopics, err := DecodeLength(r, 32)
 	if err != nil {
 		return record, fmt.Errorf("failed to decode topics vector length: %w", err)
 	}
-	for i := int64(0); i < numTopics.Int64(); i++ {
-		_, err := r.ReadBytes(32) // A hash is 32 bytes
+	if err := r.Allocate(numTopics, 32); err != nil {
+		return record, fmt.Errorf("topics: %w", err)
+	}
+	if numTopics > 0 {
+		record.Topics = make([][32]byte, numTopics)
+	}
+	for i := range record.Topics {
+		topic, err := r.ReadBytes(32) // A hash is 32 bytes
 		if err != nil {
 			return record, fmt.Errorf("failed to read topic #%d: %w", i, err)
 		}
+		copy(record.Topics[i][:], topic)
 	}
 
 	return record, nil
@@ -170,15 +187,22 @@ func DecodePalletVariant(metadata *v14.Metadata, r *Reader, variantType string)
 			return nil, fmt.Errorf("failed to decode arg '%s' for '%s.%s': %w", argName, pallet.Name, chosenVariant.Name, err)
 		}
 
+		var typeName string
+		if field.TypeName != nil {
+			typeName = *field.TypeName
+		}
 		decodedArgs[i] = DecodedArg{
-			Name:  argName,
-			Value: argValue,
+			Name:     argName,
+			TypeName: typeName,
+			Value:    argValue,
 		}
 	}
 
 	return &DecodedPalletVariant{
-		PalletName:  pallet.Name,
-		VariantName: chosenVariant.Name,
-		Args:        decodedArgs,
+		PalletName:   pallet.Name,
+		VariantName:  chosenVariant.Name,
+		PalletIndex:  palletIndex,
+		VariantIndex: variantIndex,
+		Args:         decodedArgs,
 	}, nil
 }
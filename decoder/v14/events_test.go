@@ -0,0 +1,83 @@
+package v14_test
+
+import (
+	"reflect"
+	"strings"
+	. "submarine/decoder/models"
+	. "submarine/decoder/v14"
+	"submarine/decoder/v14/v14test"
+	"submarine/scale"
+	"testing"
+)
+
+func TestDecodeEventTopics(t *testing.T) {
+	account := make([]byte, 32)
+	topicA := [32]byte{0xaa}
+	topicB := [32]byte{0xbb}
+
+	events := v14test.Events(
+		v14test.EventRecordWithTopics(v14test.ApplyExtrinsic(0), v14test.BalancesIndex, 7, [][32]byte{topicA, topicB}, account, v14test.U128(1)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(0), v14test.SystemIndex, 0, v14test.DispatchInfo(1, 1, 0, 0)),
+	)
+
+	records, err := DecodeEvents(v14test.NewMetadata(), events)
+	if err != nil {
+		t.Fatalf("decode events: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	if !reflect.DeepEqual(records[0].Topics, [][32]byte{topicA, topicB}) {
+		t.Errorf("topics = %x", records[0].Topics)
+	}
+	if records[1].Topics != nil {
+		t.Errorf("topics = %x, want none", records[1].Topics)
+	}
+}
+
+func TestDecodeEventRecordUnknownPhase(t *testing.T) {
+	record := v14test.EventRecord([]byte{3}, v14test.SystemIndex, 0, v14test.DispatchInfo(1, 1, 0, 0))
+	_, err := DecodeEventRecord(v14test.NewMetadata(), scale.NewReader(record))
+	if err == nil || !strings.Contains(err.Error(), "unknown phase index 3") {
+		t.Errorf("expected an error for an unknown phase, got %v", err)
+	}
+}
+
+func TestBlockFilterEvents(t *testing.T) {
+	account := make([]byte, 32)
+	topic := [32]byte{0x01, 0x02}
+	missing := [32]byte{0xff}
+
+	events := v14test.Events(
+		v14test.EventRecord(v14test.Initialization(), v14test.BalancesIndex, 7, account, v14test.U128(1)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(0), v14test.SystemIndex, 0, v14test.DispatchInfo(1, 1, 2, 0)),
+		v14test.EventRecordWithTopics(v14test.ApplyExtrinsic(1), v14test.BalancesIndex, 2, [][32]byte{topic}, account, account, v14test.U128(5)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(1), v14test.SystemIndex, 0, v14test.DispatchInfo(1, 1, 0, 0)),
+		v14test.EventRecord(v14test.Finalization(), v14test.BalancesIndex, 7, account, v14test.U128(2)),
+	)
+	block, err := DecodeBlock(v14test.NewMetadata(), testBlock(unsignedRemark, unsignedRemark), events)
+	if err != nil {
+		t.Fatalf("decode block: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter EventFilter
+		want   []string
+	}{
+		{"all", EventFilter{}, []string{"Balances.Withdraw", "System.ExtrinsicSuccess", "Balances.Transfer", "System.ExtrinsicSuccess", "Balances.Withdraw"}},
+		{"pallet", EventFilter{Pallet: "Balances"}, []string{"Balances.Withdraw", "Balances.Transfer", "Balances.Withdraw"}},
+		{"name", EventFilter{Name: "ExtrinsicSuccess"}, []string{"System.ExtrinsicSuccess", "System.ExtrinsicSuccess"}},
+		{"pallet and name", EventFilter{Pallet: "Balances", Name: "Transfer"}, []string{"Balances.Transfer"}},
+		{"topic", EventFilter{Topic: &topic}, []string{"Balances.Transfer"}},
+		{"missing topic", EventFilter{Topic: &missing}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := eventNames(block.FilterEvents(tt.filter)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
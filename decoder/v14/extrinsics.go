@@ -2,7 +2,7 @@ package v14
 
 import (
 	"fmt"
-	"log"
+	"strings"
 	. "submarine/decoder/models"
 	"submarine/metadata/base"
 	"submarine/metadata/generated/scaleInfo"
@@ -13,18 +13,22 @@ import (
 // DecodeExtrinsic is the main entry point for decoding an extrinsic.
 // It uses the pre-decoded metadata to understand the structure of the bytes.
 func DecodeExtrinsic(metadata *v14.Metadata, extrinsicBytes []byte) (*DecodedExtrinsic, error) {
-	r := NewReader(extrinsicBytes)
+	return DecodeExtrinsicWithOptions(metadata, extrinsicBytes, DefaultDecodeOptions)
+}
+
+// DecodeExtrinsicWithOptions decodes an extrinsic like DecodeExtrinsic, within the limits of
+// options, for extrinsics submitted by untrusted users.
+func DecodeExtrinsicWithOptions(metadata *v14.Metadata, extrinsicBytes []byte, options DecodeOptions) (*DecodedExtrinsic, error) {
+	r := NewReaderWithOptions(extrinsicBytes, options)
 
 	// An extrinsic is length-prefixed. We must decode this first to advance
 	// the reader, even if we don't use the length value itself.
-	n, err := DecodeCompact(r)
+	_, err := DecodeCompact(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode extrinsic length prefix: %w", err)
 	}
 
 	// --- 1. Decode Extrinsic Wrapper ---
-	// An extrinsic starts with a compact-encoded length of the payload.
-	// We can skip this as we have the full byte slice.
 	// The next byte describes the transaction format (version and signature presence).
 	// For example, 0x84 means signed transaction with protocol version 4.
 	txFormat, err := r.ReadByte()
@@ -32,51 +36,81 @@ func DecodeExtrinsic(metadata *v14.Metadata, extrinsicBytes []byte) (*DecodedExt
 		return nil, fmt.Errorf("failed to read transaction format byte: %w", err)
 	}
 
-	log.Printf("extrinsic %d %x", n, txFormat)
-
-	isSigned := (txFormat & 0b10000000) != 0
-	var signatureData base.Signature
+	extrinsic := DecodedExtrinsic{
+		Version:  txFormat & 0b01111111,
+		IsSigned: (txFormat & 0b10000000) != 0,
+	}
+	if extrinsic.IsSigned && extrinsic.Version != 4 {
+		return nil, fmt.Errorf("unsupported signed extrinsic format version %d", extrinsic.Version)
+	}
 
-	if isSigned {
-		// --- Correctly decode the extrinsic wrapper ---
-		// The correct order is: Address, Signature, then Extra (all signed extensions).
+	if extrinsic.IsSigned {
+		// The order is: Address, Signature, then Extra (all signed extensions).
 
-		// 1. Decode the sender's Address. The type is given by metadata.
-		_, err := base.DecodeAddress(r)
+		// 1. Decode the sender's Address.
+		extrinsic.Address, err = decodeSigner(metadata, r)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decode sender address: %w", err)
 		}
 
-		// 3. Decode the Signature. This is a MultiSignature enum.
-		signatureData, err = base.DecodeSignature(r)
+		// 2. Decode the Signature. This is a MultiSignature enum.
+		extrinsic.Signature, err = base.DecodeSignature(r)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decode signature: %w", err)
 		}
 
-		// 2. Decode the data for ALL signed extensions (Era, Nonce, Tip, etc.).
+		// 3. Decode the data for ALL signed extensions (Era, Nonce, Tip, etc.).
 		// This makes up the "SignedExtra" payload.
 		for _, extension := range metadata.Extrinsic.SignedExtensions {
-			_, err := DecodeArg(metadata, r, extension.Type)
+			start := r.Pos()
+			value, err := DecodeArg(metadata, r, extension.Type)
 			if err != nil {
 				return nil, fmt.Errorf("failed to decode signed extension '%s': %w", extension.Identifier, err)
 			}
+			extrinsic.Extensions = append(extrinsic.Extensions, DecodedSignedExtension{
+				Identifier: extension.Identifier,
+				Value:      value,
+				Raw:        r.BytesSince(start),
+			})
 		}
-
 	}
 
 	// --- 2. Decode the Call ---
 	// The call is the actual payload we want to understand.
-	// call, err := DecodeCall(metadata, r)
+	callStart := r.Pos()
 	call, err := DecodePalletVariant(metadata, r, "calls")
-
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode call ext: %w", err)
 	}
+	extrinsic.Call = *call
+	extrinsic.CallBytes = r.BytesSince(callStart)
+	extrinsic.Raw = r.BytesSince(0)
 
-	return &DecodedExtrinsic{
-		Signature: signatureData,
-		Call:      *call,
-	}, nil
+	return &extrinsic, nil
+}
+
+// decodeSigner decodes the sender of a signed extrinsic as the Address type of the runtime: a
+// MultiAddress, as assumed if the metadata doesn't say, or an account ID of 32 or 20 bytes.
+func decodeSigner(metadata *v14.Metadata, r *Reader) (base.Address, error) {
+	path := lookupsOf(metadata).signerPath
+	switch {
+	case path == "" || strings.HasSuffix(path, "::MultiAddress"):
+		return base.DecodeAddress(r)
+	case strings.HasSuffix(path, "::AccountId32"):
+		var id base.AddressId
+		if err := id.Decode(r); err != nil {
+			return base.Address{}, err
+		}
+		return base.Address{Kind: base.KindAddressId, Id: &id}, nil
+	case strings.HasSuffix(path, "::AccountId20"):
+		var addr20 base.Address20
+		if err := addr20.Decode(r); err != nil {
+			return base.Address{}, err
+		}
+		return base.Address{Kind: base.KindAddress20, Addr20: &addr20}, nil
+	default:
+		return base.Address{}, fmt.Errorf("unsupported address type %s", path)
+	}
 }
 
 // DecodeCall decodes the pallet index, call index, and the corresponding arguments.
@@ -150,9 +184,14 @@ func DecodeCall(metadata *v14.Metadata, r *Reader) (*DecodedCall, error) {
 			return nil, fmt.Errorf("failed to decode arg '%s' for call '%s.%s': %w", argName, pallet.Name, callVariant.Name, err)
 		}
 
+		var typeName string
+		if field.TypeName != nil {
+			typeName = *field.TypeName
+		}
 		decodedArgs[i] = DecodedArg{
-			Name:  argName,
-			Value: argValue,
+			Name:     argName,
+			TypeName: typeName,
+			Value:    argValue,
 		}
 	}
 
@@ -166,6 +205,11 @@ func DecodeCall(metadata *v14.Metadata, r *Reader) (*DecodedCall, error) {
 // DecodeArg is a recursive function that decodes a value of any type
 // by looking up its definition in the metadata.
 func DecodeArg(metadata *v14.Metadata, r *Reader, typeID scaleInfo.Si1LookupTypeId) (any, error) {
+	if err := r.Enter(); err != nil {
+		return nil, err
+	}
+	defer r.Leave()
+
 	// Find the type definition in the lookup table.
 	typ, ok := findType(metadata, typeID)
 	if !ok {
@@ -177,6 +221,9 @@ func DecodeArg(metadata *v14.Metadata, r *Reader, typeID scaleInfo.Si1LookupType
 	case scaleInfo.Si1TypeDefKindComposite:
 		// For a struct, decode each field recursively.
 		// We'll represent it as a map.
+		if err := r.Allocate(len(typ.Def.Composite.Fields), mapEntrySize); err != nil {
+			return nil, err
+		}
 		result := make(map[string]any)
 		for _, field := range typ.Def.Composite.Fields {
 			fieldName := "unnamed"
@@ -196,6 +243,11 @@ func DecodeArg(metadata *v14.Metadata, r *Reader, typeID scaleInfo.Si1LookupType
 		return result, nil
 
 	case scaleInfo.Si1TypeDefKindVariant:
+		// Nested calls (Utility.batch, Proxy.proxy, Sudo.sudo, ...) are decoded like the call of an extrinsic.
+		if lookupsOf(metadata).runtimeCalls[typeID.Int64()] {
+			return DecodePalletVariant(metadata, r, "calls")
+		}
+
 		// For an enum, read the variant index and decode its fields.
 		variantIndex, err := r.ReadByte()
 		if err != nil {
@@ -204,6 +256,9 @@ func DecodeArg(metadata *v14.Metadata, r *Reader, typeID scaleInfo.Si1LookupType
 		for _, variant := range typ.Def.Variant.Variants {
 			if variant.Index == variantIndex {
 				// Similar to a composite, decode the fields.
+				if err := r.Allocate(len(variant.Fields)+1, mapEntrySize); err != nil {
+					return nil, err
+				}
 				result := make(map[string]any)
 				for _, field := range variant.Fields {
 					fieldName := "unnamed"
@@ -224,13 +279,15 @@ func DecodeArg(metadata *v14.Metadata, r *Reader, typeID scaleInfo.Si1LookupType
 
 	case scaleInfo.Si1TypeDefKindSequence:
 		// For a sequence (Vec), decode the compact length then each item.
-		length, err := DecodeCompact(r)
+		length, err := DecodeLength(r, minElementSize(metadata, typ.Def.Sequence.Type))
 		if err != nil {
 			return nil, fmt.Errorf("sequence length: %w", err)
 		}
-		len64 := length.Int64()
-		slice := make([]any, len64)
-		for i := range len64 {
+		if err := r.Allocate(length, anySize); err != nil {
+			return nil, fmt.Errorf("sequence: %w", err)
+		}
+		slice := make([]any, length)
+		for i := range length {
 			elem, err := DecodeArg(metadata, r, typ.Def.Sequence.Type)
 			if err != nil {
 				return nil, fmt.Errorf("sequence (%d): %w", i, err)
@@ -241,6 +298,9 @@ func DecodeArg(metadata *v14.Metadata, r *Reader, typeID scaleInfo.Si1LookupType
 
 	case scaleInfo.Si1TypeDefKindArray:
 		// For a fixed-size array, decode each item.
+		if err := r.Allocate(int(typ.Def.Array.Len), anySize); err != nil {
+			return nil, fmt.Errorf("array: %w", err)
+		}
 		slice := make([]any, typ.Def.Array.Len)
 		for i := uint32(0); i < typ.Def.Array.Len; i++ {
 			elem, err := DecodeArg(metadata, r, typ.Def.Array.Type)
@@ -253,6 +313,9 @@ func DecodeArg(metadata *v14.Metadata, r *Reader, typeID scaleInfo.Si1LookupType
 
 	case scaleInfo.Si1TypeDefKindTuple:
 		// For a tuple, decode each item.
+		if err := r.Allocate(len(*typ.Def.Tuple), anySize); err != nil {
+			return nil, fmt.Errorf("tuple: %w", err)
+		}
 		slice := make([]any, len(*typ.Def.Tuple))
 		for i, fieldTypeID := range *typ.Def.Tuple {
 			elem, err := DecodeArg(metadata, r, fieldTypeID)
@@ -308,16 +371,16 @@ func DecodeArg(metadata *v14.Metadata, r *Reader, typeID scaleInfo.Si1LookupType
 	case scaleInfo.Si1TypeDefKindBitSequence:
 		// A bit sequence is encoded as a compact length (number of bits)
 		// followed by the packed bits.
-		numBits, err := DecodeCompact(r)
+		numBits, err := DecodeLength(r, 0)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decode bit sequence length: %w", err)
 		}
 
 		// Calculate the number of bytes needed to store the bits.
-		numBytes := (numBits.Int64() + 7) / 8
+		numBytes := (numBits + 7) / 8
 
 		// Read the packed bytes.
-		return r.ReadBytes(int(numBytes))
+		return r.ReadBytes(numBytes)
 
 	default:
 		return nil, fmt.Errorf("unsupported type definition %T for type ID %d", typ.Def, typeID)
@@ -0,0 +1,148 @@
+package v14_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	. "submarine/decoder/models"
+	. "submarine/decoder/v14"
+	"submarine/decoder/v14/v14test"
+	"submarine/scale"
+	"testing"
+)
+
+func TestRenderJSON(t *testing.T) {
+	account := make([]byte, 32)
+	u128Max := bytes.Repeat([]byte{0xff}, 16)
+
+	block := testBlock("0x" + signedTransferSr25519)
+	events := v14test.Events(
+		v14test.EventRecord(v14test.ApplyExtrinsic(0), v14test.BalancesIndex, 2, account, account, v14test.U128(12_345_000_000_000)),
+		v14test.EventRecord(v14test.Finalization(), v14test.BalancesIndex, 7, account, u128Max),
+	)
+	decoded, err := DecodeBlock(v14test.NewMetadata(), block, events)
+	if err != nil {
+		t.Fatalf("decode block: %v", err)
+	}
+	extrinsic := decoded.Extrinsics[0].Extrinsic
+	transfer := decoded.Extrinsics[0].Events[0]
+	withdraw := decoded.FinalizationEvents[0]
+
+	toJSON := JSONOptions{Format: FormatToJSON, SS58Prefix: 42}
+	toHuman := JSONOptions{Format: FormatToHuman, SS58Prefix: 42, Decimals: 12, Symbol: "UNIT"}
+
+	tests := []struct {
+		name     string
+		options  JSONOptions
+		value    any
+		expected string
+	}{
+		{
+			"toJSON extrinsic", toJSON, extrinsic, `"0x` + signedTransferSr25519 + `"`,
+		},
+		{
+			"toJSON call", toJSON, &extrinsic.Call,
+			`{"callIndex":"0x0503","args":{"dest":{"id":"5FHneW46xGXgs5mUiveU4sbTyGBzmstUspZC92UhjJM694ty"},"value":12345000000}}`,
+		},
+		{
+			"toHuman extrinsic", toHuman, extrinsic,
+			`{"isSigned":true,"method":{"args":{"dest":{"Id":"5FHneW46xGXgs5mUiveU4sbTyGBzmstUspZC92UhjJM694ty"},"value":"12.3450 mUNIT"},` +
+				`"method":"transferKeepAlive","section":"balances"},"era":{"MortalEra":{"period":"64","phase":"0"}},"nonce":"7",` +
+				`"signature":"0x` + signedTransferSr25519[74:202] + `","signer":{"Id":"5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY"},"tip":"0"}`,
+		},
+		{
+			"toJSON event", toJSON, transfer,
+			`{"phase":{"applyExtrinsic":0},"event":{"index":"0x0502","data":[` +
+				`"5C4hrfjw9DjXZTzV3MwzrrAr9P1MJhSrvWGWqi1eSuyUpnhM","5C4hrfjw9DjXZTzV3MwzrrAr9P1MJhSrvWGWqi1eSuyUpnhM",12345000000000]},"topics":[]}`,
+		},
+		{
+			"toHuman event", toHuman, transfer,
+			`{"phase":{"ApplyExtrinsic":"0"},"event":{"method":"Transfer","section":"balances","data":{` +
+				`"from":"5C4hrfjw9DjXZTzV3MwzrrAr9P1MJhSrvWGWqi1eSuyUpnhM","to":"5C4hrfjw9DjXZTzV3MwzrrAr9P1MJhSrvWGWqi1eSuyUpnhM","amount":"12.3450 UNIT"}},"topics":[]}`,
+		},
+		{
+			"toJSON large integer", toJSON, withdraw,
+			`{"phase":{"finalization":null},"event":{"index":"0x0507","data":[` +
+				`"5C4hrfjw9DjXZTzV3MwzrrAr9P1MJhSrvWGWqi1eSuyUpnhM","0xffffffffffffffffffffffffffffffff"]},"topics":[]}`,
+		},
+		{"toJSON largest number", toJSON, uint64(1<<52 - 1), `4503599627370495`},
+		{"toJSON u64", toJSON, uint64(1 << 52), `"0x0010000000000000"`},
+		{"toJSON i64", toJSON, int64(-1 << 53), `"0xffe0000000000000"`},
+		{"toJSON u128", toJSON, big.NewInt(1 << 52), `"0x00000000000000000010000000000000"`},
+		{"toJSON i128", toJSON, big.NewInt(-1 << 60), `"0xfffffffffffffffff000000000000000"`},
+		{
+			"toJSON u256", toJSON, new(big.Int).Lsh(big.NewInt(1), 200),
+			`"0x0000000000000100000000000000000000000000000000000000000000000000"`,
+		},
+		{
+			"toHuman u128 max balance", toHuman, withdraw,
+			`{"phase":{"Finalization":null},"event":{"method":"Withdraw","section":"balances","data":{` +
+				`"who":"5C4hrfjw9DjXZTzV3MwzrrAr9P1MJhSrvWGWqi1eSuyUpnhM","amount":"everything"}},"topics":[]}`,
+		},
+		{
+			"toHuman without symbol", JSONOptions{Format: FormatToHuman}, big.NewInt(-1234567), `"-1,234,567"`,
+		},
+		{
+			"lossless event", JSONOptions{}, withdraw,
+			`{"phase":"Finalization","event":{"pallet":"Balances","name":"Withdraw","args":[` +
+				`{"name":"who","typeName":"T::AccountId","value":{"unnamed":[0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0]}},` +
+				`{"name":"amount","typeName":"T::Balance","value":340282366920938463463374607431768211455}]},"topics":[]}`,
+		},
+		{
+			"toJSON scale value", toJSON,
+			scale.VStruct([]scale.Field{
+				scale.VField("free_balance", scale.VCompact(big.NewInt(1))),
+				scale.VField("data", scale.VBytes([]byte("hi"))),
+				scale.VField("status", scale.VVariant("Active", 1, []scale.Field{scale.VField("", scale.VInt(big.NewInt(2)))})),
+				scale.VField("reason", scale.VNone()),
+			}),
+			`{"freeBalance":1,"data":"0x6869","status":{"active":2},"reason":null}`,
+		},
+		{
+			"toHuman scale value", JSONOptions{Format: FormatToHuman},
+			scale.VList([]scale.Value{scale.VBytes([]byte("hi")), scale.VVariant("Idle", 0, nil), scale.VSome(scale.VInt(big.NewInt(1000)))}),
+			`["hi","Idle","1,000"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.options.JSON(tt.value)
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("expected\n%s\ngot\n%s", tt.expected, got)
+			}
+		})
+	}
+
+	// MarshalJSON renders losslessly, so that the extrinsic can be decoded from it again.
+	data, err := json.Marshal(extrinsic)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var lossless struct {
+		CallBytes string
+		Address   map[string]string
+	}
+	if err := json.Unmarshal(data, &lossless); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if lossless.CallBytes != "0x"+signedTransferSr25519[len(signedTransferSr25519)-82:] {
+		t.Errorf("callBytes = %s", lossless.CallBytes)
+	}
+	if lossless.Address["Id"] != "0x"+signedTransferSr25519[8:72] {
+		t.Errorf("address = %v", lossless.Address)
+	}
+
+	yaml, err := toHuman.YAML(withdraw)
+	if err != nil {
+		t.Fatalf("yaml: %v", err)
+	}
+	expected := "phase:\n  Finalization: null\nevent:\n  method: Withdraw\n  section: balances\n  data:\n" +
+		"    who: 5C4hrfjw9DjXZTzV3MwzrrAr9P1MJhSrvWGWqi1eSuyUpnhM\n    amount: everything\ntopics: []\n"
+	if string(yaml) != expected {
+		t.Errorf("yaml: expected\n%s\ngot\n%s", expected, yaml)
+	}
+}
@@ -0,0 +1,136 @@
+package v14_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"runtime"
+	"strings"
+	. "submarine/decoder/v14"
+	"submarine/decoder/v14/v14test"
+	"submarine/scale"
+	"testing"
+)
+
+// extrinsic returns an unsigned extrinsic of call, with its length prefix.
+func extrinsic(call []byte) []byte {
+	body := v14test.Concat([]byte{0x04}, call)
+	return v14test.Concat(v14test.Compact(uint64(len(body))), body)
+}
+
+func TestDecodeExtrinsicLimits(t *testing.T) {
+	remark := v14test.Concat([]byte{v14test.SystemIndex, 0}, v14test.Compact(1), []byte{0x01})
+	nestedSudo := func(depth int) []byte {
+		call := remark
+		for range depth {
+			call = v14test.Concat([]byte{v14test.SudoIndex, 0}, call)
+		}
+		return call
+	}
+	batch := func(length uint64, calls ...[]byte) []byte {
+		return v14test.Concat(append([][]byte{{v14test.UtilityIndex, 0}, v14test.Compact(length)}, calls...)...)
+	}
+
+	tests := []struct {
+		name    string
+		call    []byte
+		options scale.DecodeOptions
+		err     string
+	}{
+		{"nested calls", nestedSudo(10), scale.DefaultDecodeOptions, ""},
+		{"too deeply nested calls", nestedSudo(1000), scale.DefaultDecodeOptions, "nested over the maximum depth of 256"},
+		{"batch", batch(2, remark, remark), scale.DecodeOptions{MaxLength: 2}, ""},
+		{"batch too long", batch(3, remark, remark, remark), scale.DecodeOptions{MaxLength: 2}, "length 3 over the maximum of 2"},
+		{"batch over the allocation", batch(64, bytes.Repeat(remark, 64)), scale.DecodeOptions{MaxAllocation: 512}, "allocating 64 values"},
+		{"remark too long", v14test.Concat([]byte{v14test.SystemIndex, 0}, v14test.Compact(1<<40)), scale.DefaultDecodeOptions, "length 1099511627776 over the maximum"},
+	}
+
+	metadata := v14test.NewMetadata()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeExtrinsicWithOptions(metadata, extrinsic(tt.call), tt.options)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("decode: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+			}
+			if !errors.Is(err, scale.ErrLimitExceeded) {
+				t.Errorf("expected ErrLimitExceeded, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDecodeEventsLimits(t *testing.T) {
+	account := make([]byte, 32)
+	record := v14test.EventRecord(v14test.Finalization(), v14test.BalancesIndex, 7, account, v14test.U128(1))
+
+	// A claimed length beyond what the bytes could hold fails before allocating for it.
+	_, err := DecodeEvents(v14test.NewMetadata(), v14test.Concat(v14test.Compact(1<<24), record))
+	if err == nil || !strings.Contains(err.Error(), "length 16777216 needs at least") {
+		t.Errorf("expected a length error, got %v", err)
+	}
+
+	events := v14test.Events(record, record)
+	if _, err := DecodeEventsWithOptions(v14test.NewMetadata(), events, scale.DecodeOptions{MaxLength: 1}); !errors.Is(err, scale.ErrLimitExceeded) {
+		t.Errorf("expected ErrLimitExceeded, got %v", err)
+	}
+}
+
+// allocated returns the bytes allocated by fn.
+func allocated(fn func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	fn()
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc
+}
+
+func TestDecodeExtrinsicHugeLength(t *testing.T) {
+	// Utility.batch claiming 2^24 - 1 calls, in a few bytes.
+	ext := extrinsic(v14test.Concat([]byte{v14test.UtilityIndex, 0}, v14test.Compact(1<<24-1), []byte{v14test.SystemIndex, 0, 0}))
+
+	var err error
+	n := allocated(func() {
+		_, err = DecodeExtrinsic(v14test.NewMetadata(), ext)
+	})
+	if err == nil || !strings.Contains(err.Error(), "length 16777215 needs at least 16777215 bytes") {
+		t.Errorf("expected a length error, got %v", err)
+	}
+	if n > 1<<20 {
+		t.Errorf("allocated %d bytes decoding a %d-byte extrinsic", n, len(ext))
+	}
+}
+
+// fuzzOptions are limits a public API decoding user input would set.
+var fuzzOptions = scale.DecodeOptions{MaxLength: 1 << 12, MaxAllocation: 1 << 22, MaxDepth: 64}
+
+func FuzzDecodeExtrinsic(f *testing.F) {
+	signed, _ := hex.DecodeString(signedTransferSr25519)
+	f.Add(signed)
+	f.Add(extrinsic(v14test.Concat([]byte{v14test.UtilityIndex, 0}, v14test.Compact(1), []byte{v14test.SystemIndex, 0, 0})))
+	f.Add([]byte{})
+
+	metadata := v14test.NewMetadata()
+	f.Fuzz(func(t *testing.T, data []byte) {
+		DecodeExtrinsicWithOptions(metadata, data, fuzzOptions)
+	})
+}
+
+func FuzzDecodeEvents(f *testing.F) {
+	account := make([]byte, 32)
+	f.Add(v14test.Events(
+		v14test.EventRecord(v14test.ApplyExtrinsic(0), v14test.SystemIndex, 0, v14test.DispatchInfo(100, 0, 2, 0)),
+		v14test.EventRecord(v14test.Finalization(), v14test.BalancesIndex, 2, account, account, v14test.U128(1)),
+	))
+	f.Add([]byte{})
+
+	metadata := v14test.NewMetadata()
+	f.Fuzz(func(t *testing.T, data []byte) {
+		DecodeEventsWithOptions(metadata, data, fuzzOptions)
+	})
+}
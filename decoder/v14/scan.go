@@ -0,0 +1,265 @@
+package v14
+
+import (
+	"fmt"
+	. "submarine/decoder/models"
+	"submarine/metadata/base"
+	"submarine/metadata/generated/scaleInfo"
+	"submarine/metadata/generated/v14"
+	"submarine/rpc"
+	. "submarine/scale"
+)
+
+// BlockScanner decodes only the extrinsics and events of blocks selected by a filter, skipping the
+// rest, which makes scanning a chain for a few calls or events several times faster than decoding
+// whole blocks. Reuse a scanner for all blocks of the metadata's runtime.
+type BlockScanner struct {
+	metadata *v14.Metadata
+	skipper  *Skipper
+	filter   BlockFilter
+	// calls and events are the call and event variants of the pallets, by pallet and variant index.
+	calls  map[[2]uint8]palletVariant
+	events map[[2]uint8]palletVariant
+	// topics reports whether any event filter needs the topics of the events.
+	topics bool
+}
+
+type palletVariant struct {
+	pallet  string
+	variant *scaleInfo.Si1Variant
+}
+
+// NewBlockScanner prepares to scan blocks of the metadata's runtime for what filter selects.
+func NewBlockScanner(metadata *v14.Metadata, filter BlockFilter) (*BlockScanner, error) {
+	s := &BlockScanner{
+		metadata: metadata,
+		skipper:  NewSkipper(metadata),
+		filter:   filter,
+		calls:    make(map[[2]uint8]palletVariant),
+		events:   make(map[[2]uint8]palletVariant),
+	}
+	for _, pallet := range metadata.Pallets {
+		if pallet.Calls != nil {
+			if err := s.addVariants(s.calls, pallet, pallet.Calls.Type); err != nil {
+				return nil, fmt.Errorf("calls of pallet '%s': %w", pallet.Name, err)
+			}
+		}
+		if pallet.Events != nil {
+			if err := s.addVariants(s.events, pallet, pallet.Events.Type); err != nil {
+				return nil, fmt.Errorf("events of pallet '%s': %w", pallet.Name, err)
+			}
+		}
+	}
+	for _, eventFilter := range filter.Events {
+		s.topics = s.topics || eventFilter.Topic != nil
+	}
+	return s, nil
+}
+
+func (s *BlockScanner) addVariants(variants map[[2]uint8]palletVariant, pallet v14.PalletMetadata, typeID scaleInfo.Si1LookupTypeId) error {
+	typ, ok := findType(s.metadata, typeID)
+	if !ok {
+		return fmt.Errorf("type with ID %d not found in lookup table", typeID)
+	}
+	if typ.Def.Kind != scaleInfo.Si1TypeDefKindVariant {
+		return fmt.Errorf("expected a variant, but got kind %v", typ.Def.Kind)
+	}
+	for i := range typ.Def.Variant.Variants {
+		variant := &typ.Def.Variant.Variants[i]
+		variants[[2]uint8{pallet.Index, variant.Index}] = palletVariant{pallet: pallet.Name, variant: variant}
+	}
+	return nil
+}
+
+// DecodeBlockFiltered decodes the extrinsics and events of a block that filter selects. Use a
+// BlockScanner to scan many blocks.
+func DecodeBlockFiltered(metadata *v14.Metadata, block *rpc.SignedBlock, eventsBytes []byte, filter BlockFilter) (*FilteredBlock, error) {
+	s, err := NewBlockScanner(metadata, filter)
+	if err != nil {
+		return nil, err
+	}
+	return s.DecodeBlock(block, eventsBytes)
+}
+
+// DecodeBlock decodes the selected extrinsics of a block as DecodeBlock does, with their outcome
+// and all their events, and the selected events. The extrinsics are checked against the
+// extrinsics root of the block header first.
+func (s *BlockScanner) DecodeBlock(block *rpc.SignedBlock, eventsBytes []byte) (*FilteredBlock, error) {
+	rawExtrinsics, err := verifiedExtrinsics(block)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := &FilteredBlock{}
+	// positions maps the index of an extrinsic in the block to its position in decoded.Extrinsics,
+	// or -1 if it wasn't selected.
+	positions := make([]int, len(rawExtrinsics))
+	for i, extBytes := range rawExtrinsics {
+		positions[i] = -1
+		call, err := s.extrinsicCall(extBytes)
+		if err != nil {
+			return nil, fmt.Errorf("extrinsic #%d: %w", i, err)
+		}
+		if !s.selectsCall(call) {
+			continue
+		}
+		ext, err := DecodeExtrinsic(s.metadata, extBytes)
+		if err != nil {
+			return nil, fmt.Errorf("extrinsic #%d: %w", i, err)
+		}
+		positions[i] = len(decoded.Extrinsics)
+		decoded.Extrinsics = append(decoded.Extrinsics, BlockExtrinsic{Index: i, Extrinsic: *ext})
+	}
+
+	r := NewReader(eventsBytes)
+	numEvents, err := DecodeLength(r, 3)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode event vector length: %w", err)
+	}
+	for i := range numEvents {
+		start := r.Pos()
+		record, err := s.skipEventRecord(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode event record #%d: %w", i, err)
+		}
+
+		position := -1
+		if record.Phase.IsApplyExtrinsic {
+			index := int(record.Phase.AsApplyExtrinsic)
+			if index >= len(positions) {
+				return nil, fmt.Errorf("event %s.%s refers to extrinsic #%d, but the block has %d extrinsics",
+					record.Event.PalletName, record.Event.EventName, index, len(positions))
+			}
+			position = positions[index]
+		}
+		selected := s.selectsEvent(record)
+		if position < 0 && !selected {
+			continue
+		}
+
+		// Decode the record again, in full this time.
+		record, err = DecodeEventRecord(s.metadata, NewReader(r.BytesSince(start)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode event record #%d: %w", i, err)
+		}
+		if position >= 0 {
+			if err := addExtrinsicEvent(s.metadata, &decoded.Extrinsics[position], record); err != nil {
+				return nil, fmt.Errorf("extrinsic #%d: %w", record.Phase.AsApplyExtrinsic, err)
+			}
+		}
+		if selected {
+			decoded.Events = append(decoded.Events, record)
+		}
+	}
+
+	return decoded, nil
+}
+
+// extrinsicCall returns the call of an extrinsic, skipping what precedes it.
+func (s *BlockScanner) extrinsicCall(extBytes []byte) (palletVariant, error) {
+	r := NewReader(extBytes)
+	if err := SkipCompact(r); err != nil {
+		return palletVariant{}, fmt.Errorf("failed to decode extrinsic length prefix: %w", err)
+	}
+	txFormat, err := r.ReadByte()
+	if err != nil {
+		return palletVariant{}, fmt.Errorf("failed to read transaction format byte: %w", err)
+	}
+	if txFormat&0b10000000 != 0 {
+		if _, err := decodeSigner(s.metadata, r); err != nil {
+			return palletVariant{}, fmt.Errorf("failed to decode sender address: %w", err)
+		}
+		if _, err := base.DecodeSignature(r); err != nil {
+			return palletVariant{}, fmt.Errorf("failed to decode signature: %w", err)
+		}
+		for _, extension := range s.metadata.Extrinsic.SignedExtensions {
+			if err := s.skipper.Skip(r, extension.Type); err != nil {
+				return palletVariant{}, fmt.Errorf("failed to decode signed extension '%s': %w", extension.Identifier, err)
+			}
+		}
+	}
+	return s.variant(r, s.calls, "calls")
+}
+
+// variant reads the pallet and variant indices of a call or an event.
+func (s *BlockScanner) variant(r *Reader, variants map[[2]uint8]palletVariant, variantType string) (palletVariant, error) {
+	indices, err := r.ReadBytes(2)
+	if err != nil {
+		return palletVariant{}, fmt.Errorf("failed to read pallet and variant index: %w", err)
+	}
+	pv, ok := variants[[2]uint8{indices[0], indices[1]}]
+	if !ok {
+		return palletVariant{}, fmt.Errorf("%s with index %d not found in pallet with index %d", variantType, indices[1], indices[0])
+	}
+	return pv, nil
+}
+
+// skipEventRecord reads the phase, names and topics of an event record, skipping its arguments.
+// The topics are only read if an event filter needs them.
+func (s *BlockScanner) skipEventRecord(r *Reader) (EventRecord, error) {
+	var record EventRecord
+
+	phaseIndex, err := r.ReadByte()
+	if err != nil {
+		return record, fmt.Errorf("failed to read phase index: %w", err)
+	}
+	switch phaseIndex {
+	case 0: // ApplyExtrinsic
+		extrinsicIndex, err := DecodeU32(r)
+		if err != nil {
+			return record, fmt.Errorf("failed to decode extrinsic index for phase: %w", err)
+		}
+		record.Phase = EventPhase{IsApplyExtrinsic: true, AsApplyExtrinsic: extrinsicIndex}
+	case 1: // Finalization
+		record.Phase = EventPhase{IsFinalization: true}
+	case 2: // Initialization
+		record.Phase = EventPhase{IsInitialization: true}
+	default:
+		return record, fmt.Errorf("unknown phase index %d", phaseIndex)
+	}
+
+	event, err := s.variant(r, s.events, "events")
+	if err != nil {
+		return record, fmt.Errorf("failed to decode event payload: %w", err)
+	}
+	record.Event = DecodedEvent{PalletName: event.pallet, EventName: event.variant.Name}
+	for _, field := range event.variant.Fields {
+		if err := s.skipper.Skip(r, field.Type); err != nil {
+			return record, fmt.Errorf("failed to decode arg '%s' for '%s.%s': %w", fieldName(field), event.pallet, event.variant.Name, err)
+		}
+	}
+
+	numTopics, err := DecodeLength(r, 32)
+	if err != nil {
+		return record, fmt.Errorf("failed to decode topics vector length: %w", err)
+	}
+	topics, err := r.ReadBytes(numTopics * 32)
+	if err != nil {
+		return record, fmt.Errorf("failed to read topics: %w", err)
+	}
+	if s.topics && numTopics > 0 {
+		record.Topics = make([][32]byte, numTopics)
+		for i := range record.Topics {
+			copy(record.Topics[i][:], topics[i*32:])
+		}
+	}
+	return record, nil
+}
+
+func (s *BlockScanner) selectsCall(call palletVariant) bool {
+	for _, callFilter := range s.filter.Calls {
+		if callFilter.Match(call.pallet, call.variant.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *BlockScanner) selectsEvent(record EventRecord) bool {
+	for _, eventFilter := range s.filter.Events {
+		if eventFilter.Match(record) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+package v14_test
+
+import (
+	"encoding/hex"
+	"math/big"
+	"reflect"
+	"strings"
+	. "submarine/decoder/models"
+	. "submarine/decoder/v14"
+	"submarine/decoder/v14/v14test"
+	"submarine/scale"
+	"testing"
+)
+
+func TestDecodeBlockFiltered(t *testing.T) {
+	account := make([]byte, 32)
+	topic := [32]byte{1, 2, 3}
+	block := testBlock(unsignedRemark, "0x"+signedTransferSr25519, unsignedRemark)
+	events := v14test.Events(
+		v14test.EventRecord(v14test.Initialization(), v14test.BalancesIndex, 7, account, v14test.U128(1)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(0), v14test.SystemIndex, 0, v14test.DispatchInfo(100, 0, 2, 0)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(1), v14test.BalancesIndex, 7, account, v14test.U128(1500)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(1), v14test.BalancesIndex, 2, account, account, v14test.U128(12345)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(1), v14test.TransactionPaymentIndex, 0, account, v14test.U128(1500), v14test.U128(7)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(1), v14test.SystemIndex, 0, v14test.DispatchInfo(200, 3593, 0, 0)),
+		v14test.EventRecordWithTopics(v14test.ApplyExtrinsic(2), v14test.SystemIndex, 0, [][32]byte{topic}, v14test.DispatchInfo(100, 0, 2, 0)),
+		v14test.EventRecord(v14test.Finalization(), v14test.BalancesIndex, 7, account, v14test.U128(2)),
+	)
+	metadata := v14test.NewMetadata()
+
+	full, err := DecodeBlock(metadata, block, events)
+	if err != nil {
+		t.Fatalf("decode block: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		filter     BlockFilter
+		extrinsics []int
+		events     []string
+	}{
+		{
+			name:       "calls of a pallet",
+			filter:     BlockFilter{Calls: []CallFilter{{Pallet: "Balances"}}},
+			extrinsics: []int{1},
+		},
+		{
+			name:       "call by name",
+			filter:     BlockFilter{Calls: []CallFilter{{Pallet: "System", Name: "remark"}}},
+			extrinsics: []int{0, 2},
+		},
+		{
+			name:   "events by name",
+			filter: BlockFilter{Events: []EventFilter{{Pallet: "Balances", Name: "Withdraw"}}},
+			events: []string{"Balances.Withdraw", "Balances.Withdraw", "Balances.Withdraw"},
+		},
+		{
+			name:   "events by topic",
+			filter: BlockFilter{Events: []EventFilter{{Topic: &topic}}},
+			events: []string{"System.ExtrinsicSuccess"},
+		},
+		{
+			name: "calls and events",
+			filter: BlockFilter{
+				Calls:  []CallFilter{{Name: "transfer_keep_alive"}},
+				Events: []EventFilter{{Pallet: "System"}, {Name: "Transfer"}},
+			},
+			extrinsics: []int{1},
+			events:     []string{"System.ExtrinsicSuccess", "Balances.Transfer", "System.ExtrinsicSuccess", "System.ExtrinsicSuccess"},
+		},
+		{
+			name:   "nothing",
+			filter: BlockFilter{Calls: []CallFilter{{Pallet: "Sudo"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := DecodeBlockFiltered(metadata, block, events, tt.filter)
+			if err != nil {
+				t.Fatalf("decode block: %v", err)
+			}
+
+			if len(decoded.Extrinsics) != len(tt.extrinsics) {
+				t.Fatalf("got %d extrinsics, want %v", len(decoded.Extrinsics), tt.extrinsics)
+			}
+			for i, index := range tt.extrinsics {
+				// Selected extrinsics are decoded as by DecodeBlock.
+				if !reflect.DeepEqual(decoded.Extrinsics[i], full.Extrinsics[index]) {
+					t.Errorf("extrinsic #%d = %+v, want %+v", index, decoded.Extrinsics[i], full.Extrinsics[index])
+				}
+			}
+
+			if got := eventNames(decoded.Events); !reflect.DeepEqual(got, tt.events) {
+				t.Errorf("events = %v, want %v", got, tt.events)
+			}
+			// Selected events are decoded as by DecodeBlock too.
+			var expected []EventRecord
+			for _, record := range full.Events() {
+				for _, eventFilter := range tt.filter.Events {
+					if eventFilter.Match(record) {
+						expected = append(expected, record)
+						break
+					}
+				}
+			}
+			if !reflect.DeepEqual(decoded.Events, expected) {
+				t.Errorf("events = %+v, want %+v", decoded.Events, expected)
+			}
+		})
+	}
+
+	filter := BlockFilter{Calls: []CallFilter{{Pallet: "Balances"}}}
+	outOfRange := v14test.Events(
+		v14test.EventRecord(v14test.ApplyExtrinsic(3), v14test.SystemIndex, 0, v14test.DispatchInfo(1, 1, 0, 0)),
+	)
+	if _, err := DecodeBlockFiltered(metadata, block, outOfRange, filter); err == nil {
+		t.Error("expected an error for an event of a missing extrinsic")
+	}
+	unknownPhase := v14test.Events(
+		v14test.EventRecord([]byte{3}, v14test.SystemIndex, 0, v14test.DispatchInfo(1, 1, 0, 0)),
+	)
+	if _, err := DecodeBlockFiltered(metadata, block, unknownPhase, filter); err == nil || !strings.Contains(err.Error(), "unknown phase index 3") {
+		t.Errorf("expected an error for an unknown phase, got %v", err)
+	}
+	unknownPallet := testBlock("0x" + hex.EncodeToString([]byte{0x08, 0x04, 0x63}))
+	if _, err := DecodeBlockFiltered(metadata, unknownPallet, v14test.Events(), filter); err == nil {
+		t.Error("expected an error for an extrinsic of an unknown pallet")
+	}
+}
+
+// FuzzSkip checks that skipping a value of any type of the metadata accepts the input DecodeArg
+// accepts, and ends at the same position. The first byte picks the type.
+func FuzzSkip(f *testing.F) {
+	metadata := v14test.NewMetadata()
+	skipper := NewSkipper(metadata)
+	transfer, _ := hex.DecodeString(signedTransferSr25519)
+	for id := range metadata.Lookup.Types {
+		f.Add(append([]byte{byte(id)}, transfer[3:]...))
+	}
+
+	options := scale.DecodeOptions{MaxLength: 1 << 10, MaxAllocation: 1 << 20, MaxDepth: 32}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) == 0 {
+			return
+		}
+		typeID := big.NewInt(int64(int(data[0]) % len(metadata.Lookup.Types)))
+		decoder := scale.NewReaderWithOptions(data[1:], options)
+		_, decodeErr := DecodeArg(metadata, decoder, typeID)
+		r := scale.NewReaderWithOptions(data[1:], options)
+		skipErr := skipper.Skip(r, typeID)
+		if (decodeErr == nil) != (skipErr == nil) {
+			t.Fatalf("type %s: decode error %v, skip error %v", typeID, decodeErr, skipErr)
+		}
+		if decodeErr == nil && r.Pos() != decoder.Pos() {
+			t.Errorf("type %s: skipped to %d, decoded to %d", typeID, r.Pos(), decoder.Pos())
+		}
+	})
+}
+
+// benchmarkBlock is a block of transfers, as on a busy chain, and their events.
+func benchmarkBlock() ([]string, []byte) {
+	account := make([]byte, 32)
+	extrinsics := []string{unsignedRemark}
+	records := [][]byte{v14test.EventRecord(v14test.ApplyExtrinsic(0), v14test.SystemIndex, 0, v14test.DispatchInfo(100, 0, 2, 0))}
+	for i := uint32(1); i <= 500; i++ {
+		extrinsics = append(extrinsics, "0x"+signedTransferSr25519)
+		records = append(records,
+			v14test.EventRecord(v14test.ApplyExtrinsic(i), v14test.BalancesIndex, 7, account, v14test.U128(1500)),
+			v14test.EventRecord(v14test.ApplyExtrinsic(i), v14test.BalancesIndex, 2, account, account, v14test.U128(12345)),
+			v14test.EventRecord(v14test.ApplyExtrinsic(i), v14test.TransactionPaymentIndex, 0, account, v14test.U128(1500), v14test.U128(7)),
+			v14test.EventRecord(v14test.ApplyExtrinsic(i), v14test.SystemIndex, 0, v14test.DispatchInfo(200, 3593, 0, 0)),
+		)
+	}
+	return extrinsics, v14test.Events(records...)
+}
+
+func TestSkipHugeLength(t *testing.T) {
+	metadata := v14test.NewMetadata()
+	skipper := NewSkipper(metadata)
+	for _, pType := range metadata.Lookup.Types {
+		if pType.Type.Def.Sequence == nil {
+			continue
+		}
+		// A sequence claiming 2^24 - 1 elements, in a few bytes.
+		r := scale.NewReader(v14test.Concat(v14test.Compact(1<<24-1), []byte{0, 0}))
+		if err := skipper.Skip(r, pType.Id); err == nil || !strings.Contains(err.Error(), "needs at least") {
+			t.Errorf("type %s: expected a length error, got %v", pType.Id, err)
+		}
+	}
+}
+
+func BenchmarkDecodeBlock(b *testing.B) {
+	metadata := v14test.NewMetadata()
+	extrinsics, events := benchmarkBlock()
+	block := testBlock(extrinsics...)
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := DecodeBlock(metadata, block, events); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBlockScanner(b *testing.B) {
+	metadata := v14test.NewMetadata()
+	extrinsics, events := benchmarkBlock()
+	block := testBlock(extrinsics...)
+	scanner, err := NewBlockScanner(metadata, BlockFilter{Calls: []CallFilter{{Pallet: "System", Name: "remark"}}})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := scanner.DecodeBlock(block, events); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package v14
+
+import (
+	"fmt"
+	"submarine/metadata/generated/scaleInfo"
+	"submarine/metadata/generated/v14"
+	. "submarine/scale"
+)
+
+// Skipper advances readers past values of the types of the metadata without decoding them, for
+// scanning blocks for the few calls and events worth decoding. It rejects the input DecodeArg
+// rejects, within the same limits but MaxAllocation, as skipping allocates nothing. The sizes of
+// the fixed-width types are computed once, in NewSkipper, so that their values are skipped in one
+// step, without nesting into them.
+type Skipper struct {
+	metadata *v14.Metadata
+	// types are the types of the lookup table by ID, with their encoded size, or -1 for types
+	// of variable size.
+	types []skipType
+}
+
+type skipType struct {
+	typ  *scaleInfo.Si1Type
+	size int
+}
+
+// NewSkipper indexes the types of the metadata and computes the sizes of the fixed-width ones.
+func NewSkipper(metadata *v14.Metadata) *Skipper {
+	s := &Skipper{
+		metadata: metadata,
+		types:    make([]skipType, len(metadata.Lookup.Types)),
+	}
+	for i := range metadata.Lookup.Types {
+		pType := &metadata.Lookup.Types[i]
+		if id := pType.Id.Int64(); id >= 0 && id < int64(len(s.types)) {
+			s.types[id] = skipType{typ: &pType.Type, size: sizeUnknown}
+		}
+	}
+	for id := range s.types {
+		s.fixedSize(id)
+	}
+	return s
+}
+
+const (
+	sizeVariable = -1
+	sizeUnknown  = -2
+)
+
+// fixedSize returns the encoded size of type id if all its values take the same number of bytes,
+// and any bytes of that length are one.
+func (s *Skipper) fixedSize(id int) (int, bool) {
+	if id < 0 || id >= len(s.types) || s.types[id].typ == nil {
+		return 0, false
+	}
+	entry := &s.types[id]
+	if entry.size == sizeUnknown {
+		// Variable until computed, which also ends the recursion of recursive types.
+		entry.size = sizeVariable
+		if size, ok := s.computeFixedSize(entry.typ); ok {
+			entry.size = size
+		}
+	}
+	return entry.size, entry.size >= 0
+}
+
+func (s *Skipper) computeFixedSize(typ *scaleInfo.Si1Type) (int, bool) {
+	switch typ.Def.Kind {
+	case scaleInfo.Si1TypeDefKindComposite:
+		total := 0
+		for _, field := range typ.Def.Composite.Fields {
+			size, ok := s.fixedSize(int(field.Type.Int64()))
+			if !ok {
+				return 0, false
+			}
+			total += size
+		}
+		return total, true
+	case scaleInfo.Si1TypeDefKindTuple:
+		total := 0
+		for _, fieldTypeID := range *typ.Def.Tuple {
+			size, ok := s.fixedSize(int(fieldTypeID.Int64()))
+			if !ok {
+				return 0, false
+			}
+			total += size
+		}
+		return total, true
+	case scaleInfo.Si1TypeDefKindArray:
+		size, ok := s.fixedSize(int(typ.Def.Array.Type.Int64()))
+		if !ok || (size > 0 && typ.Def.Array.Len > uint32(1<<31-1)/uint32(size)) {
+			return 0, false
+		}
+		return int(typ.Def.Array.Len) * size, true
+	case scaleInfo.Si1TypeDefKindPrimitive:
+		// Bools are left out, to be checked when skipped.
+		switch *typ.Def.Primitive {
+		case scaleInfo.Si0TypeDefPrimitiveChar, scaleInfo.Si0TypeDefPrimitiveU8, scaleInfo.Si0TypeDefPrimitiveI8:
+			return 1, true
+		case scaleInfo.Si0TypeDefPrimitiveU16, scaleInfo.Si0TypeDefPrimitiveI16:
+			return 2, true
+		case scaleInfo.Si0TypeDefPrimitiveU32, scaleInfo.Si0TypeDefPrimitiveI32:
+			return 4, true
+		case scaleInfo.Si0TypeDefPrimitiveU64, scaleInfo.Si0TypeDefPrimitiveI64:
+			return 8, true
+		case scaleInfo.Si0TypeDefPrimitiveU128, scaleInfo.Si0TypeDefPrimitiveI128:
+			return 16, true
+		case scaleInfo.Si0TypeDefPrimitiveU256, scaleInfo.Si0TypeDefPrimitiveI256:
+			return 32, true
+		}
+	}
+	return 0, false
+}
+
+// Skip advances the reader past a value of type typeID.
+func (s *Skipper) Skip(r *Reader, typeID scaleInfo.Si1LookupTypeId) error {
+	return s.skip(r, int(typeID.Int64()))
+}
+
+func (s *Skipper) skip(r *Reader, id int) error {
+	if size, ok := s.fixedSize(id); ok {
+		return r.Skip(size)
+	}
+	if id < 0 || id >= len(s.types) || s.types[id].typ == nil {
+		return fmt.Errorf("type with ID %d not found in lookup table", id)
+	}
+	if err := r.Enter(); err != nil {
+		return err
+	}
+	defer r.Leave()
+
+	typ := s.types[id].typ
+	switch typ.Def.Kind {
+	case scaleInfo.Si1TypeDefKindComposite:
+		for _, field := range typ.Def.Composite.Fields {
+			if err := s.Skip(r, field.Type); err != nil {
+				return fmt.Errorf("composite (%s): %w", fieldName(field), err)
+			}
+		}
+		return nil
+
+	case scaleInfo.Si1TypeDefKindVariant:
+		// Nested calls are variants of variants too, and need no special case.
+		variantIndex, err := r.ReadByte()
+		if err != nil {
+			return fmt.Errorf("variant index: %w", err)
+		}
+		for _, variant := range typ.Def.Variant.Variants {
+			if variant.Index == variantIndex {
+				for _, field := range variant.Fields {
+					if err := s.Skip(r, field.Type); err != nil {
+						return fmt.Errorf("variant (%d, %s): %w", variantIndex, fieldName(field), err)
+					}
+				}
+				return nil
+			}
+		}
+		return fmt.Errorf("variant with index %d not found for type %d", variantIndex, id)
+
+	case scaleInfo.Si1TypeDefKindSequence:
+		elemID := int(typ.Def.Sequence.Type.Int64())
+		// Sequences of fixed-width values, Vec<u8> among them, are skipped in one step.
+		if size, ok := s.fixedSize(elemID); ok {
+			length, err := DecodeLength(r, size)
+			if err != nil {
+				return fmt.Errorf("sequence length: %w", err)
+			}
+			return r.Skip(length * size)
+		}
+		// Types of variable size take at least a byte.
+		length, err := DecodeLength(r, 1)
+		if err != nil {
+			return fmt.Errorf("sequence length: %w", err)
+		}
+		for i := range length {
+			if err := s.skip(r, elemID); err != nil {
+				return fmt.Errorf("sequence (%d): %w", i, err)
+			}
+		}
+		return nil
+
+	case scaleInfo.Si1TypeDefKindArray:
+		for i := uint32(0); i < typ.Def.Array.Len; i++ {
+			if err := s.Skip(r, typ.Def.Array.Type); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case scaleInfo.Si1TypeDefKindTuple:
+		for _, fieldTypeID := range *typ.Def.Tuple {
+			if err := s.Skip(r, fieldTypeID); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case scaleInfo.Si1TypeDefKindCompact:
+		return SkipCompact(r)
+
+	case scaleInfo.Si1TypeDefKindPrimitive:
+		switch *typ.Def.Primitive {
+		case scaleInfo.Si0TypeDefPrimitiveBool:
+			_, err := DecodeBool(r)
+			return err
+		case scaleInfo.Si0TypeDefPrimitiveStr:
+			return SkipBytes(r)
+		default:
+			return fmt.Errorf("unsupported primitive type: %d", typ.Def.Primitive)
+		}
+
+	case scaleInfo.Si1TypeDefKindBitSequence:
+		return SkipBitSequence(r)
+
+	default:
+		return fmt.Errorf("unsupported type definition %T for type ID %d", typ.Def, id)
+	}
+}
+
+func fieldName(field scaleInfo.Si1Field) string {
+	if field.Name != nil {
+		return *field.Name
+	}
+	return "unnamed"
+}
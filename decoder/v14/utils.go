@@ -1,8 +1,19 @@
 package v14
 
 import (
+	"runtime"
 	"submarine/metadata/generated/scaleInfo"
 	"submarine/metadata/generated/v14"
+	"sync"
+	"unsafe"
+	"weak"
+)
+
+// anySize is the size of a decoded value in a []any, and mapEntrySize an estimate of that of an
+// entry of a map[string]any, for Reader.Allocate.
+const (
+	anySize      = int(unsafe.Sizeof(any(nil)))
+	mapEntrySize = 3 * anySize
 )
 
 // findType is a helper to safely access the type from the lookup table.
@@ -20,3 +31,90 @@ func findType(metadata *v14.Metadata, typeID scaleInfo.Si1LookupTypeId) (scaleIn
 
 	return scaleInfo.Si1Type{}, false
 }
+
+// lookups are what the decoder looks up in a metadata over and over, computed on its first use.
+type lookups struct {
+	// runtimeCalls are the IDs of the runtime's outer call enums, see isRuntimeCall.
+	runtimeCalls map[int64]bool
+	// signerPath is the path of the type of the senders of signed extrinsics, see decodeSigner.
+	signerPath string
+}
+
+// lookupCache maps weak pointers to metadata to their lookups. Entries are removed when the
+// metadata is garbage collected.
+var lookupCache sync.Map
+
+func lookupsOf(metadata *v14.Metadata) *lookups {
+	key := weak.Make(metadata)
+	if l, ok := lookupCache.Load(key); ok {
+		return l.(*lookups)
+	}
+
+	l := &lookups{runtimeCalls: make(map[int64]bool), signerPath: signerPath(metadata)}
+	for _, pType := range metadata.Lookup.Types {
+		if pType.Type.Def.Kind == scaleInfo.Si1TypeDefKindVariant && isRuntimeCall(metadata, pType.Type.Def.Variant) {
+			l.runtimeCalls[pType.Id.Int64()] = true
+		}
+	}
+	if actual, loaded := lookupCache.LoadOrStore(key, l); loaded {
+		return actual.(*lookups)
+	}
+	runtime.AddCleanup(metadata, func(key weak.Pointer[v14.Metadata]) { lookupCache.Delete(key) }, key)
+	return l
+}
+
+// signerPath returns the path of the Address parameter of the extrinsic type of the metadata, or ""
+// if the metadata doesn't give it.
+func signerPath(metadata *v14.Metadata) string {
+	if metadata.Extrinsic.Type == nil {
+		return ""
+	}
+	extrinsicType, ok := findType(metadata, metadata.Extrinsic.Type)
+	if !ok {
+		return ""
+	}
+	for _, param := range extrinsicType.Params {
+		if param.Name != "Address" || param.Type == nil {
+			continue
+		}
+		if addressType, ok := findType(metadata, *param.Type); ok {
+			return addressType.Path
+		}
+	}
+	return ""
+}
+
+// isRuntimeCall reports whether a variant type is the runtime's outer call enum, which v14 metadata
+// doesn't name directly: it has a variant for every pallet with calls, at the pallet's index, wrapping
+// that pallet's call type. Use lookupsOf(metadata).runtimeCalls, which checks each type once.
+func isRuntimeCall(metadata *v14.Metadata, variant *scaleInfo.Si1TypeDefVariant) bool {
+	if len(variant.Variants) == 0 {
+		return false
+	}
+	for _, v := range variant.Variants {
+		if len(v.Fields) != 1 {
+			return false
+		}
+		found := false
+		for _, pallet := range metadata.Pallets {
+			if pallet.Index == v.Index {
+				found = pallet.Calls != nil && pallet.Calls.Type.Cmp(v.Fields[0].Type) == 0
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// minElementSize is the fewest bytes an element of a sequence of type typeID is encoded in, for
+// DecodeLength to bound the length of the sequence by the input left: none for zero-sized types,
+// like (), and at least one for all others.
+func minElementSize(metadata *v14.Metadata, typeID scaleInfo.Si1LookupTypeId) int {
+	if isZeroSized(metadata, typeID) {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,65 @@
+package v14test
+
+import (
+	"encoding/binary"
+	"submarine/scale"
+)
+
+// Compact returns the SCALE compact encoding of n.
+func Compact(n uint64) []byte {
+	return scale.EncodeCompactU64(n)
+}
+
+// U32 returns the little-endian encoding of n.
+func U32(n uint32) []byte {
+	return binary.LittleEndian.AppendUint32(nil, n)
+}
+
+// U128 returns the little-endian encoding of n as a u128.
+func U128(n uint64) []byte {
+	return binary.LittleEndian.AppendUint64(binary.LittleEndian.AppendUint64(nil, n), 0)
+}
+
+// Concat joins encoded values.
+func Concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+	return out
+}
+
+// Event phases.
+func ApplyExtrinsic(index uint32) []byte { return Concat([]byte{0}, U32(index)) }
+func Finalization() []byte               { return []byte{1} }
+func Initialization() []byte             { return []byte{2} }
+
+// EventRecord encodes a frame_system::EventRecord without topics.
+func EventRecord(phase []byte, palletIndex, eventIndex uint8, args ...[]byte) []byte {
+	return EventRecordWithTopics(phase, palletIndex, eventIndex, nil, args...)
+}
+
+// EventRecordWithTopics encodes a frame_system::EventRecord deposited with the given topics.
+func EventRecordWithTopics(phase []byte, palletIndex, eventIndex uint8, topics [][32]byte, args ...[]byte) []byte {
+	out := Concat(phase, []byte{palletIndex, eventIndex}, Concat(args...), Compact(uint64(len(topics))))
+	for _, topic := range topics {
+		out = append(out, topic[:]...)
+	}
+	return out
+}
+
+// Events encodes the value of the System.Events storage item.
+func Events(records ...[]byte) []byte {
+	return Concat(Compact(uint64(len(records))), Concat(records...))
+}
+
+// DispatchInfo encodes a frame_support::dispatch::DispatchInfo.
+func DispatchInfo(refTime, proofSize uint64, class, paysFee uint8) []byte {
+	return Concat(Compact(refTime), Compact(proofSize), []byte{class, paysFee})
+}
+
+// DispatchInfoRefTime encodes a DispatchInfo of NewRefTimeWeightMetadata, whose weight has no
+// proof size.
+func DispatchInfoRefTime(refTime uint64, class, paysFee uint8) []byte {
+	return Concat(Compact(refTime), []byte{class, paysFee})
+}
@@ -0,0 +1,334 @@
+// Package v14test provides hand-built v14 metadata and encoding helpers for tests
+// of packages that decode extrinsics and events.
+package v14test
+
+import (
+	"fmt"
+	"math/big"
+	"submarine/metadata/generated/scaleInfo"
+	"submarine/metadata/generated/v14"
+)
+
+// fixtureBuilder assembles a small v14 metadata by hand, mirroring the shape of
+// the Polkadot runtime for the handful of pallets the tests need.
+type fixtureBuilder struct {
+	types []v14.PortableType
+}
+
+func (b *fixtureBuilder) add(path string, def scaleInfo.Si1TypeDef) scaleInfo.Si1LookupTypeId {
+	id := big.NewInt(int64(len(b.types)))
+	b.types = append(b.types, v14.PortableType{
+		Id:   id,
+		Type: scaleInfo.Si1Type{Path: path, Def: def},
+	})
+	return id
+}
+
+// reserve allocates an id for a type that is defined later with set, for recursive types.
+func (b *fixtureBuilder) reserve() scaleInfo.Si1LookupTypeId {
+	return b.add("", scaleInfo.Si1TypeDef{})
+}
+
+func (b *fixtureBuilder) set(id scaleInfo.Si1LookupTypeId, path string, def scaleInfo.Si1TypeDef) {
+	b.types[id.Int64()].Type = scaleInfo.Si1Type{Path: path, Def: def}
+}
+
+// param sets a type parameter of a type, as the generic parameters of Rust types are in the metadata.
+func (b *fixtureBuilder) param(id scaleInfo.Si1LookupTypeId, name string, typ scaleInfo.Si1LookupTypeId) {
+	t := &b.types[id.Int64()].Type
+	t.Params = append(t.Params, scaleInfo.Si1TypeParameter{Name: name, Type: &typ})
+}
+
+func (b *fixtureBuilder) primitive(p scaleInfo.Si0TypeDefPrimitive) scaleInfo.Si1LookupTypeId {
+	return b.add("", scaleInfo.Si1TypeDef{Kind: scaleInfo.Si1TypeDefKindPrimitive, Primitive: &p})
+}
+
+func (b *fixtureBuilder) array(len uint32, item scaleInfo.Si1LookupTypeId) scaleInfo.Si1LookupTypeId {
+	return b.add("", scaleInfo.Si1TypeDef{Kind: scaleInfo.Si1TypeDefKindArray, Array: &scaleInfo.Si1TypeDefArray{Len: len, Type: item}})
+}
+
+func (b *fixtureBuilder) sequence(item scaleInfo.Si1LookupTypeId) scaleInfo.Si1LookupTypeId {
+	return b.add("", scaleInfo.Si1TypeDef{Kind: scaleInfo.Si1TypeDefKindSequence, Sequence: &scaleInfo.Si1TypeDefSequence{Type: item}})
+}
+
+func (b *fixtureBuilder) compact(item scaleInfo.Si1LookupTypeId) scaleInfo.Si1LookupTypeId {
+	return b.add("", scaleInfo.Si1TypeDef{Kind: scaleInfo.Si1TypeDefKindCompact, Compact: &scaleInfo.Si1TypeDefCompact{Type: item}})
+}
+
+func (b *fixtureBuilder) tuple(items ...scaleInfo.Si1LookupTypeId) scaleInfo.Si1LookupTypeId {
+	tuple := scaleInfo.Si1TypeDefTuple(items)
+	return b.add("", scaleInfo.Si1TypeDef{Kind: scaleInfo.Si1TypeDefKindTuple, Tuple: &tuple})
+}
+
+func (b *fixtureBuilder) composite(path string, fields ...scaleInfo.Si1Field) scaleInfo.Si1LookupTypeId {
+	return b.add(path, scaleInfo.Si1TypeDef{Kind: scaleInfo.Si1TypeDefKindComposite, Composite: &scaleInfo.Si1TypeDefComposite{Fields: fields}})
+}
+
+func (b *fixtureBuilder) variant(path string, variants ...scaleInfo.Si1Variant) scaleInfo.Si1LookupTypeId {
+	return b.add(path, scaleInfo.Si1TypeDef{Kind: scaleInfo.Si1TypeDefKindVariant, Variant: &scaleInfo.Si1TypeDefVariant{Variants: variants}})
+}
+
+func field(name string, typ scaleInfo.Si1LookupTypeId) scaleInfo.Si1Field {
+	if name == "" {
+		return scaleInfo.Si1Field{Type: typ}
+	}
+	return scaleInfo.Si1Field{Name: &name, Type: typ}
+}
+
+// typed sets the type name of a field, as rustc writes it in the metadata, e.g. T::Balance.
+func typed(f scaleInfo.Si1Field, typeName string) scaleInfo.Si1Field {
+	f.TypeName = &typeName
+	return f
+}
+
+func variant(index uint8, name string, fields ...scaleInfo.Si1Field) scaleInfo.Si1Variant {
+	return scaleInfo.Si1Variant{Name: name, Index: index, Fields: fields}
+}
+
+func withDocs(v scaleInfo.Si1Variant, docs ...string) scaleInfo.Si1Variant {
+	v.Docs = docs
+	return v
+}
+
+func constant(name string, typ scaleInfo.Si1LookupTypeId, value []byte, docs ...string) v14.PalletConstantMetadata {
+	return v14.PalletConstantMetadata{Name: name, Type: typ, Value: value, Docs: docs}
+}
+
+// Indices of the pallets in the fixture metadata.
+const (
+	SystemIndex             = 0
+	BalancesIndex           = 5
+	UtilityIndex            = 26
+	ProxyIndex              = 29
+	MultisigIndex           = 30
+	TransactionPaymentIndex = 32
+	SudoIndex               = 255
+)
+
+// Values of the constants in the fixture metadata.
+const (
+	ExistentialDeposit = 10_000_000_000
+	BaseBlockRefTime   = 5_000_000_000
+	MaxBlockRefTime    = 2_000_000_000_000
+	MaxBlockProofSize  = 18_446_744_073_709_551_615
+)
+
+// NewMetadata returns metadata with the System, Balances and TransactionPayment pallets,
+// the call-wrapping Utility, Proxy, Multisig and Sudo pallets, and the signed extensions
+// used by Polkadot.
+func NewMetadata() *v14.Metadata {
+	return newMetadata(false)
+}
+
+// NewRefTimeWeightMetadata returns the metadata of NewMetadata as of the runtimes between the u64
+// weight and WeightV2, around Polkadot's 9300, whose Weight is a struct with only a ref_time.
+func NewRefTimeWeightMetadata() *v14.Metadata {
+	return newMetadata(true)
+}
+
+func newMetadata(refTimeWeight bool) *v14.Metadata {
+	var b fixtureBuilder
+
+	u8 := b.primitive(scaleInfo.Si0TypeDefPrimitiveU8)
+	u16 := b.primitive(scaleInfo.Si0TypeDefPrimitiveU16)
+	u32 := b.primitive(scaleInfo.Si0TypeDefPrimitiveU32)
+	u64 := b.primitive(scaleInfo.Si0TypeDefPrimitiveU64)
+	u128 := b.primitive(scaleInfo.Si0TypeDefPrimitiveU128)
+	empty := b.tuple()
+	bytes := b.sequence(u8)
+	bytes32 := b.array(32, u8)
+	bytes20 := b.array(20, u8)
+	compactU32 := b.compact(u32)
+	compactU64 := b.compact(u64)
+	compactU128 := b.compact(u128)
+	accountId := b.composite("sp_core::crypto::AccountId32", field("", bytes32))
+	hash := b.composite("primitive_types::H256", field("", bytes32))
+
+	multiAddress := b.variant("sp_runtime::multiaddress::MultiAddress",
+		variant(0, "Id", field("", accountId)),
+		variant(1, "Index", field("", compactU32)),
+		variant(2, "Raw", field("", bytes)),
+		variant(3, "Address32", field("", bytes32)),
+		variant(4, "Address20", field("", bytes20)),
+	)
+
+	systemCall := b.variant("frame_system::pallet::Call",
+		variant(0, "remark", field("remark", bytes)),
+	)
+	balancesCall := b.variant("pallet_balances::pallet::Call",
+		variant(0, "transfer_allow_death",
+			typed(field("dest", multiAddress), "AccountIdLookupOf<T>"), typed(field("value", compactU128), "T::Balance")),
+		variant(3, "transfer_keep_alive",
+			typed(field("dest", multiAddress), "AccountIdLookupOf<T>"), typed(field("value", compactU128), "T::Balance")),
+	)
+
+	runtimeCall := b.reserve()
+	utilityCall := b.variant("pallet_utility::pallet::Call",
+		variant(0, "batch", field("calls", b.sequence(runtimeCall))),
+		variant(2, "batch_all", field("calls", b.sequence(runtimeCall))),
+	)
+	proxyType := b.variant("polkadot_runtime::ProxyType", variant(0, "Any"), variant(1, "NonTransfer"))
+	proxyCall := b.variant("pallet_proxy::pallet::Call",
+		variant(0, "proxy",
+			field("real", multiAddress),
+			field("force_proxy_type", b.variant("Option", variant(0, "None"), variant(1, "Some", field("", proxyType)))),
+			field("call", runtimeCall)),
+	)
+	multisigCall := b.variant("pallet_multisig::pallet::Call",
+		variant(0, "as_multi_threshold_1", field("other_signatories", b.sequence(accountId)), field("call", runtimeCall)),
+	)
+	sudoCall := b.variant("pallet_sudo::pallet::Call",
+		variant(0, "sudo", field("call", runtimeCall)),
+	)
+	b.set(runtimeCall, "polkadot_runtime::RuntimeCall", scaleInfo.Si1TypeDef{
+		Kind: scaleInfo.Si1TypeDefKindVariant,
+		Variant: &scaleInfo.Si1TypeDefVariant{Variants: []scaleInfo.Si1Variant{
+			variant(SystemIndex, "System", field("", systemCall)),
+			variant(BalancesIndex, "Balances", field("", balancesCall)),
+			variant(UtilityIndex, "Utility", field("", utilityCall)),
+			variant(ProxyIndex, "Proxy", field("", proxyCall)),
+			variant(MultisigIndex, "Multisig", field("", multisigCall)),
+			variant(SudoIndex, "Sudo", field("", sudoCall)),
+		}},
+	})
+
+	weight := b.composite("sp_weights::weight_v2::Weight", field("ref_time", compactU64), field("proof_size", compactU64))
+	blockWeightsValue := Concat(Compact(BaseBlockRefTime), Compact(0), Compact(MaxBlockRefTime), Compact(MaxBlockProofSize))
+	if refTimeWeight {
+		weight = b.composite("sp_weights::weight_v2::Weight", field("ref_time", compactU64))
+		blockWeightsValue = Concat(Compact(BaseBlockRefTime), Compact(MaxBlockRefTime))
+	}
+	dispatchInfo := b.composite("frame_support::dispatch::DispatchInfo",
+		field("weight", weight),
+		field("class", b.variant("frame_support::dispatch::DispatchClass",
+			variant(0, "Normal"), variant(1, "Operational"), variant(2, "Mandatory"))),
+		field("pays_fee", b.variant("frame_support::dispatch::Pays", variant(0, "Yes"), variant(1, "No"))),
+	)
+	blockWeights := b.composite("frame_system::limits::BlockWeights", field("base_block", weight), field("max_block", weight))
+	moduleError := b.composite("sp_runtime::ModuleError", field("index", u8), field("error", b.array(4, u8)))
+	dispatchError := b.variant("sp_runtime::DispatchError",
+		variant(0, "Other"),
+		variant(1, "CannotLookup"),
+		variant(2, "BadOrigin"),
+		variant(3, "Module", field("", moduleError)),
+		variant(4, "ConsumerRemaining"),
+		variant(5, "NoProviders"),
+		variant(6, "TooManyConsumers"),
+		variant(7, "Token", field("", b.variant("sp_runtime::TokenError",
+			variant(0, "FundsUnavailable"), variant(1, "OnlyProvider"), variant(2, "BelowMinimum"),
+			variant(3, "CannotCreate"), variant(4, "UnknownAsset"), variant(5, "Frozen"),
+			variant(6, "Unsupported"), variant(7, "CannotCreateHold"), variant(8, "NotExpendable"),
+			variant(9, "Blocked")))),
+		variant(8, "Arithmetic", field("", b.variant("sp_arithmetic::ArithmeticError",
+			variant(0, "Underflow"), variant(1, "Overflow"), variant(2, "DivisionByZero")))),
+		variant(9, "Transactional", field("", b.variant("sp_runtime::TransactionalError",
+			variant(0, "LimitReached"), variant(1, "NoLayer")))),
+		variant(10, "Exhausted"),
+		variant(11, "Corruption"),
+		variant(12, "Unavailable"),
+		variant(13, "RootNotAllowed"),
+	)
+
+	systemEvent := b.variant("frame_system::pallet::Event",
+		variant(0, "ExtrinsicSuccess", field("dispatch_info", dispatchInfo)),
+		variant(1, "ExtrinsicFailed", field("dispatch_error", dispatchError), field("dispatch_info", dispatchInfo)),
+		variant(7, "Remarked", field("sender", accountId), field("hash", hash)),
+	)
+	balancesEvent := b.variant("pallet_balances::pallet::Event",
+		variant(2, "Transfer",
+			typed(field("from", accountId), "T::AccountId"), typed(field("to", accountId), "T::AccountId"),
+			typed(field("amount", u128), "T::Balance")),
+		variant(7, "Withdraw", typed(field("who", accountId), "T::AccountId"), typed(field("amount", u128), "T::Balance")),
+	)
+	balancesError := b.variant("pallet_balances::pallet::Error",
+		withDocs(variant(0, "VestingBalance"), "Vesting balance too high to send value."),
+		withDocs(variant(1, "LiquidityRestrictions"), "Account liquidity restrictions prevent withdrawal."),
+		withDocs(variant(2, "InsufficientBalance"), "Balance too low to send value."),
+		withDocs(variant(3, "ExistentialDeposit"), "Value too low to create account due to existential deposit."),
+	)
+	transactionPaymentEvent := b.variant("pallet_transaction_payment::pallet::Event",
+		variant(0, "TransactionFeePaid", field("who", accountId), field("actual_fee", u128), field("tip", u128)),
+	)
+
+	eraVariants := []scaleInfo.Si1Variant{variant(0, "Immortal")}
+	for i := 1; i < 256; i++ {
+		eraVariants = append(eraVariants, variant(uint8(i), fmt.Sprintf("Mortal%d", i), field("", u8)))
+	}
+	era := b.variant("sp_runtime::generic::era::Era", eraVariants...)
+
+	mode := b.variant("frame_metadata_hash_extension::Mode", variant(0, "Disabled"), variant(1, "Enabled"))
+	optionHash := b.variant("Option", variant(0, "None"), variant(1, "Some", field("", bytes32)))
+
+	uncheckedExtrinsic := b.composite("sp_runtime::generic::unchecked_extrinsic::UncheckedExtrinsic", field("", bytes))
+	b.param(uncheckedExtrinsic, "Address", multiAddress)
+	b.param(uncheckedExtrinsic, "Call", runtimeCall)
+
+	metadata := &v14.Metadata{
+		Pallets: []v14.PalletMetadata{
+			{
+				Name:   "System",
+				Index:  SystemIndex,
+				Calls:  &v14.PalletCallMetadata{Type: systemCall},
+				Events: &v14.PalletEventMetadata{Type: systemEvent},
+				Constants: []v14.PalletConstantMetadata{
+					constant("BlockWeights", blockWeights, blockWeightsValue,
+						" Block & extrinsics weights: base values and limits."),
+					constant("BlockHashCount", u32, U32(4096), " Maximum number of block number to block hash mappings to keep (oldest pruned first)."),
+					constant("SS58Prefix", u16, []byte{0, 0}, " The designated SS58 prefix of this chain."),
+				},
+			},
+			{
+				Name:   "Balances",
+				Index:  BalancesIndex,
+				Calls:  &v14.PalletCallMetadata{Type: balancesCall},
+				Events: &v14.PalletEventMetadata{Type: balancesEvent},
+				Errors: &v14.PalletErrorMetadata{Type: balancesError},
+				Constants: []v14.PalletConstantMetadata{
+					constant("ExistentialDeposit", u128, U128(ExistentialDeposit), " The minimum amount required to keep an account open."),
+				},
+			},
+			{
+				Name:  "Utility",
+				Index: UtilityIndex,
+				Calls: &v14.PalletCallMetadata{Type: utilityCall},
+			},
+			{
+				Name:  "Proxy",
+				Index: ProxyIndex,
+				Calls: &v14.PalletCallMetadata{Type: proxyCall},
+			},
+			{
+				Name:  "Multisig",
+				Index: MultisigIndex,
+				Calls: &v14.PalletCallMetadata{Type: multisigCall},
+			},
+			{
+				Name:   "TransactionPayment",
+				Index:  TransactionPaymentIndex,
+				Events: &v14.PalletEventMetadata{Type: transactionPaymentEvent},
+			},
+			{
+				Name:  "Sudo",
+				Index: SudoIndex,
+				Calls: &v14.PalletCallMetadata{Type: sudoCall},
+			},
+		},
+		Extrinsic: v14.ExtrinsicMetadata{
+			Type:    uncheckedExtrinsic,
+			Version: 4,
+			SignedExtensions: []v14.SignedExtensionMetadata{
+				{Identifier: "CheckNonZeroSender", Type: empty, AdditionalSigned: empty},
+				{Identifier: "CheckSpecVersion", Type: empty, AdditionalSigned: u32},
+				{Identifier: "CheckTxVersion", Type: empty, AdditionalSigned: u32},
+				{Identifier: "CheckGenesis", Type: empty, AdditionalSigned: hash},
+				{Identifier: "CheckMortality", Type: era, AdditionalSigned: hash},
+				{Identifier: "CheckNonce", Type: compactU32, AdditionalSigned: empty},
+				{Identifier: "CheckWeight", Type: empty, AdditionalSigned: empty},
+				{Identifier: "ChargeTransactionPayment", Type: compactU128, AdditionalSigned: empty},
+				{Identifier: "CheckMetadataHash", Type: mode, AdditionalSigned: optionHash},
+			},
+		},
+	}
+	metadata.Lookup.Types = b.types
+	return metadata
+}
@@ -0,0 +1,185 @@
+package v14
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"submarine/crypto"
+	. "submarine/decoder/models"
+	"submarine/metadata/base"
+	"submarine/metadata/generated/scaleInfo"
+	"submarine/metadata/generated/v14"
+	. "submarine/scale"
+)
+
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// SigningContext holds the chain state covered by an extrinsic signature but not carried in the
+// extrinsic itself: the "additional signed" data of the signed extensions.
+type SigningContext struct {
+	SpecVersion        uint32
+	TransactionVersion uint32
+	GenesisHash        [32]byte
+
+	// BlockNumber is the number of the block the extrinsic was included in.
+	// Together with the mortal era it determines which block hash was signed.
+	BlockNumber uint64
+	// BlockHash returns the hash of the block with the given number.
+	// Only needed for mortal extrinsics.
+	BlockHash func(number uint64) ([32]byte, error)
+
+	// MetadataHash is the hash committed to by the CheckMetadataHash extension, if the signer enabled it.
+	MetadataHash *[32]byte
+}
+
+// SigningPayload rebuilds the payload that the sender of a signed extrinsic signed:
+// the call, the signed extensions and their additional signed data.
+// Payloads longer than 256 bytes are replaced by their Blake2-256 hash, as Substrate does.
+func SigningPayload(metadata *v14.Metadata, extrinsic *DecodedExtrinsic, ctx SigningContext) ([]byte, error) {
+	if !extrinsic.IsSigned {
+		return nil, fmt.Errorf("extrinsic is not signed")
+	}
+	if extrinsic.Version != 4 {
+		return nil, fmt.Errorf("unsupported signed extrinsic format version %d", extrinsic.Version)
+	}
+
+	extensions := metadata.Extrinsic.SignedExtensions
+	if len(extensions) != len(extrinsic.Extensions) {
+		return nil, fmt.Errorf("extrinsic has %d signed extensions, metadata has %d", len(extrinsic.Extensions), len(extensions))
+	}
+
+	payload := make([]byte, 0, len(extrinsic.CallBytes)+128)
+	payload = append(payload, extrinsic.CallBytes...)
+	for _, extension := range extrinsic.Extensions {
+		payload = append(payload, extension.Raw...)
+	}
+
+	for i, extension := range extensions {
+		additional, err := additionalSigned(metadata, extension, extrinsic.Extensions[i], ctx)
+		if err != nil {
+			return nil, fmt.Errorf("signed extension '%s': %w", extension.Identifier, err)
+		}
+		payload = append(payload, additional...)
+	}
+
+	if len(payload) > 256 {
+		hash := crypto.Blake2_256(payload)
+		return hash[:], nil
+	}
+	return payload, nil
+}
+
+// VerifySignature checks the signature of a signed extrinsic against the sender's public key.
+// It returns ErrInvalidSignature if the signature does not match.
+func VerifySignature(metadata *v14.Metadata, extrinsic *DecodedExtrinsic, ctx SigningContext) error {
+	payload, err := SigningPayload(metadata, extrinsic, ctx)
+	if err != nil {
+		return err
+	}
+
+	var signer [32]byte
+	switch extrinsic.Address.Kind {
+	case base.KindAddressId:
+		signer = *extrinsic.Address.Id
+	case base.KindAddress32:
+		signer = *extrinsic.Address.Addr32
+	default:
+		return fmt.Errorf("cannot verify signature of sender with address kind %d", extrinsic.Address.Kind)
+	}
+
+	var ok bool
+	signature := extrinsic.Signature
+	switch signature.Kind {
+	case base.KindSignatureEd25519:
+		ok = crypto.VerifyEd25519(signer, payload, *signature.Ed25519)
+	case base.KindSignatureSr25519:
+		ok = crypto.VerifySr25519(signer, payload, *signature.Sr25519)
+	case base.KindSignatureEcdsa:
+		// For ecdsa, the account id is the hash of the compressed public key.
+		publicKey, err := crypto.RecoverEcdsa(payload, *signature.Ecdsa)
+		ok = err == nil && crypto.Blake2_256(publicKey[:]) == signer
+	default:
+		return fmt.Errorf("unsupported signature kind %d", signature.Kind)
+	}
+
+	if !ok {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// additionalSigned returns the encoded additional signed data of a signed extension.
+func additionalSigned(metadata *v14.Metadata, extension v14.SignedExtensionMetadata, value DecodedSignedExtension, ctx SigningContext) ([]byte, error) {
+	switch extension.Identifier {
+	case "CheckSpecVersion":
+		return binary.LittleEndian.AppendUint32(nil, ctx.SpecVersion), nil
+
+	case "CheckTxVersion":
+		return binary.LittleEndian.AppendUint32(nil, ctx.TransactionVersion), nil
+
+	case "CheckGenesis":
+		return ctx.GenesisHash[:], nil
+
+	case "CheckMortality", "CheckEra":
+		era, err := base.DecodeEra(NewReader(value.Raw))
+		if err != nil {
+			return nil, err
+		}
+		if era.IsImmortal {
+			return ctx.GenesisHash[:], nil
+		}
+		if ctx.BlockHash == nil {
+			return nil, fmt.Errorf("mortal extrinsic requires SigningContext.BlockHash")
+		}
+		birth := era.Birth(ctx.BlockNumber)
+		hash, err := ctx.BlockHash(birth)
+		if err != nil {
+			return nil, fmt.Errorf("block hash of era birth #%d: %w", birth, err)
+		}
+		return hash[:], nil
+
+	case "CheckMetadataHash":
+		// Option<[u8; 32]>
+		if ctx.MetadataHash == nil {
+			return []byte{0}, nil
+		}
+		return append([]byte{1}, ctx.MetadataHash[:]...), nil
+
+	default:
+		// Most other extensions (CheckNonce, CheckWeight, ChargeTransactionPayment, ...)
+		// don't have any additional signed data.
+		if isZeroSized(metadata, extension.AdditionalSigned) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unsupported additional signed data")
+	}
+}
+
+// isZeroSized reports whether values of a type always encode to zero bytes, like `()`.
+func isZeroSized(metadata *v14.Metadata, typeID scaleInfo.Si1LookupTypeId) bool {
+	typ, ok := findType(metadata, typeID)
+	if !ok {
+		return false
+	}
+
+	switch typ.Def.Kind {
+	case scaleInfo.Si1TypeDefKindTuple:
+		for _, fieldTypeID := range *typ.Def.Tuple {
+			if !isZeroSized(metadata, fieldTypeID) {
+				return false
+			}
+		}
+		return true
+	case scaleInfo.Si1TypeDefKindComposite:
+		for _, field := range typ.Def.Composite.Fields {
+			if !isZeroSized(metadata, field.Type) {
+				return false
+			}
+		}
+		return true
+	case scaleInfo.Si1TypeDefKindArray:
+		return typ.Def.Array.Len == 0 || isZeroSized(metadata, typ.Def.Array.Type)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,254 @@
+package v14_test
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"submarine/crypto"
+	. "submarine/decoder/v14"
+	"submarine/decoder/v14/v14test"
+	"submarine/metadata/base"
+	"submarine/metadata/generated/scaleInfo"
+	"submarine/metadata/generated/v14"
+	"testing"
+)
+
+// Signed extrinsics for the fixture metadata. The sr25519 one is a Balances.transfer_keep_alive
+// from the well-known dev account Alice to Bob with a mortal era; the ed25519 and ecdsa ones are
+// System.remark calls signed with fixed test seeds, the ecdsa one long enough for its payload to be
+// hashed before signing.
+var (
+	signedTransferSr25519 = "45028400d43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d013a9e16335254ac65e68061" +
+		"3ddad914150a76a643d792e2dac9b728374a17792e3d9b8c894aba7e42a1f1c80e92ad324faa5ad6669ca8e370f16ba8" +
+		"a6ec37e08a05001c00000503008eaf04151687736326c9fea17e25fc5287613693c912909cb226aa4794f26a480740c0" +
+		"d1df02"
+
+	signedRemarkEd25519 = "e101840095dc3c93c9ab37ed9e034e52b625463c459269cae1aa0fc837a47f3b40ab1f6500bb158c620f113c0eef3938" +
+		"2f45b4ec027509ed828437c27ec61507468e083169f06d073cf84f61946ffa4ade4002e9a661ff9aef3567051728989e" +
+		"09fe8d7102000002093d000000002c68656c6c6f20776f726c64"
+
+	signedLongRemarkEcdsa = "610684000d3671da8ec1f1ef96c94c652af850fbc13b7c51f87cd89039383722c81a2f1a0269e70830fea4bd6fc15fbe" +
+		"fcf9983181feb770660671dc40179a84d5959313e67575234e2aace88889ea3ee50dd72216c420afb3a9cb09966c3744" +
+		"b81792cde500000c00000000b104000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f2021" +
+		"22232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f404142434445464748494a4b4c4d4e4f5051" +
+		"52535455565758595a5b5c5d5e5f606162636465666768696a6b6c6d6e6f707172737475767778797a7b7c7d7e7f8081" +
+		"82838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9fa0a1a2a3a4a5a6a7a8a9aaabacadaeafb0b1" +
+		"b2b3b4b5b6b7b8b9babbbcbdbebfc0c1c2c3c4c5c6c7c8c9cacbcccdcecfd0d1d2d3d4d5d6d7d8d9dadbdcdddedfe0e1" +
+		"e2e3e4e5e6e7e8e9eaebecedeeeff0f1f2f3f4f5f6f7f8f9fafbfcfdfeff000102030405060708090a0b0c0d0e0f1011" +
+		"12131415161718191a1b1c1d1e1f202122232425262728292a2b"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex: %v", err)
+	}
+	return b
+}
+
+// testBlockHash stands in for the hash of the block with the given number.
+func testBlockHash(number uint64) ([32]byte, error) {
+	return crypto.Blake2_256(binary.LittleEndian.AppendUint64(nil, number)), nil
+}
+
+func testSigningContext(t *testing.T) SigningContext {
+	var genesis [32]byte
+	copy(genesis[:], mustDecodeHex(t, "91b171bb158e2d3848fa23a9f1c25182fb8e20313b2c1eb49219da7a70ce90c3"))
+	return SigningContext{
+		SpecVersion:        1002000,
+		TransactionVersion: 26,
+		GenesisHash:        genesis,
+		BlockNumber:        22_000_000,
+		BlockHash:          testBlockHash,
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	metadata := v14test.NewMetadata()
+
+	tests := []struct {
+		name          string
+		extrinsic     string
+		signatureKind base.SignatureKind
+		call          string
+	}{
+		{"sr25519", signedTransferSr25519, base.KindSignatureSr25519, "Balances.transfer_keep_alive"},
+		{"ed25519", signedRemarkEd25519, base.KindSignatureEd25519, "System.remark"},
+		{"ecdsa", signedLongRemarkEcdsa, base.KindSignatureEcdsa, "System.remark"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extrinsic, err := DecodeExtrinsic(metadata, mustDecodeHex(t, tt.extrinsic))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+
+			if !extrinsic.IsSigned || extrinsic.Version != 4 {
+				t.Fatalf("expected signed v4 extrinsic, got signed=%v version=%d", extrinsic.IsSigned, extrinsic.Version)
+			}
+			if extrinsic.Signature.Kind != tt.signatureKind {
+				t.Errorf("expected signature kind %d, got %d", tt.signatureKind, extrinsic.Signature.Kind)
+			}
+			if call := fmt.Sprintf("%s.%s", extrinsic.Call.PalletName, extrinsic.Call.VariantName); call != tt.call {
+				t.Errorf("expected call %s, got %s", tt.call, call)
+			}
+
+			ctx := testSigningContext(t)
+			if err := VerifySignature(metadata, extrinsic, ctx); err != nil {
+				t.Errorf("expected valid signature, got %v", err)
+			}
+
+			// Any change to the signed data must invalidate the signature.
+			ctx.SpecVersion++
+			if err := VerifySignature(metadata, extrinsic, ctx); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("expected ErrInvalidSignature for wrong spec version, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifySignature_TamperedCall(t *testing.T) {
+	metadata := v14test.NewMetadata()
+
+	raw := mustDecodeHex(t, signedTransferSr25519)
+	raw[len(raw)-1] ^= 0x04 // change the transferred amount
+
+	extrinsic, err := DecodeExtrinsic(metadata, raw)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if err := VerifySignature(metadata, extrinsic, testSigningContext(t)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature, got %v", err)
+	}
+}
+
+func TestVerifySignature_MortalEraBirth(t *testing.T) {
+	metadata := v14test.NewMetadata()
+
+	extrinsic, err := DecodeExtrinsic(metadata, mustDecodeHex(t, signedTransferSr25519))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	var requested []uint64
+	ctx := testSigningContext(t)
+	ctx.BlockNumber += 10 // still within the 64 block era
+	ctx.BlockHash = func(number uint64) ([32]byte, error) {
+		requested = append(requested, number)
+		return testBlockHash(number)
+	}
+
+	if err := VerifySignature(metadata, extrinsic, ctx); err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+	if len(requested) != 1 || requested[0] != 22_000_000 {
+		t.Errorf("expected the hash of the era birth block #22000000 to be requested, got %v", requested)
+	}
+
+	ctx.BlockHash = nil
+	if err := VerifySignature(metadata, extrinsic, ctx); err == nil {
+		t.Error("expected error for mortal extrinsic without BlockHash")
+	}
+}
+
+func TestDecodeExtrinsicSigner(t *testing.T) {
+	signed := mustDecodeHex(t, signedTransferSr25519)
+	// The extrinsic without its length prefix and MultiAddress variant index, which runtimes whose
+	// Address is an account ID don't have.
+	body := v14test.Concat(signed[2:3], signed[4:])
+	withoutPrefix := v14test.Concat(v14test.Compact(uint64(len(body))), body)
+
+	// withAddress returns the fixture metadata with the Address of extrinsics changed to a type of
+	// the given path, wrapping an array of size bytes.
+	withAddress := func(path string, size uint32) *v14.Metadata {
+		metadata := v14test.NewMetadata()
+		for _, pType := range metadata.Lookup.Types {
+			if array := pType.Type.Def.Array; array == nil || array.Len != size {
+				continue
+			}
+			id := big.NewInt(int64(len(metadata.Lookup.Types)))
+			fieldType := pType.Id
+			metadata.Lookup.Types = append(metadata.Lookup.Types, v14.PortableType{Id: id, Type: scaleInfo.Si1Type{
+				Path: path,
+				Def: scaleInfo.Si1TypeDef{Kind: scaleInfo.Si1TypeDefKindComposite, Composite: &scaleInfo.Si1TypeDefComposite{
+					Fields: []scaleInfo.Si1Field{{Type: fieldType}},
+				}},
+			}})
+			extrinsicType := &metadata.Lookup.Types[metadata.Extrinsic.Type.Int64()].Type
+			for i := range extrinsicType.Params {
+				if extrinsicType.Params[i].Name == "Address" {
+					extrinsicType.Params[i].Type = &id
+				}
+			}
+			return metadata
+		}
+		t.Fatalf("no array of %d bytes in the fixture metadata", size)
+		return nil
+	}
+
+	signer := [32]byte(signed[4:36])
+	tests := []struct {
+		name      string
+		metadata  *v14.Metadata
+		extrinsic []byte
+		address   base.Address
+		err       string
+	}{
+		{"multi address", v14test.NewMetadata(), signed, base.Address{Kind: base.KindAddressId, Id: (*base.AddressId)(&signer)}, ""},
+		{"account id", withAddress("sp_core::crypto::AccountId32", 32), withoutPrefix, base.Address{Kind: base.KindAddressId, Id: (*base.AddressId)(&signer)}, ""},
+		{
+			"ethereum account id", withAddress("fp_account::AccountId20", 20),
+			v14test.Concat(v14test.Compact(uint64(len(body)-12)), body[:1], body[13:]),
+			base.Address{Kind: base.KindAddress20, Addr20: (*base.Address20)(signer[12:])}, "",
+		},
+		{"unsupported", withAddress("my_runtime::Signer", 32), withoutPrefix, base.Address{}, "unsupported address type my_runtime::Signer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extrinsic, err := DecodeExtrinsic(tt.metadata, tt.extrinsic)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !reflect.DeepEqual(extrinsic.Address, tt.address) {
+				t.Errorf("address = %+v, want %+v", extrinsic.Address, tt.address)
+			}
+			if tt.address.Kind == base.KindAddressId {
+				// The signature doesn't cover the address, so it verifies whatever its encoding.
+				if err := VerifySignature(tt.metadata, extrinsic, testSigningContext(t)); err != nil {
+					t.Errorf("expected valid signature, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeExtrinsicVersion(t *testing.T) {
+	metadata := v14test.NewMetadata()
+	signed := mustDecodeHex(t, signedTransferSr25519)
+	signed[2] = 0x85 // signed, version 5
+	if _, err := DecodeExtrinsic(metadata, signed); err == nil || !strings.Contains(err.Error(), "version 5") {
+		t.Errorf("expected an error for a signed v5 extrinsic, got %v", err)
+	}
+
+	extrinsic, err := DecodeExtrinsic(metadata, mustDecodeHex(t, signedTransferSr25519))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	extrinsic.Version = 5
+	if err := VerifySignature(metadata, extrinsic, testSigningContext(t)); err == nil || errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected an error for a v5 extrinsic, got %v", err)
+	}
+}
@@ -9,12 +9,23 @@ import (
 type ErrorSpan struct {
 	Path    []string
 	Message string
+	// Cause is the error the span was made from by WrapError, if any.
+	Cause error
 }
 
 func NewErrorSpan(message string) *ErrorSpan {
 	return &ErrorSpan{Message: message}
 }
 
+// WrapError returns a span for err, which errors.Is and errors.As see through the span.
+func WrapError(err error) *ErrorSpan {
+	return &ErrorSpan{Message: err.Error(), Cause: err}
+}
+
+func (err *ErrorSpan) Unwrap() error {
+	return err.Cause
+}
+
 func (err *ErrorSpan) WithPath(path string) *ErrorSpan {
 	if strings.ContainsAny(path, " /") {
 		path = fmt.Sprintf("[%s]", path)
@@ -30,6 +30,10 @@ func (a *AddressId) Decode(r *scale.Reader) error {
 	return nil
 }
 
+func (a AddressId) Encode() ([]byte, error) {
+	return a[:], nil
+}
+
 func (a *AddressIndex) Decode(r *scale.Reader) error {
 	compactIndex, err := scale.DecodeCompact(r)
 	if err != nil {
@@ -39,6 +43,10 @@ func (a *AddressIndex) Decode(r *scale.Reader) error {
 	return nil
 }
 
+func (a AddressIndex) Encode() ([]byte, error) {
+	return scale.EncodeCompactU64(uint64(a)), nil
+}
+
 func (a *AddressRaw) Decode(r *scale.Reader) error {
 	bytes, err := scale.DecodeBytes(r)
 	if err != nil {
@@ -48,6 +56,10 @@ func (a *AddressRaw) Decode(r *scale.Reader) error {
 	return nil
 }
 
+func (a AddressRaw) Encode() ([]byte, error) {
+	return scale.EncodeBytes(a), nil
+}
+
 func (a *Address32) Decode(r *scale.Reader) error {
 	bytes, err := r.ReadBytes(32)
 	if err != nil {
@@ -57,6 +69,10 @@ func (a *Address32) Decode(r *scale.Reader) error {
 	return nil
 }
 
+func (a Address32) Encode() ([]byte, error) {
+	return a[:], nil
+}
+
 func (a *Address20) Decode(r *scale.Reader) error {
 	bytes, err := r.ReadBytes(20)
 	if err != nil {
@@ -66,6 +82,10 @@ func (a *Address20) Decode(r *scale.Reader) error {
 	return nil
 }
 
+func (a Address20) Encode() ([]byte, error) {
+	return a[:], nil
+}
+
 type Address struct {
 	Kind   AddressKind
 	Id     *AddressId
@@ -123,3 +143,46 @@ func DecodeAddress(r *scale.Reader) (Address, error) {
 	}
 	return addr, nil
 }
+
+// DecodeLookupSource decodes an address of the runtimes before MultiAddress, which polkadot.js
+// calls GenericLookupSource: 0xff and an account ID, or an account index, either in the first
+// byte if up to 0xef, or in the 2, 4 or 8 bytes after 0xfc, 0xfd or 0xfe.
+func DecodeLookupSource(r *scale.Reader) (Address, error) {
+	prefix, err := r.ReadByte()
+	if err != nil {
+		return Address{}, fmt.Errorf("failed to read LookupSource prefix: %w", err)
+	}
+
+	var index AddressIndex
+	switch {
+	case prefix == 0xff:
+		var id AddressId
+		if err := id.Decode(r); err != nil {
+			return Address{}, err
+		}
+		return Address{Kind: KindAddressId, Id: &id}, nil
+	case prefix == 0xfe:
+		value, err := scale.DecodeU64(r)
+		if err != nil {
+			return Address{}, fmt.Errorf("failed to decode AddressIndex: %w", err)
+		}
+		index = AddressIndex(value)
+	case prefix == 0xfd:
+		value, err := scale.DecodeU32(r)
+		if err != nil {
+			return Address{}, fmt.Errorf("failed to decode AddressIndex: %w", err)
+		}
+		index = AddressIndex(value)
+	case prefix == 0xfc:
+		value, err := scale.DecodeU16(r)
+		if err != nil {
+			return Address{}, fmt.Errorf("failed to decode AddressIndex: %w", err)
+		}
+		index = AddressIndex(value)
+	case prefix <= 0xef:
+		index = AddressIndex(prefix)
+	default:
+		return Address{}, fmt.Errorf("unsupported LookupSource prefix: 0x%x", prefix)
+	}
+	return Address{Kind: KindAddressIndex, Index: &index}, nil
+}
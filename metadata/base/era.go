@@ -0,0 +1,48 @@
+package base
+
+import (
+	"fmt"
+	"submarine/scale"
+)
+
+// Era is the mortality of a transaction: either immortal, or valid for Period blocks starting
+// from the block whose number modulo Period is Phase.
+type Era struct {
+	IsImmortal bool
+	Period     uint64
+	Phase      uint64
+}
+
+func DecodeEra(r *scale.Reader) (Era, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return Era{}, fmt.Errorf("failed to read Era: %w", err)
+	}
+	if first == 0 {
+		return Era{IsImmortal: true}, nil
+	}
+
+	second, err := r.ReadByte()
+	if err != nil {
+		return Era{}, fmt.Errorf("failed to read mortal Era: %w", err)
+	}
+
+	encoded := uint64(first) | uint64(second)<<8
+	period := uint64(2) << (encoded % 16)
+	quantizeFactor := max(period>>12, 1)
+	phase := (encoded >> 4) * quantizeFactor
+	if period < 4 || phase >= period {
+		return Era{}, fmt.Errorf("invalid mortal Era: period %d, phase %d", period, phase)
+	}
+
+	return Era{Period: period, Phase: phase}, nil
+}
+
+// Birth returns the number of the block in which a transaction with this era, included
+// in block `current`, was created. The hash of that block is part of the signed payload.
+func (e Era) Birth(current uint64) uint64 {
+	if e.IsImmortal {
+		return 0
+	}
+	return (max(current, e.Phase)-e.Phase)/e.Period*e.Period + e.Phase
+}
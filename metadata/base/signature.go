@@ -26,6 +26,10 @@ func (s *SignatureEd25519) Decode(r *scale.Reader) error {
 	return nil
 }
 
+func (s SignatureEd25519) Encode() ([]byte, error) {
+	return s[:], nil
+}
+
 func (s *SignatureSr25519) Decode(r *scale.Reader) error {
 	bytes, err := r.ReadBytes(64)
 	if err != nil {
@@ -35,6 +39,10 @@ func (s *SignatureSr25519) Decode(r *scale.Reader) error {
 	return nil
 }
 
+func (s SignatureSr25519) Encode() ([]byte, error) {
+	return s[:], nil
+}
+
 func (s *SignatureEcdsa) Decode(r *scale.Reader) error {
 	bytes, err := r.ReadBytes(65)
 	if err != nil {
@@ -44,6 +52,10 @@ func (s *SignatureEcdsa) Decode(r *scale.Reader) error {
 	return nil
 }
 
+func (s SignatureEcdsa) Encode() ([]byte, error) {
+	return s[:], nil
+}
+
 type Signature struct {
 	Kind    SignatureKind
 	Ed25519 *SignatureEd25519
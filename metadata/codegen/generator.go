@@ -335,12 +335,73 @@ func (c *Codegen) getDecodeFuncForType(moduleName string, type_ *Type) (string,
 		if err != nil {
 			return "", fmt.Errorf("vec item: %w", err)
 		}
-		return fmt.Sprintf("scale.DecodeVec(reader, func(reader *scale.Reader) (%s, error) { return %s })", itemTypeName, itemDecodeFunc), nil
+		minSize, err := c.minEncodedSize(moduleName, vec.Type)
+		if err != nil {
+			return "", fmt.Errorf("vec item size: %w", err)
+		}
+		return fmt.Sprintf("scale.DecodeVec(reader, %d, func(reader *scale.Reader) (%s, error) { return %s })", minSize, itemTypeName, itemDecodeFunc), nil
 	default:
 		return "", fmt.Errorf("unknown type kind: %s", type_.Kind)
 	}
 }
 
+// minEncodedSize returns the fewest bytes a value of type_ is encoded in, which bounds the
+// length of a vec of it by the input left.
+func (c *Codegen) minEncodedSize(moduleName string, type_ *Type) (int, error) {
+	switch type_.Kind {
+	case KindRef:
+		return c.minEncodedSizeForTypeName(moduleName, *type_.Ref)
+	case KindImport:
+		resolvedType, err := c.resolveImport(type_.Import.Module, type_.Import.Item)
+		if err != nil {
+			return 0, err
+		}
+		return c.minEncodedSize(resolvedType.ModuleName, resolvedType.Type)
+	case KindStruct:
+		total := 0
+		for _, field := range type_.Struct.Fields {
+			size, err := c.minEncodedSize(moduleName, field.Type)
+			if err != nil {
+				return 0, fmt.Errorf("struct field %s: %w", field.Name, err)
+			}
+			total += size
+		}
+		return total, nil
+	case KindTuple:
+		total := 0
+		for i := range type_.Tuple.Fields {
+			size, err := c.minEncodedSize(moduleName, &type_.Tuple.Fields[i])
+			if err != nil {
+				return 0, err
+			}
+			total += size
+		}
+		return total, nil
+	case KindEnumSimple, KindEnumComplex, KindVec, KindOption:
+		// A variant index, a length or an option flag.
+		return 1, nil
+	default:
+		return 0, fmt.Errorf("unknown type kind: %s", type_.Kind)
+	}
+}
+
+func (c *Codegen) minEncodedSizeForTypeName(moduleName string, typeName string) (int, error) {
+	switch typeName {
+	case "text", "type", "bytes", "u8", "bool", "compact":
+		return 1, nil
+	case "u32":
+		return 4, nil
+	case "u64":
+		return 8, nil
+	}
+
+	type_, ok := c.Modules[moduleName].Types[typeName]
+	if !ok {
+		return 0, fmt.Errorf("Type %s not found in module %s", typeName, moduleName)
+	}
+	return c.minEncodedSize(moduleName, type_)
+}
+
 func (c *Codegen) getGoTypeForTypename(moduleName string, typeName string) (string, error) {
 	moduleCodegen := c.Generated[moduleName]
 
@@ -5,6 +5,7 @@ import (
 	scale_v10 "submarine/metadata/generated/v10"
 	scale_v11 "submarine/metadata/generated/v11"
 	scale_v12 "submarine/metadata/generated/v12"
+	scale_v13 "submarine/metadata/generated/v13"
 	scale_v14 "submarine/metadata/generated/v14"
 	scale_v9 "submarine/metadata/generated/v9"
 	"submarine/scale"
@@ -30,12 +31,18 @@ func DecodeMetadata(version uint, r *scale.Reader) (any, error) {
 			return nil, fmt.Errorf("v11: %w", err)
 		}
 		return &meta, nil
-	case 12, 13:
+	case 12:
 		meta, err := scale_v12.DecodeMetadata(r)
 		if err != nil {
 			return nil, fmt.Errorf("v12: %w", err)
 		}
 		return &meta, nil
+	case 13:
+		meta, err := scale_v13.DecodeMetadata(r)
+		if err != nil {
+			return nil, fmt.Errorf("v13: %w", err)
+		}
+		return &meta, nil
 	case 14:
 		meta, err := scale_v14.DecodeMetadata(r)
 		if err != nil {
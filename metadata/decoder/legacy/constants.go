@@ -0,0 +1,259 @@
+package legacy
+
+import (
+	"fmt"
+	"strings"
+	"submarine/decoder/models"
+	"submarine/polkadot_scale_schema"
+	"submarine/scale"
+)
+
+// DecodeConstants decodes the constants of every module, looking up their type names in registry.
+// Values have the shapes of those decoded from v14 metadata, see PlainValue.
+func DecodeConstants(metadata *Metadata, registry *polkadot_scale_schema.Registry) (models.Constants, error) {
+	var constants models.Constants
+	for _, module := range metadata.Modules {
+		for _, constant := range module.Constants {
+			r := scale.NewReader(constant.Value)
+			value, err := decodePlain(registry, r, registryModule(module.Name), constant.Type)
+			if err != nil {
+				return nil, fmt.Errorf("constant %s.%s: %w", module.Name, constant.Name, err)
+			}
+			if r.Remaining() != 0 {
+				return nil, fmt.Errorf("constant %s.%s: %d trailing bytes", module.Name, constant.Name, r.Remaining())
+			}
+
+			constants = append(constants, models.DecodedConstant{
+				PalletName: module.Name,
+				Name:       constant.Name,
+				TypeName:   constant.Type,
+				Value:      value,
+				Raw:        constant.Value,
+				Docs:       constant.Docs,
+			})
+		}
+	}
+	return constants, nil
+}
+
+// decodePlain decodes a value of the named type with registry, as PlainValue converts it.
+func decodePlain(registry *polkadot_scale_schema.Registry, r *scale.Reader, moduleName, typeName string) (any, error) {
+	schema, err := polkadot_scale_schema.ParseTypeName(typeName)
+	if err != nil {
+		return nil, err
+	}
+	value, err := registry.DecodeSchema(r, moduleName, schema)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", typeName, err)
+	}
+	plain, err := PlainValue(scale.NewSchemaResolver(registry), value, schema, moduleName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", typeName, err)
+	}
+	return plain, nil
+}
+
+// registryModule returns the polkadot.js definitions module of a runtime module, e.g. "balances" for "Balances".
+func registryModule(moduleName string) string {
+	if moduleName == "" {
+		return ""
+	}
+	return strings.ToLower(moduleName[:1]) + moduleName[1:]
+}
+
+// PlainValue converts a value of schema, whose refs are names in module, to the Go values DecodeArg
+// decodes from v14 metadata: uint8 to uint64 and int8 to int64 for fixed-width integers up to 64
+// bits, *big.Int for wider and compact ones, []any of uint8 for bytes, []byte for bit sequences,
+// string, bool, []any for lists and tuples, and map[string]any for structs, with an "unnamed" key
+// for unnamed fields. Enums, options and results are maps from the variant name to its fields:
+// {"None": {}}, {"Some": {"unnamed": value}}. The unit type is an empty []any.
+//
+// Types are resolved with resolver, as they were for decoding.
+func PlainValue(resolver *scale.SchemaResolver, value scale.Value, schema *scale.Type, module string) (any, error) {
+	schema, module, errSpan := resolver.Resolve(schema, module)
+	if errSpan != nil {
+		return nil, errSpan
+	}
+	if schema == nil {
+		return []any{}, nil
+	}
+
+	switch schema.Kind {
+	case scale.KindRef:
+		return plainPrimitive(value, *schema.Ref)
+	case scale.KindStruct:
+		return plainFields(resolver, value.Fields, schema.Struct.Fields, module)
+	case scale.KindTuple:
+		return plainList(resolver, value.List, func(i int) *scale.Type { return &schema.Tuple.Fields[i] }, module)
+	case scale.KindVec, scale.KindArray:
+		var elemType *scale.Type
+		if schema.Kind == scale.KindVec {
+			elemType = schema.Vec.Type
+		} else {
+			elemType = schema.Array.Type
+		}
+		if value.Kind == scale.ValueKindBytes {
+			return plainBytes(value.Bytes), nil
+		}
+		return plainList(resolver, value.List, func(int) *scale.Type { return elemType }, module)
+	case scale.KindEnumSimple:
+		return map[string]any{value.Variant.Name: map[string]any{}}, nil
+	case scale.KindEnumComplex:
+		for _, variant := range schema.EnumComplex.Variants {
+			if variant.Name == value.Variant.Name {
+				fields, err := plainVariantFields(resolver, value.Variant.Fields, variant.Type, module)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", variant.Name, err)
+				}
+				return map[string]any{variant.Name: fields}, nil
+			}
+		}
+		return nil, fmt.Errorf("variant %s not found", value.Variant.Name)
+	case scale.KindOption:
+		if value.Option == nil {
+			return map[string]any{"None": map[string]any{}}, nil
+		}
+		some, err := PlainValue(resolver, *value.Option, schema.Option.Type, module)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"Some": map[string]any{"unnamed": some}}, nil
+	case scale.KindResult:
+		variantType := schema.Result.Ok
+		if value.Variant.Name == "Err" {
+			variantType = schema.Result.Err
+		}
+		inner, err := PlainValue(resolver, value.Variant.Fields[0].Value, variantType, module)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{value.Variant.Name: map[string]any{"unnamed": inner}}, nil
+	case scale.KindMap:
+		// A BTreeMap, which is a newtype of a Vec of key-value tuples.
+		entries := make([]any, len(value.List))
+		for i, entry := range value.List {
+			key, err := PlainValue(resolver, entry.List[0], schema.Map.Key, module)
+			if err != nil {
+				return nil, err
+			}
+			mapValue, err := PlainValue(resolver, entry.List[1], schema.Map.Value, module)
+			if err != nil {
+				return nil, err
+			}
+			entries[i] = []any{key, mapValue}
+		}
+		return map[string]any{"unnamed": entries}, nil
+	case scale.KindOpaque:
+		return PlainValue(resolver, value, schema.Opaque.Type, module)
+	case scale.KindBitFlags:
+		flags := make(map[string]any, len(value.Fields))
+		for _, field := range value.Fields {
+			flags[field.Name] = field.Value.Bool
+		}
+		return flags, nil
+	default:
+		return nil, fmt.Errorf("unsupported type kind %s", schema.Kind)
+	}
+}
+
+func plainPrimitive(value scale.Value, name string) (any, error) {
+	switch name {
+	case "u8":
+		return uint8(value.Int.Uint64()), nil
+	case "u16":
+		return uint16(value.Int.Uint64()), nil
+	case "u32":
+		return uint32(value.Int.Uint64()), nil
+	case "u64":
+		return value.Int.Uint64(), nil
+	case "i8":
+		return int8(value.Int.Int64()), nil
+	case "i16":
+		return int16(value.Int.Int64()), nil
+	case "i32":
+		return int32(value.Int.Int64()), nil
+	case "i64":
+		return value.Int.Int64(), nil
+	case "u128", "u256", "i128", "i256", "compact":
+		return value.Int, nil
+	case "bool":
+		return value.Bool, nil
+	case "text":
+		return value.Text, nil
+	case "bytes":
+		return plainBytes(value.Bytes), nil
+	case "bitvec":
+		return scale.PackBits(value.Bits), nil
+	case "empty":
+		return []any{}, nil
+	default:
+		return nil, fmt.Errorf("unknown primitive type %s", name)
+	}
+}
+
+// plainBytes returns bytes as DecodeArg decodes a Vec<u8> or [u8; N].
+func plainBytes(bytes []byte) []any {
+	list := make([]any, len(bytes))
+	for i, b := range bytes {
+		list[i] = b
+	}
+	return list
+}
+
+func plainList(resolver *scale.SchemaResolver, values []scale.Value, elemType func(int) *scale.Type, module string) ([]any, error) {
+	list := make([]any, len(values))
+	for i, elem := range values {
+		plain, err := PlainValue(resolver, elem, elemType(i), module)
+		if err != nil {
+			return nil, fmt.Errorf("%d: %w", i, err)
+		}
+		list[i] = plain
+	}
+	return list, nil
+}
+
+func plainFields(resolver *scale.SchemaResolver, fields []scale.Field, members []scale.NamedMember, module string) (map[string]any, error) {
+	plain := make(map[string]any, len(fields))
+	for i, field := range fields {
+		value, err := PlainValue(resolver, field.Value, members[i].Type, module)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.Name, err)
+		}
+		plain[field.Name] = value
+	}
+	return plain, nil
+}
+
+// plainVariantFields converts the fields of a variant of a complex enum as DecodeArg does: named
+// fields by name, and unnamed ones under "unnamed": the elements of a tuple variant as a []any, as
+// DecodeArg gives tuples. Null variants have no fields, as unit variants.
+func plainVariantFields(resolver *scale.SchemaResolver, fields []scale.Field, variantType *scale.Type, module string) (map[string]any, error) {
+	resolved, resolvedModule, errSpan := resolver.Resolve(variantType, module)
+	if errSpan != nil {
+		return nil, errSpan
+	}
+	switch {
+	case resolved == nil || len(fields) == 0:
+		return map[string]any{}, nil
+	case variantType.Kind == scale.KindStruct:
+		return plainFields(resolver, fields, variantType.Struct.Fields, module)
+	case variantType.Kind == scale.KindTuple:
+		elements := make([]any, len(fields))
+		for i, field := range fields {
+			value, err := PlainValue(resolver, field.Value, &variantType.Tuple.Fields[i], module)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %w", i, err)
+			}
+			elements[i] = value
+		}
+		return map[string]any{"unnamed": elements}, nil
+	case resolved.Kind == scale.KindRef && *resolved.Ref == "empty":
+		return map[string]any{}, nil
+	default:
+		value, err := PlainValue(resolver, fields[0].Value, resolved, resolvedModule)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"unnamed": value}, nil
+	}
+}
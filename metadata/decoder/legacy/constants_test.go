@@ -0,0 +1,265 @@
+package legacy_test
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"strings"
+	v14 "submarine/decoder/v14"
+	"submarine/decoder/v14/v14test"
+	. "submarine/metadata/decoder/legacy"
+	v14meta "submarine/metadata/generated/v14"
+	v9 "submarine/metadata/generated/v9"
+	"submarine/polkadot_scale_schema"
+	"submarine/scale"
+	"testing"
+)
+
+func TestDecodeConstants(t *testing.T) {
+	registry := polkadot_scale_schema.NewRegistry()
+	err := polkadot_scale_schema.LoadFromSchema(registry, map[string]map[string]any{
+		"runtime": {
+			"Balance":        "UInt<128, Balance>",
+			"BlockNumber":    "u32",
+			"ModuleId":       "LockIdentifier",
+			"LockIdentifier": "[u8; 8]",
+			"Perbill":        "UInt<32, Perbill>",
+			"Weight":         "u64",
+		},
+		"system": {
+			"RuntimeDbWeight": &polkadot_scale_schema.Object{
+				Keys:   []string{"read", "write"},
+				Values: map[string]any{"read": "Weight", "write": "Weight"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+
+	constants := func(module string, items ...v9.ModuleConstantMetadata) ModuleMetadata {
+		return MakeModuleFromV9Parts(module, nil, nil, items)
+	}
+	metadata := MakeMetadataFromModules([]ModuleMetadata{
+		constants("Balances", v9.ModuleConstantMetadata{
+			Name: "ExistentialDeposit", Type: "T::Balance",
+			Value: []byte{0x00, 0xe4, 0x0b, 0x54, 0x02, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			Docs:  []string{" The minimum amount required to keep an account open."},
+		}),
+		constants("System",
+			v9.ModuleConstantMetadata{Name: "BlockHashCount", Type: "T::BlockNumber", Value: []byte{0x60, 0x09, 0, 0}},
+			v9.ModuleConstantMetadata{Name: "DbWeight", Type: "RuntimeDbWeight", Value: bytes.Repeat([]byte{0x01, 0, 0, 0, 0, 0, 0, 0}, 2)},
+		),
+		constants("Treasury",
+			v9.ModuleConstantMetadata{Name: "ModuleId", Type: "ModuleId", Value: []byte("py/trsry")},
+			v9.ModuleConstantMetadata{Name: "Burn", Type: "Permill", Value: []byte{0x10, 0x27, 0, 0}},
+		),
+	}, 11)
+
+	// Permill isn't in the registry.
+	if _, err := DecodeConstants(&metadata, registry); err == nil {
+		t.Fatal("expected an error for an unknown type")
+	}
+	metadata.Modules[2].Constants = metadata.Modules[2].Constants[:1]
+
+	decoded, err := DecodeConstants(&metadata, registry)
+	if err != nil {
+		t.Fatalf("decode constants: %v", err)
+	}
+
+	ed, err := decoded.BigInt("Balances", "ExistentialDeposit")
+	if err != nil || ed.Cmp(big.NewInt(10_000_000_000)) != 0 {
+		t.Errorf("ExistentialDeposit = %v, %v", ed, err)
+	}
+	hashCount, err := decoded.Uint32("System", "BlockHashCount")
+	if err != nil || hashCount != 2400 {
+		t.Errorf("BlockHashCount = %d, %v", hashCount, err)
+	}
+	dbWeight, err := decoded.Struct("System", "DbWeight")
+	if read, _ := dbWeight["read"].(uint64); err != nil || read != 1 {
+		t.Errorf("DbWeight = %v, %v", dbWeight, err)
+	}
+	moduleId, err := decoded.Bytes("Treasury", "ModuleId")
+	if err != nil || string(moduleId) != "py/trsry" {
+		t.Errorf("ModuleId = %q, %v", moduleId, err)
+	}
+
+	constant, _ := decoded.Get("Balances", "ExistentialDeposit")
+	if constant.TypeName != "T::Balance" || len(constant.Docs) != 1 {
+		t.Errorf("ExistentialDeposit type %q, docs %q", constant.TypeName, constant.Docs)
+	}
+}
+
+// TestPlainValueMatchesV14 decodes the same constants and values with legacy type definitions and
+// with v14 metadata, which must give the same Go values.
+func TestPlainValueMatchesV14(t *testing.T) {
+	// JSON keeps the order of struct fields and enum variants.
+	registry := polkadot_scale_schema.NewRegistry()
+	err := polkadot_scale_schema.LoadTypesBundle(registry, strings.NewReader(`{"types": {
+		"Balance": "UInt<128, Balance>",
+		"Weight": {"ref_time": "Compact<u64>", "proof_size": "Compact<u64>"},
+		"BlockWeights": {"base_block": "Weight", "max_block": "Weight"},
+		"DispatchClass": {"_enum": ["Normal", "Operational", "Mandatory"]},
+		"Pays": {"_enum": ["Yes", "No"]},
+		"DispatchInfo": {"weight": "Weight", "class": "DispatchClass", "pays_fee": "Pays"},
+		"ModuleError": {"index": "u8", "error": "[u8; 4]"},
+		"TokenError": {"_enum": ["FundsUnavailable"]},
+		"ArithmeticError": {"_enum": ["Underflow", "Overflow", "DivisionByZero"]},
+		"DispatchError": {"_enum": {
+			"Other": "Null", "CannotLookup": "Null", "BadOrigin": "Null", "Module": "ModuleError",
+			"ConsumerRemaining": "Null", "NoProviders": "Null", "TooManyConsumers": "Null",
+			"Token": "TokenError", "Arithmetic": "ArithmeticError"
+		}},
+		"ProxyType": {"_enum": ["Any", "NonTransfer"]}
+	}}`))
+	if err != nil {
+		t.Fatalf("load types: %v", err)
+	}
+	metadata := v14test.NewMetadata()
+
+	t.Run("constants", func(t *testing.T) {
+		want, err := v14.DecodeConstants(metadata)
+		if err != nil {
+			t.Fatalf("decode v14 constants: %v", err)
+		}
+		typeNames := map[string]string{
+			"BlockWeights":       "BlockWeights",
+			"BlockHashCount":     "u32",
+			"SS58Prefix":         "u16",
+			"ExistentialDeposit": "Balance",
+		}
+		var modules []ModuleMetadata
+		for _, pallet := range []string{"System", "Balances"} {
+			var items []v9.ModuleConstantMetadata
+			for _, constant := range want.Pallet(pallet) {
+				items = append(items, v9.ModuleConstantMetadata{Name: constant.Name, Type: typeNames[constant.Name], Value: constant.Raw})
+			}
+			modules = append(modules, MakeModuleFromV9Parts(pallet, nil, nil, items))
+		}
+		legacyMetadata := MakeMetadataFromModules(modules, 11)
+
+		got, err := DecodeConstants(&legacyMetadata, registry)
+		if err != nil {
+			t.Fatalf("decode legacy constants: %v", err)
+		}
+		if len(got) != len(typeNames) {
+			t.Fatalf("got %d constants, want %d", len(got), len(typeNames))
+		}
+		for _, constant := range got {
+			wanted, _ := want.Get(constant.PalletName, constant.Name)
+			if !reflect.DeepEqual(constant.Value, wanted.Value) {
+				t.Errorf("%s.%s = %#v, want %#v", constant.PalletName, constant.Name, constant.Value, wanted.Value)
+			}
+		}
+	})
+
+	tests := []struct {
+		name     string
+		typeName string
+		encoded  []byte
+		// v14Value returns the value decoded from encoded with v14 metadata.
+		v14Value func(encoded []byte) (any, error)
+	}{
+		{"module error", "DispatchError", []byte{3, 5, 2, 0, 0, 0}, failedEventArg(metadata, 0)},
+		{"unit variant", "DispatchError", []byte{2}, failedEventArg(metadata, 0)},
+		{"nested variant", "DispatchError", []byte{8, 1}, failedEventArg(metadata, 0)},
+		{"struct of enums", "DispatchInfo", v14test.DispatchInfo(300, 10, 1, 1), func(encoded []byte) (any, error) {
+			return failedEventArg(metadata, 1)(append([]byte{2}, encoded...))
+		}},
+		{"none", "Option<ProxyType>", []byte{0}, proxyTypeArg(metadata)},
+		{"some", "Option<ProxyType>", []byte{1, 1}, proxyTypeArg(metadata)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := tt.v14Value(tt.encoded)
+			if err != nil {
+				t.Fatalf("decode with v14 metadata: %v", err)
+			}
+			schema, err := polkadot_scale_schema.ParseTypeName(tt.typeName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			value, err := registry.DecodeSchema(scale.NewReader(tt.encoded), "runtime", schema)
+			if err != nil {
+				t.Fatalf("decode with legacy types: %v", err)
+			}
+			got, err := PlainValue(scale.NewSchemaResolver(registry), value, schema, "runtime")
+			if err != nil {
+				t.Fatalf("plain value: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %#v, want %#v", got, want)
+			}
+		})
+	}
+}
+
+// failedEventArg returns a func decoding System.ExtrinsicFailed with v14 metadata, returning its
+// argument i. The encoded dispatch error is followed by a dispatch info.
+func failedEventArg(metadata *v14meta.Metadata, i int) func(encoded []byte) (any, error) {
+	return func(encoded []byte) (any, error) {
+		event := v14test.Concat([]byte{v14test.SystemIndex, 1}, encoded)
+		if i == 0 {
+			event = v14test.Concat(event, v14test.DispatchInfo(0, 0, 0, 0))
+		}
+		decoded, err := v14.DecodePalletVariant(metadata, scale.NewReader(event), "events")
+		if err != nil {
+			return nil, err
+		}
+		return decoded.Args[i].Value, nil
+	}
+}
+
+// proxyTypeArg returns a func decoding a Proxy.proxy call with v14 metadata, returning its
+// force_proxy_type argument.
+func proxyTypeArg(metadata *v14meta.Metadata) func(encoded []byte) (any, error) {
+	return func(encoded []byte) (any, error) {
+		call := v14test.Concat([]byte{v14test.ProxyIndex, 0, 0}, make([]byte, 32), encoded, []byte{v14test.SystemIndex, 0, 0})
+		decoded, err := v14.DecodePalletVariant(metadata, scale.NewReader(call), "calls")
+		if err != nil {
+			return nil, err
+		}
+		return decoded.Args[1].Value, nil
+	}
+}
+
+func TestPlainValueTupleVariant(t *testing.T) {
+	registry := polkadot_scale_schema.NewRegistry()
+	err := polkadot_scale_schema.LoadTypesBundle(registry, strings.NewReader(`{"types": {
+		"Judgement": {"_enum": {"Unknown": "Null", "FeePaid": "(u8, u16, bool)", "Reasonable": "u32"}}
+	}}`))
+	if err != nil {
+		t.Fatalf("load types: %v", err)
+	}
+	schema, err := polkadot_scale_schema.ParseTypeName("Judgement")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		encoded []byte
+		want    any
+	}{
+		{"tuple", []byte{1, 7, 0x02, 0x01, 1}, map[string]any{"FeePaid": map[string]any{"unnamed": []any{uint8(7), uint16(0x0102), true}}}},
+		{"single", []byte{2, 5, 0, 0, 0}, map[string]any{"Reasonable": map[string]any{"unnamed": uint32(5)}}},
+		{"null", []byte{0}, map[string]any{"Unknown": map[string]any{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := registry.DecodeSchema(scale.NewReader(tt.encoded), "runtime", schema)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			got, err := PlainValue(scale.NewSchemaResolver(registry), value, schema, "runtime")
+			if err != nil {
+				t.Fatalf("plain value: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,10 +2,10 @@ package legacy
 
 import (
 	"fmt"
-	"strconv"
-	"strings"
 	"submarine/metadata/base"
+	"submarine/polkadot_scale_schema"
 	"submarine/scale"
+	"sync"
 )
 
 type Event struct {
@@ -19,7 +19,19 @@ type Extrinsic struct {
 	Address    *base.Address
 	Signature  *base.Signature
 	PalletName string
-	EventName  string
+	CallName   string
+	// Deprecated: EventName is the CallName, under the name it had before CallName.
+	EventName string
+	Args      []Arg
+	// Extensions are the values signed extensions add to a signed extrinsic, e.g. era, nonce and tip.
+	Extensions []Arg
+}
+
+// DecodedCall is a call with its arguments, either of an extrinsic or nested in another call's
+// arguments, e.g. Utility.batch.
+type DecodedCall struct {
+	PalletName string
+	CallName   string
 	Args       []Arg
 }
 
@@ -41,20 +53,27 @@ type EventPhase struct {
 	ExtrinsicIndex int
 }
 
-func DecodeEvents(metadata *Metadata, eventsBytes []byte) ([]Event, error) {
+// DecodeEvents decodes the events of a block, looking up the types of their arguments in registry.
+// Pass a Registry.ForSpec view for the block's runtime so types that changed shape decode correctly.
+func DecodeEvents(metadata *Metadata, registry *polkadot_scale_schema.Registry, eventsBytes []byte) ([]Event, error) {
 	r := scale.NewReader(eventsBytes)
 
-	events, err := scale.DecodeVec(r, func(r *scale.Reader) (Event, error) {
-		return DecodeEvent(metadata, r)
+	// An event is at least its phase and its module and event indices.
+	events, err := scale.DecodeVec(r, 3, func(r *scale.Reader) (Event, error) {
+		return DecodeEvent(metadata, registry, r)
 	})
 	if err != nil {
 		return nil, err
 	}
+	// Bytes left after the last event mean an argument was decoded with the wrong type.
+	if r.Remaining() != 0 {
+		return nil, fmt.Errorf("%d trailing bytes after %d events", r.Remaining(), len(events))
+	}
 
 	return events, nil
 }
 
-func DecodeEvent(metadata *Metadata, r *scale.Reader) (Event, error) {
+func DecodeEvent(metadata *Metadata, registry *polkadot_scale_schema.Registry, r *scale.Reader) (Event, error) {
 	var event Event
 
 	phaseIndex, err := r.ReadByte()
@@ -95,17 +114,19 @@ func DecodeEvent(metadata *Metadata, r *scale.Reader) (Event, error) {
 		return event, fmt.Errorf("module: %w", err)
 	}
 
-	if int(eventIndex) > len(moduleMetadata.Events) {
+	if int(eventIndex) >= len(moduleMetadata.Events) {
 		return event, fmt.Errorf("event %d out of bounds", eventIndex)
 	}
 
 	eventMetadata := moduleMetadata.Events[eventIndex]
+	event.PalletName = moduleMetadata.Name
+	event.EventName = eventMetadata.Name
 	for i, argType := range eventMetadata.Args {
 		var arg Arg
 		arg.Name = fmt.Sprintf("arg%d", i)
-		value, err := DecodeArgFromTypename(r, argType)
+		value, err := decodeArg(metadata, registry, r, moduleMetadata.Name, argType)
 		if err != nil {
-			return event, fmt.Errorf("arg %d: %w", i, err)
+			return event, fmt.Errorf("%s.%s: arg %d: %w", event.PalletName, event.EventName, i, err)
 		}
 		arg.Value = value
 		event.Args = append(event.Args, arg)
@@ -114,7 +135,10 @@ func DecodeEvent(metadata *Metadata, r *scale.Reader) (Event, error) {
 	return event, nil
 }
 
-func DecodeExtrinsic(metadata *Metadata, extrinsicBytes []byte) (Extrinsic, error) {
+// DecodeExtrinsic decodes an extrinsic of a block, looking up the types of its arguments in registry.
+// The sender's address is decoded as the registry's Address type, so pass a Registry.ForSpec view
+// for the block's runtime: it is an AccountId or a LookupSource on runtimes before MultiAddress.
+func DecodeExtrinsic(metadata *Metadata, registry *polkadot_scale_schema.Registry, extrinsicBytes []byte) (Extrinsic, error) {
 	var extrinsic Extrinsic
 
 	r := scale.NewReader(extrinsicBytes)
@@ -136,7 +160,7 @@ func DecodeExtrinsic(metadata *Metadata, extrinsicBytes []byte) (Extrinsic, erro
 
 	if isSigned {
 		// 1. Decode the sender's Address.
-		address, err := base.DecodeAddress(r)
+		address, err := decodeSigner(registry, r)
 		if err != nil {
 			return extrinsic, fmt.Errorf("failed to decode sender address: %w", err)
 		}
@@ -149,162 +173,196 @@ func DecodeExtrinsic(metadata *Metadata, extrinsicBytes []byte) (Extrinsic, erro
 		}
 		extrinsic.Signature = &signature
 
-		// // 3. Decode the signed extensions.
-		// for _, extension := range metadata.Extrinsic.SignedExtensions {
-		// 	_, err := DecodeArgFromString(metadata, r, string(extension.Type))
-		// 	if err != nil {
-		// 		return nil, fmt.Errorf("failed to decode signed extension '%s': %w", extension.Identifier, err)
-		// 	}
-		// }
+		// 3. Decode the signed extensions.
+		for _, identifier := range metadata.SignedExtensions {
+			for _, extra := range signedExtra[identifier] {
+				value, err := decodeExtra(metadata, registry, r, extra)
+				if err != nil {
+					return extrinsic, fmt.Errorf("signed extension %s: %s: %w", identifier, extra.Name, err)
+				}
+				extrinsic.Extensions = append(extrinsic.Extensions, Arg{Name: extra.Name, Value: value})
+			}
+		}
+	}
+
+	call, err := DecodeCall(metadata, registry, r)
+	if err != nil {
+		return extrinsic, err
+	}
+	// Bytes left after the call mean an argument was decoded with the wrong type.
+	if r.Remaining() != 0 {
+		return extrinsic, fmt.Errorf("%s.%s: %d trailing bytes", call.PalletName, call.CallName, r.Remaining())
+	}
+	extrinsic.PalletName = call.PalletName
+	extrinsic.CallName = call.CallName
+	extrinsic.EventName = call.CallName
+	extrinsic.Args = call.Args
+
+	return extrinsic, nil
+}
+
+// decodeSigner decodes the sender's address of a signed extrinsic as the runtime's Address type,
+// or LookupSource if the registry has no Address, following aliases to the polkadot.js class
+// implementing it. Without either, the address is a MultiAddress.
+func decodeSigner(registry *polkadot_scale_schema.Registry, r *scale.Reader) (base.Address, error) {
+	name := "Address"
+	if _, err := registry.Lookup("runtime", name); err != nil {
+		name = "LookupSource"
+		if _, err := registry.Lookup("runtime", name); err != nil {
+			return base.DecodeAddress(r)
+		}
+	}
+	for range maxAddressAliases {
+		switch name {
+		case "GenericMultiAddress":
+			return base.DecodeAddress(r)
+		case "GenericLookupSource", "GenericAddress":
+			return base.DecodeLookupSource(r)
+		case "GenericAccountId", "GenericAccountId32":
+			var id base.AddressId
+			if err := id.Decode(r); err != nil {
+				return base.Address{}, err
+			}
+			return base.Address{Kind: base.KindAddressId, Id: &id}, nil
+		case "GenericEthereumAccountId":
+			var addr20 base.Address20
+			if err := addr20.Decode(r); err != nil {
+				return base.Address{}, err
+			}
+			return base.Address{Kind: base.KindAddress20, Addr20: &addr20}, nil
+		}
+
+		schema, _, err := registry.ResolveType("runtime", name)
+		if err != nil {
+			return base.Address{}, err
+		}
+		if schema.Kind != scale.KindRef {
+			return base.Address{}, fmt.Errorf("unsupported address type %s", name)
+		}
+		name = *schema.Ref
+	}
+	return base.Address{}, fmt.Errorf("address type %s: too many aliases", name)
+}
+
+// maxAddressAliases bounds the aliases decodeSigner follows, e.g. Address -> LookupSource ->
+// IndicesLookupSource -> GenericLookupSource.
+const maxAddressAliases = 8
+
+// DecodeCall decodes a call: the module and call indices followed by the call's arguments.
+func DecodeCall(metadata *Metadata, registry *polkadot_scale_schema.Registry, r *scale.Reader) (DecodedCall, error) {
+	var call DecodedCall
+	if metadata == nil {
+		return call, fmt.Errorf("calls can't be decoded without metadata")
 	}
 
 	moduleIndex, err := r.ReadByte()
 	if err != nil {
-		return extrinsic, fmt.Errorf("module index: %w", err)
+		return call, fmt.Errorf("module index: %w", err)
 	}
 
-	extrinsicIndex, err := r.ReadByte()
+	callIndex, err := r.ReadByte()
 	if err != nil {
-		return extrinsic, fmt.Errorf("extrinsic index: %w", err)
+		return call, fmt.Errorf("call index: %w", err)
 	}
 
-	moduleMetadata, err := metadata.GetModuleForEvent(int(moduleIndex))
+	moduleMetadata, err := metadata.GetModuleForExtrinsic(int(moduleIndex))
 	if err != nil {
-		return extrinsic, fmt.Errorf("module: %w", err)
+		return call, fmt.Errorf("module: %w", err)
 	}
 
-	if int(extrinsicIndex) > len(moduleMetadata.Calls) {
-		return extrinsic, fmt.Errorf("extrinsic %d out of bounds", extrinsicIndex)
+	if int(callIndex) >= len(moduleMetadata.Calls) {
+		return call, fmt.Errorf("call %d of %s out of bounds", callIndex, moduleMetadata.Name)
 	}
 
-	extrinsicMetadata := moduleMetadata.Calls[extrinsicIndex]
-	for i, argMetadata := range extrinsicMetadata.Args {
-		var arg Arg
-		arg.Name = argMetadata.Name
-		value, err := DecodeArgFromTypename(r, argMetadata.Type)
+	callMetadata := moduleMetadata.Calls[callIndex]
+	call.PalletName = moduleMetadata.Name
+	call.CallName = callMetadata.Name
+	for _, argMetadata := range callMetadata.Args {
+		value, err := decodeArg(metadata, registry, r, moduleMetadata.Name, argMetadata.Type)
 		if err != nil {
-			return extrinsic, fmt.Errorf("arg %d: %w", i, err)
+			return call, fmt.Errorf("%s.%s: arg %s: %w", call.PalletName, call.CallName, argMetadata.Name, err)
 		}
-		arg.Value = value
-		extrinsic.Args = append(extrinsic.Args, arg)
+		call.Args = append(call.Args, Arg{Name: argMetadata.Name, Value: value})
 	}
 
-	return extrinsic, nil
+	return call, nil
 }
 
-func DecodeArgFromTypename(r *scale.Reader, typeName string) (any, error) {
-	typeName = strings.TrimSpace(typeName)
+// signedExtra are the values signed extensions add to signed extrinsics, as in polkadot.js.
+// Extensions not listed add none.
+var signedExtra = map[string][]CallArgument{
+	"CheckEra":                 {{Name: "era", Type: "ExtrinsicEra"}},
+	"CheckMortality":           {{Name: "era", Type: "ExtrinsicEra"}},
+	"CheckNonce":               {{Name: "nonce", Type: "Compact<Index>"}},
+	"ChargeTransactionPayment": {{Name: "tip", Type: "Compact<Balance>"}},
+	"ChargeAssetTxPayment":     {{Name: "tip", Type: "Compact<Balance>"}, {Name: "assetId", Type: "Option<AssetId>"}},
+}
 
-	// Handle compact encoding wrapper
-	if strings.HasPrefix(typeName, "Compact<") && strings.HasSuffix(typeName, ">") {
-		return scale.DecodeCompact(r)
+func decodeExtra(metadata *Metadata, registry *polkadot_scale_schema.Registry, r *scale.Reader, extra CallArgument) (any, error) {
+	if extra.Type == "ExtrinsicEra" {
+		return base.DecodeEra(r)
 	}
+	return decodeArg(metadata, registry, r, "runtime", extra.Type)
+}
 
-	// Handle vector wrapper
-	if strings.HasPrefix(typeName, "Vec<") && strings.HasSuffix(typeName, ">") {
-		innerTypeName := typeName[4 : len(typeName)-1]
-		// Optimization for Vec<u8> which is decoded as Bytes
-		if innerTypeName == "u8" {
-			return scale.DecodeBytes(r)
-		}
-		return scale.DecodeVec(r, func(r *scale.Reader) (any, error) {
-			return DecodeArgFromTypename(r, innerTypeName)
-		})
+// decodeArg decodes an argument of the named type. Calls, e.g. of Utility.batch or
+// Democracy.propose, are decoded through the metadata, other types through the registry.
+func decodeArg(metadata *Metadata, registry *polkadot_scale_schema.Registry, r *scale.Reader, moduleName, typeName string) (any, error) {
+	schema, err := polkadot_scale_schema.ParseTypeName(typeName)
+	if err != nil {
+		return nil, err
 	}
-
-	// Handle option wrapper
-	if strings.HasPrefix(typeName, "Option<") && strings.HasSuffix(typeName, ">") {
-		innerTypeName := typeName[7 : len(typeName)-1]
-		return scale.DecodeOption(r, func(r *scale.Reader) (any, error) {
-			return DecodeArgFromTypename(r, innerTypeName)
-		})
+	value, err := decodeSchema(metadata, registry, r, registryModule(moduleName), schema)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", typeName, err)
 	}
+	return value, nil
+}
 
-	// Handle tuple wrapper
-	if strings.HasPrefix(typeName, "(") && strings.HasSuffix(typeName, ")") {
-		innerTypesStr := typeName[1 : len(typeName)-1]
-		// This is a simplified tuple parser. It won't handle nested complex types correctly.
-		// e.g., (u32, Vec<(u8, u8)>) will fail.
-		// But it should work for simple cases like (u32, bool).
-		innerTypes := strings.Split(innerTypesStr, ",")
-		result := make([]any, len(innerTypes))
-		for i, innerType := range innerTypes {
-			val, err := DecodeArgFromTypename(r, strings.TrimSpace(innerType))
-			if err != nil {
-				return nil, fmt.Errorf("failed to decode tuple element %d ('%s'): %w", i, innerType, err)
-			}
-			result[i] = val
-		}
-		return result, nil
+// polkadotRegistry is the registry of LoadPolkadotSchema, loaded on the first use of
+// DecodeArgFromTypename.
+var polkadotRegistry = sync.OnceValues(func() (*polkadot_scale_schema.Registry, error) {
+	registry := polkadot_scale_schema.NewRegistry()
+	return registry, polkadot_scale_schema.LoadPolkadotSchema(registry)
+})
+
+// DecodeArgFromTypename decodes a value of the named type, e.g. "Compact<Balance>", with the
+// definitions of LoadPolkadotSchema, as PlainValue converts it.
+//
+// Deprecated: LoadPolkadotSchema has the definitions of the latest runtimes, which don't decode the
+// types of older ones, and calls can't be decoded without metadata. Use DecodeEvent, DecodeExtrinsic
+// or DecodeCall with a Registry.ForSpec view for the runtime.
+func DecodeArgFromTypename(r *scale.Reader, typeName string) (any, error) {
+	registry, err := polkadotRegistry()
+	if err != nil {
+		return nil, err
 	}
+	return decodeArg(nil, registry, r, "runtime", typeName)
+}
 
-	// Handle fixed-size array
-	if strings.HasPrefix(typeName, "[") && strings.HasSuffix(typeName, "]") {
-		parts := strings.Split(strings.Trim(typeName, "[]"), ";")
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid array type string: %s", typeName)
-		}
-		innerTypeName := strings.TrimSpace(parts[0])
-		sizeStr := strings.TrimSpace(parts[1])
-		size, err := strconv.Atoi(sizeStr)
-		if err != nil {
-			return nil, fmt.Errorf("invalid array size '%s': %w", sizeStr, err)
-		}
-
-		result := make([]any, size)
-		for i := range size {
-			val, err := DecodeArgFromTypename(r, innerTypeName)
-			if err != nil {
-				return nil, fmt.Errorf("failed to decode array element %d ('%s'): %w", i, innerTypeName, err)
-			}
-			result[i] = val
-		}
-		return result, nil
+func decodeSchema(metadata *Metadata, registry *polkadot_scale_schema.Registry, r *scale.Reader, moduleName string, schema *scale.Type) (any, error) {
+	switch {
+	case isCall(schema):
+		return DecodeCall(metadata, registry, r)
+	case schema.Kind == scale.KindVec && isCall(schema.Vec.Type):
+		return scale.DecodeVec(r, 2, func(r *scale.Reader) (DecodedCall, error) {
+			return DecodeCall(metadata, registry, r)
+		})
+	case schema.Kind == scale.KindOption && isCall(schema.Option.Type):
+		return scale.DecodeOption(r, func(r *scale.Reader) (DecodedCall, error) {
+			return DecodeCall(metadata, registry, r)
+		})
 	}
 
-	// Handle primitive and common types
-	switch typeName {
-	case "u8":
-		return scale.DecodeU8(r)
-	case "u16":
-		return scale.DecodeU16(r)
-	case "u32":
-		return scale.DecodeU32(r)
-	case "u64":
-		return scale.DecodeU64(r)
-	case "u128", "Balance":
-		return scale.DecodeU128(r)
-	case "bool":
-		return scale.DecodeBool(r)
-	case "Bytes":
-		return scale.DecodeBytes(r)
-	case "Text", "String":
-		return scale.DecodeText(r)
-	case "AccountId":
-		return r.ReadBytes(32)
-	case "H256", "Hash": // 32-byte hash
-		return r.ReadBytes(32)
-	// case "AccountInfo":
-	// 	return system.DecodeAccountInfoWithTripleRefCount(r)
-	// case "DispatchResult":
-	// 	return system.DecodeDispatchOutcome(r)
-	// case "Weight":
-	// 	return system.DecodeWeight(r)
-	// case "Phase":
-	// 	return system.DecodePhase(r)
-	// case "EventRecord":
-	// 	return system.DecodeEventRecord(r)
-	// case "LastRuntimeUpgradeInfo":
-	// 	return system.DecodeLastRuntimeUpgradeInfo(r)
-	// case "BlockLength":
-	// 	return system.DecodeBlockLength(r)
-	// case "BlockWeights":
-	// 	return system.DecodeBlockWeights(r)
-	// case "DispatchInfo":
-	// 	return system.DecodeDispatchInfo(r)
-	// case "DispatchError":
-	// 	return system.DecodeDispatchError(r)
-	default:
-		return nil, fmt.Errorf("unsupported type string '%s'", typeName)
+	value, err := registry.DecodeSchema(r, moduleName, schema)
+	if err != nil {
+		return nil, err
 	}
+	return PlainValue(scale.NewSchemaResolver(registry), value, schema, moduleName)
+}
+
+// isCall reports whether a type is a runtime call, which polkadot.js decodes with the metadata
+// rather than a type definition.
+func isCall(schema *scale.Type) bool {
+	return schema.Kind == scale.KindRef && (*schema.Ref == "Call" || *schema.Ref == "Proposal")
 }
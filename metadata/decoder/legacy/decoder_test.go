@@ -0,0 +1,329 @@
+package legacy_test
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"slices"
+	"strings"
+	"submarine/metadata/base"
+	. "submarine/metadata/decoder/legacy"
+	v9 "submarine/metadata/generated/v9"
+	"submarine/polkadot_scale_schema"
+	"submarine/scale"
+	"testing"
+)
+
+func testRegistry(t testing.TB) *polkadot_scale_schema.Registry {
+	t.Helper()
+	registry := polkadot_scale_schema.NewRegistry()
+	err := polkadot_scale_schema.LoadFromSchema(registry, map[string]map[string]any{
+		"runtime": {
+			"AccountId":     "GenericAccountId32",
+			"Balance":       "UInt<128, Balance>",
+			"Index":         "u32",
+			"LookupSource":  "MultiAddress",
+			"MultiAddress":  "GenericMultiAddress",
+			"Weight":        "u64",
+			"DispatchClass": map[string]any{"_enum": []any{"Normal", "Operational", "Mandatory"}},
+			"Pays":          map[string]any{"_enum": []any{"Yes", "No"}},
+		},
+		"system": {
+			"DispatchInfo": "(Weight, DispatchClass, Pays)",
+		},
+	})
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+	return registry
+}
+
+// plainBytes returns bytes as PlainValue converts them.
+func plainBytes(b []byte) []any {
+	list := make([]any, len(b))
+	for i := range b {
+		list[i] = b[i]
+	}
+	return list
+}
+
+func testMetadata() Metadata {
+	call := func(name string, args ...v9.FunctionArgumentMetadata) v9.FunctionMetadata {
+		return v9.FunctionMetadata{Name: name, Args: args}
+	}
+	event := func(name string, args ...string) v9.EventMetadata {
+		return v9.EventMetadata{Name: name, Args: args}
+	}
+	metadata := MakeMetadataFromModules([]ModuleMetadata{
+		MakeModuleFromV9Parts("System",
+			&[]v9.FunctionMetadata{call("remark", v9.FunctionArgumentMetadata{Name: "_remark", Type: "Vec<u8>"})},
+			&[]v9.EventMetadata{event("ExtrinsicSuccess", "DispatchInfo")}, nil),
+		MakeModuleFromV9Parts("Timestamp", nil, nil, nil),
+		MakeModuleFromV9Parts("Balances",
+			&[]v9.FunctionMetadata{call("transfer",
+				v9.FunctionArgumentMetadata{Name: "dest", Type: "<T::Lookup as StaticLookup>::Source"},
+				v9.FunctionArgumentMetadata{Name: "value", Type: "Compact<T::Balance>"},
+			)},
+			&[]v9.EventMetadata{event("Transfer", "AccountId", "AccountId", "Balance")}, nil),
+		MakeModuleFromV9Parts("Utility",
+			&[]v9.FunctionMetadata{call("batch", v9.FunctionArgumentMetadata{Name: "calls", Type: "Vec<<T as Trait>::Call>"})},
+			nil, nil),
+	}, 11)
+	metadata.SignedExtensions = []string{"CheckSpecVersion", "CheckGenesis", "CheckMortality", "CheckNonce", "ChargeTransactionPayment"}
+	return metadata
+}
+
+func TestDecodeExtrinsic(t *testing.T) {
+	registry := testRegistry(t)
+	metadata := testMetadata()
+
+	alice := bytes.Repeat([]byte{0xaa}, 32)
+	bob := bytes.Repeat([]byte{0xbb}, 32)
+
+	var body []byte
+	body = append(body, 0x84, 0x00)                        // signed v4, MultiAddress::Id
+	body = append(body, alice...)                          // sender
+	body = append(body, 0x01)                              // MultiSignature::Sr25519
+	body = append(body, bytes.Repeat([]byte{0x5a}, 64)...) // signature
+	body = append(body, 0x15, 0x01)                        // mortal era, period 64
+	body = append(body, 0x14)                              // nonce 5
+	body = append(body, 0x00)                              // tip 0
+	body = append(body, 0x02, 0x00, 0x08)                  // Utility.batch, 2 calls
+	body = append(body, 0x01, 0x00, 0x00)                  // Balances.transfer to MultiAddress::Id
+	body = append(body, bob...)
+	body = append(body, 0x02, 0x09, 0x3d, 0x00)     // value 1_000_000
+	body = append(body, 0x00, 0x00, 0x08, 'h', 'i') // System.remark("hi")
+	extrinsicBytes := append(scale.EncodeCompact(big.NewInt(int64(len(body)))), body...)
+
+	extrinsic, err := DecodeExtrinsic(&metadata, registry, extrinsicBytes)
+	if err != nil {
+		t.Fatalf("decode extrinsic: %v", err)
+	}
+
+	if extrinsic.Address == nil || extrinsic.Address.Kind != base.KindAddressId {
+		t.Errorf("address = %+v", extrinsic.Address)
+	}
+	if extrinsic.PalletName != "Utility" || extrinsic.CallName != "batch" || extrinsic.EventName != "batch" {
+		t.Errorf("call = %s.%s (%s)", extrinsic.PalletName, extrinsic.CallName, extrinsic.EventName)
+	}
+
+	if len(extrinsic.Extensions) != 3 {
+		t.Fatalf("extensions = %+v", extrinsic.Extensions)
+	}
+	if era, ok := extrinsic.Extensions[0].Value.(base.Era); !ok || era.IsImmortal || era.Period != 64 {
+		t.Errorf("era = %+v", extrinsic.Extensions[0].Value)
+	}
+	if nonce, ok := extrinsic.Extensions[1].Value.(*big.Int); !ok || nonce.Int64() != 5 {
+		t.Errorf("nonce = %v", extrinsic.Extensions[1].Value)
+	}
+
+	calls, ok := extrinsic.Args[0].Value.([]DecodedCall)
+	if !ok || len(calls) != 2 {
+		t.Fatalf("calls = %#v", extrinsic.Args[0].Value)
+	}
+	transfer := calls[0]
+	if transfer.PalletName != "Balances" || transfer.CallName != "transfer" || len(transfer.Args) != 2 {
+		t.Fatalf("transfer = %+v", transfer)
+	}
+	if dest := transfer.Args[0].Value; !reflect.DeepEqual(dest, map[string]any{"Id": map[string]any{"unnamed": plainBytes(bob)}}) {
+		t.Errorf("dest = %v", transfer.Args[0].Value)
+	}
+	if value, ok := transfer.Args[1].Value.(*big.Int); !ok || value.Int64() != 1_000_000 {
+		t.Errorf("value = %v", transfer.Args[1].Value)
+	}
+	if remark := calls[1].Args[0].Value; !reflect.DeepEqual(remark, plainBytes([]byte("hi"))) {
+		t.Errorf("remark = %v", calls[1].Args[0].Value)
+	}
+
+	// Truncated within the nested calls.
+	if _, err := DecodeExtrinsic(&metadata, registry, extrinsicBytes[:len(extrinsicBytes)-4]); err == nil {
+		t.Error("expected an error for a truncated extrinsic")
+	}
+	// A byte after the call.
+	long := append(scale.EncodeCompact(big.NewInt(int64(len(body)+1))), append(body, 0x00)...)
+	if _, err := DecodeExtrinsic(&metadata, registry, long); err == nil || !strings.Contains(err.Error(), "Utility.batch: 1 trailing bytes") {
+		t.Errorf("expected an error for a trailing byte, got %v", err)
+	}
+}
+
+func TestDecodeExtrinsicPreMultiAddress(t *testing.T) {
+	// Before MultiAddress, senders were AccountIds, and before that indices LookupSources, as
+	// given for the spec versions of the runtime by its types bundle.
+	registry := testRegistry(t)
+	err := polkadot_scale_schema.LoadTypesBundle(registry, strings.NewReader(`{"spec": {"polkadot": {"types": [
+		{"minmax": [0, 9], "types": {"Address": "LookupSource", "LookupSource": "IndicesLookupSource", "IndicesLookupSource": "GenericLookupSource"}},
+		{"minmax": [10, 27], "types": {"Address": "AccountId", "LookupSource": "AccountId"}}
+	]}}}`))
+	if err != nil {
+		t.Fatalf("load types bundle: %v", err)
+	}
+	metadata := testMetadata()
+	alice := bytes.Repeat([]byte{0xaa}, 32)
+	bob := bytes.Repeat([]byte{0xbb}, 32)
+	index := base.AddressIndex(0x1234)
+
+	tests := []struct {
+		name        string
+		specVersion uint32
+		sender      []byte
+		call        []byte
+		want        base.Address
+	}{
+		{
+			name:        "account id",
+			specVersion: 20,
+			sender:      alice,
+			call:        append(append([]byte{0x01, 0x00}, bob...), 0x02, 0x09, 0x3d, 0x00), // Balances.transfer to bob
+			want:        base.Address{Kind: base.KindAddressId, Id: (*base.AddressId)(alice)},
+		},
+		{
+			name:        "lookup source account id",
+			specVersion: 5,
+			sender:      append([]byte{0xff}, alice...),
+			call:        []byte{0x00, 0x00, 0x00}, // System.remark("")
+			want:        base.Address{Kind: base.KindAddressId, Id: (*base.AddressId)(alice)},
+		},
+		{
+			name:        "lookup source index",
+			specVersion: 5,
+			sender:      []byte{0xfc, 0x34, 0x12},
+			call:        []byte{0x00, 0x00, 0x00},
+			want:        base.Address{Kind: base.KindAddressIndex, Index: &index},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body []byte
+			body = append(body, 0x84)
+			body = append(body, tt.sender...)
+			body = append(body, 0x01)                              // MultiSignature::Sr25519
+			body = append(body, bytes.Repeat([]byte{0x5a}, 64)...) // signature
+			body = append(body, 0x00, 0x14, 0x00)                  // immortal era, nonce 5, tip 0
+			body = append(body, tt.call...)
+			extrinsicBytes := append(scale.EncodeCompact(big.NewInt(int64(len(body)))), body...)
+
+			view := registry.ForSpec("Polkadot", "polkadot", tt.specVersion)
+			extrinsic, err := DecodeExtrinsic(&metadata, view, extrinsicBytes)
+			if err != nil {
+				t.Fatalf("decode extrinsic: %v", err)
+			}
+			if !reflect.DeepEqual(*extrinsic.Address, tt.want) {
+				t.Errorf("address = %+v, want %+v", *extrinsic.Address, tt.want)
+			}
+			if nonce, ok := extrinsic.Extensions[1].Value.(*big.Int); !ok || nonce.Int64() != 5 {
+				t.Errorf("nonce = %v", extrinsic.Extensions[1].Value)
+			}
+			if extrinsic.PalletName == "Balances" {
+				if dest := extrinsic.Args[0].Value; !reflect.DeepEqual(dest, plainBytes(bob)) {
+					t.Errorf("dest = %v", extrinsic.Args[0].Value)
+				}
+			}
+		})
+	}
+
+	// The registry itself decodes senders as MultiAddresses, whose AccountIds take a prefix.
+	if _, err := DecodeExtrinsic(&metadata, registry.ForSpec("Polkadot", "polkadot", 30), append([]byte{0x04, 0x84}, alice...)); err == nil {
+		t.Error("expected an error for a truncated extrinsic")
+	}
+}
+
+func TestDecodeEvents(t *testing.T) {
+	registry := testRegistry(t)
+	metadata := testMetadata()
+
+	var eventsBytes []byte
+	eventsBytes = append(eventsBytes, 0x08)                                     // 2 events
+	eventsBytes = append(eventsBytes, 0x00, 0x01, 0x00, 0x00, 0x00)             // ApplyExtrinsic(1)
+	eventsBytes = append(eventsBytes, 0x01, 0x00)                               // Balances.Transfer
+	eventsBytes = append(eventsBytes, bytes.Repeat([]byte{0xaa}, 32)...)        // from
+	eventsBytes = append(eventsBytes, bytes.Repeat([]byte{0xbb}, 32)...)        // to
+	eventsBytes = append(eventsBytes, 0x40, 0x42, 0x0f, 0x00)                   // 1_000_000
+	eventsBytes = append(eventsBytes, bytes.Repeat([]byte{0x00}, 12)...)        //
+	eventsBytes = append(eventsBytes, 0x00, 0x01, 0x00, 0x00, 0x00)             // ApplyExtrinsic(1)
+	eventsBytes = append(eventsBytes, 0x00, 0x00)                               // System.ExtrinsicSuccess
+	eventsBytes = append(eventsBytes, 0x10, 0x27, 0, 0, 0, 0, 0, 0, 0x01, 0x00) // weight 10_000, Operational, Yes
+
+	events, err := DecodeEvents(&metadata, registry, eventsBytes)
+	if err != nil {
+		t.Fatalf("decode events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("events = %+v", events)
+	}
+
+	transfer := events[0]
+	if transfer.PalletName != "Balances" || transfer.EventName != "Transfer" || transfer.Phase.ExtrinsicIndex != 1 {
+		t.Errorf("event 0 = %s.%s in %+v", transfer.PalletName, transfer.EventName, transfer.Phase)
+	}
+	if amount, ok := transfer.Args[2].Value.(*big.Int); !ok || amount.Int64() != 1_000_000 {
+		t.Errorf("amount = %v", transfer.Args[2].Value)
+	}
+
+	info := []any{uint64(10_000), map[string]any{"Operational": map[string]any{}}, map[string]any{"Yes": map[string]any{}}}
+	if !reflect.DeepEqual(events[1].Args[0].Value, info) {
+		t.Errorf("dispatch info = %v", events[1].Args[0].Value)
+	}
+
+	// A byte after the last event.
+	if _, err := DecodeEvents(&metadata, registry, append(slices.Clip(eventsBytes), 0x00)); err == nil || !strings.Contains(err.Error(), "1 trailing bytes after 2 events") {
+		t.Errorf("expected an error for a trailing byte, got %v", err)
+	}
+
+	// Unknown event index.
+	eventsBytes[7] = 0x05
+	if _, err := DecodeEvents(&metadata, registry, eventsBytes); err == nil {
+		t.Error("expected an error for an unknown event")
+	}
+}
+
+func TestDecodeArgFromTypename(t *testing.T) {
+	tests := []struct {
+		typeName string
+		data     []byte
+		want     any
+	}{
+		{"Compact<Balance>", []byte{0x14}, big.NewInt(5)},
+		{"(u32, bool)", []byte{0x07, 0, 0, 0, 0x01}, []any{uint32(7), true}},
+		{"Vec<u8>", []byte{0x08, 'h', 'i'}, plainBytes([]byte("hi"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typeName, func(t *testing.T) {
+			r := scale.NewReader(tt.data)
+			got, err := DecodeArgFromTypename(r, tt.typeName)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) || r.Remaining() != 0 {
+				t.Errorf("got %#v with %d bytes left, want %#v", got, r.Remaining(), tt.want)
+			}
+		})
+	}
+
+	if _, err := DecodeArgFromTypename(scale.NewReader([]byte{0, 0}), "Call"); err == nil {
+		t.Error("expected an error for a call without metadata")
+	}
+}
+
+func FuzzDecodeExtrinsic(f *testing.F) {
+	registry := testRegistry(f)
+	metadata := testMetadata()
+	f.Add([]byte{0x10, 0x04, 0x00, 0x00, 0x00})             // unsigned System.remark("")
+	f.Add([]byte{0x14, 0x04, 0x02, 0x00, 0xfe, 0xff, 0xff}) // Utility.batch, huge length
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		DecodeExtrinsic(&metadata, registry, data)
+	})
+}
+
+func FuzzDecodeEvents(f *testing.F) {
+	registry := testRegistry(f)
+	metadata := testMetadata()
+	f.Add([]byte{0x04, 0x02, 0x00, 0x00})
+	f.Add([]byte{0xfe, 0xff, 0xff, 0xff})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		DecodeEvents(&metadata, registry, data)
+	})
+}
@@ -6,16 +6,24 @@ import (
 	v10 "submarine/metadata/generated/v10"
 	v11 "submarine/metadata/generated/v11"
 	v12 "submarine/metadata/generated/v12"
+	v13 "submarine/metadata/generated/v13"
 	v9 "submarine/metadata/generated/v9"
 )
 
 type Metadata struct {
-	Version  int
-	Modules  []ModuleMetadata
-	IndexV9  *MetadataIndexV9
-	IndexV12 *MetadataIndexV12
+	Version int
+	Modules []ModuleMetadata
+	// SignedExtensions are the identifiers of the signed extensions, in the order their values
+	// appear in signed extrinsics. Metadata before v11 doesn't list them; the fixed ones of those
+	// runtimes are used instead.
+	SignedExtensions []string
+	IndexV9          *MetadataIndexV9
+	IndexV12         *MetadataIndexV12
 }
 
+// legacySignedExtensions are the signed extensions of runtimes with metadata before v11.
+var legacySignedExtensions = []string{"CheckVersion", "CheckGenesis", "CheckEra", "CheckNonce", "CheckWeight", "ChargeTransactionPayment"}
+
 func (m *Metadata) GetModuleForExtrinsic(index int) (ModuleMetadata, error) {
 	var actualIndex int
 	var ok bool
@@ -71,10 +79,11 @@ type MetadataIndexV12 struct {
 }
 
 type ModuleMetadata struct {
-	Name   string
-	Index  int // only available in v12+
-	Calls  []Call
-	Events []EventMetadata
+	Name      string
+	Index     int // only available in v12+
+	Calls     []Call
+	Events    []EventMetadata
+	Constants []ConstantMetadata
 }
 
 type Call struct {
@@ -94,46 +103,67 @@ type EventMetadata struct {
 	Docs []string
 }
 
+type ConstantMetadata struct {
+	Name  string
+	Type  string
+	Value []byte
+	Docs  []string
+}
+
 func MakeMetadataFromAny(m any) (Metadata, error) {
 	var modules []ModuleMetadata
 	var version int
+	signedExtensions := legacySignedExtensions
 
 	switch v := m.(type) {
 	case *v9.Metadata:
 		version = 9
 		modules = make([]ModuleMetadata, len(v.Modules))
 		for i, module := range v.Modules {
-			modules[i] = MakeModuleFromV9Parts(module.Name, module.Calls, module.Events)
+			modules[i] = MakeModuleFromV9Parts(module.Name, module.Calls, module.Events, module.Constants)
 		}
 	case *v10.Metadata:
 		version = 10
 		modules = make([]ModuleMetadata, len(v.Modules))
 		for i, module := range v.Modules {
-			modules[i] = MakeModuleFromV9Parts(module.Name, module.Calls, module.Events)
+			modules[i] = MakeModuleFromV9Parts(module.Name, module.Calls, module.Events, module.Constants)
 		}
 	case *v11.Metadata:
 		version = 11
+		signedExtensions = v.Extrinsic.SignedExtensions
 		modules = make([]ModuleMetadata, len(v.Modules))
 		for i, module := range v.Modules {
-			modules[i] = MakeModuleFromV9Parts(module.Name, module.Calls, module.Events)
+			modules[i] = MakeModuleFromV9Parts(module.Name, module.Calls, module.Events, module.Constants)
 		}
 	case *v12.Metadata:
 		version = 12
+		signedExtensions = v.Extrinsic.SignedExtensions
 		modules = make([]ModuleMetadata, len(v.Modules))
 		for i, module := range v.Modules {
-			modules[i] = MakeModuleFromV9Parts(module.Name, module.Calls, module.Events)
+			modules[i] = MakeModuleFromV9Parts(module.Name, module.Calls, module.Events, module.Constants)
+			modules[i].Index = int(module.Index)
+		}
+	case *v13.Metadata:
+		version = 13
+		signedExtensions = v.Extrinsic.SignedExtensions
+		modules = make([]ModuleMetadata, len(v.Modules))
+		for i, module := range v.Modules {
+			modules[i] = MakeModuleFromV9Parts(module.Name, module.Calls, module.Events, module.Constants)
 			modules[i].Index = int(module.Index)
 		}
 	default:
-		return Metadata{}, fmt.Errorf("not a valid v9-v12 metadata struct: %v", reflect.TypeOf(m))
+		return Metadata{}, fmt.Errorf("not a valid v9-v13 metadata struct: %v", reflect.TypeOf(m))
 	}
-	return MakeMetadataFromModules(modules, version), nil
+	metadata := MakeMetadataFromModules(modules, version)
+	metadata.SignedExtensions = signedExtensions
+	return metadata, nil
 }
 
 func MakeMetadataFromModules(modules []ModuleMetadata, version int) Metadata {
 	var metadata Metadata
 	metadata.Modules = modules
 	metadata.Version = version
+	metadata.SignedExtensions = legacySignedExtensions
 
 	if metadata.Version >= 14 {
 		panic("not a legacy version")
@@ -141,14 +171,12 @@ func MakeMetadataFromModules(modules []ModuleMetadata, version int) Metadata {
 		index := MetadataIndexV12{make(map[int]int)}
 		metadata.IndexV12 = &index
 		for i, module := range metadata.Modules {
-			metadata.Modules = append(metadata.Modules, module)
 			index.IndexedModules[module.Index] = i
 		}
 	} else if metadata.Version >= 9 {
 		index := MetadataIndexV9{}
 		metadata.IndexV9 = &index
 		for i, module := range metadata.Modules {
-			metadata.Modules = append(metadata.Modules, module)
 			if len(module.Events) > 0 {
 				index.EventfulModules = append(index.EventfulModules, i)
 			}
@@ -163,7 +191,7 @@ func MakeMetadataFromModules(modules []ModuleMetadata, version int) Metadata {
 	return metadata
 }
 
-func MakeModuleFromV9Parts(name string, calls *[]v9.FunctionMetadata, events *[]v9.EventMetadata) ModuleMetadata {
+func MakeModuleFromV9Parts(name string, calls *[]v9.FunctionMetadata, events *[]v9.EventMetadata, constants []v9.ModuleConstantMetadata) ModuleMetadata {
 	var module ModuleMetadata
 
 	module.Name = name
@@ -182,6 +210,10 @@ func MakeModuleFromV9Parts(name string, calls *[]v9.FunctionMetadata, events *[]
 		}
 	}
 
+	for _, constant := range constants {
+		module.Constants = append(module.Constants, ConstantMetadata(constant))
+	}
+
 	return module
 }
 
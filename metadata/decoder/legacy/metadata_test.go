@@ -0,0 +1,76 @@
+package legacy_test
+
+import (
+	"reflect"
+	"submarine/decoder/v14/v14test"
+	"submarine/metadata/decoder"
+	. "submarine/metadata/decoder/legacy"
+	"submarine/scale"
+	"testing"
+)
+
+// text encodes a string as SCALE text.
+func text(s string) []byte {
+	return v14test.Concat(v14test.Compact(uint64(len(s))), []byte(s))
+}
+
+// vec encodes a vector of encoded items.
+func vec(items ...[]byte) []byte {
+	return v14test.Concat(v14test.Compact(uint64(len(items))), v14test.Concat(items...))
+}
+
+// TestMakeMetadataFromV13 decodes v13 metadata, whose storage has an NMap entry v12 doesn't know.
+func TestMakeMetadataFromV13(t *testing.T) {
+	storage := v14test.Concat(
+		[]byte{1}, text("System"),
+		vec(v14test.Concat(
+			text("Keys"), []byte{0},
+			[]byte{3}, vec(text("AccountId"), text("u32")), vec([]byte{5}, []byte{6}), text("u32"),
+			vec([]byte{0}), vec(),
+		)),
+	)
+	calls := v14test.Concat([]byte{1}, vec(v14test.Concat(
+		text("remark"), vec(v14test.Concat(text("_remark"), text("Vec<u8>"))), vec(),
+	)))
+	constants := vec(v14test.Concat(
+		text("BlockHashCount"), text("u32"), v14test.Compact(4), v14test.U32(2400), vec(text(" Blocks.")),
+	))
+	signedExtensions := []string{"CheckSpecVersion", "CheckTxVersion", "CheckGenesis", "CheckMortality", "CheckNonce", "CheckWeight", "ChargeTransactionPayment"}
+	var extensions [][]byte
+	for _, identifier := range signedExtensions {
+		extensions = append(extensions, text(identifier))
+	}
+	encoded := v14test.Concat(
+		vec(v14test.Concat(text("System"), storage, calls, []byte{0}, constants, vec(), []byte{7})),
+		[]byte{4}, vec(extensions...),
+	)
+
+	raw, err := decoder.DecodeMetadata(13, scale.NewReader(encoded))
+	if err != nil {
+		t.Fatalf("decode v13 metadata: %v", err)
+	}
+	metadata, err := MakeMetadataFromAny(raw)
+	if err != nil {
+		t.Fatalf("make metadata: %v", err)
+	}
+	if metadata.Version != 13 {
+		t.Errorf("version %d, want 13", metadata.Version)
+	}
+	if !reflect.DeepEqual(metadata.SignedExtensions, signedExtensions) {
+		t.Errorf("signed extensions %q, want %q", metadata.SignedExtensions, signedExtensions)
+	}
+
+	module, err := metadata.GetModuleForExtrinsic(7)
+	if err != nil || module.Name != "System" || module.Calls[0].Name != "remark" {
+		t.Errorf("module 7 = %+v, %v", module, err)
+	}
+
+	decoded, err := DecodeConstants(&metadata, testRegistry(t))
+	if err != nil {
+		t.Fatalf("decode constants: %v", err)
+	}
+	hashCount, err := decoded.Uint32("System", "BlockHashCount")
+	if err != nil || hashCount != 2400 {
+		t.Errorf("BlockHashCount = %d, %v", hashCount, err)
+	}
+}
@@ -104,7 +104,7 @@ func DecodeSi1Field(reader *scale.Reader) (Si1Field, error) {
 		return t, fmt.Errorf("field TypeName: %w", err)
 	}
 
-	t.Docs, err = scale.DecodeVec(reader, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
+	t.Docs, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Docs: %w", err)
 	}
@@ -140,7 +140,7 @@ func DecodeSi1Type(reader *scale.Reader) (Si1Type, error) {
 		return t, fmt.Errorf("field Path: %w", err)
 	}
 
-	t.Params, err = scale.DecodeVec(reader, func(reader *scale.Reader) (Si1TypeParameter, error) { return DecodeSi1TypeParameter(reader) })
+	t.Params, err = scale.DecodeVec(reader, 2, func(reader *scale.Reader) (Si1TypeParameter, error) { return DecodeSi1TypeParameter(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Params: %w", err)
 	}
@@ -150,7 +150,7 @@ func DecodeSi1Type(reader *scale.Reader) (Si1Type, error) {
 		return t, fmt.Errorf("field Def: %w", err)
 	}
 
-	t.Docs, err = scale.DecodeVec(reader, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
+	t.Docs, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Docs: %w", err)
 	}
@@ -332,7 +332,7 @@ func DecodeSi1TypeDefComposite(reader *scale.Reader) (Si1TypeDefComposite, error
 	var t Si1TypeDefComposite
 	var err error
 
-	t.Fields, err = scale.DecodeVec(reader, func(reader *scale.Reader) (Si1Field, error) { return DecodeSi1Field(reader) })
+	t.Fields, err = scale.DecodeVec(reader, 4, func(reader *scale.Reader) (Si1Field, error) { return DecodeSi1Field(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Fields: %w", err)
 	}
@@ -365,7 +365,7 @@ func DecodeSi1TypeDefSequence(reader *scale.Reader) (Si1TypeDefSequence, error)
 type Si1TypeDefTuple = []Si1LookupTypeId
 
 func DecodeSi1TypeDefTuple(reader *scale.Reader) (Si1TypeDefTuple, error) {
-	return scale.DecodeVec(reader, func(reader *scale.Reader) (Si1LookupTypeId, error) { return DecodeSi1LookupTypeId(reader) })
+	return scale.DecodeVec(reader, 1, func(reader *scale.Reader) (Si1LookupTypeId, error) { return DecodeSi1LookupTypeId(reader) })
 }
 
 type Si1TypeDefVariant struct {
@@ -376,7 +376,7 @@ func DecodeSi1TypeDefVariant(reader *scale.Reader) (Si1TypeDefVariant, error) {
 	var t Si1TypeDefVariant
 	var err error
 
-	t.Variants, err = scale.DecodeVec(reader, func(reader *scale.Reader) (Si1Variant, error) { return DecodeSi1Variant(reader) })
+	t.Variants, err = scale.DecodeVec(reader, 4, func(reader *scale.Reader) (Si1Variant, error) { return DecodeSi1Variant(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Variants: %w", err)
 	}
@@ -422,7 +422,7 @@ func DecodeSi1Variant(reader *scale.Reader) (Si1Variant, error) {
 		return t, fmt.Errorf("field Name: %w", err)
 	}
 
-	t.Fields, err = scale.DecodeVec(reader, func(reader *scale.Reader) (Si1Field, error) { return DecodeSi1Field(reader) })
+	t.Fields, err = scale.DecodeVec(reader, 4, func(reader *scale.Reader) (Si1Field, error) { return DecodeSi1Field(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Fields: %w", err)
 	}
@@ -432,7 +432,7 @@ func DecodeSi1Variant(reader *scale.Reader) (Si1Variant, error) {
 		return t, fmt.Errorf("field Index: %w", err)
 	}
 
-	t.Docs, err = scale.DecodeVec(reader, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
+	t.Docs, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Docs: %w", err)
 	}
@@ -14,7 +14,7 @@ func DecodeMetadata(reader *scale.Reader) (Metadata, error) {
 	var t Metadata
 	var err error
 
-	t.Modules, err = scale.DecodeVec(reader, func(reader *scale.Reader) (ModuleMetadata, error) { return DecodeModuleMetadata(reader) })
+	t.Modules, err = scale.DecodeVec(reader, 6, func(reader *scale.Reader) (ModuleMetadata, error) { return DecodeModuleMetadata(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Modules: %w", err)
 	}
@@ -46,27 +46,27 @@ func DecodeModuleMetadata(reader *scale.Reader) (ModuleMetadata, error) {
 	}
 
 	t.Calls, err = scale.DecodeOption(reader, func(reader *scale.Reader) ([]v9.FunctionMetadata, error) {
-		return scale.DecodeVec(reader, func(reader *scale.Reader) (v9.FunctionMetadata, error) { return v9.DecodeFunctionMetadata(reader) })
+		return scale.DecodeVec(reader, 3, func(reader *scale.Reader) (v9.FunctionMetadata, error) { return v9.DecodeFunctionMetadata(reader) })
 	})
 	if err != nil {
 		return t, fmt.Errorf("field Calls: %w", err)
 	}
 
 	t.Events, err = scale.DecodeOption(reader, func(reader *scale.Reader) ([]v9.EventMetadata, error) {
-		return scale.DecodeVec(reader, func(reader *scale.Reader) (v9.EventMetadata, error) { return v9.DecodeEventMetadata(reader) })
+		return scale.DecodeVec(reader, 3, func(reader *scale.Reader) (v9.EventMetadata, error) { return v9.DecodeEventMetadata(reader) })
 	})
 	if err != nil {
 		return t, fmt.Errorf("field Events: %w", err)
 	}
 
-	t.Constants, err = scale.DecodeVec(reader, func(reader *scale.Reader) (v9.ModuleConstantMetadata, error) {
+	t.Constants, err = scale.DecodeVec(reader, 4, func(reader *scale.Reader) (v9.ModuleConstantMetadata, error) {
 		return v9.DecodeModuleConstantMetadata(reader)
 	})
 	if err != nil {
 		return t, fmt.Errorf("field Constants: %w", err)
 	}
 
-	t.Errors, err = scale.DecodeVec(reader, func(reader *scale.Reader) (v9.ErrorMetadata, error) { return v9.DecodeErrorMetadata(reader) })
+	t.Errors, err = scale.DecodeVec(reader, 2, func(reader *scale.Reader) (v9.ErrorMetadata, error) { return v9.DecodeErrorMetadata(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Errors: %w", err)
 	}
@@ -180,7 +180,7 @@ func DecodeStorageEntryMetadata(reader *scale.Reader) (StorageEntryMetadata, err
 		return t, fmt.Errorf("field Fallback: %w", err)
 	}
 
-	t.Docs, err = scale.DecodeVec(reader, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
+	t.Docs, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Docs: %w", err)
 	}
@@ -293,7 +293,7 @@ func DecodeStorageMetadata(reader *scale.Reader) (StorageMetadata, error) {
 		return t, fmt.Errorf("field Prefix: %w", err)
 	}
 
-	t.Items, err = scale.DecodeVec(reader, func(reader *scale.Reader) (StorageEntryMetadata, error) { return DecodeStorageEntryMetadata(reader) })
+	t.Items, err = scale.DecodeVec(reader, 5, func(reader *scale.Reader) (StorageEntryMetadata, error) { return DecodeStorageEntryMetadata(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Items: %w", err)
 	}
@@ -16,7 +16,7 @@ func DecodeMetadata(reader *scale.Reader) (Metadata, error) {
 	var t Metadata
 	var err error
 
-	t.Modules, err = scale.DecodeVec(reader, func(reader *scale.Reader) (ModuleMetadata, error) { return DecodeModuleMetadata(reader) })
+	t.Modules, err = scale.DecodeVec(reader, 7, func(reader *scale.Reader) (ModuleMetadata, error) { return DecodeModuleMetadata(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Modules: %w", err)
 	}
@@ -54,27 +54,27 @@ func DecodeModuleMetadata(reader *scale.Reader) (ModuleMetadata, error) {
 	}
 
 	t.Calls, err = scale.DecodeOption(reader, func(reader *scale.Reader) ([]v9.FunctionMetadata, error) {
-		return scale.DecodeVec(reader, func(reader *scale.Reader) (v9.FunctionMetadata, error) { return v9.DecodeFunctionMetadata(reader) })
+		return scale.DecodeVec(reader, 3, func(reader *scale.Reader) (v9.FunctionMetadata, error) { return v9.DecodeFunctionMetadata(reader) })
 	})
 	if err != nil {
 		return t, fmt.Errorf("field Calls: %w", err)
 	}
 
 	t.Events, err = scale.DecodeOption(reader, func(reader *scale.Reader) ([]v9.EventMetadata, error) {
-		return scale.DecodeVec(reader, func(reader *scale.Reader) (v9.EventMetadata, error) { return v9.DecodeEventMetadata(reader) })
+		return scale.DecodeVec(reader, 3, func(reader *scale.Reader) (v9.EventMetadata, error) { return v9.DecodeEventMetadata(reader) })
 	})
 	if err != nil {
 		return t, fmt.Errorf("field Events: %w", err)
 	}
 
-	t.Constants, err = scale.DecodeVec(reader, func(reader *scale.Reader) (v9.ModuleConstantMetadata, error) {
+	t.Constants, err = scale.DecodeVec(reader, 4, func(reader *scale.Reader) (v9.ModuleConstantMetadata, error) {
 		return v9.DecodeModuleConstantMetadata(reader)
 	})
 	if err != nil {
 		return t, fmt.Errorf("field Constants: %w", err)
 	}
 
-	t.Errors, err = scale.DecodeVec(reader, func(reader *scale.Reader) (v9.ErrorMetadata, error) { return v9.DecodeErrorMetadata(reader) })
+	t.Errors, err = scale.DecodeVec(reader, 2, func(reader *scale.Reader) (v9.ErrorMetadata, error) { return v9.DecodeErrorMetadata(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Errors: %w", err)
 	}
@@ -7,6 +7,86 @@ import (
 	"submarine/scale"
 )
 
+type Metadata struct {
+	Modules   []ModuleMetadata
+	Extrinsic v11.ExtrinsicMetadata
+}
+
+func DecodeMetadata(reader *scale.Reader) (Metadata, error) {
+	var t Metadata
+	var err error
+
+	t.Modules, err = scale.DecodeVec(reader, 7, func(reader *scale.Reader) (ModuleMetadata, error) { return DecodeModuleMetadata(reader) })
+	if err != nil {
+		return t, fmt.Errorf("field Modules: %w", err)
+	}
+
+	t.Extrinsic, err = v11.DecodeExtrinsicMetadata(reader)
+	if err != nil {
+		return t, fmt.Errorf("field Extrinsic: %w", err)
+	}
+
+	return t, nil
+}
+
+type ModuleMetadata struct {
+	Name      string
+	Storage   *StorageMetadata
+	Calls     *[]v9.FunctionMetadata
+	Events    *[]v9.EventMetadata
+	Constants []v9.ModuleConstantMetadata
+	Errors    []v9.ErrorMetadata
+	Index     uint8
+}
+
+func DecodeModuleMetadata(reader *scale.Reader) (ModuleMetadata, error) {
+	var t ModuleMetadata
+	var err error
+
+	t.Name, err = scale.DecodeText(reader)
+	if err != nil {
+		return t, fmt.Errorf("field Name: %w", err)
+	}
+
+	t.Storage, err = scale.DecodeOption(reader, func(reader *scale.Reader) (StorageMetadata, error) { return DecodeStorageMetadata(reader) })
+	if err != nil {
+		return t, fmt.Errorf("field Storage: %w", err)
+	}
+
+	t.Calls, err = scale.DecodeOption(reader, func(reader *scale.Reader) ([]v9.FunctionMetadata, error) {
+		return scale.DecodeVec(reader, 3, func(reader *scale.Reader) (v9.FunctionMetadata, error) { return v9.DecodeFunctionMetadata(reader) })
+	})
+	if err != nil {
+		return t, fmt.Errorf("field Calls: %w", err)
+	}
+
+	t.Events, err = scale.DecodeOption(reader, func(reader *scale.Reader) ([]v9.EventMetadata, error) {
+		return scale.DecodeVec(reader, 3, func(reader *scale.Reader) (v9.EventMetadata, error) { return v9.DecodeEventMetadata(reader) })
+	})
+	if err != nil {
+		return t, fmt.Errorf("field Events: %w", err)
+	}
+
+	t.Constants, err = scale.DecodeVec(reader, 4, func(reader *scale.Reader) (v9.ModuleConstantMetadata, error) {
+		return v9.DecodeModuleConstantMetadata(reader)
+	})
+	if err != nil {
+		return t, fmt.Errorf("field Constants: %w", err)
+	}
+
+	t.Errors, err = scale.DecodeVec(reader, 2, func(reader *scale.Reader) (v9.ErrorMetadata, error) { return v9.DecodeErrorMetadata(reader) })
+	if err != nil {
+		return t, fmt.Errorf("field Errors: %w", err)
+	}
+
+	t.Index, err = scale.DecodeU8(reader)
+	if err != nil {
+		return t, fmt.Errorf("field Index: %w", err)
+	}
+
+	return t, nil
+}
+
 type StorageEntryDoubleMap struct {
 	Hasher     v11.StorageHasher
 	Key1       string
@@ -113,7 +193,7 @@ func DecodeStorageEntryMetadata(reader *scale.Reader) (StorageEntryMetadata, err
 		return t, fmt.Errorf("field Fallback: %w", err)
 	}
 
-	t.Docs, err = scale.DecodeVec(reader, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
+	t.Docs, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Docs: %w", err)
 	}
@@ -131,12 +211,12 @@ func DecodeStorageEntryNMap(reader *scale.Reader) (StorageEntryNMap, error) {
 	var t StorageEntryNMap
 	var err error
 
-	t.KeyVec, err = scale.DecodeVec(reader, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
+	t.KeyVec, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field KeyVec: %w", err)
 	}
 
-	t.Hashers, err = scale.DecodeVec(reader, func(reader *scale.Reader) (v11.StorageHasher, error) { return v11.DecodeStorageHasher(reader) })
+	t.Hashers, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (v11.StorageHasher, error) { return v11.DecodeStorageHasher(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Hashers: %w", err)
 	}
@@ -224,7 +304,7 @@ func DecodeStorageMetadata(reader *scale.Reader) (StorageMetadata, error) {
 		return t, fmt.Errorf("field Prefix: %w", err)
 	}
 
-	t.Items, err = scale.DecodeVec(reader, func(reader *scale.Reader) (StorageEntryMetadata, error) { return DecodeStorageEntryMetadata(reader) })
+	t.Items, err = scale.DecodeVec(reader, 5, func(reader *scale.Reader) (StorageEntryMetadata, error) { return DecodeStorageEntryMetadata(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Items: %w", err)
 	}
@@ -25,7 +25,7 @@ func DecodeErrorMetadata(reader *scale.Reader) (ErrorMetadata, error) {
 		return t, fmt.Errorf("field Name: %w", err)
 	}
 
-	t.Fields, err = scale.DecodeVec(reader, func(reader *scale.Reader) (scaleInfo.Si1Field, error) { return scaleInfo.DecodeSi1Field(reader) })
+	t.Fields, err = scale.DecodeVec(reader, 4, func(reader *scale.Reader) (scaleInfo.Si1Field, error) { return scaleInfo.DecodeSi1Field(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Fields: %w", err)
 	}
@@ -35,12 +35,12 @@ func DecodeErrorMetadata(reader *scale.Reader) (ErrorMetadata, error) {
 		return t, fmt.Errorf("field Index: %w", err)
 	}
 
-	t.Docs, err = scale.DecodeVec(reader, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
+	t.Docs, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Docs: %w", err)
 	}
 
-	t.Args, err = scale.DecodeVec(reader, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
+	t.Args, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Args: %w", err)
 	}
@@ -65,7 +65,7 @@ func DecodeEventMetadata(reader *scale.Reader) (EventMetadata, error) {
 		return t, fmt.Errorf("field Name: %w", err)
 	}
 
-	t.Fields, err = scale.DecodeVec(reader, func(reader *scale.Reader) (scaleInfo.Si1Field, error) { return scaleInfo.DecodeSi1Field(reader) })
+	t.Fields, err = scale.DecodeVec(reader, 4, func(reader *scale.Reader) (scaleInfo.Si1Field, error) { return scaleInfo.DecodeSi1Field(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Fields: %w", err)
 	}
@@ -75,12 +75,12 @@ func DecodeEventMetadata(reader *scale.Reader) (EventMetadata, error) {
 		return t, fmt.Errorf("field Index: %w", err)
 	}
 
-	t.Docs, err = scale.DecodeVec(reader, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
+	t.Docs, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Docs: %w", err)
 	}
 
-	t.Args, err = scale.DecodeVec(reader, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
+	t.Args, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Args: %w", err)
 	}
@@ -108,7 +108,7 @@ func DecodeExtrinsicMetadata(reader *scale.Reader) (ExtrinsicMetadata, error) {
 		return t, fmt.Errorf("field Version: %w", err)
 	}
 
-	t.SignedExtensions, err = scale.DecodeVec(reader, func(reader *scale.Reader) (SignedExtensionMetadata, error) {
+	t.SignedExtensions, err = scale.DecodeVec(reader, 3, func(reader *scale.Reader) (SignedExtensionMetadata, error) {
 		return DecodeSignedExtensionMetadata(reader)
 	})
 	if err != nil {
@@ -163,7 +163,7 @@ func DecodeFunctionMetadata(reader *scale.Reader) (FunctionMetadata, error) {
 		return t, fmt.Errorf("field Name: %w", err)
 	}
 
-	t.Fields, err = scale.DecodeVec(reader, func(reader *scale.Reader) (scaleInfo.Si1Field, error) { return scaleInfo.DecodeSi1Field(reader) })
+	t.Fields, err = scale.DecodeVec(reader, 4, func(reader *scale.Reader) (scaleInfo.Si1Field, error) { return scaleInfo.DecodeSi1Field(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Fields: %w", err)
 	}
@@ -173,12 +173,12 @@ func DecodeFunctionMetadata(reader *scale.Reader) (FunctionMetadata, error) {
 		return t, fmt.Errorf("field Index: %w", err)
 	}
 
-	t.Docs, err = scale.DecodeVec(reader, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
+	t.Docs, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Docs: %w", err)
 	}
 
-	t.Args, err = scale.DecodeVec(reader, func(reader *scale.Reader) (FunctionArgumentMetadata, error) {
+	t.Args, err = scale.DecodeVec(reader, 3, func(reader *scale.Reader) (FunctionArgumentMetadata, error) {
 		return DecodeFunctionArgumentMetadata(reader)
 	})
 	if err != nil {
@@ -204,7 +204,7 @@ func DecodeMetadata(reader *scale.Reader) (Metadata, error) {
 		return t, fmt.Errorf("field Lookup: %w", err)
 	}
 
-	t.Pallets, err = scale.DecodeVec(reader, func(reader *scale.Reader) (PalletMetadata, error) { return DecodePalletMetadata(reader) })
+	t.Pallets, err = scale.DecodeVec(reader, 7, func(reader *scale.Reader) (PalletMetadata, error) { return DecodePalletMetadata(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Pallets: %w", err)
 	}
@@ -264,7 +264,7 @@ func DecodePalletConstantMetadata(reader *scale.Reader) (PalletConstantMetadata,
 		return t, fmt.Errorf("field Value: %w", err)
 	}
 
-	t.Docs, err = scale.DecodeVec(reader, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
+	t.Docs, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Docs: %w", err)
 	}
@@ -338,7 +338,7 @@ func DecodePalletMetadata(reader *scale.Reader) (PalletMetadata, error) {
 		return t, fmt.Errorf("field Events: %w", err)
 	}
 
-	t.Constants, err = scale.DecodeVec(reader, func(reader *scale.Reader) (PalletConstantMetadata, error) {
+	t.Constants, err = scale.DecodeVec(reader, 4, func(reader *scale.Reader) (PalletConstantMetadata, error) {
 		return DecodePalletConstantMetadata(reader)
 	})
 	if err != nil {
@@ -372,7 +372,7 @@ func DecodePalletStorageMetadata(reader *scale.Reader) (PalletStorageMetadata, e
 		return t, fmt.Errorf("field Prefix: %w", err)
 	}
 
-	t.Items, err = scale.DecodeVec(reader, func(reader *scale.Reader) (StorageEntryMetadata, error) { return DecodeStorageEntryMetadata(reader) })
+	t.Items, err = scale.DecodeVec(reader, 5, func(reader *scale.Reader) (StorageEntryMetadata, error) { return DecodeStorageEntryMetadata(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Items: %w", err)
 	}
@@ -388,7 +388,7 @@ func DecodePortableRegistry(reader *scale.Reader) (PortableRegistry, error) {
 	var t PortableRegistry
 	var err error
 
-	t.Types, err = scale.DecodeVec(reader, func(reader *scale.Reader) (PortableType, error) { return DecodePortableType(reader) })
+	t.Types, err = scale.DecodeVec(reader, 5, func(reader *scale.Reader) (PortableType, error) { return DecodePortableType(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Types: %w", err)
 	}
@@ -456,7 +456,7 @@ func DecodeStorageEntryMap(reader *scale.Reader) (StorageEntryMap, error) {
 	var t StorageEntryMap
 	var err error
 
-	t.Hashers, err = scale.DecodeVec(reader, func(reader *scale.Reader) (v11.StorageHasher, error) { return v11.DecodeStorageHasher(reader) })
+	t.Hashers, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (v11.StorageHasher, error) { return v11.DecodeStorageHasher(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Hashers: %w", err)
 	}
@@ -506,7 +506,7 @@ func DecodeStorageEntryMetadata(reader *scale.Reader) (StorageEntryMetadata, err
 		return t, fmt.Errorf("field Fallback: %w", err)
 	}
 
-	t.Docs, err = scale.DecodeVec(reader, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
+	t.Docs, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Docs: %w", err)
 	}
@@ -19,7 +19,7 @@ func DecodeErrorMetadata(reader *scale.Reader) (ErrorMetadata, error) {
 		return t, fmt.Errorf("field Name: %w", err)
 	}
 
-	t.Docs, err = scale.DecodeVec(reader, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
+	t.Docs, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Docs: %w", err)
 	}
@@ -42,12 +42,12 @@ func DecodeEventMetadata(reader *scale.Reader) (EventMetadata, error) {
 		return t, fmt.Errorf("field Name: %w", err)
 	}
 
-	t.Args, err = scale.DecodeVec(reader, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
+	t.Args, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Args: %w", err)
 	}
 
-	t.Docs, err = scale.DecodeVec(reader, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
+	t.Docs, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Docs: %w", err)
 	}
@@ -92,14 +92,14 @@ func DecodeFunctionMetadata(reader *scale.Reader) (FunctionMetadata, error) {
 		return t, fmt.Errorf("field Name: %w", err)
 	}
 
-	t.Args, err = scale.DecodeVec(reader, func(reader *scale.Reader) (FunctionArgumentMetadata, error) {
+	t.Args, err = scale.DecodeVec(reader, 2, func(reader *scale.Reader) (FunctionArgumentMetadata, error) {
 		return DecodeFunctionArgumentMetadata(reader)
 	})
 	if err != nil {
 		return t, fmt.Errorf("field Args: %w", err)
 	}
 
-	t.Docs, err = scale.DecodeVec(reader, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
+	t.Docs, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Docs: %w", err)
 	}
@@ -115,7 +115,7 @@ func DecodeMetadata(reader *scale.Reader) (Metadata, error) {
 	var t Metadata
 	var err error
 
-	t.Modules, err = scale.DecodeVec(reader, func(reader *scale.Reader) (ModuleMetadata, error) { return DecodeModuleMetadata(reader) })
+	t.Modules, err = scale.DecodeVec(reader, 6, func(reader *scale.Reader) (ModuleMetadata, error) { return DecodeModuleMetadata(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Modules: %w", err)
 	}
@@ -149,7 +149,7 @@ func DecodeModuleConstantMetadata(reader *scale.Reader) (ModuleConstantMetadata,
 		return t, fmt.Errorf("field Value: %w", err)
 	}
 
-	t.Docs, err = scale.DecodeVec(reader, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
+	t.Docs, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Docs: %w", err)
 	}
@@ -181,27 +181,27 @@ func DecodeModuleMetadata(reader *scale.Reader) (ModuleMetadata, error) {
 	}
 
 	t.Calls, err = scale.DecodeOption(reader, func(reader *scale.Reader) ([]FunctionMetadata, error) {
-		return scale.DecodeVec(reader, func(reader *scale.Reader) (FunctionMetadata, error) { return DecodeFunctionMetadata(reader) })
+		return scale.DecodeVec(reader, 3, func(reader *scale.Reader) (FunctionMetadata, error) { return DecodeFunctionMetadata(reader) })
 	})
 	if err != nil {
 		return t, fmt.Errorf("field Calls: %w", err)
 	}
 
 	t.Events, err = scale.DecodeOption(reader, func(reader *scale.Reader) ([]EventMetadata, error) {
-		return scale.DecodeVec(reader, func(reader *scale.Reader) (EventMetadata, error) { return DecodeEventMetadata(reader) })
+		return scale.DecodeVec(reader, 3, func(reader *scale.Reader) (EventMetadata, error) { return DecodeEventMetadata(reader) })
 	})
 	if err != nil {
 		return t, fmt.Errorf("field Events: %w", err)
 	}
 
-	t.Constants, err = scale.DecodeVec(reader, func(reader *scale.Reader) (ModuleConstantMetadata, error) {
+	t.Constants, err = scale.DecodeVec(reader, 4, func(reader *scale.Reader) (ModuleConstantMetadata, error) {
 		return DecodeModuleConstantMetadata(reader)
 	})
 	if err != nil {
 		return t, fmt.Errorf("field Constants: %w", err)
 	}
 
-	t.Errors, err = scale.DecodeVec(reader, func(reader *scale.Reader) (ErrorMetadata, error) { return DecodeErrorMetadata(reader) })
+	t.Errors, err = scale.DecodeVec(reader, 2, func(reader *scale.Reader) (ErrorMetadata, error) { return DecodeErrorMetadata(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Errors: %w", err)
 	}
@@ -315,7 +315,7 @@ func DecodeStorageEntryMetadata(reader *scale.Reader) (StorageEntryMetadata, err
 		return t, fmt.Errorf("field Fallback: %w", err)
 	}
 
-	t.Docs, err = scale.DecodeVec(reader, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
+	t.Docs, err = scale.DecodeVec(reader, 1, func(reader *scale.Reader) (string, error) { return scale.DecodeText(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Docs: %w", err)
 	}
@@ -455,7 +455,7 @@ func DecodeStorageMetadata(reader *scale.Reader) (StorageMetadata, error) {
 		return t, fmt.Errorf("field Prefix: %w", err)
 	}
 
-	t.Items, err = scale.DecodeVec(reader, func(reader *scale.Reader) (StorageEntryMetadata, error) { return DecodeStorageEntryMetadata(reader) })
+	t.Items, err = scale.DecodeVec(reader, 5, func(reader *scale.Reader) (StorageEntryMetadata, error) { return DecodeStorageEntryMetadata(reader) })
 	if err != nil {
 		return t, fmt.Errorf("field Items: %w", err)
 	}
@@ -78,3 +78,17 @@ func ParseModule(rawModuleDef map[string]any) (Module, *ErrorSpan) {
 
 	return module, nil
 }
+
+// ResolveType implements scale.TypeResolver: refs name types of the same module, imports name
+// the module explicitly.
+func (allModules *AllModules) ResolveType(moduleName, name string) (*Type, string, error) {
+	module, ok := allModules.Modules[moduleName]
+	if !ok {
+		return nil, "", fmt.Errorf("module %s not found", moduleName)
+	}
+	typ, ok := module.Types[name]
+	if !ok {
+		return nil, "", fmt.Errorf("type %s not found in module %s", name, moduleName)
+	}
+	return typ, moduleName, nil
+}
@@ -0,0 +1,52 @@
+package schema_parser_test
+
+import (
+	. "submarine/metadata/schema_parser"
+	. "submarine/scale"
+	"testing"
+)
+
+func TestAllModulesResolveType(t *testing.T) {
+	parse := func(raw M) Module {
+		module, err := ParseModule(raw)
+		if err != nil {
+			t.Fatalf("parse module: %v", err)
+		}
+		return module
+	}
+	allModules := &AllModules{
+		ModuleNames: []string{"base", "events"},
+		Modules: map[string]Module{
+			"base": parse(M{
+				"AccountId": M{"type": "array", "item": "u8", "len": 2},
+			}),
+			"events": parse(M{
+				"AccountId": M{"type": "import", "module": "base", "item": "AccountId"},
+				"Transfer": M{"type": "struct", "fields": A{
+					M{"name": "from", "type": "AccountId"},
+					M{"name": "amount", "type": "u32"},
+				}},
+			}),
+		},
+	}
+
+	r := NewReader([]byte{0xaa, 0xbb, 0x0a, 0, 0, 0})
+	value, err := DecodeWithSchemaInModule(r, &Type{Kind: KindImport, Import: &Import{Module: "events", Item: "Transfer"}}, allModules, "")
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	from, _ := value.Field("from")
+	if from.Kind != ValueKindBytes || len(from.Bytes) != 2 || from.Bytes[0] != 0xaa {
+		t.Errorf("from = %v", from)
+	}
+	if amount, _ := value.Field("amount"); amount.Int == nil || amount.Int.Int64() != 10 {
+		t.Errorf("amount = %v", amount)
+	}
+
+	if _, _, err := allModules.ResolveType("events", "Missing"); err == nil {
+		t.Error("expected an error for a missing type")
+	}
+	if _, _, err := allModules.ResolveType("missing", "AccountId"); err == nil {
+		t.Error("expected an error for a missing module")
+	}
+}
@@ -0,0 +1,240 @@
+package polkadot_scale_schema
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// LoadTypesBundleFile loads the types bundle in a JSON file, see LoadTypesBundle.
+func LoadTypesBundleFile(r *Registry, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := LoadTypesBundle(r, file); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadTypesBundle loads the type definitions of a chain in the format of the type options of the
+// polkadot.js API:
+//
+//	{
+//	  "types": {"Balance": "u64"},
+//	  "typesAlias": {"assets": {"Balance": "TAssetBalance"}},
+//	  "typesSpec": {"karura": {"Balance": "u128"}},
+//	  "typesChain": {"Karura": {"Balance": "u128"}},
+//	  "typesBundle": {
+//	    "spec": {"karura": {"types": [{"minmax": [0, 1000], "types": {"Balance": "u64"}}], "alias": {...}}},
+//	    "chain": {"Karura": {...}}
+//	  }
+//	}
+//
+// A file with just the "spec" and "chain" of a typesBundle is accepted too. Aliases rename types in
+// a module, named like the modules of schema.json, e.g. "assets" for the Assets pallet. Types apply
+// to every module.
+//
+// The definitions are layered on top of those loaded before, e.g. by LoadPolkadotSchema. Those of
+// "types" and "typesAlias" override every other, in the registry and in all its ForSpec views, as
+// the types given to the polkadot.js API do; those scoped to a spec name, chain or spec versions
+// override the others in the ForSpec views of matching runtimes.
+func LoadTypesBundle(r *Registry, reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	value, err := ParseJSON(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	bundle, ok := value.(*Object)
+	if !ok {
+		return fmt.Errorf("types bundle must be an object, got %T", value)
+	}
+
+	for _, key := range bundle.Keys {
+		value := bundle.Values[key]
+		var err error
+		switch key {
+		case "types":
+			err = loadBundleTypes(r, Scope{Override: true}, value)
+		case "typesAlias":
+			err = loadBundleAliases(r, Scope{Override: true}, value)
+		case "typesSpec":
+			err = forEachNamed(value, func(name string, types any) error {
+				return loadBundleTypes(r, Scope{SpecName: name}, types)
+			})
+		case "typesChain":
+			err = forEachNamed(value, func(name string, types any) error {
+				return loadBundleTypes(r, Scope{Chain: name}, types)
+			})
+		case "typesBundle":
+			err = loadTypesBundle(r, value)
+		case "spec", "chain":
+			err = loadVersionedTypes(r, key, value)
+		default:
+			err = fmt.Errorf("unknown key")
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// loadBundleTypes loads type definitions that apply to every module.
+func loadBundleTypes(r *Registry, scope Scope, value any) error {
+	types, ok := asObject(value)
+	if !ok {
+		return fmt.Errorf("types must be an object, got %T", value)
+	}
+	return loadModuleTypes(r, scope, "", types, false)
+}
+
+// loadBundleAliases loads the aliases of modules, e.g. {"assets": {"Balance": "TAssetBalance"}}.
+func loadBundleAliases(r *Registry, scope Scope, value any) error {
+	return forEachNamed(value, func(moduleName string, aliases any) error {
+		if moduleName == "" {
+			return fmt.Errorf("module name must not be empty")
+		}
+		moduleAliases, ok := asObject(aliases)
+		if !ok {
+			return fmt.Errorf("aliases must be an object, got %T", aliases)
+		}
+		return loadModuleTypes(r, scope, moduleName, moduleAliases, true)
+	})
+}
+
+// loadTypesBundle loads the "spec" and "chain" of a typesBundle.
+func loadTypesBundle(r *Registry, value any) error {
+	bundle, ok := asObject(value)
+	if !ok {
+		return fmt.Errorf("must be an object, got %T", value)
+	}
+	for _, key := range bundle.Keys {
+		if key != "spec" && key != "chain" {
+			return fmt.Errorf("%s: unknown key", key)
+		}
+		if err := loadVersionedTypes(r, key, bundle.Values[key]); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// loadVersionedTypes loads the definitions of a typesBundle by spec name or chain, e.g.
+// {"karura": {"types": [{"minmax": [0, 1000], "types": {...}}], "alias": {...}}}. Other keys, like
+// rpc or signedExtensions, don't define types and are ignored.
+func loadVersionedTypes(r *Registry, kind string, value any) error {
+	return forEachNamed(value, func(name string, definition any) error {
+		scope := Scope{SpecName: name}
+		if kind == "chain" {
+			scope = Scope{Chain: name}
+		}
+
+		obj, ok := asObject(definition)
+		if !ok {
+			return fmt.Errorf("must be an object, got %T", definition)
+		}
+
+		if versions, ok := obj.Get("types"); ok {
+			list, ok := versions.([]any)
+			if !ok {
+				return fmt.Errorf("types must be a list, got %T", versions)
+			}
+			for i, version := range list {
+				if err := loadVersion(r, scope, version); err != nil {
+					return fmt.Errorf("types[%d]: %w", i, err)
+				}
+			}
+		}
+
+		if aliases, ok := obj.Get("alias"); ok {
+			if err := loadBundleAliases(r, scope, aliases); err != nil {
+				return fmt.Errorf("alias: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// loadVersion loads the definitions of a range of spec versions: {"minmax": [0, 1000], "types": {...}}
+func loadVersion(r *Registry, scope Scope, value any) error {
+	version, ok := asObject(value)
+	if !ok {
+		return fmt.Errorf("must be an object, got %T", value)
+	}
+
+	minmax, _ := version.Get("minmax")
+	specs, err := parseMinMax(minmax)
+	if err != nil {
+		return fmt.Errorf("minmax: %w", err)
+	}
+	scope.Specs = specs
+
+	types, ok := version.Get("types")
+	if !ok {
+		return fmt.Errorf("types missing")
+	}
+	return loadBundleTypes(r, scope, types)
+}
+
+// parseMinMax parses an inclusive range of spec versions, where null means unbounded.
+func parseMinMax(value any) (*SpecRange, error) {
+	if value == nil {
+		return nil, nil
+	}
+	bounds, ok := value.([]any)
+	if !ok || len(bounds) > 2 {
+		return nil, fmt.Errorf("expected [min, max], got %v", value)
+	}
+	for len(bounds) < 2 {
+		bounds = append(bounds, nil)
+	}
+	if bounds[0] == nil && bounds[1] == nil {
+		return nil, nil
+	}
+
+	specs := &SpecRange{Min: 0, Max: math.MaxUint32}
+	for i, bound := range bounds {
+		if bound == nil {
+			continue
+		}
+		n, err := parseInt(bound)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 || n > math.MaxUint32 {
+			return nil, fmt.Errorf("spec version %d out of range", n)
+		}
+		if i == 0 {
+			specs.Min = uint32(n)
+		} else {
+			specs.Max = uint32(n)
+		}
+	}
+	if specs.Min > specs.Max {
+		return nil, fmt.Errorf("min %d is greater than max %d", specs.Min, specs.Max)
+	}
+	return specs, nil
+}
+
+// forEachNamed calls f with each key and value of an object, in order.
+func forEachNamed(value any, f func(name string, value any) error) error {
+	obj, ok := asObject(value)
+	if !ok {
+		return fmt.Errorf("must be an object, got %T", value)
+	}
+	for _, name := range obj.Keys {
+		if err := f(name, obj.Values[name]); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
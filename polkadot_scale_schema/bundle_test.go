@@ -0,0 +1,159 @@
+package polkadot_scale_schema_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"submarine/polkadot_scale_schema"
+	s "submarine/scale"
+	"testing"
+)
+
+const testBundle = `{
+	"types": {
+		"Balance": "u64",
+		"Pair<A, B>": "(A, B)"
+	},
+	"typesAlias": {
+		"assets": {"Balance": "u8"}
+	},
+	"typesSpec": {
+		"karura": {"Index": "u64", "Balance": "u128"}
+	},
+	"typesChain": {
+		"Karura Testnet": {"Index": "u16"}
+	},
+	"typesBundle": {
+		"spec": {
+			"karura": {
+				"types": [
+					{"minmax": [0, 1000], "types": {"Weight": "u32", "Balance": "u16"}},
+					{"minmax": [1001, null], "types": {"Weight": "u64"}}
+				],
+				"alias": {"tokens": {"CurrencyId": "u8"}},
+				"rpc": {"oracle": {}}
+			}
+		}
+	}
+}`
+
+func TestLoadTypesBundle(t *testing.T) {
+	registry := polkadot_scale_schema.NewRegistry()
+	err := polkadot_scale_schema.LoadFromSchema(registry, map[string]map[string]any{
+		"runtime": {
+			"Balance":    "u128",
+			"Index":      "u32",
+			"Weight":     "u64",
+			"CurrencyId": "u32",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+	if err := polkadot_scale_schema.LoadTypesBundle(registry, strings.NewReader(testBundle)); err != nil {
+		t.Fatalf("Failed to load bundle: %v", err)
+	}
+
+	karura := func(version uint32) *polkadot_scale_schema.Registry {
+		return registry.ForSpec("Karura", "karura", version)
+	}
+
+	tests := []struct {
+		name     string
+		registry *polkadot_scale_schema.Registry
+		module   string
+		typeName string
+		expected string
+	}{
+		{"types override the built-in definitions", registry, "runtime", "Balance", "u64"},
+		{"types apply to every module", registry, "system", "Balance", "u64"},
+		{"alias", registry, "assets", "Balance", "u8"},
+		{"types win over spec name", karura(9000), "runtime", "Balance", "u64"},
+		{"types win over spec range", karura(1000), "runtime", "Balance", "u64"},
+		{"alias wins over spec name", karura(9000), "assets", "Balance", "u8"},
+		{"spec name", karura(9000), "runtime", "Index", "u64"},
+		{"chain wins over spec name", registry.ForSpec("Karura Testnet", "karura", 9000), "runtime", "Index", "u16"},
+		{"other spec name", registry.ForSpec("Acala", "acala", 9000), "runtime", "Index", "u32"},
+		{"spec range", karura(1000), "runtime", "Weight", "u32"},
+		{"unbounded spec range", karura(1001), "runtime", "Weight", "u64"},
+		{"bundle alias", karura(1), "tokens", "CurrencyId", "u8"},
+		{"bundle alias of other modules", karura(1), "runtime", "CurrencyId", "u32"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lazy, err := tt.registry.Lookup(tt.module, tt.typeName)
+			if err != nil {
+				t.Fatalf("Lookup failed: %v", err)
+			}
+			scaleType, err := lazy.ToScaleType()
+			if err != nil {
+				t.Fatalf("Failed to convert to scale type: %v", err)
+			}
+			if scaleType.Kind != s.KindRef || *scaleType.Ref != tt.expected {
+				t.Errorf("Expected %s, got %+v", tt.expected, scaleType)
+			}
+		})
+	}
+
+	value, err := karura(1).Decode(s.NewReader([]byte{0x01, 0x02, 0, 0, 0}), "runtime", "Pair<u8, Weight>")
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if got := formatValue(value); got != "[1 2]" {
+		t.Errorf("Expected [1 2], got %s", got)
+	}
+}
+
+func TestLoadTypesBundleFile(t *testing.T) {
+	// A bare typesBundle, with just spec and chain.
+	path := filepath.Join(t.TempDir(), "bundle.json")
+	bundle := `{"chain": {"Karura": {"types": [{"minmax": [null, 10], "types": {"Index": "u8"}}]}}}`
+	if err := os.WriteFile(path, []byte(bundle), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := polkadot_scale_schema.NewRegistry()
+	if err := polkadot_scale_schema.LoadTypesBundleFile(registry, path); err != nil {
+		t.Fatalf("Failed to load bundle: %v", err)
+	}
+	if _, err := registry.ForSpec("Karura", "karura", 10).Lookup("runtime", "Index"); err != nil {
+		t.Errorf("Lookup in range failed: %v", err)
+	}
+	if _, err := registry.ForSpec("Karura", "karura", 11).Lookup("runtime", "Index"); err == nil {
+		t.Error("Expected Index to be undefined after the spec range")
+	}
+
+	if err := polkadot_scale_schema.LoadTypesBundleFile(registry, filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestLoadTypesBundleErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		bundle string
+	}{
+		{"invalid JSON", `{"types": `},
+		{"not an object", `[]`},
+		{"unknown key", `{"typez": {}}`},
+		{"types not an object", `{"types": "u8"}`},
+		{"invalid definition", `{"types": {"Bad": 1}}`},
+		{"empty alias module", `{"typesAlias": {"": {"A": "u8"}}}`},
+		{"versions not a list", `{"spec": {"karura": {"types": {}}}}`},
+		{"minmax not a range", `{"spec": {"karura": {"types": [{"minmax": [1, 2, 3], "types": {}}]}}}`},
+		{"minmax reversed", `{"spec": {"karura": {"types": [{"minmax": [10, 1], "types": {}}]}}}`},
+		{"minmax negative", `{"spec": {"karura": {"types": [{"minmax": [-1, 1], "types": {}}]}}}`},
+		{"version without types", `{"spec": {"karura": {"types": [{"minmax": [0, 1]}]}}}`},
+		{"unknown typesBundle key", `{"typesBundle": {"specs": {}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := polkadot_scale_schema.NewRegistry()
+			if err := polkadot_scale_schema.LoadTypesBundle(registry, strings.NewReader(tt.bundle)); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
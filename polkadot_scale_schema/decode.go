@@ -0,0 +1,157 @@
+package polkadot_scale_schema
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"submarine/rust_types"
+	"submarine/rust_types/sanitizer"
+	s "submarine/scale"
+)
+
+// builtinTypes are the polkadot.js base classes that schema.json refers to without defining.
+var builtinTypes = map[string]string{
+	"BitVec":                   "bitvec",
+	"Bytes":                    "bytes",
+	"Text":                     "text",
+	"Str":                      "text",
+	"Type":                     "text",
+	"Null":                     "empty",
+	"GenericAccountId":         "[u8; 32]",
+	"GenericAccountId32":       "[u8; 32]",
+	"GenericEthereumAccountId": "[u8; 20]",
+	"GenericAccountIndex":      "u32",
+	"GenericVote":              "u8",
+}
+
+var (
+	// UInt<128, Balance> -> u128, Int<64> -> i64
+	sizedIntPattern = regexp.MustCompile(`\b(U?)Int<\s*(\d+)\s*(?:,[^<>]*)?>`)
+	// [u8; 32; H256] -> [u8; 32]
+	namedArrayPattern = regexp.MustCompile(`\[([^;\[\]]+);\s*(\d+)\s*;[^\[\]]*\]`)
+)
+
+// normalizeDefinition rewrites polkadot.js-only syntax of a type definition to plain Rust types.
+func normalizeDefinition(raw string) string {
+	raw = sizedIntPattern.ReplaceAllStringFunc(raw, func(match string) string {
+		groups := sizedIntPattern.FindStringSubmatch(match)
+		if groups[1] == "U" {
+			return "u" + groups[2]
+		}
+		return "i" + groups[2]
+	})
+	return namedArrayPattern.ReplaceAllString(raw, "[$1; $2]")
+}
+
+// ParseTypeName converts a type name as found in legacy metadata, e.g. "Vec<T::AccountId>", to a schema.
+func ParseTypeName(typeName string) (*s.Type, error) {
+	rustType, err := sanitizer.ParseAndSanitize(normalizeDefinition(typeName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", typeName, err)
+	}
+	scaleType, errSpan := rust_types.ToScaleSchema(&rustType)
+	if errSpan != nil {
+		return nil, fmt.Errorf("failed to convert %q to scale type: %v", typeName, errSpan)
+	}
+	return &scaleType, nil
+}
+
+// Decode decodes a value of the named type, e.g. "Compact<BalanceOf<T>>", resolving the types it
+// refers to in the registry. Types are looked up in moduleName when a name is defined by several modules.
+func (r *Registry) Decode(reader *s.Reader, moduleName, typeName string) (s.Value, error) {
+	schema, err := ParseTypeName(typeName)
+	if err != nil {
+		return s.Value{}, err
+	}
+	value, err := r.DecodeSchema(reader, moduleName, schema)
+	if err != nil {
+		return s.Value{}, fmt.Errorf("%s: %w", typeName, err)
+	}
+	return value, nil
+}
+
+// DecodeSchema decodes a value of a parsed type, resolving the types it refers to in the registry.
+func (r *Registry) DecodeSchema(reader *s.Reader, moduleName string, schema *s.Type) (s.Value, error) {
+	value, errSpan := s.DecodeWithSchemaInModule(reader, schema, r, moduleName)
+	if errSpan != nil {
+		return s.Value{}, fmt.Errorf("%w", errSpan)
+	}
+	return value, nil
+}
+
+// Encode encodes a value of the named type, as Decode decodes it.
+func (r *Registry) Encode(value s.Value, moduleName, typeName string) ([]byte, error) {
+	schema, err := ParseTypeName(typeName)
+	if err != nil {
+		return nil, err
+	}
+	data, err := r.EncodeSchema(value, moduleName, schema)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", typeName, err)
+	}
+	return data, nil
+}
+
+// EncodeSchema encodes a value of a parsed type, resolving the types it refers to in the registry.
+func (r *Registry) EncodeSchema(value s.Value, moduleName string, schema *s.Type) ([]byte, error) {
+	data, errSpan := s.EncodeWithSchemaInModule(value, schema, r, moduleName)
+	if errSpan != nil {
+		return nil, fmt.Errorf("%w", errSpan)
+	}
+	return data, nil
+}
+
+// ResolveType implements scale.TypeResolver. Names are looked up in moduleName when several modules
+// define them, and so are the names their definitions refer to.
+func (r *Registry) ResolveType(moduleName, name string) (*s.Type, string, error) {
+	if builtin, ok := builtinTypes[name]; ok {
+		definition, err := ParseTypeName(builtin)
+		return definition, moduleName, err
+	}
+	if name == "GenericMultiAddress" {
+		return multiAddress(), moduleName, nil
+	}
+
+	lazy, err := r.lookupPath(moduleName, name)
+	if err != nil {
+		return nil, "", err
+	}
+	definition, err := lazy.ToScaleType()
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", name, err)
+	}
+	return definition, moduleName, nil
+}
+
+// lookupPath looks up a type, falling back for paths like Lookup::Source, what is left of
+// <T::Lookup as StaticLookup>::Source, to the names polkadot.js gives them: LookupSource, then Source.
+func (r *Registry) lookupPath(moduleName, name string) (*LazyType, error) {
+	lazy, err := r.Lookup(moduleName, name)
+	if err == nil || !strings.Contains(name, "::") {
+		return lazy, err
+	}
+	if joined, joinErr := r.Lookup(moduleName, strings.ReplaceAll(name, "::", "")); joinErr == nil {
+		return joined, nil
+	}
+	if last, lastErr := r.Lookup(moduleName, name[strings.LastIndex(name, "::")+2:]); lastErr == nil {
+		return last, nil
+	}
+	return nil, err
+}
+
+// multiAddress is sp_runtime::MultiAddress, which polkadot.js implements as a class.
+func multiAddress() *s.Type {
+	ref := func(name string) *s.Type { return &s.Type{Kind: s.KindRef, Ref: ptr(name)} }
+	bytes := func(n int) *s.Type { return &s.Type{Kind: s.KindArray, Array: &s.Array{Type: ref("u8"), Len: n}} }
+	return &s.Type{Kind: s.KindEnumComplex, EnumComplex: &s.EnumComplex{Variants: []s.NamedMember{
+		{Name: "Id", Type: bytes(32)},
+		{Name: "Index", Type: ref("compact")},
+		{Name: "Raw", Type: ref("bytes")},
+		{Name: "Address32", Type: bytes(32)},
+		{Name: "Address20", Type: bytes(20)},
+	}}}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,81 @@
+package extractor
+
+import (
+	"fmt"
+	"slices"
+	"submarine/polkadot_scale_schema"
+)
+
+// Diff reports how schema b differs from schema a, one line per module or type. Lines start with
+// "+" for an added module or type, "-" for a removed one, and "~" for a type whose definition
+// changed, e.g. "~ runtime::Weight: \"u32\" -> \"u64\"", or a module whose types were reordered,
+// "~ runtime: order". Definitions are compared as JSON, so reordered struct fields or enum
+// variants are changes too.
+func Diff(a, b *polkadot_scale_schema.Object) []string {
+	var report []string
+	for _, moduleName := range b.Keys {
+		if _, ok := a.Get(moduleName); !ok {
+			report = append(report, "+ "+moduleName)
+		}
+	}
+	for _, moduleName := range a.Keys {
+		if _, ok := b.Get(moduleName); !ok {
+			report = append(report, "- "+moduleName)
+		}
+	}
+
+	for _, moduleName := range b.Keys {
+		aTypes, aOk := asObject(a.Values[moduleName])
+		bTypes, bOk := asObject(b.Values[moduleName])
+		if !aOk || !bOk {
+			continue
+		}
+
+		for _, typeName := range bTypes.Keys {
+			aDef, ok := aTypes.Get(typeName)
+			if !ok {
+				report = append(report, fmt.Sprintf("+ %s::%s", moduleName, typeName))
+				continue
+			}
+			aJSON, bJSON := compactJSON(aDef), compactJSON(bTypes.Values[typeName])
+			if aJSON != bJSON {
+				report = append(report, fmt.Sprintf("~ %s::%s: %s -> %s", moduleName, typeName, aJSON, bJSON))
+			}
+		}
+		for _, typeName := range aTypes.Keys {
+			if _, ok := bTypes.Get(typeName); !ok {
+				report = append(report, fmt.Sprintf("- %s::%s", moduleName, typeName))
+			}
+		}
+
+		if sameKeys(aTypes, bTypes) && !slices.Equal(aTypes.Keys, bTypes.Keys) {
+			report = append(report, fmt.Sprintf("~ %s: order", moduleName))
+		}
+	}
+	return report
+}
+
+func asObject(value any) (*polkadot_scale_schema.Object, bool) {
+	obj, ok := value.(*polkadot_scale_schema.Object)
+	return obj, ok
+}
+
+func sameKeys(a, b *polkadot_scale_schema.Object) bool {
+	if len(a.Keys) != len(b.Keys) {
+		return false
+	}
+	for _, key := range a.Keys {
+		if _, ok := b.Get(key); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func compactJSON(value any) string {
+	data, err := polkadot_scale_schema.MarshalIndentJSON(value, "")
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
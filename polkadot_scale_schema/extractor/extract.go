@@ -0,0 +1,110 @@
+// Package extractor regenerates schema.json from the definitions.ts files of @polkadot/types,
+// without Node.js. It evaluates the object literals the definitions are written in, with the
+// constants, spreads and imports they use, and keeps the order of their keys.
+package extractor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"submarine/polkadot_scale_schema"
+)
+
+// definitionFiles are the names of the definitions of a module, in sources and in built packages.
+var definitionFiles = []string{"definitions.ts", "definitions.js"}
+
+// Extract reads the types of each module's definitions in the interfaces directory of
+// @polkadot/types, returning them like schema.json: the modules sorted by name, each with its
+// types in the order they are defined. Modules that define no types, only RPC methods or runtime
+// APIs, are left out.
+//
+// dir is a checkout of polkadot-js/api, the package directory of @polkadot/types, or its
+// interfaces directory.
+func Extract(dir string) (*polkadot_scale_schema.Object, error) {
+	interfaces, err := findInterfaces(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(interfaces)
+	if err != nil {
+		return nil, err
+	}
+
+	e := newEvaluator()
+	schema := newObject()
+	for _, entry := range entries { // sorted by name
+		if !entry.IsDir() {
+			continue
+		}
+		path := definitionsPath(filepath.Join(interfaces, entry.Name()))
+		if path == "" {
+			continue
+		}
+
+		types, err := extractTypes(e, path)
+		if err != nil {
+			return nil, fmt.Errorf("module %s: %w", entry.Name(), err)
+		}
+		if types != nil && len(types.Keys) > 0 {
+			set(schema, entry.Name(), types)
+		}
+	}
+
+	if len(schema.Keys) == 0 {
+		return nil, fmt.Errorf("no type definitions found in %s", interfaces)
+	}
+	return schema, nil
+}
+
+// findInterfaces finds the directory with a subdirectory of definitions for each module.
+func findInterfaces(dir string) (string, error) {
+	candidates := []string{
+		dir,
+		filepath.Join(dir, "interfaces"),
+		filepath.Join(dir, "src", "interfaces"),
+		filepath.Join(dir, "packages", "types", "src", "interfaces"),
+	}
+	for _, candidate := range candidates {
+		if definitionsPath(filepath.Join(candidate, "runtime")) != "" {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no interfaces directory with runtime/definitions.ts found in %s", dir)
+}
+
+func definitionsPath(moduleDir string) string {
+	for _, name := range definitionFiles {
+		path := filepath.Join(moduleDir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// extractTypes evaluates the types property of the default export of a definitions file.
+func extractTypes(e *evaluator, path string) (*polkadot_scale_schema.Object, error) {
+	src, err := e.load(path)
+	if err != nil {
+		return nil, err
+	}
+	definitions, err := e.defaultExport(src)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := definitions.Get("types")
+	if !ok {
+		return nil, nil
+	}
+	if t, ok := value.(thunk); ok {
+		if value, err = e.force(t); err != nil {
+			return nil, err
+		}
+	}
+	types, ok := value.(*polkadot_scale_schema.Object)
+	if !ok {
+		return nil, fmt.Errorf("%s: types must be an object, got %T", path, value)
+	}
+	return types, nil
+}
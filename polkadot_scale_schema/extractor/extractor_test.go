@@ -0,0 +1,273 @@
+package extractor_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"submarine/polkadot_scale_schema"
+	. "submarine/polkadot_scale_schema/extractor"
+	"testing"
+)
+
+// writeFiles writes files to a temporary directory, returning it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+var testDefinitions = map[string]string{
+	"packages/types/src/interfaces/runtime/definitions.ts": `// Copyright 2017-2025 @polkadot/types authors & contributors
+// SPDX-License-Identifier: Apache-2.0
+
+import type { Definitions } from '../../types/index.js';
+
+import { objectSpread } from '@polkadot/util';
+
+import { rpc } from './rpc.js';
+import { runtime } from './runtime.js';
+
+const numberTypes = {
+  Fixed64: 'Int<64, Fixed64>',
+  FixedU128: 'UInt<128, FixedU128>'
+};
+
+export const knownOrigins: Record<string, string> = {
+  Council: 'CollectiveOrigin',
+  System: 'SystemOrigin'
+};
+
+export default {
+  rpc,
+  runtime,
+  types: objectSpread({}, numberTypes, {
+    AccountId: 'AccountId32',
+    /* a block comment */
+    Balance: 'UInt<128, Balance>',
+    Fixed64: "Int<64, Fixed64>", // set again, keeps its place
+    'Pair<A, B>': '(A, B)',
+    WithdrawReasons: {
+      _set: {
+        _bitLength: 8,
+        TransactionPayment: 0b0000_0001,
+        Transfer: 0x02,
+        Reserve: 4
+      }
+    },
+    Origins: knownOrigins,
+  })
+} as Definitions;
+`,
+	// Not evaluated, as only the types are.
+	"packages/types/src/interfaces/runtime/rpc.ts": `
+import { createMethod } from '../../create.js';
+
+export const rpc = {
+  getVersion: createMethod(() => ({ type: 'RuntimeVersion' }))
+};
+`,
+	"packages/types/src/interfaces/runtime/runtime.ts": `export const runtime = { Core: [{ methods: {}, version: 4 }] };`,
+
+	"packages/types/src/interfaces/xcm/definitions.ts": `
+import type { Definitions } from '../../types/index.js';
+
+import { v0 } from './v0.js';
+import * as v1 from './v1.js';
+
+const definitions: Definitions = {
+  rpc: {},
+  types: {
+    ...v0,
+    ...v1.types,
+    XcmOrigin: {
+      _enum: {
+        Xcm: 'MultiLocation'
+      }
+    },
+    DoubleEncodedCall: {
+      encoded: ` + "`Vec<u8>`" + `
+    },
+    Teleport: {
+      _enum: ['A', 'B']
+    }
+  }
+};
+
+export default definitions;
+`,
+	"packages/types/src/interfaces/xcm/v0.ts": `export const v0 = {
+  MultiLocationV0: { _enum: { Here: 'Null', X1: 'JunctionV0' } }
+};
+`,
+	"packages/types/src/interfaces/xcm/v1/index.ts": `export const types = { JunctionV1: 'u8' };`,
+
+	// Only RPC methods: left out.
+	"packages/types/src/interfaces/author/definitions.ts": `export default { rpc: { submit: { description: 'Submit', params: [] } }, types: {} };`,
+	"packages/types/src/interfaces/definitions.ts":        `export { default as runtime } from './runtime/definitions.js';`,
+}
+
+const expectedSchema = `{
+  "runtime": {
+    "Fixed64": "Int<64, Fixed64>",
+    "FixedU128": "UInt<128, FixedU128>",
+    "AccountId": "AccountId32",
+    "Balance": "UInt<128, Balance>",
+    "Pair<A, B>": "(A, B)",
+    "WithdrawReasons": {
+      "_set": {
+        "_bitLength": 8,
+        "TransactionPayment": 1,
+        "Transfer": 2,
+        "Reserve": 4
+      }
+    },
+    "Origins": {
+      "Council": "CollectiveOrigin",
+      "System": "SystemOrigin"
+    }
+  },
+  "xcm": {
+    "MultiLocationV0": {
+      "_enum": {
+        "Here": "Null",
+        "X1": "JunctionV0"
+      }
+    },
+    "JunctionV1": "u8",
+    "XcmOrigin": {
+      "_enum": {
+        "Xcm": "MultiLocation"
+      }
+    },
+    "DoubleEncodedCall": {
+      "encoded": "Vec<u8>"
+    },
+    "Teleport": {
+      "_enum": [
+        "A",
+        "B"
+      ]
+    }
+  }
+}`
+
+func TestExtract(t *testing.T) {
+	dir := writeFiles(t, testDefinitions)
+
+	for _, root := range []string{dir, filepath.Join(dir, "packages/types/src"), filepath.Join(dir, "packages/types/src/interfaces")} {
+		schema, err := Extract(root)
+		if err != nil {
+			t.Fatalf("Extract(%s) failed: %v", root, err)
+		}
+		output, err := polkadot_scale_schema.MarshalIndentJSON(schema, "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(output) != expectedSchema {
+			t.Errorf("Extract(%s) =\n%s\nwant\n%s", root, output, expectedSchema)
+		}
+	}
+
+	if _, err := Extract(t.TempDir()); err == nil {
+		t.Error("Expected an error for a directory without definitions")
+	}
+}
+
+func TestExtractErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition string
+		message    string
+	}{
+		{
+			name:       "unsupported expression in types",
+			definition: "export default {\n  types: {\n    Foo: makeType('u8')\n  }\n};",
+			message:    "definitions.ts:3:10: unsupported expression makeType(",
+		},
+		{
+			name:       "undefined name",
+			definition: "export default { types: { ...missing } };",
+			message:    "definitions.ts:1:30: missing is not defined",
+		},
+		{
+			name:       "template substitution",
+			definition: "const n = 32; export default { types: { Foo: `[u8; ${n}]` } };",
+			message:    "template literal substitutions are not supported",
+		},
+		{
+			name:       "circular const",
+			definition: "const a = { ...b }; const b = { ...a }; export default { types: a };",
+			message:    "refers to itself",
+		},
+		{
+			name:       "missing import",
+			definition: "import { v9 } from './v9.js'; export default { types: v9 };",
+			message:    "cannot find ./v9.js",
+		},
+		{
+			name:       "types not an object",
+			definition: "export default { types: 'u8' };",
+			message:    "types must be an object",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, map[string]string{"runtime/definitions.ts": tt.definition})
+			_, err := Extract(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("Extract() error = %v, want %q", err, tt.message)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	parse := func(data string) *polkadot_scale_schema.Object {
+		value, err := polkadot_scale_schema.ParseJSON([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value.(*polkadot_scale_schema.Object)
+	}
+	a := parse(`{
+		"balances": {"AccountData": {"free": "Balance", "reserved": "Balance"}},
+		"runtime": {"Balance": "u128", "Weight": "u32", "Index": "u32"},
+		"staking": {"Exposure": {"total": "Balance"}}
+	}`)
+	b := parse(`{
+		"balances": {"AccountData": {"reserved": "Balance", "free": "Balance"}},
+		"runtime": {"Weight": "u64", "Balance": "u128", "Hash": "H256"},
+		"system": {"Phase": "u8"}
+	}`)
+
+	expected := []string{
+		"+ system",
+		"- staking",
+		`~ balances::AccountData: {"free":"Balance","reserved":"Balance"} -> {"reserved":"Balance","free":"Balance"}`,
+		`~ runtime::Weight: "u32" -> "u64"`,
+		"+ runtime::Hash",
+		"- runtime::Index",
+	}
+	if report := Diff(a, b); !slices.Equal(report, expected) {
+		t.Errorf("Diff() =\n%s\nwant\n%s", strings.Join(report, "\n"), strings.Join(expected, "\n"))
+	}
+
+	reordered := parse(`{"runtime": {"Weight": "u32", "Balance": "u128", "Index": "u32"}, "balances": {"AccountData": {"free": "Balance", "reserved": "Balance"}}, "staking": {"Exposure": {"total": "Balance"}}}`)
+	if report := Diff(a, reordered); !slices.Equal(report, []string{"~ runtime: order"}) {
+		t.Errorf("Diff() of reordered types = %q", report)
+	}
+	if report := Diff(a, a); len(report) != 0 {
+		t.Errorf("Diff() of the same schema = %q", report)
+	}
+}
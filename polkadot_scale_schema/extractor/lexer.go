@@ -0,0 +1,173 @@
+package extractor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string // the identifier, punctuation, number as written, or the value of a string
+	pos  int    // byte offset in the source
+}
+
+// punctuation is matched longest first.
+var punctuation = []string{
+	"...", "===", "!==", "=>", "==", "!=", "<=", ">=", "&&", "||", "??", "?.",
+	"{", "}", "[", "]", "(", ")", ",", ":", ";", ".", "=", "<", ">", "?", "!", "+", "-", "*", "/", "%", "&", "|", "@",
+}
+
+// tokenize splits TypeScript source into tokens, dropping comments. It knows just enough of the
+// language for the definition files of @polkadot/types.
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+
+		case strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			i += end
+
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at offset %d", i)
+			}
+			i += end + 4
+
+		case c == '\'' || c == '"' || c == '`':
+			value, n, err := readString(src[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%w at offset %d", err, i)
+			}
+			tokens = append(tokens, token{tokenString, value, i})
+			i += n
+
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(src) && (isIdentByte(src[i]) || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokenNumber, src[start:i], start})
+
+		case isIdentByte(c):
+			start := i
+			for i < len(src) && isIdentByte(src[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokenIdent, src[start:i], start})
+
+		default:
+			matched := false
+			for _, punct := range punctuation {
+				if strings.HasPrefix(src[i:], punct) {
+					tokens = append(tokens, token{tokenPunct, punct, i})
+					i += len(punct)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected %q at offset %d", c, i)
+			}
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(src)}), nil
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+// readString reads a quoted string or a template literal without substitutions, returning its
+// value and length in the source.
+func readString(src string) (string, int, error) {
+	quote := src[0]
+	var value strings.Builder
+	for i := 1; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == quote:
+			return value.String(), i + 1, nil
+		case c == '\n' && quote != '`':
+			return "", 0, fmt.Errorf("unterminated string")
+		case c == '$' && quote == '`' && i+1 < len(src) && src[i+1] == '{':
+			return "", 0, fmt.Errorf("template literal substitutions are not supported")
+		case c == '\\':
+			if i+1 >= len(src) {
+				return "", 0, fmt.Errorf("unterminated string")
+			}
+			i++
+			switch esc := src[i]; esc {
+			case 'n':
+				value.WriteByte('\n')
+			case 't':
+				value.WriteByte('\t')
+			case 'r':
+				value.WriteByte('\r')
+			case '0':
+				value.WriteByte(0)
+			case '\n': // line continuation
+			case 'u', 'x':
+				digits := 4
+				if esc == 'x' {
+					digits = 2
+				}
+				if i+digits >= len(src) {
+					return "", 0, fmt.Errorf("invalid escape")
+				}
+				code, err := strconv.ParseUint(src[i+1:i+1+digits], 16, 32)
+				if err != nil {
+					return "", 0, fmt.Errorf("invalid escape")
+				}
+				value.WriteRune(rune(code))
+				i += digits
+			default:
+				value.WriteByte(esc)
+			}
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+// parseNumber parses a JavaScript number literal, like 42, 0x1f, 0b101 or 1_000.
+func parseNumber(text string) (float64, error) {
+	text = strings.ReplaceAll(text, "_", "")
+	if len(text) > 2 && text[0] == '0' {
+		base := 0
+		switch text[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+		if base != 0 {
+			n, err := strconv.ParseUint(text[2:], base, 64)
+			return float64(n), err
+		}
+	}
+	return strconv.ParseFloat(text, 64)
+}
@@ -0,0 +1,685 @@
+package extractor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"submarine/polkadot_scale_schema"
+)
+
+// source is a TypeScript or JavaScript file, scanned for its declarations. Declarations are
+// evaluated when they are used, so files may contain code the evaluator doesn't support, like
+// the functions of rpc definitions, as long as the types don't use it.
+type source struct {
+	path    string
+	text    string
+	tokens  []token
+	consts  map[string]int // name -> index of the first token of the initializer
+	imports map[string]importRef
+	// defaultExport is the index of the first token of the default export, -1 if there is none.
+	defaultExport int
+}
+
+type importRef struct {
+	from string // the import specifier, e.g. ./v1.js
+	name string // the imported name, "default" for default imports and "*" for namespaces
+}
+
+// namespace is the value of import * as name.
+type namespace struct {
+	source *source
+}
+
+// undefined is the value of undefined, which JSON drops from objects.
+type undefined struct{}
+
+// thunk is an unevaluated expression, the value of a property of a default export.
+type thunk struct {
+	source *source
+	pos    int
+}
+
+// evaluator evaluates the expressions of sources, loading the files they import.
+type evaluator struct {
+	sources    map[string]*source
+	values     map[string]any  // evaluated consts, by path and name
+	evaluating map[string]bool // consts being evaluated, to detect cycles
+}
+
+func newEvaluator() *evaluator {
+	return &evaluator{
+		sources:    make(map[string]*source),
+		values:     make(map[string]any),
+		evaluating: make(map[string]bool),
+	}
+}
+
+func (e *evaluator) load(path string) (*source, error) {
+	if src, ok := e.sources[path]; ok {
+		return src, nil
+	}
+
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := tokenize(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	src := &source{
+		path:          path,
+		text:          string(text),
+		tokens:        tokens,
+		consts:        make(map[string]int),
+		imports:       make(map[string]importRef),
+		defaultExport: -1,
+	}
+	if err := src.scan(); err != nil {
+		return nil, err
+	}
+	e.sources[path] = src
+	return src, nil
+}
+
+// errorf returns an error at a token, with the file, line and column.
+func (src *source) errorf(i int, format string, args ...any) error {
+	pos := src.tokens[i].pos
+	line := strings.Count(src.text[:pos], "\n") + 1
+	column := pos - strings.LastIndexByte(src.text[:pos], '\n')
+	return fmt.Errorf("%s:%d:%d: %s", src.path, line, column, fmt.Sprintf(format, args...))
+}
+
+func (src *source) is(i int, text string) bool {
+	t := src.tokens[i]
+	return (t.kind == tokenPunct || t.kind == tokenIdent) && t.text == text
+}
+
+func (src *source) expect(i int, text string) (int, error) {
+	if !src.is(i, text) {
+		return i, src.errorf(i, "expected %q, found %q", text, src.tokens[i].text)
+	}
+	return i + 1, nil
+}
+
+// scan records the imports, consts and default export of the file.
+func (src *source) scan() error {
+	i := 0
+	for src.tokens[i].kind != tokenEOF {
+		var err error
+		switch {
+		case src.is(i, "import") && !src.is(i+1, "("):
+			i, err = src.scanImport(i + 1)
+		case src.is(i, "export") && src.is(i+1, "default"):
+			src.defaultExport = i + 2
+			i = src.skipStatement(i + 2)
+		case src.is(i, "export") && isDeclaration(src.tokens[i+1]):
+			i, err = src.scanDeclaration(i + 2)
+		case isDeclaration(src.tokens[i]):
+			i, err = src.scanDeclaration(i + 1)
+		default:
+			i = src.skipStatement(i)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isDeclaration(t token) bool {
+	return t.kind == tokenIdent && (t.text == "const" || t.text == "let" || t.text == "var")
+}
+
+// scanImport scans an import statement after the import keyword.
+func (src *source) scanImport(i int) (int, error) {
+	var names [][2]string // local, imported
+	if src.is(i, "type") && !src.is(i+1, ",") && !src.is(i+1, "from") {
+		i++ // types have no values
+	}
+	if src.tokens[i].kind == tokenString { // import './side-effect.js'
+		return src.skipStatement(i), nil
+	}
+
+	if src.tokens[i].kind == tokenIdent {
+		names = append(names, [2]string{src.tokens[i].text, "default"})
+		i++
+		if src.is(i, ",") {
+			i++
+		}
+	}
+	switch {
+	case src.is(i, "*"):
+		var err error
+		if i, err = src.expect(i+1, "as"); err != nil {
+			return i, err
+		}
+		names = append(names, [2]string{src.tokens[i].text, "*"})
+		i++
+	case src.is(i, "{"):
+		i++
+		for !src.is(i, "}") {
+			if src.is(i, "type") && src.tokens[i+1].kind == tokenIdent && !src.is(i+1, "as") {
+				i++
+			}
+			imported := src.tokens[i].text
+			local := imported
+			i++
+			if src.is(i, "as") {
+				local = src.tokens[i+1].text
+				i += 2
+			}
+			names = append(names, [2]string{local, imported})
+			if src.is(i, ",") {
+				i++
+			} else if !src.is(i, "}") {
+				return i, src.errorf(i, "expected ',' or '}' in import, found %q", src.tokens[i].text)
+			}
+		}
+		i++
+	}
+
+	i, err := src.expect(i, "from")
+	if err != nil {
+		return i, err
+	}
+	if src.tokens[i].kind != tokenString {
+		return i, src.errorf(i, "expected module specifier")
+	}
+	for _, name := range names {
+		src.imports[name[0]] = importRef{from: src.tokens[i].text, name: name[1]}
+	}
+	return src.skipStatement(i + 1), nil
+}
+
+// scanDeclaration scans the declarators of a const, let or var statement after the keyword.
+func (src *source) scanDeclaration(i int) (int, error) {
+	for {
+		if src.tokens[i].kind != tokenIdent {
+			// destructuring, which definitions don't use
+			return src.skipStatement(i), nil
+		}
+		name := src.tokens[i].text
+		i++
+		if src.is(i, ":") {
+			i = src.skipType(i + 1)
+		}
+		if !src.is(i, "=") {
+			return src.skipStatement(i), nil
+		}
+		src.consts[name] = i + 1
+		i = src.skipExpr(i + 1)
+		if !src.is(i, ",") {
+			return src.skipStatement(i), nil
+		}
+		i++
+	}
+}
+
+// skipStatement skips to the end of a statement: a semicolon, or a closing brace followed by the
+// start of another statement.
+func (src *source) skipStatement(i int) int {
+	depth := 0
+	for ; src.tokens[i].kind != tokenEOF; i++ {
+		t := src.tokens[i]
+		if t.kind != tokenPunct {
+			continue
+		}
+		switch t.text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+			if depth == 0 && t.text == "}" && startsStatement(src.tokens[i+1]) {
+				return i + 1
+			}
+		case ";":
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return i
+}
+
+func startsStatement(t token) bool {
+	if t.kind == tokenEOF {
+		return true
+	}
+	switch t.text {
+	case "export", "import", "const", "let", "var", "function", "class", "interface", "type", "enum":
+		return t.kind == tokenIdent
+	}
+	return false
+}
+
+// skipExpr skips to the end of an expression, at a comma, semicolon or closing bracket.
+func (src *source) skipExpr(i int) int {
+	depth := 0
+	for ; src.tokens[i].kind != tokenEOF; i++ {
+		t := src.tokens[i]
+		if t.kind != tokenPunct {
+			continue
+		}
+		switch t.text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case ",", ";":
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return i
+}
+
+// skipType skips a type annotation, in which angle brackets nest too.
+func (src *source) skipType(i int) int {
+	depth := 0
+	for ; src.tokens[i].kind != tokenEOF; i++ {
+		t := src.tokens[i]
+		if t.kind != tokenPunct {
+			continue
+		}
+		switch t.text {
+		case "(", "[", "{", "<":
+			depth++
+		case ")", "]", "}", ">":
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case "=>":
+			// function types: () => void
+		case ",", ";", "=":
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return i
+}
+
+// resolveImport returns the path of the file a relative import specifier refers to.
+func (src *source) resolveImport(from string) (string, error) {
+	if !strings.HasPrefix(from, ".") {
+		return "", fmt.Errorf("imports from package %s are not supported", from)
+	}
+	base := filepath.Join(filepath.Dir(src.path), from)
+	base = strings.TrimSuffix(strings.TrimSuffix(base, ".js"), ".ts")
+	for _, candidate := range []string{base + ".ts", base + ".js", filepath.Join(base, "index.ts"), filepath.Join(base, "index.js")} {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("cannot find %s", from)
+}
+
+// lookup evaluates the value a name refers to in a file.
+func (e *evaluator) lookup(src *source, i int, name string) (any, error) {
+	if pos, ok := src.consts[name]; ok {
+		key := src.path + "#" + name
+		if value, ok := e.values[key]; ok {
+			return value, nil
+		}
+		if e.evaluating[key] {
+			return nil, src.errorf(i, "%s refers to itself", name)
+		}
+		e.evaluating[key] = true
+		defer delete(e.evaluating, key)
+
+		value, _, err := e.eval(src, pos)
+		if err != nil {
+			return nil, err
+		}
+		e.values[key] = value
+		return value, nil
+	}
+
+	if ref, ok := src.imports[name]; ok {
+		path, err := src.resolveImport(ref.from)
+		if err != nil {
+			return nil, src.errorf(i, "%s: %v", name, err)
+		}
+		imported, err := e.load(path)
+		if err != nil {
+			return nil, err
+		}
+		return e.export(imported, i, src, ref.name)
+	}
+
+	if name == "undefined" {
+		return undefined{}, nil
+	}
+	return nil, src.errorf(i, "%s is not defined", name)
+}
+
+// export evaluates an export of a file, for an import at token i of importer.
+func (e *evaluator) export(src *source, i int, importer *source, name string) (any, error) {
+	switch name {
+	case "*":
+		return namespace{src}, nil
+	case "default":
+		if src.defaultExport < 0 {
+			return nil, importer.errorf(i, "%s has no default export", src.path)
+		}
+		value, _, err := e.eval(src, src.defaultExport)
+		return value, err
+	}
+	pos, ok := src.consts[name]
+	if _, imported := src.imports[name]; !ok && !imported {
+		return nil, importer.errorf(i, "%s does not export %s", src.path, name)
+	}
+	return e.lookup(src, max(pos-1, 0), name)
+}
+
+// eval evaluates the expression at token i, returning its value and the index of the token after it.
+func (e *evaluator) eval(src *source, i int) (any, int, error) {
+	value, i, err := e.evalPrimary(src, i)
+	if err != nil {
+		return nil, i, err
+	}
+
+	for {
+		switch {
+		case src.is(i, "as") || src.is(i, "satisfies"):
+			i = src.skipType(i + 1)
+		case src.is(i, "!"): // non-null assertion
+			i++
+		case src.is(i, ".") || src.is(i, "?."):
+			name := src.tokens[i+1].text
+			if value, err = e.member(src, i+1, value, name); err != nil {
+				return nil, i, err
+			}
+			i += 2
+		default:
+			return value, i, nil
+		}
+	}
+}
+
+// member evaluates value.name.
+func (e *evaluator) member(src *source, i int, value any, name string) (any, error) {
+	switch v := value.(type) {
+	case namespace:
+		return e.export(v.source, i, src, name)
+	case *polkadot_scale_schema.Object:
+		member, ok := v.Get(name)
+		if !ok {
+			return undefined{}, nil
+		}
+		return member, nil
+	default:
+		return nil, src.errorf(i, "cannot read %s of %T", name, v)
+	}
+}
+
+func (e *evaluator) evalPrimary(src *source, i int) (any, int, error) {
+	t := src.tokens[i]
+	switch t.kind {
+	case tokenString:
+		return t.text, i + 1, nil
+
+	case tokenNumber:
+		n, err := parseNumber(t.text)
+		if err != nil {
+			return nil, i, src.errorf(i, "invalid number %s", t.text)
+		}
+		return n, i + 1, nil
+
+	case tokenIdent:
+		switch t.text {
+		case "true", "false":
+			return t.text == "true", i + 1, nil
+		case "null":
+			return nil, i + 1, nil
+		case "objectSpread":
+			return e.evalSpreadCall(src, i+1)
+		case "Object":
+			if src.is(i+1, ".") && src.is(i+2, "assign") {
+				return e.evalSpreadCall(src, i+3)
+			}
+		}
+		if src.is(i+1, "(") || src.is(i+1, "=>") {
+			return nil, i, src.errorf(i, "unsupported expression %s%s", t.text, src.tokens[i+1].text)
+		}
+		value, err := e.lookup(src, i, t.text)
+		return value, i + 1, err
+
+	case tokenPunct:
+		switch t.text {
+		case "{":
+			return e.evalObject(src, i)
+		case "[":
+			return e.evalArray(src, i)
+		case "-":
+			value, next, err := e.evalPrimary(src, i+1)
+			if err != nil {
+				return nil, next, err
+			}
+			n, ok := value.(float64)
+			if !ok {
+				return nil, next, src.errorf(i, "cannot negate %T", value)
+			}
+			return -n, next, nil
+		case "(":
+			value, next, err := e.eval(src, i+1)
+			if err != nil {
+				return nil, next, err
+			}
+			next, err = src.expect(next, ")")
+			return value, next, err
+		}
+	}
+	return nil, i, src.errorf(i, "unsupported expression %q", t.text)
+}
+
+// evalSpreadCall evaluates the arguments of objectSpread(target, ...sources) or Object.assign,
+// which merge objects.
+func (e *evaluator) evalSpreadCall(src *source, i int) (any, int, error) {
+	i, err := src.expect(i, "(")
+	if err != nil {
+		return nil, i, err
+	}
+	obj := newObject()
+	for !src.is(i, ")") {
+		var value any
+		value, i, err = e.eval(src, i)
+		if err != nil {
+			return nil, i, err
+		}
+		if err := e.spread(src, i, obj, value); err != nil {
+			return nil, i, err
+		}
+		if src.is(i, ",") {
+			i++
+		} else if !src.is(i, ")") {
+			return nil, i, src.errorf(i, "expected ',' or ')', found %q", src.tokens[i].text)
+		}
+	}
+	return obj, i + 1, nil
+}
+
+// spread copies the properties of value into obj, like {...value}.
+func (e *evaluator) spread(src *source, i int, obj *polkadot_scale_schema.Object, value any) error {
+	switch v := value.(type) {
+	case nil, undefined:
+		return nil
+	case *polkadot_scale_schema.Object:
+		for _, key := range v.Keys {
+			set(obj, key, v.Values[key])
+		}
+		return nil
+	default:
+		return src.errorf(i, "cannot spread %T", v)
+	}
+}
+
+func (e *evaluator) evalObject(src *source, i int) (any, int, error) {
+	return e.evalObjectLazily(src, i, false)
+}
+
+// evalObjectLazily evaluates an object literal. If lazy, property values are left unevaluated.
+func (e *evaluator) evalObjectLazily(src *source, i int, lazy bool) (*polkadot_scale_schema.Object, int, error) {
+	obj := newObject()
+	i++ // {
+	for !src.is(i, "}") {
+		if src.is(i, "...") {
+			value, next, err := e.eval(src, i+1)
+			if err != nil {
+				return nil, next, err
+			}
+			if err := e.spread(src, i, obj, value); err != nil {
+				return nil, next, err
+			}
+			i = next
+		} else {
+			t := src.tokens[i]
+			var key string
+			switch t.kind {
+			case tokenIdent, tokenString:
+				key = t.text
+			case tokenNumber:
+				n, err := parseNumber(t.text)
+				if err != nil {
+					return nil, i, src.errorf(i, "invalid number %s", t.text)
+				}
+				key = strconv.FormatFloat(n, 'f', -1, 64)
+			default:
+				return nil, i, src.errorf(i, "unsupported property %q", t.text)
+			}
+			i++
+
+			var value any
+			switch {
+			case src.is(i, ":"):
+				if lazy {
+					value = thunk{src, i + 1}
+					i = src.skipExpr(i + 1)
+				} else {
+					var err error
+					if value, i, err = e.eval(src, i+1); err != nil {
+						return nil, i, err
+					}
+				}
+			case src.is(i, ",") || src.is(i, "}"): // shorthand
+				if lazy {
+					value = thunk{src, i - 1}
+				} else {
+					var err error
+					if value, err = e.lookup(src, i-1, key); err != nil {
+						return nil, i, err
+					}
+				}
+			default:
+				return nil, i, src.errorf(i, "unsupported property %s%s", key, src.tokens[i].text)
+			}
+			set(obj, key, value)
+		}
+
+		if src.is(i, ",") {
+			i++
+		} else if !src.is(i, "}") {
+			return nil, i, src.errorf(i, "expected ',' or '}', found %q", src.tokens[i].text)
+		}
+	}
+	return obj, i + 1, nil
+}
+
+func (e *evaluator) evalArray(src *source, i int) (any, int, error) {
+	list := []any{}
+	i++ // [
+	for !src.is(i, "]") {
+		if src.is(i, "...") {
+			value, next, err := e.eval(src, i+1)
+			if err != nil {
+				return nil, next, err
+			}
+			items, ok := value.([]any)
+			if !ok {
+				return nil, next, src.errorf(i, "cannot spread %T into an array", value)
+			}
+			list = append(list, items...)
+			i = next
+		} else {
+			value, next, err := e.eval(src, i)
+			if err != nil {
+				return nil, next, err
+			}
+			if _, ok := value.(undefined); ok {
+				value = nil
+			}
+			list = append(list, value)
+			i = next
+		}
+
+		if src.is(i, ",") {
+			i++
+		} else if !src.is(i, "]") {
+			return nil, i, src.errorf(i, "expected ',' or ']', found %q", src.tokens[i].text)
+		}
+	}
+	return list, i + 1, nil
+}
+
+// force evaluates a thunk.
+func (e *evaluator) force(t thunk) (any, error) {
+	value, _, err := e.eval(t.source, t.pos)
+	return value, err
+}
+
+// defaultExport evaluates the default export of a file, leaving the values of its properties
+// unevaluated if it is an object.
+func (e *evaluator) defaultExport(src *source) (*polkadot_scale_schema.Object, error) {
+	if src.defaultExport < 0 {
+		return nil, fmt.Errorf("%s: no default export", src.path)
+	}
+	i := src.defaultExport
+	// export default definitions, with definitions a const
+	for range len(src.consts) {
+		if src.tokens[i].kind != tokenIdent || !(src.is(i+1, ";") || src.is(i+1, "as") || src.tokens[i+1].kind == tokenEOF) {
+			break
+		}
+		pos, ok := src.consts[src.tokens[i].text]
+		if !ok {
+			break
+		}
+		i = pos
+	}
+	if !src.is(i, "{") {
+		value, _, err := e.eval(src, i)
+		if err != nil {
+			return nil, err
+		}
+		obj, ok := value.(*polkadot_scale_schema.Object)
+		if !ok {
+			return nil, src.errorf(i, "default export must be an object, got %T", value)
+		}
+		return obj, nil
+	}
+	obj, _, err := e.evalObjectLazily(src, i, true)
+	return obj, err
+}
+
+func newObject() *polkadot_scale_schema.Object {
+	return &polkadot_scale_schema.Object{Values: make(map[string]any)}
+}
+
+// set sets a property like JavaScript does: a new key goes last, an existing key keeps its place,
+// and undefined values are dropped.
+func set(obj *polkadot_scale_schema.Object, key string, value any) {
+	if _, ok := value.(undefined); ok {
+		return
+	}
+	if _, exists := obj.Values[key]; !exists {
+		obj.Keys = append(obj.Keys, key)
+	}
+	obj.Values[key] = value
+}
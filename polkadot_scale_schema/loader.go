@@ -1,143 +1,392 @@
 package polkadot_scale_schema
 
 import (
+	"bytes"
 	_ "embed"
-	"encoding/json"
 	"fmt"
+	"strings"
 	s "submarine/scale"
+	"unicode"
 )
 
 //go:embed schema.json
 var schemaData []byte
 
+// knownData are the definitions of the Polkadot and Kusama runtimes before their latest types, by
+// spec version, from @polkadot/types-known (src/spec/polkadot.ts and kusama.ts), in the format of
+// a typesBundle.
+//
+//go:embed known.json
+var knownData []byte
+
+// EmbeddedSchema returns the schema.json that LoadPolkadotSchema loads.
+func EmbeddedSchema() []byte {
+	return bytes.Clone(schemaData)
+}
+
+// LoadPolkadotSchema loads the definitions of polkadot.js: those of the latest runtimes, and those
+// of older Polkadot and Kusama runtimes, which the ForSpec views of those runtimes see instead.
 func LoadPolkadotSchema(r *Registry) error {
-	var schema map[string]map[string]any
-	if err := json.Unmarshal(schemaData, &schema); err != nil {
+	schema, err := ParseJSON(schemaData)
+	if err != nil {
 		return fmt.Errorf("failed to parse JSON: %w", err)
 	}
+	obj, ok := schema.(*Object)
+	if !ok {
+		return fmt.Errorf("schema must be an object, got %T", schema)
+	}
+	if err := LoadFromObject(r, Scope{}, obj); err != nil {
+		return err
+	}
 
-	return LoadFromSchema(r, schema)
+	known, err := ParseJSON(knownData)
+	if err != nil {
+		return fmt.Errorf("known.json: failed to parse JSON: %w", err)
+	}
+	if err := loadTypesBundle(r, known); err != nil {
+		return fmt.Errorf("known.json: %w", err)
+	}
+	return nil
 }
 
+// LoadFromSchema loads modules of type definitions. Maps have no order, so structs and enums with
+// several fields or variants must be given as *Object, e.g. parsed by ParseJSON; set flags, whose
+// bits are explicit, are sorted by name. Use LoadFromObject for definitions parsed from JSON.
 func LoadFromSchema(r *Registry, schema map[string]map[string]any) error {
-	for moduleName, moduleTypes := range schema {
-		for typeName, typeDef := range moduleTypes {
-			scaleType, err := parseTypeDef(typeDef)
-			if err != nil {
-				return fmt.Errorf("failed to parse type %s::%s: %w", moduleName, typeName, err)
-			}
+	return LoadScopedFromSchema(r, Scope{}, schema)
+}
 
-			entry := TypeEntry{
-				Module: moduleName,
-				Type:   scaleType,
-			}
+// LoadScopedFromSchema loads definitions that only apply to the runtimes in scope, e.g. those of
+// a runtime upgrade that changed the shape of a type.
+func LoadScopedFromSchema(r *Registry, scope Scope, schema map[string]map[string]any) error {
+	modules := make(map[string]any, len(schema))
+	for moduleName, moduleTypes := range schema {
+		modules[moduleName] = sortedObject(moduleTypes)
+	}
+	return LoadFromObject(r, scope, sortedObject(modules))
+}
 
-			r.types[typeName] = append(r.types[typeName], entry)
+// LoadFromObject loads modules of type definitions parsed by ParseJSON, in order.
+func LoadFromObject(r *Registry, scope Scope, schema *Object) error {
+	for _, moduleName := range schema.Keys {
+		moduleTypes, ok := asObject(schema.Values[moduleName])
+		if !ok {
+			return fmt.Errorf("module %s must be an object, got %T", moduleName, schema.Values[moduleName])
+		}
+		if err := loadModuleTypes(r, scope, moduleName, moduleTypes, false); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-func parseTypeDef(typeDef any) (*LazyType, error) {
-	switch def := typeDef.(type) {
-	case string:
-		return NewLazyType(def), nil
+// loadModuleTypes loads the type definitions of a module, or of every module if moduleName is empty.
+// Local definitions are only visible in the module.
+func loadModuleTypes(r *Registry, scope Scope, moduleName string, moduleTypes *Object, local bool) error {
+	for _, key := range moduleTypes.Keys {
+		typeName, params, err := splitGenericName(key)
+		if err != nil {
+			return fmt.Errorf("type %s: %w", qualifiedName(moduleName, key), err)
+		}
+		scaleType, err := parseTypeDef(moduleTypes.Values[key])
+		if err != nil {
+			return fmt.Errorf("failed to parse type %s: %w", qualifiedName(moduleName, typeName), err)
+		}
+		scaleType.setParams(params)
 
-	case map[string]any:
-		if enumDef, hasEnum := def["_enum"]; hasEnum {
-			return parseEnum(enumDef)
+		entry := TypeEntry{
+			Module: moduleName,
+			Type:   scaleType,
+			Scope:  scope,
+			Local:  local,
 		}
 
-		if setDef, hasSet := def["_set"]; hasSet {
-			bitLength := 8
-			if bitLengthVal, hasBitLength := def["_bitLength"]; hasBitLength {
-				if bl, ok := bitLengthVal.(float64); ok {
-					bitLength = int(bl)
-				}
-			}
-			return parseSet(setDef, bitLength)
+		r.types[typeName] = append(r.types[typeName], entry)
+	}
+
+	return nil
+}
+
+func qualifiedName(moduleName, typeName string) string {
+	if moduleName == "" {
+		return typeName
+	}
+	return moduleName + "::" + typeName
+}
+
+// splitGenericName splits the name of a generic definition, e.g. "Pair<A, B>", into the type name
+// and its parameters.
+func splitGenericName(key string) (string, []string, error) {
+	name, rest, generic := strings.Cut(key, "<")
+	if !generic {
+		return key, nil, nil
+	}
+	rest, closed := strings.CutSuffix(strings.TrimSpace(rest), ">")
+	if !closed {
+		return "", nil, fmt.Errorf("expected '>' at the end of a generic definition name")
+	}
+
+	var params []string
+	for _, param := range strings.Split(rest, ",") {
+		param = strings.TrimSpace(param)
+		if !isIdent(param) {
+			return "", nil, fmt.Errorf("invalid type parameter %q", param)
 		}
+		params = append(params, param)
+	}
+	return strings.TrimSpace(name), params, nil
+}
 
-		return parseStruct(def)
+func isIdent(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if !(c == '_' || unicode.IsLetter(c) || (i > 0 && unicode.IsDigit(c))) {
+			return false
+		}
+	}
+	return true
+}
 
+// asObject accepts objects parsed by ParseJSON and maps.
+func asObject(value any) (*Object, bool) {
+	switch v := value.(type) {
+	case *Object:
+		return v, true
+	case map[string]any:
+		return sortedObject(v), true
 	default:
+		return nil, false
+	}
+}
+
+func parseTypeDef(typeDef any) (*LazyType, error) {
+	if str, ok := typeDef.(string); ok {
+		return NewLazyType(str), nil
+	}
+
+	def, ok := asObject(typeDef)
+	if !ok {
 		return nil, fmt.Errorf("unsupported type definition: %T", typeDef)
 	}
+
+	if enumDef, hasEnum := def.Get("_enum"); hasEnum {
+		return parseEnum(enumDef)
+	}
+
+	if setDef, hasSet := def.Get("_set"); hasSet {
+		bitLength := 8
+		if bitLengthVal, hasBitLength := def.Get("_bitLength"); hasBitLength {
+			if bl, err := parseInt(bitLengthVal); err == nil {
+				bitLength = int(bl)
+			}
+		}
+		return parseSet(setDef, bitLength)
+	}
+
+	if isMap(typeDef) && len(def.Keys) > 1 {
+		return nil, errMapOrder("struct fields")
+	}
+	return parseStruct(def)
+}
+
+// isMap reports whether a definition is a Go map rather than an *Object, which keeps its keys in order.
+func isMap(value any) bool {
+	_, ok := value.(map[string]any)
+	return ok
+}
+
+// errMapOrder is the error for struct fields or enum variants given as a Go map, whose keys have no
+// order: sorting them by name would give the wrong encoding.
+func errMapOrder(what string) error {
+	return fmt.Errorf("%s in a Go map have no order: pass an *Object or JSON", what)
+}
+
+// isUnused reports whether an enum variant is a polkadot.js placeholder for an unused index, e.g.
+// __Unused4 in sparse enums.
+func isUnused(variantName string) bool {
+	return strings.HasPrefix(variantName, "__Unused")
 }
 
 func parseEnum(enumDef any) (*LazyType, error) {
-	switch enum := enumDef.(type) {
-	case []any:
-		variants := make([]string, len(enum))
-		for i, variant := range enum {
-			if variantStr, ok := variant.(string); ok {
-				variants[i] = variantStr
-			} else {
+	if list, ok := enumDef.([]any); ok {
+		var variants []string
+		var indices []int
+		sparse := false
+		for i, variant := range list {
+			variantStr, ok := variant.(string)
+			if !ok {
 				return nil, fmt.Errorf("enum variant must be string, got %T", variant)
 			}
+			if isUnused(variantStr) {
+				sparse = true
+				continue
+			}
+			variants = append(variants, variantStr)
+			indices = append(indices, i)
+		}
+		if !sparse {
+			indices = nil
 		}
 
 		scaleType := s.Type{
 			Kind: s.KindEnumSimple,
 			EnumSimple: &s.EnumSimple{
 				Variants: variants,
+				Indices:  indices,
 			},
 		}
 
 		lazyType := &LazyType{parsed: &scaleType}
 		return lazyType, nil
+	}
 
-	case map[string]any:
-		var variants []s.NamedMember
-		for variantName, variantType := range enum {
-			if variantType == nil {
-				variants = append(variants, s.NamedMember{
-					Name: variantName,
-					Type: nil,
-				})
-			} else {
-				variantLazyType, err := parseTypeDef(variantType)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse variant %s: %w", variantName, err)
-				}
-
-				variantScaleType, err := variantLazyType.ToScaleType()
-				if err != nil {
-					return nil, fmt.Errorf("failed to convert variant %s to scale type: %w", variantName, err)
-				}
-
-				variants = append(variants, s.NamedMember{
-					Name: variantName,
-					Type: variantScaleType,
-				})
-			}
+	enum, ok := asObject(enumDef)
+	if !ok {
+		return nil, fmt.Errorf("unsupported _enum type: %T", enumDef)
+	}
+	if isIndexedEnum(enum) {
+		return parseIndexedEnum(enum)
+	}
+	if isMap(enumDef) && len(enum.Keys) > 1 {
+		return nil, errMapOrder("enum variants")
+	}
+
+	var variants []s.NamedMember
+	var indices []int
+	sparse := false
+	for i, variantName := range enum.Keys {
+		variantType := enum.Values[variantName]
+		if isUnused(variantName) {
+			sparse = true
+			continue
 		}
+		indices = append(indices, i)
 
-		scaleType := s.Type{
-			Kind: s.KindEnumComplex,
-			EnumComplex: &s.EnumComplex{
-				Variants: variants,
-			},
+		if variantType == nil {
+			variants = append(variants, s.NamedMember{
+				Name: variantName,
+				Type: nil,
+			})
+			continue
 		}
 
-		lazyType := &LazyType{parsed: &scaleType}
-		return lazyType, nil
+		variantLazyType, err := parseTypeDef(variantType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse variant %s: %w", variantName, err)
+		}
 
+		variantScaleType, err := variantLazyType.ToScaleType()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert variant %s to scale type: %w", variantName, err)
+		}
+
+		variants = append(variants, s.NamedMember{
+			Name: variantName,
+			Type: variantScaleType,
+		})
+	}
+	if !sparse {
+		indices = nil
+	}
+
+	scaleType := s.Type{
+		Kind: s.KindEnumComplex,
+		EnumComplex: &s.EnumComplex{
+			Variants: variants,
+			Indices:  indices,
+		},
+	}
+
+	lazyType := &LazyType{parsed: &scaleType}
+	return lazyType, nil
+}
+
+// isIndexedEnum reports whether an enum gives the discriminants of its variants, as in
+// {"_enum": {"PERSISTENT": 1, "LOCAL": 2}}.
+func isIndexedEnum(enum *Object) bool {
+	if len(enum.Keys) == 0 {
+		return false
+	}
+	for _, value := range enum.Values {
+		if _, err := parseInt(value); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func parseIndexedEnum(enum *Object) (*LazyType, error) {
+	variants := make([]string, len(enum.Keys))
+	indices := make([]int, len(enum.Keys))
+	seen := make(map[int]string)
+	for i, variantName := range enum.Keys {
+		index, _ := parseInt(enum.Values[variantName])
+		if index < 0 || index > 255 {
+			return nil, fmt.Errorf("variant %s: index %d out of range", variantName, index)
+		}
+		if other, exists := seen[int(index)]; exists {
+			return nil, fmt.Errorf("variants %s and %s have the same index %d", other, variantName, index)
+		}
+		seen[int(index)] = variantName
+		variants[i] = variantName
+		indices[i] = int(index)
+	}
+
+	scaleType := s.Type{
+		Kind: s.KindEnumSimple,
+		EnumSimple: &s.EnumSimple{
+			Variants: variants,
+			Indices:  indices,
+		},
+	}
+
+	lazyType := &LazyType{parsed: &scaleType}
+	return lazyType, nil
+}
+
+// parseInt accepts the integers of JSON numbers and Go literals.
+func parseInt(value any) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint64:
+		return int64(v), nil
+	case float32:
+		return int64(v), nil
+	case float64:
+		if v != float64(int64(v)) {
+			return 0, fmt.Errorf("%v is not an integer", v)
+		}
+		return int64(v), nil
 	default:
-		return nil, fmt.Errorf("unsupported _enum type: %T", enumDef)
+		return 0, fmt.Errorf("expected an integer, got %T", value)
 	}
 }
 
 func parseSet(setDef any, bitLength int) (*LazyType, error) {
-	setMap, ok := setDef.(map[string]any)
+	setObj, ok := asObject(setDef)
 	if !ok {
 		return nil, fmt.Errorf("_set must be an object, got %T", setDef)
 	}
 
 	var flags []s.BitFlag
 
-	for flagName, flagValue := range setMap {
+	for _, flagName := range setObj.Keys {
+		flagValue := setObj.Values[flagName]
+		if flagName == "_bitLength" {
+			// polkadot.js gives the bit length among the flags.
+			bl, err := parseInt(flagValue)
+			if err != nil {
+				return nil, fmt.Errorf("_bitLength: %w", err)
+			}
+			bitLength = int(bl)
+			continue
+		}
 		var bitValue uint64
 
 		switch v := flagValue.(type) {
@@ -173,15 +422,15 @@ func parseSet(setDef any, bitLength int) (*LazyType, error) {
 	return lazyType, nil
 }
 
-func parseStruct(structDef map[string]any) (*LazyType, error) {
+func parseStruct(structDef *Object) (*LazyType, error) {
 	var fields []s.NamedMember
 
-	for fieldName, fieldType := range structDef {
+	for _, fieldName := range structDef.Keys {
 		if fieldName == "_fallback" {
 			continue
 		}
 
-		fieldLazyType, err := parseTypeDef(fieldType)
+		fieldLazyType, err := parseTypeDef(structDef.Values[fieldName])
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse field %s: %w", fieldName, err)
 		}
@@ -1,6 +1,9 @@
 package polkadot_scale_schema_test
 
 import (
+	"bytes"
+	"fmt"
+	"strings"
 	"submarine/polkadot_scale_schema"
 	s "submarine/scale"
 	"testing"
@@ -17,10 +20,7 @@ func TestPolkadotSchemaLoadAndLookup(t *testing.T) {
 			"Hash":        "[u8; 32]",
 
 			// Struct types
-			"Transfer": map[string]any{
-				"to":     "AccountId",
-				"amount": "Balance",
-			},
+			"Transfer": object(t, `{"to": "AccountId", "amount": "Balance"}`),
 
 			// Simple enum
 			"Verdict": map[string]any{
@@ -29,12 +29,7 @@ func TestPolkadotSchemaLoadAndLookup(t *testing.T) {
 
 			// Complex enum with variants
 			"MultiAddress": map[string]any{
-				"_enum": map[string]any{
-					"Id":      "AccountId",
-					"Index":   "u32",
-					"Raw":     "Vec<u8>",
-					"Address": nil,
-				},
+				"_enum": object(t, `{"Id": "AccountId", "Index": "u32", "Raw": "Vec<u8>", "Address": null}`),
 			},
 
 			// Bitflags
@@ -63,11 +58,7 @@ func TestPolkadotSchemaLoadAndLookup(t *testing.T) {
 
 		"pallet_balances": {
 			"AccountId": "Vec<u8>", // Same name, different module
-			"Transfer": map[string]any{
-				"from":   "AccountId",
-				"to":     "AccountId",
-				"amount": "u128",
-			},
+			"Transfer":  object(t, `{"from": "AccountId", "to": "AccountId", "amount": "u128"}`),
 		},
 	}
 
@@ -271,6 +262,16 @@ func TestPolkadotSchemaLoadAndLookup(t *testing.T) {
 	}
 }
 
+// object parses a JSON object, which keeps the order of struct fields and enum variants.
+func object(t *testing.T, data string) *polkadot_scale_schema.Object {
+	t.Helper()
+	value, err := polkadot_scale_schema.ParseJSON([]byte(data))
+	if err != nil {
+		t.Fatalf("parse %s: %v", data, err)
+	}
+	return value.(*polkadot_scale_schema.Object)
+}
+
 // Helper function to create string pointers
 func stringPtr(s string) *string {
 	return &s
@@ -493,6 +494,67 @@ func TestRegistryEdgeCases(t *testing.T) {
 	}
 }
 
+func TestRegistryMalformedType(t *testing.T) {
+	registry := polkadot_scale_schema.NewRegistry()
+	err := polkadot_scale_schema.LoadFromSchema(registry, map[string]map[string]any{
+		"runtime": {
+			"Broken":  "Vec<(u8, u16>",
+			"Wrapper": "Vec<Broken>",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+
+	lazy, err := registry.Lookup("runtime", "Broken")
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if _, err := lazy.ToScaleType(); err == nil || !strings.Contains(err.Error(), "at offset 12") {
+		t.Errorf("ToScaleType() error = %v, want a parse error at offset 12", err)
+	}
+
+	if _, err := registry.Decode(s.NewReader([]byte{0x04, 0x01}), "runtime", "Wrapper"); err == nil {
+		t.Error("Decode() of a type referring to a malformed type succeeded")
+	}
+	if _, err := registry.Decode(s.NewReader([]byte{0x01}), "runtime", "Option<u8"); err == nil {
+		t.Error("Decode() of a malformed type name succeeded")
+	}
+}
+
+func TestLoadFromSchemaMapOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		def   any
+		error string
+	}{
+		{"struct", map[string]any{"to": "AccountId", "amount": "Balance"}, "struct fields in a Go map have no order"},
+		{"enum", map[string]any{"_enum": map[string]any{"Id": "AccountId", "Index": "u32"}}, "enum variants in a Go map have no order"},
+		{"single field", map[string]any{"amount": "Balance"}, ""},
+		{"unit", map[string]any{}, ""},
+		{"enum list", map[string]any{"_enum": []any{"Yes", "No"}}, ""},
+		{"indexed enum", map[string]any{"_enum": map[string]any{"A": 1, "B": 4}}, ""},
+		{"set", map[string]any{"_set": map[string]any{"Read": 1, "Write": 2}}, ""},
+		{"object", object(t, `{"to": "AccountId", "amount": "Balance"}`), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := polkadot_scale_schema.NewRegistry()
+			err := polkadot_scale_schema.LoadFromSchema(registry, map[string]map[string]any{"runtime": {"Def": tt.def}})
+			if tt.error == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.error) || !strings.Contains(err.Error(), "*Object") {
+				t.Errorf("expected an error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
 func TestRegistryMethods(t *testing.T) {
 	schema := map[string]map[string]any{
 		"runtime": {
@@ -551,3 +613,355 @@ func TestRegistryMethods(t *testing.T) {
 		}
 	})
 }
+
+func TestRegistryForSpec(t *testing.T) {
+	registry := polkadot_scale_schema.NewRegistry()
+	load := func(scope polkadot_scale_schema.Scope, types map[string]any) {
+		if err := polkadot_scale_schema.LoadScopedFromSchema(registry, scope, map[string]map[string]any{"runtime": types}); err != nil {
+			t.Fatalf("Failed to load schema: %v", err)
+		}
+	}
+	load(polkadot_scale_schema.Scope{}, map[string]any{"Weight": "u64", "RefCount": "u32", "Index": "u32"})
+	load(polkadot_scale_schema.Scope{Specs: &polkadot_scale_schema.SpecRange{Min: 0, Max: 25}}, map[string]any{"RefCount": "u8"})
+	load(polkadot_scale_schema.Scope{Chain: "Kusama", Specs: &polkadot_scale_schema.SpecRange{Min: 1019, Max: 2024}}, map[string]any{"RefCount": "u16"})
+	load(polkadot_scale_schema.Scope{Chain: "Kusama"}, map[string]any{"Weight": "u32"})
+
+	tests := []struct {
+		name     string
+		registry *polkadot_scale_schema.Registry
+		typeName string
+		expected string
+	}{
+		{"unscoped registry ignores overrides", registry, "RefCount", "u32"},
+		{"spec range", registry.ForSpec("Polkadot", "polkadot", 25), "RefCount", "u8"},
+		{"after spec range", registry.ForSpec("Polkadot", "polkadot", 26), "RefCount", "u32"},
+		{"chain and spec range win over spec range", registry.ForSpec("kusama", "kusama", 1020), "RefCount", "u16"},
+		{"spec range wins over chain", registry.ForSpec("Kusama", "kusama", 20), "RefCount", "u8"},
+		{"chain", registry.ForSpec("Kusama", "kusama", 9000), "Weight", "u32"},
+		{"other chain", registry.ForSpec("Polkadot", "polkadot", 9000), "Weight", "u64"},
+		{"not overridden", registry.ForSpec("Kusama", "kusama", 1020), "Index", "u32"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lazy, err := tt.registry.Lookup("runtime", tt.typeName)
+			if err != nil {
+				t.Fatalf("Lookup failed: %v", err)
+			}
+			typ, err := lazy.ToScaleType()
+			if err != nil {
+				t.Fatalf("ToScaleType failed: %v", err)
+			}
+			if typ.Kind != s.KindRef || *typ.Ref != tt.expected {
+				t.Errorf("Expected %s, got %+v", tt.expected, typ)
+			}
+		})
+	}
+
+	// Views decode with the definitions of their runtime.
+	value, err := registry.ForSpec("Polkadot", "polkadot", 10).Decode(s.NewReader([]byte{0x07}), "system", "Vec<RefCount>")
+	if err == nil {
+		t.Errorf("Expected an error decoding a truncated Vec, got %v", value)
+	}
+	value, err = registry.ForSpec("Polkadot", "polkadot", 10).Decode(s.NewReader([]byte{0x04, 0x07}), "system", "Vec<RefCount>")
+	if err != nil || len(value.List) != 1 || value.List[0].Int.Int64() != 7 {
+		t.Errorf("Expected [7], got %v, %v", value, err)
+	}
+}
+
+func TestLoadFromObject(t *testing.T) {
+	schema, err := polkadot_scale_schema.ParseJSON([]byte(`{
+		"runtime": {
+			"Info": {"weight": "u64", "class": "Class", "paysFee": "bool"},
+			"Class": {"_enum": ["Normal", "Operational", "Mandatory"]},
+			"Sparse": {"_enum": {"Zero": "Null", "__Unused1": "Null", "Two": "u8"}},
+			"Kind": {"_enum": {"PERSISTENT": 1, "LOCAL": 2}},
+			"Flags": {"_set": {"_bitLength": 16, "B": 2, "A": 1}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	registry := polkadot_scale_schema.NewRegistry()
+	if err := polkadot_scale_schema.LoadFromObject(registry, polkadot_scale_schema.Scope{}, schema.(*polkadot_scale_schema.Object)); err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+
+	scaleType := func(typeName string) *s.Type {
+		lazy, err := registry.Lookup("runtime", typeName)
+		if err != nil {
+			t.Fatalf("Lookup %s failed: %v", typeName, err)
+		}
+		typ, err := lazy.ToScaleType()
+		if err != nil {
+			t.Fatalf("ToScaleType %s failed: %v", typeName, err)
+		}
+		return typ
+	}
+
+	// Fields, variants and flags keep their JSON order.
+	info := scaleType("Info")
+	if len(info.Struct.Fields) != 3 || info.Struct.Fields[0].Name != "weight" || info.Struct.Fields[2].Name != "paysFee" {
+		t.Errorf("Info fields out of order: %+v", info.Struct.Fields)
+	}
+	flags := scaleType("Flags")
+	if len(flags.BitFlags.Flags) != 2 || flags.BitFlags.Flags[0].Name != "B" || flags.BitFlags.BitLength != 16 {
+		t.Errorf("Flags out of order: %+v", flags.BitFlags.Flags)
+	}
+
+	sparse := scaleType("Sparse")
+	if len(sparse.EnumComplex.Variants) != 2 || sparse.EnumComplex.Indices[1] != 2 {
+		t.Errorf("Sparse = %+v", sparse.EnumComplex)
+	}
+	kind := scaleType("Kind")
+	if kind.Kind != s.KindEnumSimple || kind.EnumSimple.Indices[0] != 1 {
+		t.Errorf("Kind = %+v", kind.EnumSimple)
+	}
+
+	tests := []struct {
+		typeName string
+		data     []byte
+		check    func(s.Value) bool
+	}{
+		{"Info", []byte{1, 0, 0, 0, 0, 0, 0, 0, 2, 1}, func(v s.Value) bool {
+			return formatValue(v) == "{weight:1 class:Mandatory paysFee:true}"
+		}},
+		{"Sparse", []byte{2, 9}, func(v s.Value) bool { return formatValue(v) == "Two(9)" && v.Variant.Index == 2 }},
+		{"Kind", []byte{2}, func(v s.Value) bool { return formatValue(v) == "LOCAL" && v.Variant.Index == 2 }},
+	}
+	for _, tt := range tests {
+		value, err := registry.Decode(s.NewReader(tt.data), "runtime", tt.typeName)
+		if err != nil || !tt.check(value) {
+			t.Errorf("Decode %s = %v, %v", tt.typeName, value, err)
+		}
+	}
+	if _, err := registry.Decode(s.NewReader([]byte{1}), "runtime", "Sparse"); err == nil {
+		t.Error("Expected an error for an unused variant")
+	}
+
+	// Indexed enums must have distinct indices.
+	bad, _ := polkadot_scale_schema.ParseJSON([]byte(`{"runtime": {"Bad": {"_enum": {"A": 1, "B": 1}}}}`))
+	if err := polkadot_scale_schema.LoadFromObject(polkadot_scale_schema.NewRegistry(), polkadot_scale_schema.Scope{}, bad.(*polkadot_scale_schema.Object)); err == nil {
+		t.Error("Expected an error for duplicate enum indices")
+	}
+}
+
+func TestLoadPolkadotSchema(t *testing.T) {
+	registry := polkadot_scale_schema.NewRegistry()
+	if err := polkadot_scale_schema.LoadPolkadotSchema(registry); err != nil {
+		t.Fatalf("Failed to load schema.json: %v", err)
+	}
+
+	value, err := registry.Decode(s.NewReader([]byte{0x02}), "offchain", "StorageKind")
+	if err != nil || formatValue(value) != "LOCAL" {
+		t.Errorf("StorageKind = %v, %v", value, err)
+	}
+}
+
+func TestLoadPolkadotSchemaForSpec(t *testing.T) {
+	registry := polkadot_scale_schema.NewRegistry()
+	if err := polkadot_scale_schema.LoadPolkadotSchema(registry); err != nil {
+		t.Fatalf("Failed to load schema.json: %v", err)
+	}
+
+	// DispatchInfo with a u64 weight of 1000, Normal, Yes.
+	dispatchInfo := []byte{0xe8, 0x03, 0, 0, 0, 0, 0, 0, 0x00, 0x00}
+	accountInfo := append([]byte{0x05, 0, 0, 0, 0x02}, make([]byte, 64)...)
+
+	tests := []struct {
+		name     string
+		registry *polkadot_scale_schema.Registry
+		typeName string
+		data     []byte
+		expected string
+	}{
+		{"polkadot genesis", registry.ForSpec("Polkadot", "polkadot", 0), "DispatchInfo", dispatchInfo, "{weight:1000 class:Normal paysFee:Yes}"},
+		{"polkadot before v14", registry.ForSpec("Polkadot", "polkadot", 9100), "DispatchInfo", dispatchInfo, "{weight:1000 class:Normal paysFee:Yes}"},
+		{"kusama u32 weight", registry.ForSpec("Kusama", "kusama", 1020), "DispatchInfo", []byte{0xe8, 0x03, 0, 0, 0x01}, "{weight:1000 class:Operational}"},
+		{"kusama bool pays fee", registry.ForSpec("Kusama", "kusama", 1040), "DispatchInfo", []byte{0xe8, 0x03, 0, 0, 0x00, 0x01}, "{weight:1000 class:Normal paysFee:true}"},
+		{"u8 ref count", registry.ForSpec("Polkadot", "polkadot", 0), "AccountInfo", accountInfo, "{nonce:5 refcount:2 data:{free:0 reserved:0 miscFrozen:0 feeFrozen:0}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := s.NewReader(tt.data)
+			value, err := tt.registry.Decode(r, "system", tt.typeName)
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if got := formatValue(value); got != tt.expected || r.Remaining() != 0 {
+				t.Errorf("Expected %s, got %s with %d bytes left", tt.expected, got, r.Remaining())
+			}
+		})
+	}
+
+	// The latest definitions don't decode the old DispatchInfo.
+	r := s.NewReader(dispatchInfo)
+	if value, err := registry.Decode(r, "system", "DispatchInfo"); err == nil && r.Remaining() == 0 {
+		t.Errorf("Expected the latest DispatchInfo to differ, got %s", formatValue(value))
+	}
+
+	addresses := []struct {
+		registry *polkadot_scale_schema.Registry
+		expected string
+	}{
+		{registry.ForSpec("Polkadot", "polkadot", 27), "AccountId"},
+		{registry.ForSpec("Polkadot", "polkadot", 28), "MultiAddress"},
+		{registry.ForSpec("Kusama", "kusama", 1045), "LookupSource"},
+		{registry.ForSpec("Kusama", "kusama", 2027), "AccountId"},
+		{registry.ForSpec("Kusama", "kusama", 2028), "MultiAddress"},
+		{registry.ForSpec("Westend", "westend", 0), "MultiAddress"},
+	}
+	for _, tt := range addresses {
+		lazy, err := tt.registry.Lookup("runtime", "Address")
+		if err != nil {
+			t.Fatalf("Lookup failed: %v", err)
+		}
+		if typ, err := lazy.ToScaleType(); err != nil || typ.Kind != s.KindRef || *typ.Ref != tt.expected {
+			t.Errorf("Address = %+v, %v, want %s", typ, err, tt.expected)
+		}
+	}
+}
+
+func TestEmbeddedSchemaRoundTrip(t *testing.T) {
+	schema := polkadot_scale_schema.EmbeddedSchema()
+	value, err := polkadot_scale_schema.ParseJSON(schema)
+	if err != nil {
+		t.Fatalf("Failed to parse schema.json: %v", err)
+	}
+	output, err := polkadot_scale_schema.MarshalIndentJSON(value, "  ")
+	if err != nil {
+		t.Fatalf("Failed to encode schema.json: %v", err)
+	}
+	if !bytes.Equal(output, schema) {
+		t.Error("Encoding schema.json doesn't reproduce it")
+	}
+}
+
+func TestRegistryGenerics(t *testing.T) {
+	schema, err := polkadot_scale_schema.ParseJSON([]byte(`{
+		"runtime": {
+			"Balance": "u32",
+			"Pair<A, B>": "(A, B)",
+			"Entry<K>": {"key": "K", "value": "Balance"},
+			"Heartbeat": "u8",
+			"DispatchError": {"_enum": ["Other", "BadOrigin"]}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	registry := polkadot_scale_schema.NewRegistry()
+	if err := polkadot_scale_schema.LoadFromObject(registry, polkadot_scale_schema.Scope{}, schema.(*polkadot_scale_schema.Object)); err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+
+	tests := []struct {
+		typeName string
+		data     []byte
+		expected string
+		wantErr  bool
+	}{
+		{"Vec<Pair<u8, u16>>", []byte{0x04, 0x01, 0x02, 0x00}, "[[1 2]]", false},
+		{"Entry<Pair<u8, u8>>", []byte{0x01, 0x02, 0x03, 0, 0, 0}, "{key:[1 2] value:3}", false},
+		{"Pair<u8>", []byte{0x01, 0x02}, "", true},
+		{"Heartbeat<T::BlockNumber>", []byte{0x07}, "7", false},
+		{"Result<(), DispatchError>", []byte{0x01, 0x01}, "Err(BadOrigin)", false},
+		{"Result<(), DispatchError>", []byte{0x00}, "Ok([])", false},
+		{"Result<(), DispatchError>", []byte{0x02}, "", true},
+		{"BTreeMap<u8, Balance>", []byte{0x04, 0x09, 0x0a, 0, 0, 0}, "[[9 10]]", false},
+		{"BTreeSet<u16>", []byte{0x08, 0x01, 0x00, 0x02, 0x00}, "[1 2]", false},
+		{"Range<Balance>", []byte{0x01, 0, 0, 0, 0x02, 0, 0, 0}, "{start:1 end:2}", false},
+		{"WrapperOpaque<Balance>", []byte{0x10, 0x05, 0, 0, 0}, "5", false},
+		{"WrapperOpaque<Balance>", []byte{0x14, 0x05, 0, 0, 0, 0}, "", true},
+		{"WrapperKeepOpaque<Balance>", []byte{0x10, 0x05, 0, 0, 0}, "0x05000000", false},
+		{"Option<Compact<Balance>>", []byte{0x01, 0x01, 0x01}, "Some(64)", false},
+		{"Option<Balance>", []byte{0x00}, "None", false},
+		{"BitVec", []byte{0x0c, 0x05}, "0b101", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typeName, func(t *testing.T) {
+			r := s.NewReader(tt.data)
+			value, err := registry.Decode(r, "runtime", tt.typeName)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %s", formatValue(value))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if got := formatValue(value); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+
+			encoded, err := registry.Encode(value, "runtime", tt.typeName)
+			if err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+			if !bytes.Equal(encoded, tt.data) {
+				t.Errorf("Round trip: expected %x, got %x", tt.data, encoded)
+			}
+			if r.Remaining() != 0 {
+				t.Errorf("%d bytes left", r.Remaining())
+			}
+		})
+	}
+}
+
+// formatValue prints a value compactly: structs as {name:value ...}, variants as Name or
+// Name(fields), and options as None or Some(value).
+func formatValue(value s.Value) string {
+	switch value.Kind {
+	case s.ValueKindInt, s.ValueKindCompact:
+		return value.Int.String()
+	case s.ValueKindBool:
+		return fmt.Sprint(value.Bool)
+	case s.ValueKindBytes:
+		return fmt.Sprintf("0x%x", value.Bytes)
+	case s.ValueKindText:
+		return value.Text
+	case s.ValueKindList:
+		items := make([]string, len(value.List))
+		for i, item := range value.List {
+			items[i] = formatValue(item)
+		}
+		return "[" + strings.Join(items, " ") + "]"
+	case s.ValueKindStruct:
+		return "{" + formatFields(value.Fields) + "}"
+	case s.ValueKindVariant:
+		if value.Variant.Fields == nil {
+			return value.Variant.Name
+		}
+		return value.Variant.Name + "(" + formatFields(value.Variant.Fields) + ")"
+	case s.ValueKindOption:
+		if value.Option == nil {
+			return "None"
+		}
+		return "Some(" + formatValue(*value.Option) + ")"
+	case s.ValueKindBitSequence:
+		bits := make([]byte, len(value.Bits))
+		for i, bit := range value.Bits {
+			bits[i] = '0'
+			if bit {
+				bits[i] = '1'
+			}
+		}
+		return "0b" + string(bits)
+	default:
+		return "null"
+	}
+}
+
+func formatFields(fields []s.Field) string {
+	items := make([]string, len(fields))
+	for i, field := range fields {
+		items[i] = formatValue(field.Value)
+		if field.Name != "" {
+			items[i] = field.Name + ":" + items[i]
+		}
+	}
+	return strings.Join(items, " ")
+}
@@ -0,0 +1,146 @@
+package polkadot_scale_schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Object is a JSON object that keeps the order of its keys, which in type definitions is the order
+// of struct fields, enum variants and set flags.
+type Object struct {
+	Keys   []string
+	Values map[string]any
+}
+
+// Get returns the value of a key.
+func (o *Object) Get(key string) (any, bool) {
+	value, ok := o.Values[key]
+	return value, ok
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (o *Object) UnmarshalJSON(data []byte) error {
+	value, err := ParseJSON(data)
+	if err != nil {
+		return err
+	}
+	obj, ok := value.(*Object)
+	if !ok {
+		return fmt.Errorf("expected a JSON object, got %T", value)
+	}
+	*o = *obj
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, writing the keys in order. Like the JSON polkadot.js
+// writes, and unlike json.Marshal, it doesn't escape <, > and & in strings.
+func (o *Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	buf.WriteByte('{')
+	for i, key := range o.Keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := enc.Encode(key); err != nil {
+			return nil, err
+		}
+		buf.WriteByte(':')
+		if err := enc.Encode(o.Values[key]); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	buf.WriteByte('}')
+	// Encode ends each value with a newline, which Compact drops.
+	var out bytes.Buffer
+	if err := json.Compact(&out, buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// MarshalIndentJSON encodes a value like the JSON.stringify(value, null, indent) of polkadot.js,
+// keeping the order of objects and not escaping <, > and &.
+func MarshalIndentJSON(value any, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// ParseJSON decodes JSON like json.Unmarshal into an any, except that objects become *Object.
+func ParseJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	value, err := parseJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return value, nil
+}
+
+func parseJSONValue(dec *json.Decoder) (any, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		obj := &Object{Values: make(map[string]any)}
+		for dec.More() {
+			keyToken, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyToken.(string)
+			value, err := parseJSONValue(dec)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			if _, exists := obj.Values[key]; !exists {
+				obj.Keys = append(obj.Keys, key)
+			}
+			obj.Values[key] = value
+		}
+		_, err := dec.Token() // }
+		return obj, err
+
+	case json.Delim('['):
+		list := []any{}
+		for dec.More() {
+			value, err := parseJSONValue(dec)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", len(list), err)
+			}
+			list = append(list, value)
+		}
+		_, err := dec.Token() // ]
+		return list, err
+
+	default:
+		return token, nil
+	}
+}
+
+// sortedObject makes an Object of a map, with its keys sorted since a map has no order.
+func sortedObject(m map[string]any) *Object {
+	obj := &Object{Values: m}
+	for key := range m {
+		obj.Keys = append(obj.Keys, key)
+	}
+	sort.Strings(obj.Keys)
+	return obj
+}
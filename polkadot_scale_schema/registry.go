@@ -2,14 +2,21 @@ package polkadot_scale_schema
 
 import (
 	"fmt"
-	"submarine/rust_types"
-	"submarine/rust_types/sanitizer"
+	"strings"
 	s "submarine/scale"
 	"sync"
 )
 
 type Registry struct {
 	types map[string][]TypeEntry
+	// spec is the runtime a view made by ForSpec decodes for, nil for the registry itself.
+	spec *runtimeSpec
+}
+
+type runtimeSpec struct {
+	chain    string
+	specName string
+	version  uint32
 }
 
 func NewRegistry() *Registry {
@@ -20,6 +27,7 @@ func NewRegistry() *Registry {
 
 type LazyType struct {
 	raw    string
+	params []string // type parameters of a generic definition
 	parsed *s.Type
 	mutex  sync.Mutex
 }
@@ -38,24 +46,127 @@ func (lt *LazyType) ToScaleType() (*s.Type, error) {
 		return lt.parsed, nil
 	}
 
-	rustType := sanitizer.ParseAndSanitize(lt.raw)
-	scaleType, errSpan := rust_types.ToScaleSchema(&rustType)
-	if errSpan != nil {
-		return nil, fmt.Errorf("failed to convert to scale type: %v", errSpan)
+	parsed, err := ParseTypeName(lt.raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(lt.params) > 0 {
+		parsed = &s.Type{Kind: s.KindGeneric, Generic: &s.Generic{Params: lt.params, Type: parsed}}
 	}
 
-	lt.parsed = &scaleType
+	lt.parsed = parsed
 	return lt.parsed, nil
 }
 
+// setParams makes the type a generic definition with the given parameters.
+func (lt *LazyType) setParams(params []string) {
+	lt.params = params
+	if lt.parsed != nil && len(params) > 0 {
+		lt.parsed = &s.Type{Kind: s.KindGeneric, Generic: &s.Generic{Params: params, Type: lt.parsed}}
+	}
+}
+
 type TypeEntry struct {
-	Module string
+	Module string // empty for definitions that apply in every module, like those of types bundles
 	Type   *LazyType
+	// Scope restricts the definition to some runtimes. Scoped definitions are only visible in
+	// the views returned by ForSpec, where they override unscoped ones.
+	Scope Scope
+	// Local definitions, like the aliases of types bundles, are only visible in their module.
+	Local bool
+}
+
+// Scope restricts a definition to the runtimes of a chain or spec name and a range of spec
+// versions, like the typesChain and typesSpec overrides of polkadot.js.
+type Scope struct {
+	Chain    string     // e.g. "Kusama"; empty for any chain
+	SpecName string     // e.g. "kusama"; empty for any spec name
+	Specs    *SpecRange // nil for any spec version
+	// Override marks the definitions a user gives for every runtime, like the types option of the
+	// polkadot.js API, which win over the definitions of any other scope, as they do in polkadot.js.
+	Override bool
+}
+
+// SpecRange is an inclusive range of spec versions. Use math.MaxUint32 for no upper bound.
+type SpecRange struct {
+	Min uint32
+	Max uint32
+}
+
+// IsZero reports whether the scope applies to every runtime without overriding other definitions.
+func (scope Scope) IsZero() bool {
+	return scope == Scope{}
+}
+
+// everyRuntime reports whether the scope applies to every runtime, as those the registry itself sees.
+func (scope Scope) everyRuntime() bool {
+	return scope.Chain == "" && scope.SpecName == "" && scope.Specs == nil
+}
+
+func (scope Scope) matches(spec runtimeSpec) bool {
+	if scope.Chain != "" && !strings.EqualFold(scope.Chain, spec.chain) {
+		return false
+	}
+	if scope.SpecName != "" && !strings.EqualFold(scope.SpecName, spec.specName) {
+		return false
+	}
+	return scope.Specs == nil || (scope.Specs.Min <= spec.version && spec.version <= scope.Specs.Max)
+}
+
+// specificity ranks the scopes matching a runtime: overrides win over everything else, then those
+// with a spec range win over those without, then chain overrides win over spec name ones, which win
+// over unscoped definitions.
+func (scope Scope) specificity() int {
+	if scope.Override {
+		return 8
+	}
+	n := 0
+	if scope.SpecName != "" {
+		n++
+	}
+	if scope.Chain != "" {
+		n += 2
+	}
+	if scope.Specs != nil {
+		n += 4
+	}
+	return n
+}
+
+// ForSpec returns a view of the registry for the runtime of a chain, e.g. "Kusama", with a spec
+// name, e.g. "kusama", at a spec version, in which the definitions scoped to that runtime override
+// the others. The view shares the registry's types.
+func (r *Registry) ForSpec(chain, specName string, specVersion uint32) *Registry {
+	return &Registry{types: r.types, spec: &runtimeSpec{chain, specName, specVersion}}
+}
+
+// visible returns the entries of a type name the registry or view sees from a module: those of the
+// most specific scope that applies to every runtime for the registry, or that matches its runtime
+// for a view.
+func (r *Registry) visible(moduleName string, entries []TypeEntry) []TypeEntry {
+	var out []TypeEntry
+	best := -1
+	for _, entry := range entries {
+		if entry.Local && entry.Module != moduleName {
+			continue
+		}
+		if r.spec == nil && !entry.Scope.everyRuntime() || r.spec != nil && !entry.Scope.matches(*r.spec) {
+			continue
+		}
+		switch specificity := entry.Scope.specificity(); {
+		case specificity > best:
+			best = specificity
+			out = []TypeEntry{entry}
+		case specificity == best:
+			out = append(out, entry)
+		}
+	}
+	return out
 }
 
 func (r *Registry) Lookup(moduleName, typeName string) (*LazyType, error) {
-	entries, exists := r.types[typeName]
-	if !exists {
+	entries := r.visible(moduleName, r.types[typeName])
+	if len(entries) == 0 {
 		return nil, fmt.Errorf("type %s not found", typeName)
 	}
 
@@ -63,9 +174,10 @@ func (r *Registry) Lookup(moduleName, typeName string) (*LazyType, error) {
 		return entries[0].Type, nil
 	}
 
-	for _, entry := range entries {
-		if entry.Module == moduleName {
-			return entry.Type, nil
+	// Of several definitions in a module, including those for every module, the one added last wins.
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Module == moduleName || entries[i].Module == "" {
+			return entries[i].Type, nil
 		}
 	}
 
@@ -80,7 +192,7 @@ func (r *Registry) GetAllTypes(typeName string) ([]TypeEntry, bool) {
 func (r *Registry) GetModuleTypes(moduleName string) map[string]*LazyType {
 	result := make(map[string]*LazyType)
 	for typeName, entries := range r.types {
-		for _, entry := range entries {
+		for _, entry := range r.visible(moduleName, entries) {
 			if entry.Module == moduleName {
 				result[typeName] = entry.Type
 			}
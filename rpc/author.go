@@ -0,0 +1,149 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+type TransactionStatusKind string
+
+const (
+	TxStatusFuture          TransactionStatusKind = "future"
+	TxStatusReady           TransactionStatusKind = "ready"
+	TxStatusBroadcast       TransactionStatusKind = "broadcast"
+	TxStatusInBlock         TransactionStatusKind = "inBlock"
+	TxStatusRetracted       TransactionStatusKind = "retracted"
+	TxStatusFinalityTimeout TransactionStatusKind = "finalityTimeout"
+	TxStatusFinalized       TransactionStatusKind = "finalized"
+	TxStatusUsurped         TransactionStatusKind = "usurped"
+	TxStatusDropped         TransactionStatusKind = "dropped"
+	TxStatusInvalid         TransactionStatusKind = "invalid"
+)
+
+// TransactionStatus is a status update of a watched transaction.
+type TransactionStatus struct {
+	Kind TransactionStatusKind
+	// Peers the transaction was broadcast to, for broadcast.
+	Peers []string
+	// Block hash for inBlock, retracted, finalityTimeout and finalized.
+	// Hash of the replacing transaction for usurped.
+	Hash string
+}
+
+// IsFinal reports whether the node sends no more updates after this status.
+func (s TransactionStatus) IsFinal() bool {
+	switch s.Kind {
+	case TxStatusFinalized, TxStatusFinalityTimeout, TxStatusUsurped, TxStatusDropped, TxStatusInvalid:
+		return true
+	default:
+		return false
+	}
+}
+
+// UnmarshalJSON decodes the status format of author_extrinsicUpdate, where statuses without
+// data are plain strings and the others are single-key objects like {"inBlock": "0x.."}.
+func (s *TransactionStatus) UnmarshalJSON(data []byte) error {
+	var simple string
+	if err := json.Unmarshal(data, &simple); err == nil {
+		switch kind := TransactionStatusKind(simple); kind {
+		case TxStatusFuture, TxStatusReady, TxStatusDropped, TxStatusInvalid:
+			*s = TransactionStatus{Kind: kind}
+			return nil
+		default:
+			return fmt.Errorf("unknown transaction status: %s", simple)
+		}
+	}
+
+	var complex map[string]json.RawMessage
+	if err := json.Unmarshal(data, &complex); err != nil {
+		return fmt.Errorf("transaction status: %w", err)
+	}
+	if len(complex) != 1 {
+		return fmt.Errorf("transaction status: expected a single key, got %d", len(complex))
+	}
+
+	for key, value := range complex {
+		kind := TransactionStatusKind(key)
+		switch kind {
+		case TxStatusBroadcast:
+			*s = TransactionStatus{Kind: kind}
+			return json.Unmarshal(value, &s.Peers)
+		case TxStatusInBlock, TxStatusRetracted, TxStatusFinalityTimeout, TxStatusFinalized, TxStatusUsurped:
+			*s = TransactionStatus{Kind: kind}
+			return json.Unmarshal(value, &s.Hash)
+		default:
+			return fmt.Errorf("unknown transaction status: %s", key)
+		}
+	}
+	panic("unreachable")
+}
+
+// SubmitExtrinsic submits a signed extrinsic to the transaction pool and returns its hash.
+func (client *RPC) SubmitExtrinsic(extrinsic []byte) (string, error) {
+	return client.Send("author_submitExtrinsic", []any{"0x" + hex.EncodeToString(extrinsic)}).AsString()
+}
+
+// TransactionWatch delivers the status updates of a transaction submitted with SubmitAndWatch.
+type TransactionWatch struct {
+	sub      *Subscription
+	statuses chan TransactionStatus
+	err      error
+}
+
+// SubmitAndWatch submits a signed extrinsic and follows it through the transaction pool and the chain.
+func (client *RPC) SubmitAndWatch(extrinsic []byte) (*TransactionWatch, error) {
+	sub, err := client.Subscribe(
+		"author_submitAndWatchExtrinsic",
+		[]any{"0x" + hex.EncodeToString(extrinsic)},
+		"author_unwatchExtrinsic",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	watch := &TransactionWatch{
+		sub:      sub,
+		statuses: make(chan TransactionStatus),
+	}
+	go watch.run()
+	return watch, nil
+}
+
+func (w *TransactionWatch) run() {
+	defer close(w.statuses)
+	for msg := range w.sub.C() {
+		var status TransactionStatus
+		if err := json.Unmarshal(msg, &status); err != nil {
+			w.err = err
+			w.sub.Unsubscribe()
+			return
+		}
+
+		select {
+		case w.statuses <- status:
+		case <-w.sub.done:
+			return
+		}
+
+		if status.IsFinal() {
+			w.sub.end()
+		}
+	}
+}
+
+// Status returns the channel of status updates. It is closed after a final status,
+// after Unsubscribe, or when the connection is lost.
+func (w *TransactionWatch) Status() <-chan TransactionStatus {
+	return w.statuses
+}
+
+// Err returns the error that ended the watch early, if any. Only valid after Status is closed.
+func (w *TransactionWatch) Err() error {
+	return w.err
+}
+
+// Unsubscribe stops watching the transaction.
+func (w *TransactionWatch) Unsubscribe() error {
+	return w.sub.Unsubscribe()
+}
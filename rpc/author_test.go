@@ -0,0 +1,175 @@
+package rpc_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	. "submarine/rpc"
+	"submarine/rpc/rpctest"
+	"testing"
+)
+
+func newClient(t *testing.T, node *rpctest.Node) *RPC {
+	t.Helper()
+	client, err := NewRPC(node.URL())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestTransactionStatusUnmarshal(t *testing.T) {
+	tests := []struct {
+		json string
+		want TransactionStatus
+	}{
+		{`"future"`, TransactionStatus{Kind: TxStatusFuture}},
+		{`"ready"`, TransactionStatus{Kind: TxStatusReady}},
+		{`{"broadcast":["peer1","peer2"]}`, TransactionStatus{Kind: TxStatusBroadcast, Peers: []string{"peer1", "peer2"}}},
+		{`{"inBlock":"0x01"}`, TransactionStatus{Kind: TxStatusInBlock, Hash: "0x01"}},
+		{`{"retracted":"0x02"}`, TransactionStatus{Kind: TxStatusRetracted, Hash: "0x02"}},
+		{`{"finalityTimeout":"0x03"}`, TransactionStatus{Kind: TxStatusFinalityTimeout, Hash: "0x03"}},
+		{`{"finalized":"0x04"}`, TransactionStatus{Kind: TxStatusFinalized, Hash: "0x04"}},
+		{`{"usurped":"0x05"}`, TransactionStatus{Kind: TxStatusUsurped, Hash: "0x05"}},
+		{`"dropped"`, TransactionStatus{Kind: TxStatusDropped}},
+		{`"invalid"`, TransactionStatus{Kind: TxStatusInvalid}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			var got TransactionStatus
+			if err := json.Unmarshal([]byte(tt.json), &got); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	for _, invalid := range []string{`"pending"`, `{"inBlock":"0x01","ready":null}`, `{"unknown":"0x01"}`, `42`} {
+		var got TransactionStatus
+		if err := json.Unmarshal([]byte(invalid), &got); err == nil {
+			t.Errorf("expected an error for %s, got %+v", invalid, got)
+		}
+	}
+}
+
+func TestSubmitExtrinsic(t *testing.T) {
+	node := rpctest.NewNode()
+	defer node.Close()
+
+	var submitted string
+	node.Handle("author_submitExtrinsic", func(params []json.RawMessage) (any, error) {
+		json.Unmarshal(params[0], &submitted)
+		return "0xabcd", nil
+	})
+
+	client := newClient(t, node)
+	hash, err := client.SubmitExtrinsic([]byte{0x01, 0x02})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if hash != "0xabcd" {
+		t.Errorf("hash = %s, want 0xabcd", hash)
+	}
+	if submitted != "0x0102" {
+		t.Errorf("submitted %s, want 0x0102", submitted)
+	}
+}
+
+func TestSubmitExtrinsicRejected(t *testing.T) {
+	node := rpctest.NewNode()
+	defer node.Close()
+	node.Handle("author_submitExtrinsic", func([]json.RawMessage) (any, error) {
+		return nil, &rpctest.Error{Code: 1010, Message: "Invalid Transaction"}
+	})
+
+	client := newClient(t, node)
+	_, err := client.SubmitExtrinsic([]byte{0x01})
+	var rpcErr *RpcError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != 1010 {
+		t.Fatalf("expected RPC error 1010, got %v", err)
+	}
+}
+
+func TestSubmitAndWatch(t *testing.T) {
+	node := rpctest.NewNode()
+	defer node.Close()
+	node.HandleSubscription("author_submitAndWatchExtrinsic", "author_extrinsicUpdate", func([]json.RawMessage) ([]any, error) {
+		return []any{
+			"ready",
+			map[string]any{"broadcast": []string{"peer"}},
+			map[string]any{"inBlock": "0xb1"},
+			map[string]any{"retracted": "0xb1"},
+			map[string]any{"inBlock": "0xb2"},
+			map[string]any{"finalized": "0xb2"},
+		}, nil
+	})
+
+	client := newClient(t, node)
+	watch, err := client.SubmitAndWatch([]byte{0x01})
+	if err != nil {
+		t.Fatalf("submit and watch: %v", err)
+	}
+
+	var got []TransactionStatus
+	for status := range watch.Status() {
+		got = append(got, status)
+	}
+	if err := watch.Err(); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+
+	want := []TransactionStatus{
+		{Kind: TxStatusReady},
+		{Kind: TxStatusBroadcast, Peers: []string{"peer"}},
+		{Kind: TxStatusInBlock, Hash: "0xb1"},
+		{Kind: TxStatusRetracted, Hash: "0xb1"},
+		{Kind: TxStatusInBlock, Hash: "0xb2"},
+		{Kind: TxStatusFinalized, Hash: "0xb2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("statuses:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestSubmitAndWatchInvalidStatus(t *testing.T) {
+	node := rpctest.NewNode()
+	defer node.Close()
+	node.HandleResult("author_unwatchExtrinsic", true)
+	node.HandleSubscription("author_submitAndWatchExtrinsic", "author_extrinsicUpdate", func([]json.RawMessage) ([]any, error) {
+		return []any{"ready", "bogus", "future"}, nil
+	})
+
+	client := newClient(t, node)
+	watch, err := client.SubmitAndWatch([]byte{0x01})
+	if err != nil {
+		t.Fatalf("submit and watch: %v", err)
+	}
+
+	var got []TransactionStatus
+	for status := range watch.Status() {
+		got = append(got, status)
+	}
+	if len(got) != 1 || got[0].Kind != TxStatusReady {
+		t.Errorf("statuses = %+v, want only ready", got)
+	}
+	if watch.Err() == nil {
+		t.Error("expected an error for the unknown status")
+	}
+}
+
+func TestSubmitAndWatchRejected(t *testing.T) {
+	node := rpctest.NewNode()
+	defer node.Close()
+	node.HandleSubscription("author_submitAndWatchExtrinsic", "author_extrinsicUpdate", func([]json.RawMessage) ([]any, error) {
+		return nil, &rpctest.Error{Code: 1012, Message: "Transaction is temporarily banned"}
+	})
+
+	client := newClient(t, node)
+	if _, err := client.SubmitAndWatch([]byte{0x01}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,65 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Justification is a finality proof of a block, encoded by the consensus engine that produced it.
+// The node sends it as an [engineId, data] pair.
+type Justification struct {
+	EngineId [4]byte
+	Data     []byte
+}
+
+func (j *Justification) UnmarshalJSON(data []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return fmt.Errorf("justification: %w", err)
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("justification: expected an [engineId, data] pair, got %d elements", len(pair))
+	}
+
+	engineId, err := unmarshalBytes(pair[0])
+	if err != nil {
+		return fmt.Errorf("justification engine id: %w", err)
+	}
+	if len(engineId) != 4 {
+		return fmt.Errorf("justification engine id: expected 4 bytes, got %d", len(engineId))
+	}
+	j.EngineId = [4]byte(engineId)
+
+	if j.Data, err = unmarshalBytes(pair[1]); err != nil {
+		return fmt.Errorf("justification data: %w", err)
+	}
+	return nil
+}
+
+func (j Justification) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{bytesToInts(j.EngineId[:]), bytesToInts(j.Data)})
+}
+
+// unmarshalBytes accepts bytes as an array of numbers, the serde encoding used by the node, or as
+// a 0x-prefixed hex string.
+func unmarshalBytes(data []byte) ([]byte, error) {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	}
+	var numbers []byte
+	if err := json.Unmarshal(data, &numbers); err != nil {
+		return nil, err
+	}
+	return numbers, nil
+}
+
+func bytesToInts(b []byte) []int {
+	out := make([]int, len(b))
+	for i, v := range b {
+		out[i] = int(v)
+	}
+	return out
+}
@@ -12,6 +12,8 @@ type BlockHeader struct {
 
 type SignedBlock struct {
 	Block Block `json:"block"`
+	// Justifications are the finality proofs of the block, if the node kept any.
+	Justifications []Justification `json:"justifications"`
 }
 
 type Block struct {
@@ -20,9 +22,10 @@ type Block struct {
 }
 
 type RuntimeVersion struct {
-	SpecName    string `json:"specName"`
-	ImplName    string `json:"implName"`
-	Apis        []any  `json:"apis"`
-	SpecVersion int    `json:"specVersion"`
+	SpecName           string `json:"specName"`
+	ImplName           string `json:"implName"`
+	Apis               []any  `json:"apis"`
+	SpecVersion        int    `json:"specVersion"`
+	TransactionVersion int    `json:"transactionVersion"`
 }
 
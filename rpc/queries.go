@@ -63,3 +63,54 @@ func (client *RPC) GetEvents(blockHash string) []byte {
 	}
 	return eventsBytes
 }
+
+// GetHeader returns the header of the block with the given hash.
+func (client *RPC) GetHeader(blockHash string) (*BlockHeader, error) {
+	var header BlockHeader
+	if err := client.Send("chain_getHeader", []any{blockHash}).As(&header); err != nil {
+		return nil, fmt.Errorf("get header %s: %w", blockHash, err)
+	}
+	return &header, nil
+}
+
+// GetBlock returns the block with the given hash.
+func (client *RPC) GetBlock(blockHash string) (*SignedBlock, error) {
+	var block SignedBlock
+	if err := client.Send("chain_getBlock", []any{blockHash}).As(&block); err != nil {
+		return nil, fmt.Errorf("get block %s: %w", blockHash, err)
+	}
+	return &block, nil
+}
+
+// GetStorage returns the raw value stored under key at the given block, or nil if there is none.
+func (client *RPC) GetStorage(key string, blockHash string) ([]byte, error) {
+	var valueHex *string
+	if err := client.Send("state_getStorage", []any{key, blockHash}).As(&valueHex); err != nil {
+		return nil, fmt.Errorf("get storage %s: %w", key, err)
+	}
+	if valueHex == nil {
+		return nil, nil
+	}
+
+	value, err := hex.DecodeString(strings.TrimPrefix(*valueHex, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("decode storage hex: %w", err)
+	}
+	return value, nil
+}
+
+// ReadProof is the result of state_getReadProof: the trie nodes proving the values of some storage
+// keys against the state root of a block.
+type ReadProof struct {
+	At    string   `json:"at"`
+	Proof []string `json:"proof"`
+}
+
+// GetReadProof returns the proof of the storage values under keys at the given block.
+func (client *RPC) GetReadProof(keys []string, blockHash string) (*ReadProof, error) {
+	var proof ReadProof
+	if err := client.Send("state_getReadProof", []any{keys, blockHash}).As(&proof); err != nil {
+		return nil, fmt.Errorf("get read proof: %w", err)
+	}
+	return &proof, nil
+}
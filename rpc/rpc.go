@@ -15,12 +15,18 @@ import (
 // Base Types
 
 type RPC struct {
-	conn      *websocket.Conn
-	idCounter atomic.Uint64
-	mu        sync.RWMutex
-	pending   map[uint64]chan *json.RawMessage
-	ctx       context.Context
-	cancel    context.CancelFunc
+	conn          *websocket.Conn
+	idCounter     atomic.Uint64
+	mu            sync.RWMutex
+	pending       map[uint64]*pendingCall
+	subscriptions map[string]*Subscription
+	ctx           context.Context
+	cancel        context.CancelFunc
+}
+
+type pendingCall struct {
+	ch  chan *RpcResponse
+	sub *Subscription // set for calls that start a subscription
 }
 
 type RpcRequest struct {
@@ -45,10 +51,19 @@ func (e *RpcError) Error() string {
 	return e.Message
 }
 
+// RpcNotification is a message pushed by the node for an active subscription.
+type RpcNotification struct {
+	Method string `json:"method"`
+	Params struct {
+		Subscription json.RawMessage `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+	} `json:"params"`
+}
+
 // Pending Request
 
 type PendingRequest struct {
-	ch chan *json.RawMessage
+	ch chan *RpcResponse
 }
 
 func (p *PendingRequest) AsString() (string, error) {
@@ -61,11 +76,14 @@ func (p *PendingRequest) AsString() (string, error) {
 }
 
 func (p *PendingRequest) RawMessage() (*json.RawMessage, error) {
-	rawResult, ok := <-p.ch
-	if !ok || rawResult == nil {
+	resp, ok := <-p.ch
+	if !ok || resp == nil {
 		return nil, errors.New("request failed or connection closed")
 	}
-	return rawResult, nil
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return &resp.Result, nil
 }
 
 func (p *PendingRequest) As(value any) error {
@@ -88,10 +106,11 @@ func NewRPC(url string) (*RPC, error) {
 	}
 
 	client := &RPC{
-		conn:    conn,
-		pending: make(map[uint64]chan *json.RawMessage),
-		ctx:     ctx,
-		cancel:  cancel,
+		conn:          conn,
+		pending:       make(map[uint64]*pendingCall),
+		subscriptions: make(map[string]*Subscription),
+		ctx:           ctx,
+		cancel:        cancel,
 	}
 
 	// Start the background loop to read messages
@@ -109,43 +128,74 @@ func (r *RPC) readLoop() {
 			_, message, err := r.conn.ReadMessage()
 			if err != nil {
 				log.Printf("read error: %v", err)
-				// Close all pending channels on error
-				r.mu.Lock()
-				for id, ch := range r.pending {
-					close(ch)
-					delete(r.pending, id)
-				}
-				r.mu.Unlock()
+				r.closeAll()
 				return
 			}
 
+			var notification RpcNotification
+			if err := json.Unmarshal(message, &notification); err == nil && notification.Method != "" {
+				r.dispatchNotification(&notification)
+				continue
+			}
+
 			var resp RpcResponse
 			if err := json.Unmarshal(message, &resp); err != nil {
 				log.Printf("unmarshal error: %v", err)
 				continue
 			}
 
-			if resp.Error != nil {
-				log.Printf("RPC Error: %s", resp.Error.Error())
+			r.mu.Lock()
+			call, ok := r.pending[resp.ID]
+			if ok {
+				delete(r.pending, resp.ID)
+				// Register the subscription before any of its notifications are read.
+				if call.sub != nil && resp.Error == nil {
+					call.sub.id = subscriptionKey(resp.Result)
+					r.subscriptions[call.sub.id] = call.sub
+				}
 			}
-
-			r.mu.RLock()
-			ch, ok := r.pending[resp.ID]
-			r.mu.RUnlock()
+			r.mu.Unlock()
 
 			if ok {
-				ch <- &resp.Result
-				// Clean up the map
-				r.mu.Lock()
-				delete(r.pending, resp.ID)
-				close(ch)
-				r.mu.Unlock()
+				call.ch <- &resp
+				close(call.ch)
 			}
 		}
 	}
 }
 
+func (r *RPC) dispatchNotification(notification *RpcNotification) {
+	r.mu.RLock()
+	sub, ok := r.subscriptions[subscriptionKey(notification.Params.Subscription)]
+	r.mu.RUnlock()
+
+	if !ok {
+		log.Printf("notification for unknown subscription %s", notification.Params.Subscription)
+		return
+	}
+	sub.push(notification.Params.Result)
+}
+
+// closeAll fails all pending requests and ends all subscriptions.
+func (r *RPC) closeAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, call := range r.pending {
+		close(call.ch)
+		delete(r.pending, id)
+	}
+	for id, sub := range r.subscriptions {
+		sub.close()
+		delete(r.subscriptions, id)
+	}
+}
+
 func (r *RPC) Send(method string, params []any) *PendingRequest {
+	return r.send(method, params, nil)
+}
+
+func (r *RPC) send(method string, params []any, sub *Subscription) *PendingRequest {
 	id := r.idCounter.Add(1)
 	request := RpcRequest{
 		ID:      id,
@@ -160,11 +210,11 @@ func (r *RPC) Send(method string, params []any) *PendingRequest {
 		log.Fatalf("failed to marshal request: %v", err)
 	}
 
-	respChan := make(chan *json.RawMessage, 1)
+	respChan := make(chan *RpcResponse, 1)
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.pending[id] = respChan
+	r.pending[id] = &pendingCall{ch: respChan, sub: sub}
 
 	if err := r.conn.WriteMessage(websocket.TextMessage, jsonReq); err != nil {
 		log.Printf("write error: %v", err)
@@ -202,11 +252,5 @@ func SendMany[T any](
 func (r *RPC) Close() {
 	r.cancel()
 	r.conn.Close()
-
-	r.mu.Lock()
-	for id, ch := range r.pending {
-		close(ch)
-		delete(r.pending, id)
-	}
-	r.mu.Unlock()
+	r.closeAll()
 }
@@ -0,0 +1,208 @@
+// Package rpctest provides a scripted stand-in for a Substrate node's websocket JSON-RPC server,
+// for testing code built on the rpc package without a live chain.
+package rpctest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// Handler returns the result of a method call, or an error to send back as a JSON-RPC error.
+type Handler func(params []json.RawMessage) (any, error)
+
+// SubscriptionHandler returns the notifications of a new subscription. They are pushed to the
+// client, in order, right after the call's response.
+type SubscriptionHandler func(params []json.RawMessage) ([]any, error)
+
+// Error is a JSON-RPC error with a code. Other errors returned by handlers are sent with code -32000.
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Node is a websocket JSON-RPC server that answers with registered handlers.
+type Node struct {
+	server *httptest.Server
+
+	mu            sync.Mutex
+	handlers      map[string]Handler
+	subscriptions map[string]subscriptionMethod
+	calls         []string
+	nextSubID     int
+}
+
+type subscriptionMethod struct {
+	notificationMethod string
+	handler            SubscriptionHandler
+}
+
+var upgrader = websocket.Upgrader{}
+
+// NewNode starts a node without any handlers. Unknown methods are answered with a "method not found" error.
+func NewNode() *Node {
+	n := &Node{
+		handlers:      make(map[string]Handler),
+		subscriptions: make(map[string]subscriptionMethod),
+	}
+	n.server = httptest.NewServer(http.HandlerFunc(n.serve))
+	return n
+}
+
+// URL returns the websocket URL of the node.
+func (n *Node) URL() string {
+	return "ws" + strings.TrimPrefix(n.server.URL, "http")
+}
+
+// Close shuts the node down, dropping all connections.
+func (n *Node) Close() {
+	n.server.CloseClientConnections()
+	n.server.Close()
+}
+
+// Handle registers the handler of a method.
+func (n *Node) Handle(method string, handler Handler) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.handlers[method] = handler
+}
+
+// HandleResult registers a method that always returns result.
+func (n *Node) HandleResult(method string, result any) {
+	n.Handle(method, func([]json.RawMessage) (any, error) { return result, nil })
+}
+
+// HandleSubscription registers a pubsub method whose notifications are sent with notificationMethod.
+func (n *Node) HandleSubscription(method, notificationMethod string, handler SubscriptionHandler) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.subscriptions[method] = subscriptionMethod{notificationMethod, handler}
+}
+
+// Calls returns the methods called so far, in order.
+func (n *Node) Calls() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.calls...)
+}
+
+type request struct {
+	ID     uint64            `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type response struct {
+	Jsonrpc string `json:"jsonrpc"`
+	ID      uint64 `json:"id"`
+	Result  any    `json:"result,omitempty"`
+	Error   *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+type notification struct {
+	Jsonrpc string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  struct {
+		Subscription string `json:"subscription"`
+		Result       any    `json:"result"`
+	} `json:"params"`
+}
+
+func (n *Node) serve(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var req request
+		if err := json.Unmarshal(message, &req); err != nil {
+			return
+		}
+
+		for _, msg := range n.call(&req) {
+			if err := conn.WriteJSON(msg); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// call returns the messages to send in reply to a request: its response and, for
+// subscriptions, the notifications that follow it.
+func (n *Node) call(req *request) []any {
+	n.mu.Lock()
+	n.calls = append(n.calls, req.Method)
+	handler, isMethod := n.handlers[req.Method]
+	subscription, isSubscription := n.subscriptions[req.Method]
+	n.nextSubID++
+	subID := fmt.Sprintf("sub-%d", n.nextSubID)
+	n.mu.Unlock()
+
+	resp := response{Jsonrpc: "2.0", ID: req.ID}
+
+	switch {
+	case isMethod:
+		result, err := handler(req.Params)
+		if err != nil {
+			setError(&resp, err)
+		} else {
+			resp.Result = result
+		}
+		return []any{resp}
+
+	case isSubscription:
+		results, err := subscription.handler(req.Params)
+		if err != nil {
+			setError(&resp, err)
+			return []any{resp}
+		}
+		resp.Result = subID
+
+		messages := []any{resp}
+		for _, result := range results {
+			var notif notification
+			notif.Jsonrpc = "2.0"
+			notif.Method = subscription.notificationMethod
+			notif.Params.Subscription = subID
+			notif.Params.Result = result
+			messages = append(messages, notif)
+		}
+		return messages
+
+	default:
+		setError(&resp, &Error{Code: -32601, Message: "Method not found"})
+		return []any{resp}
+	}
+}
+
+func setError(resp *response, err error) {
+	code := -32000
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		code = rpcErr.Code
+	}
+	resp.Error = &struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}{code, err.Error()}
+}
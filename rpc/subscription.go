@@ -0,0 +1,134 @@
+package rpc
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// Subscription receives the notifications of a pubsub RPC method, in the order the node sent them.
+// Notifications are queued without limit, so a slow reader never blocks other requests.
+type Subscription struct {
+	client      *RPC
+	id          string
+	unsubscribe string
+
+	mu     sync.Mutex
+	queue  []json.RawMessage
+	closed bool
+	wake   chan struct{}
+	done   chan struct{}
+	ch     chan json.RawMessage
+	once   sync.Once
+}
+
+func newSubscription(client *RPC, unsubscribeMethod string) *Subscription {
+	sub := &Subscription{
+		client:      client,
+		unsubscribe: unsubscribeMethod,
+		wake:        make(chan struct{}, 1),
+		done:        make(chan struct{}),
+		ch:          make(chan json.RawMessage),
+	}
+	go sub.pump()
+	return sub
+}
+
+// Subscribe calls a pubsub method and returns the subscription it started.
+// unsubscribeMethod is called by Unsubscribe to end the subscription on the node.
+func (r *RPC) Subscribe(method string, params []any, unsubscribeMethod string) (*Subscription, error) {
+	sub := newSubscription(r, unsubscribeMethod)
+	if _, err := r.send(method, params, sub).RawMessage(); err != nil {
+		sub.stop()
+		return nil, err
+	}
+	return sub, nil
+}
+
+// C returns the channel of notification results. It is closed when the subscription ends.
+func (s *Subscription) C() <-chan json.RawMessage {
+	return s.ch
+}
+
+// Unsubscribe ends the subscription on the node and closes C without delivering queued notifications.
+func (s *Subscription) Unsubscribe() error {
+	s.client.removeSubscription(s)
+	s.stop()
+	return s.client.Send(s.unsubscribe, []any{s.id}).As(new(bool))
+}
+
+// end stops accepting notifications, for subscriptions that the node has ended by itself.
+// Queued notifications are still delivered.
+func (s *Subscription) end() {
+	s.client.removeSubscription(s)
+	s.close()
+}
+
+func (s *Subscription) push(msg json.RawMessage) {
+	s.mu.Lock()
+	if !s.closed {
+		s.queue = append(s.queue, msg)
+	}
+	s.mu.Unlock()
+	s.signal()
+}
+
+func (s *Subscription) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.signal()
+}
+
+func (s *Subscription) stop() {
+	s.once.Do(func() { close(s.done) })
+}
+
+func (s *Subscription) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Subscription) pump() {
+	defer close(s.ch)
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return
+			}
+			select {
+			case <-s.wake:
+			case <-s.done:
+				return
+			}
+			continue
+		}
+		msg := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		select {
+		case s.ch <- msg:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (r *RPC) removeSubscription(sub *Subscription) {
+	r.mu.Lock()
+	if r.subscriptions[sub.id] == sub {
+		delete(r.subscriptions, sub.id)
+	}
+	r.mu.Unlock()
+}
+
+// subscriptionKey normalizes a subscription id, which nodes send either as a string or a number.
+func subscriptionKey(raw json.RawMessage) string {
+	return strings.Trim(string(raw), `"`)
+}
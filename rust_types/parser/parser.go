@@ -2,6 +2,8 @@ package parser
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"unicode"
 
 	. "submarine/rust_types"
@@ -16,6 +18,56 @@ func NewRustTypesParser(s string) *RustTypesParser {
 	return &RustTypesParser{s: s, pos: 0}
 }
 
+// ParseError is a syntax error in a type, at a byte offset of the input.
+type ParseError struct {
+	Input   string
+	Offset  int
+	Message string
+}
+
+// snippetContext is how many bytes of the input are shown on each side of the error.
+const snippetContext = 40
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("%s at offset %d\n%s", err.Message, err.Offset, err.Snippet())
+}
+
+// Snippet returns the input around the error, with a caret under the offset:
+//
+//	Vec<(u8, u16>
+//	            ^
+func (err *ParseError) Snippet() string {
+	start, end := max(err.Offset-snippetContext, 0), min(err.Offset+snippetContext, len(err.Input))
+	prefix, suffix := "", ""
+	if start > 0 {
+		prefix = "..."
+	}
+	if end < len(err.Input) {
+		suffix = "..."
+	}
+	// Whitespace is flattened so that the caret lines up.
+	line := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return ' '
+		}
+		return r
+	}, err.Input[start:end])
+	caret := strings.Repeat(" ", len(prefix)+err.Offset-start) + "^"
+	return prefix + line + suffix + "\n" + caret
+}
+
+func (p *RustTypesParser) errorf(format string, args ...any) *ParseError {
+	return &ParseError{Input: p.s, Offset: p.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// expected reports a missing token at the current position, quoting what was found instead.
+func (p *RustTypesParser) expected(what string) *ParseError {
+	if p.pos >= len(p.s) {
+		return p.errorf("expected %s, found end of input", what)
+	}
+	return p.errorf("expected %s, found %q", what, p.s[p.pos])
+}
+
 func (p *RustTypesParser) Advance() rune {
 	if p.pos >= len(p.s) {
 		return 0
@@ -60,6 +112,15 @@ func (p *RustTypesParser) skipWhitespace() {
 	}
 }
 
+// expect consumes a token, after any whitespace.
+func (p *RustTypesParser) expect(token string) error {
+	p.skipWhitespace()
+	if !p.Consume(token) {
+		return p.expected(fmt.Sprintf("'%s'", token))
+	}
+	return nil
+}
+
 func (p *RustTypesParser) parseIdent() string {
 	start := p.pos
 	for p.pos < len(p.s) {
@@ -73,30 +134,70 @@ func (p *RustTypesParser) parseIdent() string {
 	return p.s[start:p.pos]
 }
 
+// consumeKeyword consumes a keyword that is not the prefix of a longer identifier.
+func (p *RustTypesParser) consumeKeyword(keyword string) bool {
+	start := p.pos
+	if p.Consume(keyword) {
+		if r := p.Peek(); !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return true
+		}
+	}
+	p.pos = start
+	return false
+}
+
+// Parse parses a whole type. Any input left after it is an error.
 func (p *RustTypesParser) Parse() (RustType, error) {
 	p.skipWhitespace()
-	return p.parseType()
+	rust_type, err := p.parseType()
+	if err != nil {
+		return RustType{}, err
+	}
+	p.skipWhitespace()
+	if p.pos < len(p.s) {
+		return RustType{}, p.errorf("unexpected %q after type", p.s[p.pos])
+	}
+	return rust_type, nil
 }
 
 func (p *RustTypesParser) parseType() (RustType, error) {
 	p.skipWhitespace()
 
-	// Check for tuple syntax: (T, U, ...)
-	if p.Peek() == '(' {
+	switch p.Peek() {
+	case '(': // tuple: (T, U, ...)
 		return p.parseTuple()
+	case '[': // array or slice: [T; N] or [T]
+		return p.parseArray()
+	case '&': // reference: &'a mut T
+		return p.parseReference()
+	case '<': // qualified path: <T as Trait>::Assoc
+		return p.parseQualifiedPath()
 	}
 
-	// Check for array syntax: [T; N]
-	if p.Peek() == '[' {
-		return p.parseArray()
+	if p.consumeKeyword("dyn") {
+		return p.parseDyn()
 	}
+	if p.Matches("fn") {
+		start := p.pos
+		p.Skip(2)
+		p.skipWhitespace()
+		if p.Peek() == '(' {
+			return p.parseFn("")
+		}
+		p.pos = start
+	}
+
+	return p.parsePath()
+}
 
+// parsePath parses a path type like foo::Bar<Baz>, or the Fn(A) -> B sugar of the Fn traits.
+func (p *RustTypesParser) parsePath() (RustType, error) {
 	// Parse path (sequence of identifiers separated by ::)
 	var segments []string
 
 	ident := p.parseIdent()
 	if ident == "" {
-		return RustType{}, fmt.Errorf("expected identifier at position %d", p.pos)
+		return RustType{}, p.expected("type")
 	}
 	segments = append(segments, ident)
 
@@ -108,130 +209,275 @@ func (p *RustTypesParser) parseType() (RustType, error) {
 
 		ident := p.parseIdent()
 		if ident == "" {
-			return RustType{}, fmt.Errorf("expected identifier after :: at position %d", p.pos)
+			return RustType{}, p.expected("identifier after '::'")
 		}
 		segments = append(segments, ident)
 		p.skipWhitespace()
 	}
 
-	// Check for generics
-	if p.Peek() == '<' {
-		p.Advance() // consume '<'
-		p.skipWhitespace()
-
-		params, err := p.parseGenericParams()
-		if err != nil {
-			return RustType{}, err
+	if len(segments) == 1 && p.Peek() == '(' {
+		switch ident {
+		case "Fn", "FnMut", "FnOnce":
+			return p.parseFn(ident)
 		}
+	}
 
-		p.skipWhitespace()
-		if p.Peek() != '>' {
-			return RustType{}, fmt.Errorf("expected '>' at position %d", p.pos)
-		}
-		p.Advance() // consume '>'
+	generics, err := p.parseGenerics()
+	if err != nil {
+		return RustType{}, err
+	}
+	return Base(segments, generics), nil
+}
 
-		return Base(segments, params), nil
+// parseGenerics parses the generic arguments of a path, if there are any.
+func (p *RustTypesParser) parseGenerics() ([]RustType, error) {
+	p.skipWhitespace()
+	if p.Peek() != '<' {
+		return nil, nil
 	}
+	p.Advance() // consume '<'
 
-	// Return simple path
-	return Base(segments, nil), nil
+	params, err := p.parseList('>', p.parseGenericArg)
+	if err != nil {
+		return nil, err
+	}
+	if len(params) == 0 {
+		return nil, nil
+	}
+	return params, nil
 }
 
-func (p *RustTypesParser) parseGenericParams() ([]RustType, error) {
-	var params []RustType
+// parseGenericArg parses a type, lifetime or const generic argument.
+func (p *RustTypesParser) parseGenericArg() (RustType, error) {
+	p.skipWhitespace()
+	switch r := p.Peek(); {
+	case r == '\'':
+		return p.parseLifetime()
+	case r == '{':
+		return p.parseConstBlock()
+	case r == '-' || unicode.IsDigit(r):
+		start := p.pos
+		p.Consume("-")
+		if p.parseInt() < 0 {
+			return RustType{}, p.expected("integer")
+		}
+		p.parseIdent() // suffix, as in 32u32
+		return Const(p.s[start:p.pos]), nil
+	}
+	if p.consumeKeyword("true") {
+		return Const("true"), nil
+	}
+	if p.consumeKeyword("false") {
+		return Const("false"), nil
+	}
+	return p.parseType()
+}
 
+// parseList parses comma separated items up to and including the closing delimiter,
+// allowing a trailing comma.
+func (p *RustTypesParser) parseList(closing rune, parseItem func() (RustType, error)) ([]RustType, error) {
+	items := []RustType{}
 	for {
-		param, err := p.parseType()
+		p.skipWhitespace()
+		if p.Peek() == closing {
+			p.Advance()
+			return items, nil
+		}
+
+		item, err := parseItem()
 		if err != nil {
 			return nil, err
 		}
-		params = append(params, param)
+		items = append(items, item)
 
 		p.skipWhitespace()
-
 		if p.Peek() == ',' {
 			p.Advance() // consume ','
-			p.skipWhitespace()
-		} else {
-			break
+		} else if p.Peek() != closing {
+			return nil, p.expected(fmt.Sprintf("',' or '%c'", closing))
 		}
 	}
-
-	return params, nil
 }
 
 func (p *RustTypesParser) parseTuple() (RustType, error) {
-	if p.Peek() != '(' {
-		return RustType{}, fmt.Errorf("expected '(' at position %d", p.pos)
+	if err := p.expect("("); err != nil {
+		return RustType{}, err
+	}
+
+	types, err := p.parseList(')', p.parseType)
+	if err != nil {
+		return RustType{}, err
+	}
+	return Tuple(types), nil
+}
+
+func (p *RustTypesParser) parseArray() (RustType, error) {
+	if err := p.expect("["); err != nil {
+		return RustType{}, err
+	}
+
+	base, err := p.parseType()
+	if err != nil {
+		return RustType{}, err
+	}
+
+	p.skipWhitespace()
+	if p.Consume("]") {
+		return Slice(base), nil
+	}
+	if err := p.expect(";"); err != nil {
+		return RustType{}, err
 	}
-	p.Advance() // consume '('
 	p.skipWhitespace()
 
-	// Handle empty tuple
-	if p.Peek() == ')' {
-		p.Advance() // consume ')'
-		return Tuple([]RustType{}), nil
+	// Parse array length (simple integer)
+	len := p.parseInt()
+	if len < 0 {
+		return RustType{}, p.expected("array length")
 	}
 
-	var types []RustType
-	for {
-		typ, err := p.parseType()
+	if err := p.expect("]"); err != nil {
+		return RustType{}, err
+	}
+
+	return Array(base, len), nil
+}
+
+func (p *RustTypesParser) parseReference() (RustType, error) {
+	if err := p.expect("&"); err != nil {
+		return RustType{}, err
+	}
+	p.skipWhitespace()
+
+	var lifetime string
+	if p.Peek() == '\'' {
+		lt, err := p.parseLifetime()
 		if err != nil {
 			return RustType{}, err
 		}
-		types = append(types, typ)
-
+		lifetime = *lt.Lifetime
 		p.skipWhitespace()
+	}
+	mutable := p.consumeKeyword("mut")
 
-		if p.Peek() == ',' {
-			p.Advance() // consume ','
-			p.skipWhitespace()
-		} else {
-			break
-		}
+	typ, err := p.parseType()
+	if err != nil {
+		return RustType{}, err
 	}
+	return Reference(lifetime, mutable, typ), nil
+}
 
-	p.skipWhitespace()
-	if p.Peek() != ')' {
-		return RustType{}, fmt.Errorf("expected ')' at position %d", p.pos)
+func (p *RustTypesParser) parseLifetime() (RustType, error) {
+	if err := p.expect("'"); err != nil {
+		return RustType{}, err
+	}
+	name := p.parseIdent()
+	if name == "" {
+		return RustType{}, p.expected("lifetime name")
 	}
-	p.Advance() // consume ')'
+	return Lifetime(name), nil
+}
 
-	return Tuple(types), nil
+// parseConstBlock parses a const generic expression in braces, like { N + 1 }, keeping it as written.
+func (p *RustTypesParser) parseConstBlock() (RustType, error) {
+	start := p.pos
+	depth := 0
+	for p.pos < len(p.s) {
+		switch p.Advance() {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return Const(p.s[start:p.pos]), nil
+			}
+		}
+	}
+	return RustType{}, p.expected("'}'")
 }
 
-func (p *RustTypesParser) parseArray() (RustType, error) {
-	if p.Peek() != '[' {
-		return RustType{}, fmt.Errorf("expected '[' at position %d", p.pos)
+func (p *RustTypesParser) parseQualifiedPath() (RustType, error) {
+	if err := p.expect("<"); err != nil {
+		return RustType{}, err
 	}
-	p.Advance() // consume '['
-	p.skipWhitespace()
 
-	base, err := p.parseType()
+	self, err := p.parseType()
 	if err != nil {
 		return RustType{}, err
 	}
 
 	p.skipWhitespace()
-	if p.Peek() != ';' {
-		return RustType{}, fmt.Errorf("expected ';' at position %d", p.pos)
+	var trait *RustType
+	if p.consumeKeyword("as") {
+		p.skipWhitespace()
+		traitType, err := p.parsePath()
+		if err != nil {
+			return RustType{}, err
+		}
+		trait = &traitType
+	}
+
+	if err := p.expect(">"); err != nil {
+		return RustType{}, err
+	}
+	if err := p.expect("::"); err != nil {
+		return RustType{}, err
 	}
-	p.Advance() // consume ';'
 	p.skipWhitespace()
 
-	// Parse array length (simple integer)
-	len := p.parseInt()
-	if len < 0 {
-		return RustType{}, fmt.Errorf("expected array length at position %d", p.pos)
+	item, err := p.parsePath()
+	if err != nil {
+		return RustType{}, err
 	}
+	if item.Kind != KindBase {
+		return RustType{}, p.errorf("expected an associated item path, found %s", item.String())
+	}
+	return QualifiedPath(self, trait, item.Base.Path, item.Base.Generics), nil
+}
 
-	p.skipWhitespace()
-	if p.Peek() != ']' {
-		return RustType{}, fmt.Errorf("expected ']' at position %d", p.pos)
+// parseDyn parses the bounds of a trait object, after the dyn keyword: Trait + Send + 'static
+func (p *RustTypesParser) parseDyn() (RustType, error) {
+	var bounds []RustType
+	for {
+		p.skipWhitespace()
+		var bound RustType
+		var err error
+		if p.Peek() == '\'' {
+			bound, err = p.parseLifetime()
+		} else {
+			bound, err = p.parsePath()
+		}
+		if err != nil {
+			return RustType{}, err
+		}
+		bounds = append(bounds, bound)
+
+		p.skipWhitespace()
+		if !p.Consume("+") {
+			return Dyn(bounds), nil
+		}
 	}
-	p.Advance() // consume ']'
+}
 
-	return Array(base, len), nil
+// parseFn parses the parameters and return type of a function pointer or Fn trait: (A, B) -> C
+func (p *RustTypesParser) parseFn(trait string) (RustType, error) {
+	if err := p.expect("("); err != nil {
+		return RustType{}, err
+	}
+	params, err := p.parseList(')', p.parseType)
+	if err != nil {
+		return RustType{}, err
+	}
+
+	p.skipWhitespace()
+	if !p.Consume("->") {
+		return Fn(trait, params, nil), nil
+	}
+	ret, err := p.parseType()
+	if err != nil {
+		return RustType{}, err
+	}
+	return Fn(trait, params, &ret), nil
 }
 
 func (p *RustTypesParser) parseInt() int {
@@ -243,9 +489,10 @@ func (p *RustTypesParser) parseInt() int {
 		return -1
 	}
 
-	result := 0
-	for i := start; i < p.pos; i++ {
-		result = result*10 + int(p.s[i]-'0')
+	result, err := strconv.Atoi(p.s[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return -1
 	}
 	return result
 }
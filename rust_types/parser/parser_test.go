@@ -1,7 +1,10 @@
 package parser_test
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 
 	. "submarine/rust_types"
@@ -107,8 +110,11 @@ func deepEqualPath(a, b RustType, path string) *string {
 		return nil
 
 	default:
-		diff := fmt.Sprintf("%s: unknown kind %d", path, a.Kind)
-		return &diff
+		if !reflect.DeepEqual(a, b) {
+			diff := fmt.Sprintf("%s: %s vs %s", path, a.String(), b.String())
+			return &diff
+		}
+		return nil
 	}
 }
 
@@ -381,6 +387,123 @@ func TestRustTypesParser(t *testing.T) {
 			Array(Base([]string{"u8"}, nil), 32),
 			"[u8; 32]",
 		},
+		{
+			"(u8, u16,)",
+			Tuple([]RustType{
+				Base([]string{"u8"}, nil),
+				Base([]string{"u16"}, nil),
+			}),
+			"(u8, u16)",
+		},
+
+		// References and slices
+		{
+			"&'static [u8]",
+			Reference("static", false, Slice(Base([]string{"u8"}, nil))),
+			"&'static [u8]",
+		},
+		{
+			"&mut Vec<u8>",
+			Reference("", true, Base([]string{"Vec"}, []RustType{
+				Base([]string{"u8"}, nil),
+			})),
+			"&mut Vec<u8>",
+		},
+		{
+			"&'a &str",
+			Reference("a", false, Reference("", false, Base([]string{"str"}, nil))),
+			"&'a &str",
+		},
+
+		// Qualified paths
+		{
+			"<T as Trait>::Call",
+			QualifiedPath(Base([]string{"T"}, nil), &[]RustType{Base([]string{"Trait"}, nil)}[0], []string{"Call"}, nil),
+			"<T as Trait>::Call",
+		},
+		{
+			"<T::Lookup as StaticLookup>::Source",
+			QualifiedPath(Base([]string{"T", "Lookup"}, nil), &[]RustType{Base([]string{"StaticLookup"}, nil)}[0], []string{"Source"}, nil),
+			"<T::Lookup as StaticLookup>::Source",
+		},
+		{
+			"Vec<<T as frame_system::Config<I>>::AccountId>",
+			Base([]string{"Vec"}, []RustType{
+				QualifiedPath(
+					Base([]string{"T"}, nil),
+					&[]RustType{Base([]string{"frame_system", "Config"}, []RustType{Base([]string{"I"}, nil)})}[0],
+					[]string{"AccountId"}, nil,
+				),
+			}),
+			"Vec<<T as frame_system::Config<I>>::AccountId>",
+		},
+		{
+			"<Vec<u8>>::Item",
+			QualifiedPath(Base([]string{"Vec"}, []RustType{Base([]string{"u8"}, nil)}), nil, []string{"Item"}, nil),
+			"<Vec<u8>>::Item",
+		},
+
+		// Lifetime and const generic arguments
+		{
+			"Cow<'a, [u8]>",
+			Base([]string{"Cow"}, []RustType{
+				Lifetime("a"),
+				Slice(Base([]string{"u8"}, nil)),
+			}),
+			"Cow<'a, [u8]>",
+		},
+		{
+			"BoundedVec<u8, 32>",
+			Base([]string{"BoundedVec"}, []RustType{
+				Base([]string{"u8"}, nil),
+				Const("32"),
+			}),
+			"BoundedVec<u8, 32>",
+		},
+		{
+			"Foo<{ N + 1 }, -1, true>",
+			Base([]string{"Foo"}, []RustType{
+				Const("{ N + 1 }"),
+				Const("-1"),
+				Const("true"),
+			}),
+			"Foo<{ N + 1 }, -1, true>",
+		},
+
+		// Trait objects and function pointers
+		{
+			"Box<dyn Fn(u32, u8) -> bool + Send + 'static>",
+			Base([]string{"Box"}, []RustType{
+				Dyn([]RustType{
+					Fn("Fn", []RustType{Base([]string{"u32"}, nil), Base([]string{"u8"}, nil)}, &[]RustType{Base([]string{"bool"}, nil)}[0]),
+					Base([]string{"Send"}, nil),
+					Lifetime("static"),
+				}),
+			}),
+			"Box<dyn Fn(u32, u8) -> bool + Send + 'static>",
+		},
+		{
+			"fn()",
+			Fn("", []RustType{}, nil),
+			"fn()",
+		},
+		{
+			"fn(&[u8]) -> Result<(), Error>",
+			Fn("", []RustType{Reference("", false, Slice(Base([]string{"u8"}, nil)))}, &[]RustType{
+				Base([]string{"Result"}, []RustType{Tuple([]RustType{}), Base([]string{"Error"}, nil)}),
+			}[0]),
+			"fn(&[u8]) -> Result<(), Error>",
+		},
+		{
+			"fnord::Fn",
+			Base([]string{"fnord", "Fn"}, nil),
+			"fnord::Fn",
+		},
+		{
+			"dynamic",
+			Base([]string{"dynamic"}, nil),
+			"dynamic",
+		},
 	}
 
 	for _, tt := range tests {
@@ -408,3 +531,62 @@ func TestRustTypesParser(t *testing.T) {
 		})
 	}
 }
+
+func TestRustTypesParserErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		offset  int
+		message string
+	}{
+		{"", 0, "expected type, found end of input"},
+		{"Vec<u8", 6, "expected ',' or '>', found end of input"},
+		{"Vec<(u8, u16>", 12, "expected ',' or ')', found '>'"},
+		{"[u8; N]", 5, "expected array length, found 'N'"},
+		{"[u8; 99999999999999999999999]", 5, "expected array length"},
+		{"foo::", 5, "expected identifier after '::'"},
+		{"Vec<u8> extra", 8, "unexpected 'e' after type"},
+		{"<T as Trait>Call", 12, "expected '::', found 'C'"},
+		{"<T as Trait>::(u8)", 14, "expected type, found '('"},
+		{"&'", 2, "expected lifetime name"},
+		{"Foo<{ N >", 9, "expected '}', found end of input"},
+		{"fn(u8) ->", 9, "expected type, found end of input"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := NewRustTypesParser(tt.input).Parse()
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Parse() error = %v, want a *ParseError", err)
+			}
+			if parseErr.Offset != tt.offset {
+				t.Errorf("Offset = %d, want %d", parseErr.Offset, tt.offset)
+			}
+			if !strings.Contains(parseErr.Message, tt.message) {
+				t.Errorf("Message = %q, want %q", parseErr.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestParseErrorSnippet(t *testing.T) {
+	_, err := NewRustTypesParser("Vec<(u8,\tu16>").Parse()
+	expected := "expected ',' or ')', found '>' at offset 12\nVec<(u8, u16>\n            ^"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Error() = %q, want %q", err, expected)
+	}
+
+	long := "Vec<" + strings.Repeat("u8, ", 30) + "!>"
+	_, err = NewRustTypesParser(long).Parse()
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Parse() error = %v, want a *ParseError", err)
+	}
+	lines := strings.Split(parseErr.Snippet(), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "...") || strings.HasSuffix(lines[0], "...") {
+		t.Fatalf("Snippet() = %q", parseErr.Snippet())
+	}
+	if caret := strings.Index(lines[1], "^"); lines[0][caret] != '!' {
+		t.Errorf("caret under %q, want '!'", lines[0][caret])
+	}
+}
@@ -2,25 +2,19 @@ package sanitizer
 
 import (
 	"regexp"
+	"slices"
 	"strings"
 
 	. "submarine/rust_types"
 	"submarine/rust_types/parser"
 )
 
-func ParseAndSanitize(typeName string) RustType {
-	// We need to normalize spaces here, even though the Parser does normalize spaces, for RemoveAsTrait() to work.
-	typeName = NormalizeSpaces(typeName)
-
-	// This is done outside of the AST because the as trait syntax makes parsing way more complicated.
-	// If we make as trait a type, we'd have to change RustTypeBase.Path from []string to []RustType
-	// This makes parsing ambiguous. We'd have to introduce associativity to fix it, but then it makes the code more complex.
-	// The current parser is very simple, and the parser output is really easy to manipulate.
-	typeName = RemoveAsTrait(typeName)
-
-	rust_type := ParseRustType(typeName)
-	rust_type = SanitizeRustType(rust_type)
-	return rust_type
+func ParseAndSanitize(typeName string) (RustType, error) {
+	rust_type, err := ParseRustType(typeName)
+	if err != nil {
+		return RustType{}, err
+	}
+	return SanitizeRustType(rust_type), nil
 }
 
 // 'Foo\nBar  Baz' -> 'Foo Bar Baz'
@@ -28,43 +22,9 @@ func NormalizeSpaces(s string) string {
 	return regexp.MustCompile(`\s+`).ReplaceAllLiteralString(s, " ")
 }
 
-// <Foo as Trait>::Bar -> Foo::Bar
-func RemoveAsTrait(s string) string {
-	for {
-		i := strings.Index(s, ">::")
-		if i <= 0 {
-			break
-		}
-		j := i
-		open_count := 0
-		for j = i; j >= 0; j -= 1 { // iterate backwards from i
-			switch s[j] {
-			case '>':
-				open_count += 1
-			case '<':
-				open_count -= 1
-			}
-			if open_count == 0 {
-				break
-			}
-		}
-		before := s[:j]             // until '<'
-		insideAsTrait := s[j+1 : i] // inside '<' '>', excluding
-		after := s[i+1:]            // after '>'
-
-		asTraitSrc, _, _ := strings.Cut(insideAsTrait, " as ")
-		s = before + asTraitSrc + after
-	}
-	return s
-}
-
-func ParseRustType(s string) RustType {
-	rust_type, err := parser.NewRustTypesParser(s).Parse()
-	if err != nil {
-		panic(err)
-	}
-	return rust_type
-
+// ParseRustType parses a type, returning a *parser.ParseError if it is malformed.
+func ParseRustType(s string) (RustType, error) {
+	return parser.NewRustTypesParser(s).Parse()
 }
 
 func SanitizeRustType(rust_type RustType) RustType {
@@ -75,7 +35,9 @@ func SanitizeRustType(rust_type RustType) RustType {
 			name := base.Path[0]
 			switch name {
 			case "Box":
-				return SanitizeRustType(rust_type.Base.Generics[0])
+				if len(base.Generics) == 1 {
+					return SanitizeRustType(base.Generics[0])
+				}
 			case "Compact":
 				return Base([]string{"compact"}, nil)
 			}
@@ -88,36 +50,36 @@ func SanitizeRustType(rust_type RustType) RustType {
 				return Base([]string{fixed}, nil)
 			}
 
-			if fixed, found := strings.CutPrefix(name, "Bounded"); found {
+			if fixed, found := strings.CutPrefix(name, "Bounded"); found && len(base.Generics) > 0 {
 				generics := base.Generics[0 : len(base.Generics)-1] // skip Size in BoundedVec<...,Size>
-				return Base([]string{fixed}, generics)
+				return SanitizeRustType(Base([]string{fixed}, generics))
 			}
 
 			if fixed, found := strings.CutPrefix(name, "Weak"); found {
-				return Base([]string{fixed}, base.Generics)
+				return SanitizeRustType(Base([]string{fixed}, base.Generics))
 			}
 
-			if rust_type.String() == "String" {
+			if rust_type.String() == "String" || rust_type.String() == "str" {
 				return Base([]string{"text"}, nil)
 			}
 
-			var newName string = name
-
-			if newName == "VecDeque" {
-				newName = "Vec"
+			if name == "VecDeque" {
+				name = "Vec"
 			}
 
-			// Handle Vec<T> and Option<T>, Foo<T, U, V> becomes Foo
-			if newName == "Vec" || newName == "Option" {
-				var newGenerics []RustType
-				newGenerics = make([]RustType, len(base.Generics))
-				for i := range base.Generics {
-					newGenerics[i] = SanitizeRustType(base.Generics[i])
+			if containers[name] {
+				var generics []RustType
+				for _, generic := range base.Generics {
+					if !isTypeArg(generic) {
+						continue
+					}
+					generics = append(generics, SanitizeRustType(generic))
 				}
-				return Base([]string{newName}, newGenerics)
-			} else {
-				return Base([]string{newName}, nil)
+				return Base([]string{name}, generics)
 			}
+
+			// Foo<T::AccountId, U> becomes Foo<AccountId, U>
+			return Base([]string{name}, sanitizeGenerics(base.Generics))
 		}
 
 		if base.Path[0] == "T" {
@@ -128,8 +90,8 @@ func SanitizeRustType(rust_type RustType) RustType {
 			return Base([]string{"empty"}, nil)
 		}
 
-		// foo::bar::Baz<T, U, V> becomes foo::bar::Baz
-		return Base(base.Path, nil)
+		// foo::bar::Baz<T::AccountId> becomes foo::bar::Baz<AccountId>
+		return Base(base.Path, sanitizeGenerics(base.Generics))
 
 	case KindArray:
 		array := rust_type.Array
@@ -142,7 +104,53 @@ func SanitizeRustType(rust_type RustType) RustType {
 		}
 		return Tuple(newTuple)
 
+	case KindReference:
+		// A reference encodes as what it refers to
+		return SanitizeRustType(rust_type.Reference.Type)
+
+	case KindSlice:
+		return Base([]string{"Vec"}, []RustType{SanitizeRustType(*rust_type.Slice)})
+
+	case KindQualifiedPath:
+		// <T as Trait>::Call becomes T::Call, and <T::Lookup as StaticLookup>::Source becomes T::Lookup::Source
+		qualified := rust_type.QualifiedPath
+		path := qualified.Item.Path
+		if qualified.Self.Kind == KindBase {
+			path = append(slices.Clone(qualified.Self.Base.Path), path...)
+		}
+		return SanitizeRustType(Base(path, qualified.Item.Generics))
+
 	default:
-		panic("exhaustive")
+		// Lifetimes, const arguments, trait objects and function pointers have no encoding to simplify
+		return rust_type
+	}
+}
+
+// containers are the generic types whose parameters are all kept, even T.
+var containers = map[string]bool{
+	"Vec": true, "Option": true, "Result": true, "BTreeMap": true, "BTreeSet": true, "HashMap": true,
+	"HashSet": true, "Range": true, "RangeInclusive": true, "WrapperOpaque": true, "WrapperKeepOpaque": true,
+}
+
+// isTypeArg reports whether a generic argument is a type, rather than a lifetime or a const.
+func isTypeArg(generic RustType) bool {
+	return generic.Kind != KindLifetime && generic.Kind != KindConst
+}
+
+// sanitizeGenerics sanitizes type arguments, dropping lifetimes, consts and the runtime and instance
+// parameters T and I, which only select the runtime's types, as in Heartbeat<T> or Call<T, I>.
+func sanitizeGenerics(generics []RustType) []RustType {
+	var out []RustType
+	for _, generic := range generics {
+		if !isTypeArg(generic) {
+			continue
+		}
+		if generic.Kind == KindBase && len(generic.Base.Path) == 1 && len(generic.Base.Generics) == 0 {
+			if name := generic.Base.Path[0]; name == "T" || name == "I" {
+				continue
+			}
+		}
+		out = append(out, SanitizeRustType(generic))
 	}
+	return out
 }
@@ -49,39 +49,6 @@ func TestNormalizeSpaces(t *testing.T) {
 	}
 }
 
-func TestRemoveAsTrait(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    string
-		expected string
-	}{
-		{
-			name:     "simple as trait removal",
-			input:    "<Foo as Trait>::Bar",
-			expected: "Foo::Bar",
-		},
-		{
-			name:     "multiple as trait patterns",
-			input:    "<A as TraitA>::<B as TraitB>::method",
-			expected: "A::B::method",
-		},
-		{
-			name:     "no as trait pattern",
-			input:    "regular::path::Type",
-			expected: "regular::path::Type",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := sanitizer.RemoveAsTrait(tt.input)
-			if result != tt.expected {
-				t.Errorf("RemoveAsTrait(%q) = %q, want %q", tt.input, result, tt.expected)
-			}
-		})
-	}
-}
-
 func TestParseAndSanitizeRustType(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -122,7 +89,10 @@ func TestParseAndSanitizeRustType(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			rust_type := sanitizer.ParseRustType(tt.input)
+			rust_type, err := sanitizer.ParseRustType(tt.input)
+			if err != nil {
+				t.Fatalf("ParseRustType(%q) error = %v", tt.input, err)
+			}
 			result := sanitizer.SanitizeRustType(rust_type)
 			if result.String() != tt.expected {
 				t.Errorf("SanitizeRustType(%q).String() = %q, want %q", tt.input, result, tt.expected)
@@ -160,7 +130,7 @@ func TestSanitizeRustType(t *testing.T) {
 		{
 			name:     "path simplification",
 			input:    Base([]string{"std", "collections", "HashMap"}, []RustType{Base([]string{"String"}, nil), Base([]string{"i32"}, nil)}),
-			expected: "std::collections::HashMap",
+			expected: "std::collections::HashMap<text, i32>",
 		},
 	}
 
@@ -200,17 +170,54 @@ func TestSanitize(t *testing.T) {
 			input:  "<A as  B<T>>::C",
 			output: "A::C",
 		},
+		{
+			name:   "as trait without self path",
+			input:  "<T as Trait>::Call",
+			output: "Call",
+		},
+		{
+			name:   "as trait with a self path",
+			input:  "<T::Lookup as StaticLookup>::Source",
+			output: "Lookup::Source",
+		},
+		{
+			name:   "nested as trait",
+			input:  "Vec<<T as Trait<I>>::Proposal>",
+			output: "Vec<Proposal>",
+		},
+		{
+			name:   "static str reference",
+			input:  "Option<&'static str>",
+			output: "Option<text>",
+		},
+		{
+			name:   "slice reference",
+			input:  "&'static [u8]",
+			output: "Vec<u8>",
+		},
+		{
+			name:   "lifetime and const arguments",
+			input:  "Foo<'a, T::Balance, 32>",
+			output: "Foo<Balance>",
+		},
+		{
+			name:   "bounded vec with a const bound",
+			input:  "BoundedVec<u8, 32>",
+			output: "Vec<u8>",
+		},
+		{
+			name:   "box without generics",
+			input:  "Box",
+			output: "Box",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Since Sanitize doesn't return anything, we just test that it doesn't panic
-			defer func() {
-				if r := recover(); r != nil {
-					t.Errorf("Sanitize(%q) panicked: %v", tt.input, r)
-				}
-			}()
-			output := sanitizer.ParseAndSanitize(tt.input)
+			output, err := sanitizer.ParseAndSanitize(tt.input)
+			if err != nil {
+				t.Fatalf("ParseAndSanitize(%q) error = %v", tt.input, err)
+			}
 			if output.String() != tt.output {
 				t.Errorf("ParseAndSanitize(%v).String() = %q, want %q", tt.input, output, tt.output)
 			}
@@ -227,7 +234,7 @@ var GOLDEN_TESTS []struct{ input, output string } = []struct {
 	{"AccountId", "AccountId"},
 	{"AccountIndex", "AccountIndex"},
 	{"AccountValidity", "AccountValidity"},
-	{"AccountVote<BalanceOf<T>>", "AccountVote"}, // <- [x] democracy
+	{"AccountVote<BalanceOf<T>>", "AccountVote<Balance>"}, // <- [x] democracy
 	{"Approvals", "Approvals"},
 	{"AuctionIndex", "AuctionIndex"},
 	{"AuthorityId", "AuthorityId"},
@@ -241,11 +248,11 @@ var GOLDEN_TESTS []struct{ input, output string } = []struct {
 	{"Box<<T as Config>::Call>", "Call"},
 	{"Box<<T as Trait<I>>::Proposal>", "Proposal"},
 	{"Box<<T as Trait>::Call>", "Call"},
-	{"Box<EquivocationProof<T::Hash, T::BlockNumber>>", "EquivocationProof"}, // <- [x] grandpa - per-module alias
-	{"Box<EquivocationProof<T::Header>>", "EquivocationProof"},               // <- [x] babe - per-module alias
-	{"Box<IdentityInfo<T::MaxAdditionalFields>>", "IdentityInfo"},
-	{"Box<RawSolution<CompactOf<T>>>", "RawSolution"}, // <-
-	{"Box<RawSolution<SolutionOf<T>>>", "RawSolution"},
+	{"Box<EquivocationProof<T::Hash, T::BlockNumber>>", "EquivocationProof<Hash, BlockNumber>"}, // <- [x] grandpa - per-module alias
+	{"Box<EquivocationProof<T::Header>>", "EquivocationProof<Header>"},                          // <- [x] babe - per-module alias
+	{"Box<IdentityInfo<T::MaxAdditionalFields>>", "IdentityInfo<MaxAdditionalFields>"},
+	{"Box<RawSolution<CompactOf<T>>>", "RawSolution<Compact>"}, // <-
+	{"Box<RawSolution<SolutionOf<T>>>", "RawSolution<Solution>"},
 	{"CallHash", "CallHash"},
 	{"CallHashOf<T>", "CallHash"}, // <- [x]
 	{"CollatorId", "CollatorId"},
@@ -270,32 +277,32 @@ var GOLDEN_TESTS []struct{ input, output string } = []struct {
 	{"CompactAssignments", "CompactAssignments"},
 	{"Conviction", "Conviction"},
 	{"Data", "Data"},
-	{"DefunctVoter<<T::Lookup as StaticLookup>::Source>", "DefunctVoter"}, // <-
+	{"DefunctVoter<<T::Lookup as StaticLookup>::Source>", "DefunctVoter<Lookup::Source>"}, // <-
 	{"DispatchError", "DispatchError"},
 	{"DispatchInfo", "DispatchInfo"},
 	{"DispatchResult", "DispatchResult"},
 	{`DoubleVoteReport<<T::KeyOwnerProofSystem as
-                 KeyOwnerProofSystem<(KeyTypeId, ValidatorId)>>::Proof>`, "DoubleVoteReport"}, // <- [x] parachains
+                 KeyOwnerProofSystem<(KeyTypeId, ValidatorId)>>::Proof>`, "DoubleVoteReport<KeyOwnerProofSystem::Proof>"}, // <- [x] parachains
 	{"EcdsaSignature", "EcdsaSignature"},
 	{"ElectionCompute", "ElectionCompute"},
 	{"ElectionScore", "ElectionScore"},
 	{"ElectionSize", "ElectionSize"},
-	{"EquivocationProof<T::Hash, T::BlockNumber>", "EquivocationProof"}, // <-
-	{"EquivocationProof<T::Header>", "EquivocationProof"},
+	{"EquivocationProof<T::Hash, T::BlockNumber>", "EquivocationProof<Hash, BlockNumber>"}, // <-
+	{"EquivocationProof<T::Header>", "EquivocationProof<Header>"},
 	{"EraIndex", "EraIndex"},
 	{"EthereumAddress", "EthereumAddress"},
 	{"Hash", "Hash"},
 	{"HeadData", "HeadData"},
-	{"Heartbeat<T::BlockNumber>", "Heartbeat"},
+	{"Heartbeat<T::BlockNumber>", "Heartbeat<BlockNumber>"},
 	{"IdentityFields", "IdentityFields"},
 	{"IdentityInfo", "IdentityInfo"},
-	{"Judgement<BalanceOf<T>>", "Judgement"},
+	{"Judgement<BalanceOf<T>>", "Judgement<Balance>"},
 	{"Key", "Key"},
 	{"Kind", "Kind"},
 	{"LeasePeriod", "LeasePeriod"},
 	{"MemberCount", "MemberCount"},
 	{"MoreAttestations", "MoreAttestations"},
-	{"NewBidder<AccountId>", "NewBidder"},
+	{"NewBidder<AccountId>", "NewBidder<AccountId>"},
 	{"NextConfigDescriptor", "NextConfigDescriptor"},
 	{"OpaqueCall", "OpaqueCall"},
 	{"OpaqueTimeSlot", "OpaqueTimeSlot"},
@@ -307,9 +314,9 @@ var GOLDEN_TESTS []struct{ input, output string } = []struct {
 	{"Option<ReferendumIndex>", "Option<ReferendumIndex>"},
 	{"Option<StatementKind>", "Option<StatementKind>"},
 	{"Option<T::AccountId>", "Option<AccountId>"},
-	{"Option<T::ProxyType>", "Option<ProxyType>"},              // <-
-	{"Option<Timepoint<T::BlockNumber>>", "Option<Timepoint>"}, // <-
-	{"Option<schedule::Period<T::BlockNumber>>", "Option<schedule::Period>"},
+	{"Option<T::ProxyType>", "Option<ProxyType>"},                           // <-
+	{"Option<Timepoint<T::BlockNumber>>", "Option<Timepoint<BlockNumber>>"}, // <-
+	{"Option<schedule::Period<T::BlockNumber>>", "Option<schedule::Period<BlockNumber>>"},
 	{"Option<u32>", "Option<u32>"},
 	{"ParaId", "ParaId"},
 	{"ParaInfo", "ParaInfo"},
@@ -320,19 +327,19 @@ var GOLDEN_TESTS []struct{ input, output string } = []struct {
 	{"PropIndex", "PropIndex"},
 	{"ProposalIndex", "ProposalIndex"},
 	{"ProxyType", "ProxyType"},
-	{"RawSolution<CompactOf<T>>", "RawSolution"},     // <- [x] staking
-	{"ReadySolution<T::AccountId>", "ReadySolution"}, // <- [x] staking
+	{"RawSolution<CompactOf<T>>", "RawSolution<Compact>"},       // <- [x] staking
+	{"ReadySolution<T::AccountId>", "ReadySolution<AccountId>"}, // <- [x] staking
 	{"ReferendumIndex", "ReferendumIndex"},
 	{"RegistrarIndex", "RegistrarIndex"},
 	{"Remark", "Remark"},
 	{"Renouncing", "Renouncing"},
 	{"RewardDestination", "RewardDestination"},
-	{"RewardDestination<T::AccountId>", "RewardDestination"}, // <- [x] staking
+	{"RewardDestination<T::AccountId>", "RewardDestination<AccountId>"}, // <- [x] staking
 	{"SessionIndex", "SessionIndex"},
 	{"SlotRange", "SlotRange"},
 	{"SolutionOrSnapshotSize", "SolutionOrSnapshotSize"},
 	{"Status", "Status"},
-	{"Supports<T::AccountId>", "Supports"}, // <---- [x] staking
+	{"Supports<T::AccountId>", "Supports<AccountId>"}, // <---- [x] staking
 	{"T::AccountId", "AccountId"},
 	{"T::AccountIndex", "AccountIndex"},
 	{"T::BlockNumber", "BlockNumber"},
@@ -340,9 +347,9 @@ var GOLDEN_TESTS []struct{ input, output string } = []struct {
 	{"T::KeyOwnerProof", "KeyOwnerProof"},
 	{"T::Keys", "Keys"},
 	{"T::ProxyType", "ProxyType"},
-	{"TaskAddress<BlockNumber>", "TaskAddress"},
-	{"Timepoint<BlockNumber>", "Timepoint"},
-	{"Timepoint<T::BlockNumber>", "Timepoint"},
+	{"TaskAddress<BlockNumber>", "TaskAddress<BlockNumber>"},
+	{"Timepoint<BlockNumber>", "Timepoint<BlockNumber>"},
+	{"Timepoint<T::BlockNumber>", "Timepoint<BlockNumber>"},
 	{"ValidationCode", "ValidationCode"},
 	{"ValidatorPrefs", "ValidatorPrefs"},
 	{"Vec<(AccountId, Balance)>", "Vec<(AccountId, Balance)>"},
@@ -360,7 +367,7 @@ var GOLDEN_TESTS []struct{ input, output string } = []struct {
 	{"Vec<T::Header>", "Vec<Header>"},
 	{"Vec<ValidatorIndex>", "Vec<ValidatorIndex>"},
 	{"Vec<u32>", "Vec<u32>"},
-	{"VestingInfo<BalanceOf<T>, T::BlockNumber>", "VestingInfo"},
+	{"VestingInfo<BalanceOf<T>, T::BlockNumber>", "VestingInfo<Balance, BlockNumber>"},
 	{"VoteThreshold", "VoteThreshold"},
 	{"Weight", "Weight"},
 	{"[u8; 32]", "[u8; 32]"},
@@ -372,16 +379,23 @@ var GOLDEN_TESTS []struct{ input, output string } = []struct {
 	{"u64", "u64"},
 }
 
+func TestParseAndSanitizeError(t *testing.T) {
+	for _, input := range []string{"Vec<u8", "Vec<u8>>", "<T as Trait>", "[u8; 32"} {
+		t.Run(input, func(t *testing.T) {
+			if output, err := sanitizer.ParseAndSanitize(input); err == nil {
+				t.Errorf("ParseAndSanitize(%q) = %q, want an error", input, output)
+			}
+		})
+	}
+}
+
 func TestParseAndSanitizeGolden(t *testing.T) {
 	for _, tt := range GOLDEN_TESTS {
 		t.Run(tt.input, func(t *testing.T) {
-			// Since Sanitize doesn't return anything, we just test that it doesn't panic
-			defer func() {
-				if r := recover(); r != nil {
-					t.Errorf("Sanitize(%q) panicked: %v", tt.input, r)
-				}
-			}()
-			output := sanitizer.ParseAndSanitize(tt.input)
+			output, err := sanitizer.ParseAndSanitize(tt.input)
+			if err != nil {
+				t.Fatalf("ParseAndSanitize(%q) error = %v", tt.input, err)
+			}
 			if output.String() != tt.output {
 				t.Errorf("ParseAndSanitize(%v).String() = %q, want %q", tt.input, output, tt.output)
 			}
@@ -1,6 +1,7 @@
 package rust_types
 
 import (
+	"fmt"
 	"strings"
 	. "submarine/errorspan"
 	s "submarine/scale"
@@ -60,6 +61,25 @@ func ToScaleSchema(rust_type *RustType) (s.Type, *ErrorSpan) {
 			}
 		}
 
+	case KindReference:
+		return ToScaleSchema(&rust_type.Reference.Type)
+
+	case KindSlice:
+		inner, err := ToScaleSchema(rust_type.Slice)
+		if err != nil {
+			return s.Type{}, err.WithPath("element")
+		}
+		ty = s.Type{
+			Kind: s.KindVec,
+			Vec: &s.Vec{
+				Type: &inner,
+			},
+		}
+
+	case KindQualifiedPath, KindLifetime, KindConst, KindDyn, KindFn:
+		// Qualified paths are resolved by the sanitizer, the rest are not encoded
+		return s.Type{}, NewErrorSpan(fmt.Sprintf("%s has no SCALE encoding", rust_type.String()))
+
 	default:
 		return s.Type{}, NewErrorSpan("unexpected rust_types.RustTypeKind").
 			WithPathf("kind=%d", int(rust_type.Kind))
@@ -110,17 +130,79 @@ func convertBaseType(base *RustTypeBase) (s.Type, *ErrorSpan) {
 		}, nil
 	}
 
-	// Handle generic types with parameters (but not Vec/Option)
-	if len(base.Generics) > 0 {
-		return s.Type{}, NewErrorSpan("generic types other than Vec and Option are not supported").
-			WithPathf("type=%s", path[0]).
-			WithPathf("generics_count=%d", len(base.Generics))
+	if len(path) == 1 {
+		if ty, ok, err := convertContainer(path[0], base.Generics); ok {
+			return ty, err
+		}
 	}
 
-	// All other types, treat as reference
+	// All other types, treat as reference, keeping type arguments for generic definitions
 	typeName := strings.Join(path, "::")
+	var args []s.Type
+	for i := range base.Generics {
+		arg, err := ToScaleSchema(&base.Generics[i])
+		if err != nil {
+			return s.Type{}, err.WithPathInt(i).WithPath(typeName)
+		}
+		args = append(args, arg)
+	}
 	return s.Type{
 		Kind: s.KindRef,
 		Ref:  &typeName,
+		Args: args,
 	}, nil
 }
+
+// containerParams are the number of generic parameters of the std and polkadot.js containers
+// converted to their own kinds.
+var containerParams = map[string]int{
+	"Result":            2,
+	"BTreeMap":          2,
+	"HashMap":           2,
+	"BTreeSet":          1,
+	"HashSet":           1,
+	"Range":             1,
+	"RangeInclusive":    1,
+	"WrapperOpaque":     1,
+	"WrapperKeepOpaque": 1,
+}
+
+func convertContainer(name string, generics []RustType) (s.Type, bool, *ErrorSpan) {
+	numParams, ok := containerParams[name]
+	if !ok {
+		return s.Type{}, false, nil
+	}
+	if len(generics) != numParams {
+		return s.Type{}, true, NewErrorSpan(fmt.Sprintf("%s requires exactly %d generic parameters", name, numParams)).
+			WithPathf("generics_count=%d", len(generics)).
+			WithPath(name)
+	}
+
+	params := make([]*s.Type, numParams)
+	for i := range generics {
+		param, err := ToScaleSchema(&generics[i])
+		if err != nil {
+			return s.Type{}, true, err.WithPath("generic_param").WithPath(name)
+		}
+		params[i] = &param
+	}
+
+	switch name {
+	case "Result":
+		return s.Type{Kind: s.KindResult, Result: &s.Result{Ok: params[0], Err: params[1]}}, true, nil
+	case "BTreeMap", "HashMap":
+		return s.Type{Kind: s.KindMap, Map: &s.Map{Key: params[0], Value: params[1]}}, true, nil
+	case "BTreeSet", "HashSet":
+		return s.Type{Kind: s.KindVec, Vec: &s.Vec{Type: params[0]}}, true, nil
+	case "Range", "RangeInclusive":
+		return s.Type{Kind: s.KindStruct, Struct: &s.Struct{Fields: []s.NamedMember{
+			{Name: "start", Type: params[0]},
+			{Name: "end", Type: params[0]},
+		}}}, true, nil
+	case "WrapperOpaque":
+		return s.Type{Kind: s.KindOpaque, Opaque: &s.Opaque{Type: params[0]}}, true, nil
+	default: // WrapperKeepOpaque is kept encoded
+		bytes := "bytes"
+		return s.Type{Kind: s.KindRef, Ref: &bytes}, true, nil
+	}
+}
@@ -1,6 +1,7 @@
 package rust_types_test
 
 import (
+	"reflect"
 	r "submarine/rust_types"
 	s "submarine/scale"
 	"testing"
@@ -160,7 +161,7 @@ func TestToScaleSchema(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "unsupported generic type",
+			name: "map type",
 			input: &r.RustType{
 				Kind: r.KindBase,
 				Base: &r.RustTypeBase{
@@ -171,8 +172,112 @@ func TestToScaleSchema(t *testing.T) {
 					},
 				},
 			},
+			expected: s.Type{
+				Kind: s.KindMap,
+				Map: &s.Map{
+					Key:   &s.Type{Kind: s.KindRef, Ref: stringPtr("String")},
+					Value: &s.Type{Kind: s.KindRef, Ref: stringPtr("u32")},
+				},
+			},
+		},
+		{
+			name: "Result type",
+			input: &r.RustType{
+				Kind: r.KindBase,
+				Base: &r.RustTypeBase{
+					Path: []string{"Result"},
+					Generics: []r.RustType{
+						r.Tuple([]r.RustType{}),
+						{Kind: r.KindBase, Base: &r.RustTypeBase{Path: []string{"DispatchError"}}},
+					},
+				},
+			},
+			expected: s.Type{
+				Kind: s.KindResult,
+				Result: &s.Result{
+					Ok:  &s.Type{Kind: s.KindTuple, Tuple: &s.Tuple{Fields: []s.Type{}}},
+					Err: &s.Type{Kind: s.KindRef, Ref: stringPtr("DispatchError")},
+				},
+			},
+		},
+		{
+			name: "Result with wrong generic count",
+			input: &r.RustType{
+				Kind: r.KindBase,
+				Base: &r.RustTypeBase{
+					Path:     []string{"Result"},
+					Generics: []r.RustType{{Kind: r.KindBase, Base: &r.RustTypeBase{Path: []string{"u8"}}}},
+				},
+			},
 			wantErr: true,
 		},
+		{
+			name: "slice reference",
+			input: &r.RustType{
+				Kind: r.KindReference,
+				Reference: &r.RustTypeReference{
+					Lifetime: "static",
+					Type: r.RustType{
+						Kind:  r.KindSlice,
+						Slice: &r.RustType{Kind: r.KindBase, Base: &r.RustTypeBase{Path: []string{"u8"}}},
+					},
+				},
+			},
+			expected: s.Type{
+				Kind: s.KindVec,
+				Vec: &s.Vec{
+					Type: &s.Type{
+						Kind: s.KindRef,
+						Ref:  stringPtr("u8"),
+					},
+				},
+			},
+		},
+		{
+			name: "trait object",
+			input: &r.RustType{
+				Kind: r.KindBase,
+				Base: &r.RustTypeBase{
+					Path: []string{"Vec"},
+					Generics: []r.RustType{
+						{Kind: r.KindDyn, Dyn: &[]r.RustType{{Kind: r.KindBase, Base: &r.RustTypeBase{Path: []string{"Trait"}}}}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Range type",
+			input: &r.RustType{
+				Kind: r.KindBase,
+				Base: &r.RustTypeBase{
+					Path:     []string{"Range"},
+					Generics: []r.RustType{{Kind: r.KindBase, Base: &r.RustTypeBase{Path: []string{"u32"}}}},
+				},
+			},
+			expected: s.Type{
+				Kind: s.KindStruct,
+				Struct: &s.Struct{Fields: []s.NamedMember{
+					{Name: "start", Type: &s.Type{Kind: s.KindRef, Ref: stringPtr("u32")}},
+					{Name: "end", Type: &s.Type{Kind: s.KindRef, Ref: stringPtr("u32")}},
+				}},
+			},
+		},
+		{
+			name: "generic ref keeps its arguments",
+			input: &r.RustType{
+				Kind: r.KindBase,
+				Base: &r.RustTypeBase{
+					Path:     []string{"Pair"},
+					Generics: []r.RustType{{Kind: r.KindBase, Base: &r.RustTypeBase{Path: []string{"u32"}}}},
+				},
+			},
+			expected: s.Type{
+				Kind: s.KindRef,
+				Ref:  stringPtr("Pair"),
+				Args: []s.Type{{Kind: s.KindRef, Ref: stringPtr("u32")}},
+			},
+		},
 		{
 			name: "nested Vec of Option",
 			input: &r.RustType{
@@ -237,41 +342,5 @@ func stringPtr(s string) *string {
 
 // deepEqualType compares two s.Type values for equality
 func deepEqualType(a, b s.Type) bool {
-	if a.Kind != b.Kind {
-		return false
-	}
-
-	switch a.Kind {
-	case s.KindRef:
-		return (a.Ref == nil && b.Ref == nil) || (a.Ref != nil && b.Ref != nil && *a.Ref == *b.Ref)
-	case s.KindVec:
-		return (a.Vec == nil && b.Vec == nil) ||
-			(a.Vec != nil && b.Vec != nil && deepEqualType(*a.Vec.Type, *b.Vec.Type))
-	case s.KindOption:
-		return (a.Option == nil && b.Option == nil) ||
-			(a.Option != nil && b.Option != nil && deepEqualType(*a.Option.Type, *b.Option.Type))
-	case s.KindArray:
-		return (a.Array == nil && b.Array == nil) ||
-			(a.Array != nil && b.Array != nil &&
-				a.Array.Len == b.Array.Len &&
-				deepEqualType(*a.Array.Type, *b.Array.Type))
-	case s.KindTuple:
-		if a.Tuple == nil && b.Tuple == nil {
-			return true
-		}
-		if a.Tuple == nil || b.Tuple == nil {
-			return false
-		}
-		if len(a.Tuple.Fields) != len(b.Tuple.Fields) {
-			return false
-		}
-		for i, field := range a.Tuple.Fields {
-			if !deepEqualType(field, b.Tuple.Fields[i]) {
-				return false
-			}
-		}
-		return true
-	default:
-		return false
-	}
+	return reflect.DeepEqual(a, b)
 }
@@ -11,13 +11,27 @@ const (
 	KindBase RustTypeKind = iota
 	KindTuple
 	KindArray
+	KindReference
+	KindSlice
+	KindQualifiedPath
+	KindLifetime
+	KindConst
+	KindDyn
+	KindFn
 )
 
 type RustType struct {
-	Kind  RustTypeKind
-	Base  *RustTypeBase
-	Tuple *[]RustType
-	Array *RustTypeArray
+	Kind          RustTypeKind
+	Base          *RustTypeBase
+	Tuple         *[]RustType
+	Array         *RustTypeArray
+	Reference     *RustTypeReference
+	Slice         *RustType
+	QualifiedPath *RustTypeQualifiedPath
+	Lifetime      *string     // without the leading ', e.g. static
+	Const         *string     // a const generic argument as written, e.g. 32 or { N }
+	Dyn           *[]RustType // the trait and lifetime bounds of a trait object
+	Fn            *RustTypeFn
 }
 
 type RustTypeBase struct {
@@ -31,6 +45,27 @@ type RustTypeArray struct {
 	Len  int
 }
 
+// types like &'static [u8] or &mut T
+type RustTypeReference struct {
+	Lifetime string // empty if elided
+	Mutable  bool
+	Type     RustType
+}
+
+// types like <T as Trait>::Assoc or <T::Lookup as StaticLookup>::Source
+type RustTypeQualifiedPath struct {
+	Self  RustType
+	Trait *RustType // nil in <T>::Assoc
+	Item  RustTypeBase
+}
+
+// function pointers like fn(u32) -> bool, and the Fn(u32) -> bool sugar of the Fn traits
+type RustTypeFn struct {
+	Trait  string // Fn, FnMut or FnOnce, empty for a function pointer
+	Params []RustType
+	Return *RustType // nil if the function returns ()
+}
+
 // Constructor functions
 func Base(path []string, generics []RustType) RustType {
 	return RustType{
@@ -59,35 +94,154 @@ func Array(base RustType, len int) RustType {
 	}
 }
 
+func Reference(lifetime string, mutable bool, typ RustType) RustType {
+	return RustType{
+		Kind: KindReference,
+		Reference: &RustTypeReference{
+			Lifetime: lifetime,
+			Mutable:  mutable,
+			Type:     typ,
+		},
+	}
+}
+
+func Slice(typ RustType) RustType {
+	return RustType{
+		Kind:  KindSlice,
+		Slice: &typ,
+	}
+}
+
+func QualifiedPath(self RustType, trait *RustType, path []string, generics []RustType) RustType {
+	return RustType{
+		Kind: KindQualifiedPath,
+		QualifiedPath: &RustTypeQualifiedPath{
+			Self:  self,
+			Trait: trait,
+			Item:  RustTypeBase{Path: path, Generics: generics},
+		},
+	}
+}
+
+func Lifetime(name string) RustType {
+	return RustType{
+		Kind:     KindLifetime,
+		Lifetime: &name,
+	}
+}
+
+func Const(value string) RustType {
+	return RustType{
+		Kind:  KindConst,
+		Const: &value,
+	}
+}
+
+func Dyn(bounds []RustType) RustType {
+	return RustType{
+		Kind: KindDyn,
+		Dyn:  &bounds,
+	}
+}
+
+func Fn(trait string, params []RustType, ret *RustType) RustType {
+	return RustType{
+		Kind: KindFn,
+		Fn: &RustTypeFn{
+			Trait:  trait,
+			Params: params,
+			Return: ret,
+		},
+	}
+}
+
+func joinTypes(types []RustType, sep string) string {
+	var strs []string
+	for _, typ := range types {
+		strs = append(strs, typ.String())
+	}
+	return strings.Join(strs, sep)
+}
+
+func (base RustTypeBase) String() string {
+	path := strings.Join(base.Path, "::")
+	if len(base.Generics) == 0 {
+		return path
+	}
+	return fmt.Sprintf("%s<%s>", path, joinTypes(base.Generics, ", "))
+}
+
 func (rt RustType) String() string {
 	switch rt.Kind {
 	case KindBase:
 		if rt.Base == nil {
 			return ""
 		}
-		path := strings.Join(rt.Base.Path, "::")
-		if len(rt.Base.Generics) == 0 {
-			return path
-		}
-		var params []string
-		for _, param := range rt.Base.Generics {
-			params = append(params, param.String())
-		}
-		return fmt.Sprintf("%s<%s>", path, strings.Join(params, ", "))
+		return rt.Base.String()
 	case KindTuple:
 		if rt.Tuple == nil {
 			return "()"
 		}
-		var params []string
-		for _, param := range *rt.Tuple {
-			params = append(params, param.String())
-		}
-		return fmt.Sprintf("(%s)", strings.Join(params, ", "))
+		return fmt.Sprintf("(%s)", joinTypes(*rt.Tuple, ", "))
 	case KindArray:
 		if rt.Array == nil {
 			return "[]"
 		}
 		return fmt.Sprintf("[%s; %d]", rt.Array.Base.String(), rt.Array.Len)
+	case KindReference:
+		if rt.Reference == nil {
+			return "&"
+		}
+		out := "&"
+		if rt.Reference.Lifetime != "" {
+			out += "'" + rt.Reference.Lifetime + " "
+		}
+		if rt.Reference.Mutable {
+			out += "mut "
+		}
+		return out + rt.Reference.Type.String()
+	case KindSlice:
+		if rt.Slice == nil {
+			return "[]"
+		}
+		return fmt.Sprintf("[%s]", rt.Slice.String())
+	case KindQualifiedPath:
+		if rt.QualifiedPath == nil {
+			return ""
+		}
+		qualified := rt.QualifiedPath
+		if qualified.Trait == nil {
+			return fmt.Sprintf("<%s>::%s", qualified.Self.String(), qualified.Item.String())
+		}
+		return fmt.Sprintf("<%s as %s>::%s", qualified.Self.String(), qualified.Trait.String(), qualified.Item.String())
+	case KindLifetime:
+		if rt.Lifetime == nil {
+			return ""
+		}
+		return "'" + *rt.Lifetime
+	case KindConst:
+		if rt.Const == nil {
+			return ""
+		}
+		return *rt.Const
+	case KindDyn:
+		if rt.Dyn == nil {
+			return "dyn"
+		}
+		return "dyn " + joinTypes(*rt.Dyn, " + ")
+	case KindFn:
+		if rt.Fn == nil {
+			return "fn()"
+		}
+		name := rt.Fn.Trait
+		if name == "" {
+			name = "fn"
+		}
+		out := fmt.Sprintf("%s(%s)", name, joinTypes(rt.Fn.Params, ", "))
+		if rt.Fn.Return != nil {
+			out += " -> " + rt.Fn.Return.String()
+		}
+		return out
 	default:
 		return ""
 	}
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math/big"
+	"unsafe"
 )
 
 // DecodeCompact decodes a SCALE compact-encoded integer.
@@ -76,10 +77,8 @@ func DecodeU128(r *Reader) (*big.Int, error) {
 	if err != nil {
 		return nil, err
 	}
-	// Reverse for big.Int which expects big-endian bytes
-	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
-		b[i], b[j] = b[j], b[i]
-	}
+	// Reverse for big.Int which expects big-endian bytes. b aliases the reader's data, so reverse a copy.
+	b = reverseBytes(b)
 	return new(big.Int).SetBytes(b), nil
 }
 
@@ -88,10 +87,8 @@ func DecodeU256(r *Reader) (*big.Int, error) {
 	if err != nil {
 		return nil, err
 	}
-	// Reverse for big.Int which expects big-endian bytes
-	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
-		b[i], b[j] = b[j], b[i]
-	}
+	// Reverse for big.Int which expects big-endian bytes. b aliases the reader's data, so reverse a copy.
+	b = reverseBytes(b)
 	return new(big.Int).SetBytes(b), nil
 }
 
@@ -132,10 +129,8 @@ func DecodeI128(r *Reader) (*big.Int, error) {
 	if err != nil {
 		return nil, err
 	}
-	// Reverse for big.Int which expects big-endian bytes
-	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
-		b[i], b[j] = b[j], b[i]
-	}
+	// Reverse for big.Int which expects big-endian bytes. b aliases the reader's data, so reverse a copy.
+	b = reverseBytes(b)
 	// Two's complement conversion for signed
 	result := new(big.Int).SetBytes(b)
 	// Check if the sign bit is set (MSB of original little-endian data)
@@ -152,10 +147,8 @@ func DecodeI256(r *Reader) (*big.Int, error) {
 	if err != nil {
 		return nil, err
 	}
-	// Reverse for big.Int which expects big-endian bytes
-	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
-		b[i], b[j] = b[j], b[i]
-	}
+	// Reverse for big.Int which expects big-endian bytes. b aliases the reader's data, so reverse a copy.
+	b = reverseBytes(b)
 	// Two's complement conversion for signed
 	result := new(big.Int).SetBytes(b)
 	// Check if the sign bit is set (MSB of original little-endian data)
@@ -182,11 +175,14 @@ func DecodeBool(r *Reader) (bool, error) {
 }
 
 func DecodeText(r *Reader) (string, error) {
-	length, err := DecodeCompact(r)
+	length, err := DecodeLength(r, 1)
 	if err != nil {
 		return "", fmt.Errorf("text.len: %w", err)
 	}
-	bytes, err := r.ReadBytes(int(length.Int64()))
+	if err := r.Allocate(length, 1); err != nil {
+		return "", fmt.Errorf("text: %w", err)
+	}
+	bytes, err := r.ReadBytes(length)
 	if err != nil {
 		return "", fmt.Errorf("text: %w", err)
 	}
@@ -194,26 +190,55 @@ func DecodeText(r *Reader) (string, error) {
 }
 
 func DecodeBytes(r *Reader) ([]byte, error) {
-	length, err := DecodeCompact(r)
+	length, err := DecodeLength(r, 1)
 	if err != nil {
 		return nil, fmt.Errorf("bytes.len: %w", err)
 	}
-	bytes, err := r.ReadBytes(int(length.Int64()))
+	bytes, err := r.ReadBytes(length)
 	if err != nil {
 		return nil, fmt.Errorf("bytes: %w", err)
 	}
 	return bytes, nil
 }
 
-func DecodeVec[T any](r *Reader, decoder func(*Reader) (T, error)) ([]T, error) {
-	length, err := DecodeCompact(r)
+// DecodeBitSequence decodes a BitVec<u8, Lsb0>: the number of bits, followed by the bits packed
+// in bytes, least significant bit first.
+func DecodeBitSequence(r *Reader) ([]bool, error) {
+	numBits, err := DecodeLength(r, 0)
+	if err != nil {
+		return nil, fmt.Errorf("bitvec.len: %w", err)
+	}
+	if numBits > r.Remaining()*8 {
+		return nil, fmt.Errorf("bitvec: %d bits, only %d bytes left", numBits, r.Remaining())
+	}
+	if err := r.Allocate(numBits, 1); err != nil {
+		return nil, fmt.Errorf("bitvec: %w", err)
+	}
+	bytes, err := r.ReadBytes((numBits + 7) / 8)
+	if err != nil {
+		return nil, fmt.Errorf("bitvec: %w", err)
+	}
+	bits := make([]bool, numBits)
+	for i := range bits {
+		bits[i] = bytes[i/8]&(1<<(i%8)) != 0
+	}
+	return bits, nil
+}
+
+// DecodeVec decodes a Vec<T> with decoder. minSize is the fewest bytes an element is encoded in,
+// which bounds the length by the input left, or 0 if elements may take none.
+func DecodeVec[T any](r *Reader, minSize int, decoder func(*Reader) (T, error)) ([]T, error) {
+	var zero T
+	length, err := DecodeLength(r, minSize)
 	if err != nil {
 		return nil, fmt.Errorf("vec.len: %w", err)
 	}
-	len64 := length.Int64()
+	if err := r.Allocate(length, int(unsafe.Sizeof(zero))); err != nil {
+		return nil, fmt.Errorf("vec: %w", err)
+	}
 
-	vec := make([]T, len64)
-	for i := range len64 {
+	vec := make([]T, length)
+	for i := range length {
 		item, err := decoder(r)
 		if err != nil {
 			return nil, fmt.Errorf("vec[%d]: %w", i, err)
@@ -1,6 +1,7 @@
 package scale_test
 
 import (
+	"math/big"
 	"reflect"
 	. "submarine/scale"
 	"testing"
@@ -682,7 +683,7 @@ func TestDecodeVec(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := NewReader(tt.data)
-			result, err := DecodeVec(r, DecodeU8)
+			result, err := DecodeVec(r, 1, DecodeU8)
 
 			if tt.wantErr {
 				if err == nil {
@@ -701,6 +702,16 @@ func TestDecodeVec(t *testing.T) {
 			}
 		})
 	}
+
+	// Elements of a type encoded in no bytes, like (), take none of the input.
+	empty := func(*Reader) (any, error) { return nil, nil }
+	if result, err := DecodeVec(NewReader([]byte{0x0c}), 0, empty); err != nil || len(result) != 3 {
+		t.Errorf("vec of empty elements: got %v, %v", result, err)
+	}
+	// A length the elements can't fit in the input left is rejected before decoding any.
+	if _, err := DecodeVec(NewReader([]byte{0x08, 1, 2, 3}), 4, DecodeU32); err == nil {
+		t.Error("expected an error for 2 elements of 4 bytes in 3 bytes")
+	}
 }
 
 func TestDecodeOption(t *testing.T) {
@@ -769,3 +780,16 @@ func bytes_padz(val []byte, count int) []byte {
 	}
 	return result
 }
+
+func TestDecodeBigIntsKeepInput(t *testing.T) {
+	data := bytes(0x01, 32)
+	data[0] = 0x02
+	for _, decode := range []func(*Reader) (*big.Int, error){DecodeU128, DecodeU256, DecodeI128, DecodeI256} {
+		if _, err := decode(NewReader(data)); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if data[0] != 0x02 || data[31] != 0x01 {
+			t.Fatalf("input modified: %x", data)
+		}
+	}
+}
@@ -1,136 +1,518 @@
 package scale
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"math/big"
 	. "submarine/errorspan"
 )
 
-func DecodeWithSchema(r *Reader, schema *Type) (Value, *ErrorSpan) {
+// TypeResolver resolves the named types a schema refers to, by Ref or Import.
+type TypeResolver interface {
+	// ResolveType returns the definition of the type name as referred to from module, and the
+	// module the names in the definition refer to. Imports are resolved from their own module.
+	ResolveType(module, name string) (*Type, string, error)
+}
+
+// DecodeWithSchema decodes a value of schema. Refs other than primitives, and imports, are
+// resolved with resolver, which may be nil for schemas made of primitives only.
+func DecodeWithSchema(r *Reader, schema *Type, resolver TypeResolver) (Value, *ErrorSpan) {
+	return DecodeWithSchemaInModule(r, schema, resolver, "")
+}
+
+// DecodeWithSchemaInModule is DecodeWithSchema for a schema whose refs are names in module.
+func DecodeWithSchemaInModule(r *Reader, schema *Type, resolver TypeResolver, module string) (Value, *ErrorSpan) {
+	d := &schemaDecoder{
+		schemaTypes: newSchemaTypes(resolver),
+		expanding:   make(expansions),
+	}
+	return d.decode(r, schema, module)
+}
+
+type typeKey struct {
+	module string
+	name   string
+}
+
+// instanceKey identifies a named type instantiated with type arguments, by the canonical form of
+// the whole argument list.
+type instanceKey struct {
+	typeKey
+	args string
+}
+
+// canonicalArgs returns a string that is the same for equal argument lists, and differs for any
+// difference in any of the arguments.
+func canonicalArgs(args []Type) string {
+	if len(args) == 0 {
+		return ""
+	}
+	// Types are trees of plain values, which always marshal.
+	data, _ := json.Marshal(args)
+	return string(data)
+}
+
+type resolvedType struct {
+	schema *Type
+	module string
+}
+
+// schemaTypes memoizes resolved types and instances of generic ones, for the duration of a decode
+// or an encode.
+type schemaTypes struct {
+	resolver  TypeResolver
+	resolved  map[typeKey]resolvedType
+	instances map[instanceKey]*Type
+	minSizes  map[sizeKey]int
+}
+
+func newSchemaTypes(resolver TypeResolver) *schemaTypes {
+	return &schemaTypes{
+		resolver:  resolver,
+		resolved:  make(map[typeKey]resolvedType),
+		instances: make(map[instanceKey]*Type),
+		minSizes:  make(map[sizeKey]int),
+	}
+}
+
+type schemaDecoder struct {
+	*schemaTypes
+	expanding expansions
+}
+
+// expansions map the named types being decoded to the reader position they started at. A type
+// reached again at the same position refers to itself without consuming input, and would never
+// finish decoding.
+type expansions map[instanceKey]int
+
+// enter records that instance starts at pos, returning the func that forgets it when done.
+func (e expansions) enter(instance instanceKey, pos int, name string) (func(), *ErrorSpan) {
+	if start, ok := e[instance]; ok && start == pos {
+		return nil, NewErrorSpan(fmt.Sprintf("recursive type %s", name))
+	}
+	outer, nested := e[instance]
+	e[instance] = pos
+	return func() {
+		if nested {
+			e[instance] = outer
+		} else {
+			delete(e, instance)
+		}
+	}, nil
+}
+
+func (d *schemaDecoder) decode(r *Reader, schema *Type, module string) (Value, *ErrorSpan) {
+	if schema == nil {
+		// Unit variants of complex enums have no type.
+		return VNull(), nil
+	}
+	if err := r.Enter(); err != nil {
+		return Value{}, WrapError(err)
+	}
+	defer r.Leave()
+
 	switch schema.Kind {
 	case KindStruct:
-		return decodeStruct(r, schema.Struct)
+		return d.decodeStruct(r, schema.Struct, module)
 	case KindTuple:
-		return decodeTuple(r, schema.Tuple)
+		return d.decodeTuple(r, schema.Tuple, module)
 	case KindEnumSimple:
 		return decodeEnumSimple(r, schema.EnumSimple)
 	case KindEnumComplex:
-		return decodeEnumComplex(r, schema.EnumComplex)
+		return d.decodeEnumComplex(r, schema.EnumComplex, module)
 	case KindVec:
-		return decodeVec(r, schema.Vec)
+		return d.decodeVec(r, schema.Vec, module)
 	case KindOption:
-		return decodeOption(r, schema.Option)
+		return d.decodeOption(r, schema.Option, module)
 	case KindArray:
-		return decodeArray(r, schema.Array)
+		return d.decodeArray(r, schema.Array, module)
 	case KindRef:
-		return decodeRef(r, *schema.Ref)
+		if primitives[*schema.Ref] {
+			return decodeRef(r, *schema.Ref)
+		}
+		return d.decodeNamed(r, typeKey{module, *schema.Ref}, schema.Args)
 	case KindBitFlags:
 		return decodeBitFlags(r, schema.BitFlags)
+	case KindResult:
+		return d.decodeResult(r, schema.Result, module)
+	case KindMap:
+		return d.decodeMap(r, schema.Map, module)
+	case KindOpaque:
+		return d.decodeOpaque(r, schema.Opaque, module)
+	case KindGeneric:
+		return Value{}, NewErrorSpan(fmt.Sprintf("generic type with parameters %v needs type arguments", schema.Generic.Params))
 	case KindImport:
-		return Value{}, NewErrorSpan(fmt.Sprintf("import types not supported: module: %s item: %s", schema.Import.Module, schema.Import.Item))
+		value, err := d.decodeNamed(r, typeKey{schema.Import.Module, schema.Import.Item}, nil)
+		if err != nil {
+			return Value{}, err.WithPath(schema.Import.Module)
+		}
+		return value, nil
 	default:
 		return Value{}, NewErrorSpan(fmt.Sprintf("unknown type kind: %s", schema.Kind))
 	}
 }
 
+func (d *schemaDecoder) decodeNamed(r *Reader, key typeKey, args []Type) (Value, *ErrorSpan) {
+	schema, module, instance, err := d.lookup(key, args)
+	if err != nil {
+		return Value{}, err
+	}
+
+	leave, err := d.expanding.enter(instance, r.Pos(), key.name)
+	if err != nil {
+		return Value{}, err
+	}
+	defer leave()
+
+	value, err := d.decode(r, schema, module)
+	if err != nil {
+		return Value{}, err.WithPath(key.name)
+	}
+	return value, nil
+}
+
+// SchemaResolver resolves the named types of schemas as decoding does, for walking values decoded
+// with DecodeWithSchema along their schema. It keeps the types it resolved between calls.
+type SchemaResolver struct {
+	*schemaTypes
+}
+
+// NewSchemaResolver returns a resolver of the named types of schemas, looked up with resolver.
+func NewSchemaResolver(resolver TypeResolver) *SchemaResolver {
+	return &SchemaResolver{schemaTypes: newSchemaTypes(resolver)}
+}
+
+// Resolve returns the definition a ref or an import stands for, instantiated with the arguments of
+// the ref if it is generic, and the module the names in it refer to, following refs to refs.
+// Primitives and the other kinds of schemas are returned as they are.
+func (s *SchemaResolver) Resolve(schema *Type, module string) (*Type, string, *ErrorSpan) {
+	seen := make(map[instanceKey]bool)
+	for schema != nil {
+		var key typeKey
+		var args []Type
+		switch {
+		case schema.Kind == KindRef && !primitives[*schema.Ref]:
+			key, args = typeKey{module, *schema.Ref}, schema.Args
+		case schema.Kind == KindImport:
+			key = typeKey{schema.Import.Module, schema.Import.Item}
+		default:
+			return schema, module, nil
+		}
+
+		resolved, resolvedModule, instance, err := s.lookup(key, args)
+		if err != nil {
+			return nil, "", err.WithPath(key.name)
+		}
+		if seen[instance] {
+			return nil, "", NewErrorSpan(fmt.Sprintf("recursive type %s", key.name))
+		}
+		seen[instance] = true
+		schema, module = resolved, resolvedModule
+	}
+	return nil, module, nil
+}
+
+// lookup returns the definition of a named type, instantiated with args if it is generic, and the
+// module the names in it refer to.
+func (t *schemaTypes) lookup(key typeKey, args []Type) (*Type, string, instanceKey, *ErrorSpan) {
+	resolved, err := t.resolve(key)
+	if err != nil {
+		return nil, "", instanceKey{}, err
+	}
+
+	instance := instanceKey{key, ""}
+	schema := resolved.schema
+	if schema.Kind == KindGeneric {
+		instance.args = canonicalArgs(args)
+		if schema, err = t.instantiate(instance, schema.Generic, args); err != nil {
+			return nil, "", instanceKey{}, err.WithPath(key.name)
+		}
+	}
+	return schema, resolved.module, instance, nil
+}
+
+// instantiate substitutes the arguments of a ref to a generic definition for its parameters. Refs
+// to non-generic definitions ignore their arguments, as polkadot.js does.
+func (t *schemaTypes) instantiate(instance instanceKey, generic *Generic, args []Type) (*Type, *ErrorSpan) {
+	if schema, ok := t.instances[instance]; ok {
+		return schema, nil
+	}
+	if len(args) != len(generic.Params) {
+		return nil, NewErrorSpan(fmt.Sprintf("expected %d type arguments, got %d", len(generic.Params), len(args)))
+	}
+	bindings := make(map[string]*Type, len(args))
+	for i, param := range generic.Params {
+		bindings[param] = &args[i]
+	}
+	schema := substitute(generic.Type, bindings)
+	t.instances[instance] = schema
+	return schema, nil
+}
+
+// substitute returns a copy of schema with the refs to bound names replaced.
+func substitute(schema *Type, bindings map[string]*Type) *Type {
+	if schema == nil {
+		return nil
+	}
+
+	out := *schema
+	switch schema.Kind {
+	case KindRef:
+		if bound, ok := bindings[*schema.Ref]; ok && len(schema.Args) == 0 {
+			return bound
+		}
+		out.Args = substituteAll(schema.Args, bindings)
+	case KindStruct:
+		out.Struct = &Struct{Fields: substituteMembers(schema.Struct.Fields, bindings)}
+	case KindEnumComplex:
+		out.EnumComplex = &EnumComplex{Variants: substituteMembers(schema.EnumComplex.Variants, bindings), Indices: schema.EnumComplex.Indices}
+	case KindTuple:
+		out.Tuple = &Tuple{Fields: substituteAll(schema.Tuple.Fields, bindings)}
+	case KindVec:
+		out.Vec = &Vec{Type: substitute(schema.Vec.Type, bindings)}
+	case KindOption:
+		out.Option = &Option{Type: substitute(schema.Option.Type, bindings)}
+	case KindArray:
+		out.Array = &Array{Type: substitute(schema.Array.Type, bindings), Len: schema.Array.Len}
+	case KindResult:
+		out.Result = &Result{Ok: substitute(schema.Result.Ok, bindings), Err: substitute(schema.Result.Err, bindings)}
+	case KindMap:
+		out.Map = &Map{Key: substitute(schema.Map.Key, bindings), Value: substitute(schema.Map.Value, bindings)}
+	case KindOpaque:
+		out.Opaque = &Opaque{Type: substitute(schema.Opaque.Type, bindings)}
+	case KindGeneric:
+		// Parameters of a nested definition shadow the outer ones.
+		inner := make(map[string]*Type, len(bindings))
+		for name, bound := range bindings {
+			inner[name] = bound
+		}
+		for _, param := range schema.Generic.Params {
+			delete(inner, param)
+		}
+		out.Generic = &Generic{Params: schema.Generic.Params, Type: substitute(schema.Generic.Type, inner)}
+	}
+	return &out
+}
+
+func substituteAll(types []Type, bindings map[string]*Type) []Type {
+	if types == nil {
+		return nil
+	}
+	out := make([]Type, len(types))
+	for i := range types {
+		out[i] = *substitute(&types[i], bindings)
+	}
+	return out
+}
+
+func substituteMembers(members []NamedMember, bindings map[string]*Type) []NamedMember {
+	out := make([]NamedMember, len(members))
+	for i, member := range members {
+		out[i] = NamedMember{Name: member.Name, Type: substitute(member.Type, bindings)}
+	}
+	return out
+}
+
+func (t *schemaTypes) resolve(key typeKey) (resolvedType, *ErrorSpan) {
+	if resolved, ok := t.resolved[key]; ok {
+		return resolved, nil
+	}
+	if t.resolver == nil {
+		return resolvedType{}, NewErrorSpan(fmt.Sprintf("unknown primitive type: %s", key.name))
+	}
+	schema, module, err := t.resolver.ResolveType(key.module, key.name)
+	if err != nil {
+		return resolvedType{}, WrapError(err)
+	}
+	resolved := resolvedType{schema, module}
+	t.resolved[key] = resolved
+	return resolved, nil
+}
+
+// primitives are the refs decodeRef decodes without a resolver.
+var primitives = map[string]bool{
+	"u8": true, "u16": true, "u32": true, "u64": true, "u128": true, "u256": true,
+	"i8": true, "i16": true, "i32": true, "i64": true, "i128": true, "i256": true,
+	"bool": true, "text": true, "bytes": true, "compact": true, "bitvec": true, "empty": true,
+}
+
+// minSize returns the fewest bytes a value of schema is encoded in, for DecodeLength to bound the
+// length of collections of them by the input left. Sizes are memoized.
+func (t *schemaTypes) minSize(schema *Type, module string) int {
+	if schema == nil {
+		return 0
+	}
+	key := sizeKey{schema, module}
+	if size, ok := t.minSizes[key]; ok {
+		return size
+	}
+	// A recursive type reached again is bounded by the enclosing value.
+	t.minSizes[key] = 0
+	size := t.computeMinSize(schema, module)
+	t.minSizes[key] = size
+	return size
+}
+
+func (t *schemaTypes) computeMinSize(schema *Type, module string) int {
+	switch schema.Kind {
+	case KindStruct:
+		total := 0
+		for _, field := range schema.Struct.Fields {
+			total += t.minSize(field.Type, module)
+		}
+		return min(total, math.MaxInt32)
+	case KindTuple:
+		total := 0
+		for i := range schema.Tuple.Fields {
+			total += t.minSize(&schema.Tuple.Fields[i], module)
+		}
+		return min(total, math.MaxInt32)
+	case KindArray:
+		size := t.minSize(schema.Array.Type, module)
+		if size > 0 && schema.Array.Len > math.MaxInt32/size {
+			return math.MaxInt32
+		}
+		return max(schema.Array.Len*size, 0)
+	case KindRef:
+		if size, ok := primitiveSizes[*schema.Ref]; ok {
+			return size
+		}
+		if primitives[*schema.Ref] {
+			// bool, and the compact lengths of text, bytes and bit sequences.
+			return 1
+		}
+		schema, module, _, err := t.lookup(typeKey{module, *schema.Ref}, schema.Args)
+		if err != nil {
+			// Decoding reports the error.
+			return 0
+		}
+		return t.minSize(schema, module)
+	case KindImport:
+		schema, module, _, err := t.lookup(typeKey{schema.Import.Module, schema.Import.Item}, nil)
+		if err != nil {
+			return 0
+		}
+		return t.minSize(schema, module)
+	case KindBitFlags:
+		for _, size := range []int{1, 2, 4, 8, 16, 32} {
+			if schema.BitFlags.BitLength <= size*8 {
+				return size
+			}
+		}
+		return 0
+	case KindGeneric:
+		return 0
+	default:
+		// Enums, vectors, options, results, maps and opaque values start with an index or a length.
+		return 1
+	}
+}
+
 func decodeRef(r *Reader, refType string) (Value, *ErrorSpan) {
 	switch refType {
 	case "u8":
 		val, err := DecodeU8(r)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
 		return VIntFromInt64(int64(val)), nil
 	case "u16":
 		val, err := DecodeU16(r)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
 		return VIntFromInt64(int64(val)), nil
 	case "u32":
 		val, err := DecodeU32(r)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
 		return VIntFromInt64(int64(val)), nil
 	case "u64":
 		val, err := DecodeU64(r)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
 		return VInt(new(big.Int).SetUint64(val)), nil
 	case "u128":
 		val, err := DecodeU128(r)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
 		return VInt(val), nil
 	case "u256":
 		val, err := DecodeU256(r)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
 		return VInt(val), nil
 	case "i8":
 		val, err := DecodeI8(r)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
 		return VIntFromInt64(int64(val)), nil
 	case "i16":
 		val, err := DecodeI16(r)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
 		return VIntFromInt64(int64(val)), nil
 	case "i32":
 		val, err := DecodeI32(r)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
 		return VIntFromInt64(int64(val)), nil
 	case "i64":
 		val, err := DecodeI64(r)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
 		return VIntFromInt64(val), nil
 	case "i128":
 		val, err := DecodeI128(r)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
 		return VInt(val), nil
 	case "i256":
 		val, err := DecodeI256(r)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
 		return VInt(val), nil
 	case "bool":
 		val, err := DecodeBool(r)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
 		return VBool(val), nil
 	case "text":
 		val, err := DecodeText(r)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
 		return VText(val), nil
 	case "bytes":
 		val, err := DecodeBytes(r)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
 		return VBytes(val), nil
 	case "compact":
 		val, err := DecodeCompact(r)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
-		return VInt(val), nil
+		return VCompact(val), nil
+	case "bitvec":
+		val, err := DecodeBitSequence(r)
+		if err != nil {
+			return Value{}, WrapError(err)
+		}
+		return VBitSequence(val), nil
 	case "empty": // Unit type
 		return VNull(), nil
 	default:
@@ -138,22 +520,36 @@ func decodeRef(r *Reader, refType string) (Value, *ErrorSpan) {
 	}
 }
 
-func decodeStruct(r *Reader, s *Struct) (Value, *ErrorSpan) {
-	result := make(map[string]Value)
-	for _, field := range s.Fields {
-		value, err := DecodeWithSchema(r, field.Type)
+func (d *schemaDecoder) decodeStruct(r *Reader, s *Struct, module string) (Value, *ErrorSpan) {
+	fields, err := d.decodeFields(r, s, module)
+	if err != nil {
+		return Value{}, err
+	}
+	return VStruct(fields), nil
+}
+
+func (d *schemaDecoder) decodeFields(r *Reader, s *Struct, module string) ([]Field, *ErrorSpan) {
+	if err := r.Allocate(len(s.Fields), fieldSize); err != nil {
+		return nil, WrapError(err)
+	}
+	fields := make([]Field, len(s.Fields))
+	for i, field := range s.Fields {
+		value, err := d.decode(r, field.Type, module)
 		if err != nil {
-			return Value{}, err.WithPath(field.Name)
+			return nil, err.WithPath(field.Name)
 		}
-		result[field.Name] = value
+		fields[i] = VField(field.Name, value)
 	}
-	return VStruct(result), nil
+	return fields, nil
 }
 
-func decodeTuple(r *Reader, t *Tuple) (Value, *ErrorSpan) {
+func (d *schemaDecoder) decodeTuple(r *Reader, t *Tuple, module string) (Value, *ErrorSpan) {
+	if err := r.Allocate(len(t.Fields), valueSize); err != nil {
+		return Value{}, WrapError(err)
+	}
 	result := make([]Value, len(t.Fields))
 	for i, fieldType := range t.Fields {
-		value, err := DecodeWithSchema(r, &fieldType)
+		value, err := d.decode(r, &fieldType, module)
 		if err != nil {
 			return Value{}, err.WithPathInt(i)
 		}
@@ -165,86 +561,137 @@ func decodeTuple(r *Reader, t *Tuple) (Value, *ErrorSpan) {
 func decodeEnumSimple(r *Reader, e *EnumSimple) (Value, *ErrorSpan) {
 	index, err := DecodeU8(r)
 	if err != nil {
-		return Value{}, NewErrorSpan(err.Error()).WithPath("index")
+		return Value{}, WrapError(err).WithPath("index")
+	}
+	position, err2 := variantPosition(e.Indices, len(e.Variants), index)
+	if err2 != nil {
+		return Value{}, err2.WithPath("index")
+	}
+	return VVariant(e.Variants[position], index, nil), nil
+}
+
+// variantPosition returns the position in an enum's variants of the one with discriminant index.
+func variantPosition(indices []int, numVariants int, index uint8) (int, *ErrorSpan) {
+	if indices == nil {
+		if int(index) >= numVariants {
+			return 0, NewErrorSpan(fmt.Sprintf("enum index %d out of bounds (max %d)", index, numVariants-1))
+		}
+		return int(index), nil
 	}
-	if int(index) >= len(e.Variants) {
-		return Value{}, NewErrorSpan(fmt.Sprintf("enum index %d out of bounds (max %d)", index, len(e.Variants)-1)).WithPath("index")
+	for i, variantIndex := range indices {
+		if variantIndex == int(index) {
+			return i, nil
+		}
 	}
-	return VText(e.Variants[index]), nil
+	return 0, NewErrorSpan(fmt.Sprintf("enum index %d is not a variant", index))
 }
 
-func decodeEnumComplex(r *Reader, e *EnumComplex) (Value, *ErrorSpan) {
+func (d *schemaDecoder) decodeEnumComplex(r *Reader, e *EnumComplex, module string) (Value, *ErrorSpan) {
 	index, err := DecodeU8(r)
 	if err != nil {
-		return Value{}, NewErrorSpan(err.Error()).WithPath("index")
+		return Value{}, WrapError(err).WithPath("index")
 	}
-	if int(index) >= len(e.Variants) {
-		return Value{}, NewErrorSpan(fmt.Sprintf("enum index %d out of bounds (max %d)", index, len(e.Variants)-1)).WithPath("index")
+	position, err2 := variantPosition(e.Indices, len(e.Variants), index)
+	if err2 != nil {
+		return Value{}, err2.WithPath("index")
 	}
 
-	variant := e.Variants[index]
-	value, err2 := DecodeWithSchema(r, variant.Type)
+	variant := e.Variants[position]
+	fields, err2 := d.decodeVariantFields(r, variant.Type, module)
 	if err2 != nil {
 		return Value{}, err2.WithPath(variant.Name)
 	}
+	return VVariant(variant.Name, index, fields), nil
+}
 
-	result := make(map[string]Value)
-	result[variant.Name] = value
-	return VStruct(result), nil
+// decodeVariantFields decodes the fields of a variant of a complex enum, see Variant.
+func (d *schemaDecoder) decodeVariantFields(r *Reader, schema *Type, module string) ([]Field, *ErrorSpan) {
+	switch {
+	case schema == nil:
+		return []Field{}, nil
+	case schema.Kind == KindStruct:
+		return d.decodeFields(r, schema.Struct, module)
+	case schema.Kind == KindTuple:
+		fields := make([]Field, len(schema.Tuple.Fields))
+		for i := range schema.Tuple.Fields {
+			value, err := d.decode(r, &schema.Tuple.Fields[i], module)
+			if err != nil {
+				return nil, err.WithPathInt(i)
+			}
+			fields[i] = VField("", value)
+		}
+		return fields, nil
+	default:
+		value, err := d.decode(r, schema, module)
+		if err != nil {
+			return nil, err
+		}
+		return []Field{VField("", value)}, nil
+	}
 }
 
-func decodeVec(r *Reader, v *Vec) (Value, *ErrorSpan) {
+func (d *schemaDecoder) decodeVec(r *Reader, v *Vec, module string) (Value, *ErrorSpan) {
 	// Optimization for Vec<u8>
 	if v.Type.Kind == KindRef && v.Type.Ref != nil && *v.Type.Ref == "u8" {
 		bytes, err := DecodeBytes(r)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
 		return VBytes(bytes), nil
 	}
 
-	length, err := DecodeCompact(r)
+	length, err := DecodeLength(r, d.minSize(v.Type, module))
 	if err != nil {
-		return Value{}, NewErrorSpan(err.Error()).WithPath("length")
+		return Value{}, WrapError(err).WithPath("length")
+	}
+	if err := r.Allocate(length, valueSize); err != nil {
+		return Value{}, WrapError(err)
 	}
 
-	result := make([]Value, length.Int64())
-	for i := range length.Int64() {
-		value, err2 := DecodeWithSchema(r, v.Type)
+	result := make([]Value, length)
+	for i := range length {
+		value, err2 := d.decode(r, v.Type, module)
 		if err2 != nil {
-			return Value{}, err2.WithPathInt(int(i))
+			return Value{}, err2.WithPathInt(i)
 		}
 		result[i] = value
 	}
 	return VList(result), nil
 }
 
-func decodeOption(r *Reader, o *Option) (Value, *ErrorSpan) {
+func (d *schemaDecoder) decodeOption(r *Reader, o *Option, module string) (Value, *ErrorSpan) {
 	hasValue, err := DecodeBool(r)
 	if err != nil {
-		return Value{}, NewErrorSpan(err.Error()).WithPath("flag")
+		return Value{}, WrapError(err).WithPath("flag")
 	}
 
 	if !hasValue {
-		return VStruct(make(map[string]Value)), nil // Empty struct for None
+		return VNone(), nil
 	}
 
-	return DecodeWithSchema(r, o.Type)
+	value, err2 := d.decode(r, o.Type, module)
+	if err2 != nil {
+		return Value{}, err2
+	}
+	return VSome(value), nil
 }
 
-func decodeArray(r *Reader, a *Array) (Value, *ErrorSpan) {
+func (d *schemaDecoder) decodeArray(r *Reader, a *Array, module string) (Value, *ErrorSpan) {
 	// Optimization for [u8; N]
 	if a.Type.Kind == KindRef && a.Type.Ref != nil && *a.Type.Ref == "u8" {
 		bytes, err := r.ReadBytes(a.Len)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
 		return VBytes(bytes), nil
 	}
 
+	if err := r.Allocate(a.Len, valueSize); err != nil {
+		return Value{}, WrapError(err)
+	}
 	result := make([]Value, a.Len)
 	for i := 0; i < a.Len; i++ {
-		value, err := DecodeWithSchema(r, a.Type)
+		value, err := d.decode(r, a.Type, module)
 		if err != nil {
 			return Value{}, err.WithPathInt(i)
 		}
@@ -286,24 +733,90 @@ func decodeBitFlags(r *Reader, bf *BitFlags) (Value, *ErrorSpan) {
 	case bf.BitLength <= 128:
 		rawValue, err = DecodeU128(r)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
 	case bf.BitLength <= 256:
 		rawValue, err = DecodeU256(r)
 		if err != nil {
-			return Value{}, NewErrorSpan(err.Error())
+			return Value{}, WrapError(err)
 		}
 	default:
 		return Value{}, NewErrorSpan(fmt.Sprintf("unsupported bit length: %d", bf.BitLength))
 	}
 
 	// Create a struct with boolean fields for each flag
-	result := make(map[string]Value)
-	for _, flag := range bf.Flags {
+	fields := make([]Field, len(bf.Flags))
+	for i, flag := range bf.Flags {
 		flagBig := new(big.Int).SetUint64(flag.Value)
 		isSet := new(big.Int).And(rawValue, flagBig).Cmp(big.NewInt(0)) != 0
-		result[flag.Name] = VBool(isSet)
+		fields[i] = VField(flag.Name, VBool(isSet))
+	}
+
+	return VStruct(fields), nil
+}
+
+func (d *schemaDecoder) decodeResult(r *Reader, res *Result, module string) (Value, *ErrorSpan) {
+	index, err := DecodeU8(r)
+	if err != nil {
+		return Value{}, WrapError(err).WithPath("index")
+	}
+
+	var name string
+	var schema *Type
+	switch index {
+	case 0:
+		name, schema = "Ok", res.Ok
+	case 1:
+		name, schema = "Err", res.Err
+	default:
+		return Value{}, NewErrorSpan(fmt.Sprintf("result index %d out of bounds (max 1)", index)).WithPath("index")
+	}
+
+	value, err2 := d.decode(r, schema, module)
+	if err2 != nil {
+		return Value{}, err2.WithPath(name)
+	}
+	return VVariant(name, index, []Field{VField("", value)}), nil
+}
+
+func (d *schemaDecoder) decodeMap(r *Reader, m *Map, module string) (Value, *ErrorSpan) {
+	length, err := DecodeLength(r, d.minSize(m.Key, module)+d.minSize(m.Value, module))
+	if err != nil {
+		return Value{}, WrapError(err).WithPath("length")
+	}
+	// A list of two values, key and value, for each entry.
+	if err := r.Allocate(length, 3*valueSize); err != nil {
+		return Value{}, WrapError(err)
 	}
 
-	return VStruct(result), nil
+	result := make([]Value, length)
+	for i := range length {
+		key, err := d.decode(r, m.Key, module)
+		if err != nil {
+			return Value{}, err.WithPath("key").WithPathInt(i)
+		}
+		value, err := d.decode(r, m.Value, module)
+		if err != nil {
+			return Value{}, err.WithPath("value").WithPathInt(i)
+		}
+		result[i] = VList([]Value{key, value})
+	}
+	return VList(result), nil
+}
+
+func (d *schemaDecoder) decodeOpaque(r *Reader, o *Opaque, module string) (Value, *ErrorSpan) {
+	data, err := DecodeBytes(r)
+	if err != nil {
+		return Value{}, WrapError(err).WithPath("length")
+	}
+
+	inner := r.sub(data)
+	value, err2 := d.decode(inner, o.Type, module)
+	if err2 != nil {
+		return Value{}, err2
+	}
+	if inner.Remaining() != 0 {
+		return Value{}, NewErrorSpan(fmt.Sprintf("%d bytes left after opaque value", inner.Remaining()))
+	}
+	return value, nil
 }
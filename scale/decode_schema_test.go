@@ -1,8 +1,10 @@
 package scale_test
 
 import (
+	"fmt"
 	"math/big"
 	"reflect"
+	"slices"
 	. "submarine/scale"
 	"testing"
 )
@@ -37,9 +39,9 @@ func TestDecodeWithSchema(t *testing.T) {
 					},
 				},
 			},
-			expected: VStruct(map[string]Value{
-				"a": VIntFromInt64(8),
-				"b": VIntFromInt64(16),
+			expected: VStruct([]Field{
+				VField("a", VIntFromInt64(8)),
+				VField("b", VIntFromInt64(16)),
 			}),
 		},
 		{
@@ -68,7 +70,7 @@ func TestDecodeWithSchema(t *testing.T) {
 					Variants: []string{"Red", "Green", "Blue"},
 				},
 			},
-			expected: VText("Red"),
+			expected: VVariant("Red", 0, nil),
 		},
 		{
 			name: "simple enum - second variant",
@@ -79,7 +81,7 @@ func TestDecodeWithSchema(t *testing.T) {
 					Variants: []string{"Red", "Green", "Blue"},
 				},
 			},
-			expected: VText("Green"),
+			expected: VVariant("Green", 1, nil),
 		},
 		{
 			name: "complex enum",
@@ -93,9 +95,7 @@ func TestDecodeWithSchema(t *testing.T) {
 					},
 				},
 			},
-			expected: VStruct(map[string]Value{
-				"Some": VIntFromInt64(8),
-			}),
+			expected: VVariant("Some", 1, []Field{VField("", VIntFromInt64(8))}),
 		},
 		{
 			name: "vec of u8",
@@ -128,7 +128,7 @@ func TestDecodeWithSchema(t *testing.T) {
 					Type: ref("u8"),
 				},
 			},
-			expected: VStruct(make(map[string]Value)),
+			expected: VNone(),
 		},
 		{
 			name: "option some",
@@ -139,8 +139,65 @@ func TestDecodeWithSchema(t *testing.T) {
 					Type: ref("u8"),
 				},
 			},
+			expected: VSome(VIntFromInt64(42)),
+		},
+		{
+			name: "result ok",
+			data: []byte{0x00, 0x2A},
+			schema: &Type{
+				Kind:   KindResult,
+				Result: &Result{Ok: ref("u8"), Err: ref("text")},
+			},
+			expected: VVariant("Ok", 0, []Field{VField("", VIntFromInt64(42))}),
+		},
+		{
+			name: "result err",
+			data: []byte{0x01, 0x08, 'n', 'o'},
+			schema: &Type{
+				Kind:   KindResult,
+				Result: &Result{Ok: ref("u8"), Err: ref("text")},
+			},
+			expected: VVariant("Err", 1, []Field{VField("", VText("no"))}),
+		},
+		{
+			name: "result invalid index",
+			data: []byte{0x02, 0x2A},
+			schema: &Type{
+				Kind:   KindResult,
+				Result: &Result{Ok: ref("u8"), Err: ref("text")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "map",
+			data: []byte{0x08, 0x01, 0x0A, 0x00, 0x02, 0x14, 0x00},
+			schema: &Type{
+				Kind: KindMap,
+				Map:  &Map{Key: ref("u8"), Value: ref("u16")},
+			},
+			expected: VList([]Value{
+				VList([]Value{VIntFromInt64(1), VIntFromInt64(10)}),
+				VList([]Value{VIntFromInt64(2), VIntFromInt64(20)}),
+			}),
+		},
+		{
+			name: "opaque",
+			data: []byte{0x08, 0x2A, 0x00},
+			schema: &Type{
+				Kind:   KindOpaque,
+				Opaque: &Opaque{Type: ref("u16")},
+			},
 			expected: VIntFromInt64(42),
 		},
+		{
+			name: "opaque with trailing bytes",
+			data: []byte{0x0C, 0x2A, 0x00, 0x00},
+			schema: &Type{
+				Kind:   KindOpaque,
+				Opaque: &Opaque{Type: ref("u16")},
+			},
+			wantErr: true,
+		},
 		{
 			name: "array",
 			data: []byte{0x01, 0x02, 0x03},
@@ -187,15 +244,15 @@ func TestDecodeWithSchema(t *testing.T) {
 					},
 				},
 			},
-			expected: VStruct(map[string]Value{
-				"inner1": VStruct(map[string]Value{
-					"a": VIntFromInt64(8),
-					"b": VIntFromInt64(4),
-				}),
-				"inner2": VStruct(map[string]Value{
-					"x": VIntFromInt64(16),
-					"y": VIntFromInt64(32),
-				}),
+			expected: VStruct([]Field{
+				VField("inner1", VStruct([]Field{
+					VField("a", VIntFromInt64(8)),
+					VField("b", VIntFromInt64(4)),
+				})),
+				VField("inner2", VStruct([]Field{
+					VField("x", VIntFromInt64(16)),
+					VField("y", VIntFromInt64(32)),
+				})),
 			}),
 		},
 		{
@@ -234,15 +291,15 @@ func TestDecodeWithSchema(t *testing.T) {
 					},
 				},
 			},
-			expected: VStruct(map[string]Value{
-				"Display":        VBool(true),
-				"Legal":          VBool(false),
-				"Web":            VBool(true),
-				"Riot":           VBool(false),
-				"Email":          VBool(true),
-				"PgpFingerprint": VBool(false),
-				"Image":          VBool(false),
-				"Twitter":        VBool(true),
+			expected: VStruct([]Field{
+				VField("Display", VBool(true)),
+				VField("Legal", VBool(false)),
+				VField("Web", VBool(true)),
+				VField("Riot", VBool(false)),
+				VField("Email", VBool(true)),
+				VField("PgpFingerprint", VBool(false)),
+				VField("Image", VBool(false)),
+				VField("Twitter", VBool(true)),
 			}),
 		},
 		{
@@ -259,10 +316,10 @@ func TestDecodeWithSchema(t *testing.T) {
 					},
 				},
 			},
-			expected: VStruct(map[string]Value{
-				"Display": VBool(true),
-				"Email":   VBool(true),
-				"Twitter": VBool(true),
+			expected: VStruct([]Field{
+				VField("Display", VBool(true)),
+				VField("Email", VBool(true)),
+				VField("Twitter", VBool(true)),
 			}),
 		},
 		{
@@ -278,9 +335,9 @@ func TestDecodeWithSchema(t *testing.T) {
 					},
 				},
 			},
-			expected: VStruct(map[string]Value{
-				"Flag1": VBool(false),
-				"Flag2": VBool(false),
+			expected: VStruct([]Field{
+				VField("Flag1", VBool(false)),
+				VField("Flag2", VBool(false)),
 			}),
 		},
 		{
@@ -295,12 +352,78 @@ func TestDecodeWithSchema(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name:     "simple enum with explicit indices",
+			data:     []byte{0x02},
+			schema:   &Type{Kind: KindEnumSimple, EnumSimple: &EnumSimple{Variants: []string{"PERSISTENT", "LOCAL"}, Indices: []int{1, 2}}},
+			expected: VVariant("LOCAL", 2, nil),
+		},
+		{
+			name:    "simple enum with explicit indices, unknown index",
+			data:    []byte{0x00},
+			schema:  &Type{Kind: KindEnumSimple, EnumSimple: &EnumSimple{Variants: []string{"PERSISTENT", "LOCAL"}, Indices: []int{1, 2}}},
+			wantErr: true,
+		},
+		{
+			name: "sparse complex enum",
+			data: []byte{0x05, 0x07},
+			schema: &Type{Kind: KindEnumComplex, EnumComplex: &EnumComplex{
+				Variants: []NamedMember{{Name: "First", Type: nil}, {Name: "Fifth", Type: ref("u8")}},
+				Indices:  []int{0, 5},
+			}},
+			expected: VVariant("Fifth", 5, []Field{VField("", VIntFromInt64(7))}),
+		},
+		{
+			name: "struct fields keep their order",
+			data: []byte{0x01, 0x02},
+			schema: &Type{Kind: KindStruct, Struct: &Struct{Fields: []NamedMember{
+				{Name: "z", Type: ref("u8")},
+				{Name: "a", Type: ref("u8")},
+			}}},
+			expected: VStruct([]Field{VField("z", VIntFromInt64(1)), VField("a", VIntFromInt64(2))}),
+		},
+		{
+			name: "complex enum - unit variant",
+			data: []byte{0x00},
+			schema: &Type{Kind: KindEnumComplex, EnumComplex: &EnumComplex{
+				Variants: []NamedMember{{Name: "Unit", Type: nil}, {Name: "Other", Type: ref("u8")}},
+			}},
+			expected: VVariant("Unit", 0, []Field{}),
+		},
+		{
+			name: "complex enum - tuple variant",
+			data: []byte{0x00, 0x01, 0x02},
+			schema: &Type{Kind: KindEnumComplex, EnumComplex: &EnumComplex{
+				Variants: []NamedMember{{Name: "Pair", Type: &Type{Kind: KindTuple, Tuple: &Tuple{Fields: []Type{*ref("u8"), *ref("u8")}}}}},
+			}},
+			expected: VVariant("Pair", 0, []Field{VField("", VIntFromInt64(1)), VField("", VIntFromInt64(2))}),
+		},
+		{
+			name: "complex enum - struct variant",
+			data: []byte{0x01, 0x05, 0x01},
+			schema: &Type{Kind: KindEnumComplex, EnumComplex: &EnumComplex{
+				Variants: []NamedMember{
+					{Name: "None", Type: nil},
+					{Name: "Transfer", Type: &Type{Kind: KindStruct, Struct: &Struct{Fields: []NamedMember{
+						{Name: "amount", Type: ref("u8")},
+						{Name: "keepAlive", Type: ref("bool")},
+					}}}},
+				},
+			}},
+			expected: VVariant("Transfer", 1, []Field{VField("amount", VIntFromInt64(5)), VField("keepAlive", VBool(true))}),
+		},
+		{
+			name:     "option of a unit",
+			data:     []byte{0x01},
+			schema:   &Type{Kind: KindOption, Option: &Option{Type: ref("empty")}},
+			expected: VSome(VNull()),
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := NewReader(tt.data)
-			result, err := DecodeWithSchema(r, tt.schema)
+			result, err := DecodeWithSchema(r, tt.schema, nil)
 
 			if tt.wantErr {
 				if err == nil {
@@ -317,6 +440,14 @@ func TestDecodeWithSchema(t *testing.T) {
 			if !reflect.DeepEqual(result, tt.expected) {
 				t.Errorf("expected %v (%T), got %v (%T)", tt.expected, tt.expected, result, result)
 			}
+
+			encoded, err := EncodeWithSchema(result, tt.schema, nil)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			if !slices.Equal(encoded, tt.data) {
+				t.Errorf("round trip: expected %x, got %x", tt.data, encoded)
+			}
 		})
 	}
 }
@@ -337,13 +468,16 @@ func TestDecodeWithSchema_PrimitiveTypes(t *testing.T) {
 		{"text", []byte{0x14, 0x48, 0x65, 0x6C, 0x6C, 0x6F}, "text", VText("Hello")},
 		{"bytes", []byte{0x0C, 0x01, 0x02, 0x03}, "bytes", VBytes([]byte{1, 2, 3})},
 		{"empty", []byte{}, "empty", VNull()},
+		{"compact", []byte{0x01, 0x01}, "compact", VCompact(big.NewInt(64))},
+		{"bitvec", []byte{0x14, 0x15}, "bitvec", VBitSequence([]bool{true, false, true, false, true})},
+		{"empty bitvec", []byte{0x00}, "bitvec", VBitSequence([]bool{})},
 	}
 
 	for _, tt := range primitiveTests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := NewReader(tt.data)
 			schema := ref(tt.refType)
-			result, err := DecodeWithSchema(r, schema)
+			result, err := DecodeWithSchema(r, schema, nil)
 			if err != nil {
 				t.Errorf("unexpected error: %v", err)
 				return
@@ -351,6 +485,223 @@ func TestDecodeWithSchema_PrimitiveTypes(t *testing.T) {
 			if !reflect.DeepEqual(result, tt.expected) {
 				t.Errorf("expected %v, got %v", tt.expected, result)
 			}
+			if encoded, err := EncodeWithSchema(result, schema, nil); err != nil || !slices.Equal(encoded, tt.data) {
+				t.Errorf("round trip: expected %x, got %x, %v", tt.data, encoded, err)
+			}
+		})
+	}
+}
+
+// mapResolver resolves names of a single module and counts the lookups.
+type mapResolver struct {
+	types   map[string]*Type
+	lookups int
+}
+
+func (m *mapResolver) ResolveType(module, name string) (*Type, string, error) {
+	m.lookups++
+	typ, ok := m.types[module+"."+name]
+	if !ok {
+		return nil, "", fmt.Errorf("type %s.%s not found", module, name)
+	}
+	return typ, module, nil
+}
+
+func TestDecodeWithSchema_Resolver(t *testing.T) {
+	resolver := &mapResolver{types: map[string]*Type{
+		"runtime.Balance":   ref("u32"),
+		"runtime.Balances":  {Kind: KindVec, Vec: &Vec{Type: ref("Balance")}},
+		"runtime.AccountId": {Kind: KindImport, Import: &Import{Module: "crypto", Item: "Public"}},
+		"crypto.Public":     {Kind: KindArray, Array: &Array{Type: ref("Byte"), Len: 2}},
+		"crypto.Byte":       ref("u8"),
+		// A linked list: recursive through an Option, which consumes a byte each level.
+		"runtime.List": {Kind: KindStruct, Struct: &Struct{Fields: []NamedMember{
+			{Name: "head", Type: ref("u8")},
+			{Name: "tail", Type: &Type{Kind: KindOption, Option: &Option{Type: ref("List")}}},
+		}}},
+		// Aliases of each other, which never consume input.
+		"runtime.Ping": ref("Pong"),
+		"runtime.Pong": ref("Ping"),
+		// Pair<A, B> = (A, Vec<B>)
+		"runtime.Pair": {Kind: KindGeneric, Generic: &Generic{Params: []string{"A", "B"}, Type: &Type{
+			Kind: KindTuple, Tuple: &Tuple{Fields: []Type{*ref("A"), {Kind: KindVec, Vec: &Vec{Type: ref("B")}}}},
+		}}},
+	}}
+	generic := func(name string, args ...Type) *Type {
+		return &Type{Kind: KindRef, Ref: &name, Args: args}
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		schema   *Type
+		expected Value
+		wantErr  bool
+	}{
+		{
+			name:     "ref",
+			data:     []byte{0x08, 0x01, 0, 0, 0, 0x02, 0, 0, 0},
+			schema:   ref("Balances"),
+			expected: VList([]Value{VIntFromInt64(1), VIntFromInt64(2)}),
+		},
+		{
+			name:     "import resolves refs in its own module",
+			data:     []byte{0xaa, 0xbb},
+			schema:   ref("AccountId"),
+			expected: VList([]Value{VIntFromInt64(0xaa), VIntFromInt64(0xbb)}),
+		},
+		{
+			name:   "recursive type",
+			data:   []byte{0x01, 0x01, 0x02, 0x00},
+			schema: ref("List"),
+			expected: VStruct([]Field{
+				VField("head", VIntFromInt64(1)),
+				VField("tail", VSome(VStruct([]Field{VField("head", VIntFromInt64(2)), VField("tail", VNone())}))),
+			}),
+		},
+		{
+			name:    "alias cycle",
+			data:    []byte{0x01},
+			schema:  ref("Ping"),
+			wantErr: true,
+		},
+		{
+			name:    "unknown type",
+			data:    []byte{0x01},
+			schema:  ref("Unknown"),
+			wantErr: true,
+		},
+		{
+			name:     "generic instantiation",
+			data:     []byte{0x07, 0x04, 0x01, 0, 0, 0},
+			schema:   generic("Pair", *ref("u8"), *ref("Balance")),
+			expected: VList([]Value{VIntFromInt64(7), VList([]Value{VIntFromInt64(1)})}),
+		},
+		{
+			name:     "nested generic instantiation",
+			data:     []byte{0x07, 0x04, 0x08, 0x04, 0x09},
+			schema:   generic("Pair", *ref("u8"), *generic("Pair", *ref("u8"), *ref("u8"))),
+			expected: VList([]Value{VIntFromInt64(7), VList([]Value{VList([]Value{VIntFromInt64(8), VBytes([]byte{9})})})}),
+		},
+		{
+			name:    "generic argument count",
+			data:    []byte{0x07, 0x00},
+			schema:  generic("Pair", *ref("u8")),
+			wantErr: true,
+		},
+		{
+			name:    "uninstantiated generic",
+			data:    []byte{0x07, 0x00},
+			schema:  ref("Pair"),
+			wantErr: true,
+		},
+		{
+			name:     "arguments of a non-generic type",
+			data:     []byte{0x01, 0, 0, 0},
+			schema:   generic("Balance", *ref("u8")),
+			expected: VIntFromInt64(1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := DecodeWithSchemaInModule(NewReader(tt.data), tt.schema, resolver, "runtime")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %v", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+			encoded, err := EncodeWithSchemaInModule(result, tt.schema, resolver, "runtime")
+			if err != nil || !slices.Equal(encoded, tt.data) {
+				t.Errorf("round trip: expected %x, got %x, %v", tt.data, encoded, err)
+			}
+		})
+	}
+
+	// Each name is resolved once per decode.
+	resolver.lookups = 0
+	data := []byte{0x0c, 1, 0, 0, 0, 2, 0, 0, 0, 3, 0, 0, 0}
+	if _, err := DecodeWithSchemaInModule(NewReader(data), ref("Balances"), resolver, "runtime"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolver.lookups != 2 {
+		t.Errorf("expected 2 lookups, got %d", resolver.lookups)
+	}
+
+	// Without a resolver, only primitives decode.
+	if _, err := DecodeWithSchema(NewReader([]byte{0x01}), ref("Balance"), nil); err == nil {
+		t.Error("expected an error without a resolver")
+	}
+}
+
+func TestSchemaResolver(t *testing.T) {
+	resolver := NewSchemaResolver(&mapResolver{types: map[string]*Type{
+		"runtime.Balance":   ref("u32"),
+		"runtime.Amount":    ref("Balance"),
+		"runtime.AccountId": {Kind: KindImport, Import: &Import{Module: "crypto", Item: "Public"}},
+		"crypto.Public":     {Kind: KindArray, Array: &Array{Type: ref("u8"), Len: 32}},
+		"runtime.Ping":      ref("Pong"),
+		"runtime.Pong":      ref("Ping"),
+		"runtime.Boxed":     {Kind: KindGeneric, Generic: &Generic{Params: []string{"T"}, Type: &Type{Kind: KindVec, Vec: &Vec{Type: ref("T")}}}},
+		"runtime.Pair":      {Kind: KindGeneric, Generic: &Generic{Params: []string{"A", "B"}, Type: &Type{Kind: KindTuple, Tuple: &Tuple{Fields: []Type{*ref("A"), *ref("B")}}}}},
+	}})
+	boxed, pair := "Boxed", "Pair"
+
+	tests := []struct {
+		name   string
+		schema *Type
+		want   *Type
+		module string
+	}{
+		{"primitive", ref("u8"), ref("u8"), "runtime"},
+		{"aliases", ref("Amount"), ref("u32"), "runtime"},
+		{"import", ref("AccountId"), &Type{Kind: KindArray, Array: &Array{Type: ref("u8"), Len: 32}}, "crypto"},
+		{"generic", &Type{Kind: KindRef, Ref: &boxed, Args: []Type{*ref("Balance")}}, &Type{Kind: KindVec, Vec: &Vec{Type: ref("Balance")}}, "runtime"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, module, err := resolver.Resolve(tt.schema, "runtime")
+			if err != nil {
+				t.Fatalf("resolve: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) || module != tt.module {
+				t.Errorf("got %v in %q, want %v in %q", got, module, tt.want, tt.module)
+			}
 		})
 	}
+
+	// Instances are told apart by all of their arguments, wherever the arguments are stored, and
+	// shared by refs with equal ones.
+	args := []Type{*ref("u8"), *ref("u16")}
+	first, _, err := resolver.Resolve(&Type{Kind: KindRef, Ref: &pair, Args: args}, "runtime")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	args[1] = *ref("u32")
+	second, _, err := resolver.Resolve(&Type{Kind: KindRef, Ref: &pair, Args: args}, "runtime")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if want := (&Type{Kind: KindTuple, Tuple: &Tuple{Fields: []Type{*ref("u8"), *ref("u32")}}}); !reflect.DeepEqual(second, want) {
+		t.Errorf("Pair<u8, u32> = %v, want %v", second, want)
+	}
+	third, _, err := resolver.Resolve(&Type{Kind: KindRef, Ref: &pair, Args: []Type{*ref("u8"), *ref("u16")}}, "runtime")
+	if err != nil || third != first {
+		t.Errorf("Pair<u8, u16> = %v, %v, want the instance %v", third, err, first)
+	}
+
+	if _, _, err := resolver.Resolve(ref("Ping"), "runtime"); err == nil {
+		t.Error("expected an error for an alias cycle")
+	}
+	if _, _, err := resolver.Resolve(ref("Unknown"), "runtime"); err == nil {
+		t.Error("expected an error for an unknown type")
+	}
 }
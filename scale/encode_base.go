@@ -0,0 +1,81 @@
+package scale
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+)
+
+// EncodeCompact returns the SCALE compact encoding of a non-negative integer.
+func EncodeCompact(n *big.Int) []byte {
+	if n.IsUint64() {
+		v := n.Uint64()
+		switch {
+		case v < 1<<6:
+			return []byte{byte(v << 2)}
+		case v < 1<<14:
+			return binary.LittleEndian.AppendUint16(nil, uint16(v<<2|0b01))
+		case v < 1<<30:
+			return binary.LittleEndian.AppendUint32(nil, uint32(v<<2|0b10))
+		}
+	}
+
+	// Big-integer mode: the number of bytes (at least 4) minus 4, followed by the bytes little-endian.
+	bytesLE := reverseBytes(n.Bytes())
+	for len(bytesLE) < 4 {
+		bytesLE = append(bytesLE, 0)
+	}
+	return append([]byte{byte((len(bytesLE)-4)<<2 | 0b11)}, bytesLE...)
+}
+
+// EncodeCompactU64 returns the SCALE compact encoding of n.
+func EncodeCompactU64(n uint64) []byte {
+	return EncodeCompact(new(big.Int).SetUint64(n))
+}
+
+// EncodeInt returns the little-endian encoding of n in size bytes, two's complement if signed.
+func EncodeInt(n *big.Int, size int, signed bool) ([]byte, error) {
+	bits := uint(size * 8)
+	lowest, highest := new(big.Int), new(big.Int).Lsh(big.NewInt(1), bits)
+	if signed {
+		highest.Rsh(highest, 1)
+		lowest.Neg(highest)
+	}
+	if n.Cmp(lowest) < 0 || n.Cmp(highest) >= 0 {
+		return nil, fmt.Errorf("%s out of range for %d-bit integer", n, bits)
+	}
+
+	if n.Sign() < 0 {
+		n = new(big.Int).Add(n, new(big.Int).Lsh(big.NewInt(1), bits))
+	}
+	return reverseBytes(n.FillBytes(make([]byte, size))), nil
+}
+
+// EncodeBool returns the encoding of b, a byte of 0 or 1.
+func EncodeBool(b bool) []byte {
+	if b {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// EncodeBytes returns the encoding of a Vec<u8>: its compact length, followed by the bytes.
+func EncodeBytes(b []byte) []byte {
+	return append(EncodeCompactU64(uint64(len(b))), b...)
+}
+
+// EncodeBitSequence returns the encoding of a BitVec<u8, Lsb0>, see DecodeBitSequence.
+func EncodeBitSequence(bits []bool) []byte {
+	return append(EncodeCompactU64(uint64(len(bits))), PackBits(bits)...)
+}
+
+// PackBits packs bits in bytes, least significant bit first, as a BitVec<u8, Lsb0> stores them.
+func PackBits(bits []bool) []byte {
+	packed := make([]byte, (len(bits)+7)/8)
+	for i, bit := range bits {
+		if bit {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	return packed
+}
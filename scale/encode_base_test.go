@@ -0,0 +1,106 @@
+package scale_test
+
+import (
+	"math/big"
+	"reflect"
+	. "submarine/scale"
+	"testing"
+)
+
+func TestEncodeCompact(t *testing.T) {
+	u128Max, _ := new(big.Int).SetString("340282366920938463463374607431768211455", 10)
+
+	tests := []struct {
+		name     string
+		value    *big.Int
+		expected []byte
+	}{
+		{"mode 0 - zero", big.NewInt(0), []byte{0x00}},
+		{"mode 0 - max", big.NewInt(63), []byte{0xFC}},
+		{"mode 1 - 64", big.NewInt(64), []byte{0x01, 0x01}},
+		{"mode 1 - max", big.NewInt(16383), []byte{0xFD, 0xFF}},
+		{"mode 2 - 16384", big.NewInt(16384), []byte{0x02, 0x00, 0x01, 0x00}},
+		{"mode 2 - max", big.NewInt(1073741823), []byte{0xFE, 0xFF, 0xFF, 0xFF}},
+		{"mode 3 - 4 bytes", big.NewInt(1073741824), []byte{0x03, 0x00, 0x00, 0x00, 0x40}},
+		{"mode 3 - u64 max", new(big.Int).SetUint64(1<<64 - 1), []byte{0x13, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}},
+		{"mode 3 - u128 max", u128Max, append([]byte{0x33}, bytes(0xFF, 16)...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := EncodeCompact(tt.value)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %x, got %x", tt.expected, result)
+			}
+
+			decoded, err := DecodeCompact(NewReader(result))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if decoded.Cmp(tt.value) != 0 {
+				t.Errorf("round trip: expected %s, got %s", tt.value, decoded)
+			}
+		})
+	}
+}
+
+func TestEncodeInt(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    *big.Int
+		size     int
+		signed   bool
+		expected []byte
+		wantErr  bool
+	}{
+		{"u8", big.NewInt(0xff), 1, false, []byte{0xff}, false},
+		{"u16", big.NewInt(0x1234), 2, false, []byte{0x34, 0x12}, false},
+		{"u32 zero", big.NewInt(0), 4, false, []byte{0, 0, 0, 0}, false},
+		{"i8 min", big.NewInt(-128), 1, true, []byte{0x80}, false},
+		{"i16 minus one", big.NewInt(-1), 2, true, []byte{0xff, 0xff}, false},
+		{"i64 max", big.NewInt(1<<63 - 1), 8, true, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, false},
+		{"u8 overflow", big.NewInt(256), 1, false, nil, true},
+		{"u8 negative", big.NewInt(-1), 1, false, nil, true},
+		{"i8 overflow", big.NewInt(128), 1, true, nil, true},
+		{"i8 underflow", big.NewInt(-129), 1, true, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := EncodeInt(tt.value, tt.size, tt.signed)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %x", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %x, got %x", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestEncodeBitSequence(t *testing.T) {
+	bits := []bool{true, false, false, false, false, false, false, false, false, true}
+	encoded := EncodeBitSequence(bits)
+	if expected := []byte{0x28, 0x01, 0x02}; !reflect.DeepEqual(encoded, expected) {
+		t.Errorf("expected %x, got %x", expected, encoded)
+	}
+
+	decoded, err := DecodeBitSequence(NewReader(encoded))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, bits) {
+		t.Errorf("round trip: expected %v, got %v", bits, decoded)
+	}
+
+	// More bits than bytes left.
+	if _, err := DecodeBitSequence(NewReader([]byte{0x24, 0x01})); err == nil {
+		t.Error("expected an error for a truncated bit sequence")
+	}
+}
@@ -0,0 +1,413 @@
+package scale
+
+import (
+	"fmt"
+	"math/big"
+	. "submarine/errorspan"
+)
+
+// EncodeWithSchema encodes a value of schema, as decoded by DecodeWithSchema: decoding the result
+// gives back the value. Refs other than primitives, and imports, are resolved with resolver, which
+// may be nil for schemas made of primitives only.
+func EncodeWithSchema(value Value, schema *Type, resolver TypeResolver) ([]byte, *ErrorSpan) {
+	return EncodeWithSchemaInModule(value, schema, resolver, "")
+}
+
+// EncodeWithSchemaInModule is EncodeWithSchema for a schema whose refs are names in module.
+func EncodeWithSchemaInModule(value Value, schema *Type, resolver TypeResolver, module string) ([]byte, *ErrorSpan) {
+	e := &schemaEncoder{schemaTypes: newSchemaTypes(resolver)}
+	if err := e.encode(value, schema, module); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+type schemaEncoder struct {
+	*schemaTypes
+	buf []byte
+}
+
+func (e *schemaEncoder) encode(value Value, schema *Type, module string) *ErrorSpan {
+	if schema == nil {
+		// Unit variants of complex enums have no type.
+		return expectKind(value, ValueKindNull)
+	}
+
+	switch schema.Kind {
+	case KindStruct:
+		if err := expectKind(value, ValueKindStruct); err != nil {
+			return err
+		}
+		return e.encodeFields(value.Fields, schema.Struct, module)
+	case KindTuple:
+		return e.encodeTuple(value, schema.Tuple, module)
+	case KindEnumSimple:
+		return e.encodeEnumSimple(value, schema.EnumSimple)
+	case KindEnumComplex:
+		return e.encodeEnumComplex(value, schema.EnumComplex, module)
+	case KindVec:
+		return e.encodeVec(value, schema.Vec, module)
+	case KindOption:
+		return e.encodeOption(value, schema.Option, module)
+	case KindArray:
+		return e.encodeArray(value, schema.Array, module)
+	case KindRef:
+		if primitives[*schema.Ref] {
+			return e.encodeRef(value, *schema.Ref)
+		}
+		return e.encodeNamed(value, typeKey{module, *schema.Ref}, schema.Args)
+	case KindBitFlags:
+		return e.encodeBitFlags(value, schema.BitFlags)
+	case KindResult:
+		return e.encodeResult(value, schema.Result, module)
+	case KindMap:
+		return e.encodeMap(value, schema.Map, module)
+	case KindOpaque:
+		return e.encodeOpaque(value, schema.Opaque, module)
+	case KindGeneric:
+		return NewErrorSpan(fmt.Sprintf("generic type with parameters %v needs type arguments", schema.Generic.Params))
+	case KindImport:
+		if err := e.encodeNamed(value, typeKey{schema.Import.Module, schema.Import.Item}, nil); err != nil {
+			return err.WithPath(schema.Import.Module)
+		}
+		return nil
+	default:
+		return NewErrorSpan(fmt.Sprintf("unknown type kind: %s", schema.Kind))
+	}
+}
+
+func expectKind(value Value, kind ValueKind) *ErrorSpan {
+	if value.Kind != kind {
+		return NewErrorSpan(fmt.Sprintf("expected %s value, got %s", kind, value.Kind))
+	}
+	return nil
+}
+
+func (e *schemaEncoder) encodeNamed(value Value, key typeKey, args []Type) *ErrorSpan {
+	schema, module, _, err := e.lookup(key, args)
+	if err != nil {
+		return err
+	}
+	if err := e.encode(value, schema, module); err != nil {
+		return err.WithPath(key.name)
+	}
+	return nil
+}
+
+// intSizes are the sizes in bytes of the fixed-width integer primitives.
+var intSizes = map[string]int{
+	"u8": 1, "u16": 2, "u32": 4, "u64": 8, "u128": 16, "u256": 32,
+	"i8": 1, "i16": 2, "i32": 4, "i64": 8, "i128": 16, "i256": 32,
+}
+
+func (e *schemaEncoder) encodeRef(value Value, refType string) *ErrorSpan {
+	if size, ok := intSizes[refType]; ok {
+		if err := expectKind(value, ValueKindInt); err != nil {
+			return err
+		}
+		encoded, err := EncodeInt(value.Int, size, refType[0] == 'i')
+		if err != nil {
+			return WrapError(err)
+		}
+		e.buf = append(e.buf, encoded...)
+		return nil
+	}
+
+	switch refType {
+	case "bool":
+		if err := expectKind(value, ValueKindBool); err != nil {
+			return err
+		}
+		e.buf = append(e.buf, EncodeBool(value.Bool)...)
+	case "text":
+		if err := expectKind(value, ValueKindText); err != nil {
+			return err
+		}
+		e.buf = append(e.buf, EncodeBytes([]byte(value.Text))...)
+	case "bytes":
+		if err := expectKind(value, ValueKindBytes); err != nil {
+			return err
+		}
+		e.buf = append(e.buf, EncodeBytes(value.Bytes)...)
+	case "compact":
+		if err := expectKind(value, ValueKindCompact); err != nil {
+			return err
+		}
+		if value.Int.Sign() < 0 {
+			return NewErrorSpan(fmt.Sprintf("compact %s is negative", value.Int))
+		}
+		e.buf = append(e.buf, EncodeCompact(value.Int)...)
+	case "bitvec":
+		if err := expectKind(value, ValueKindBitSequence); err != nil {
+			return err
+		}
+		e.buf = append(e.buf, EncodeBitSequence(value.Bits)...)
+	case "empty":
+		return expectKind(value, ValueKindNull)
+	default:
+		return NewErrorSpan(fmt.Sprintf("unknown primitive type: %s", refType))
+	}
+	return nil
+}
+
+// encodeFields encodes the fields of a struct in the order of its schema, by name.
+func (e *schemaEncoder) encodeFields(fields []Field, s *Struct, module string) *ErrorSpan {
+	if len(fields) != len(s.Fields) {
+		return NewErrorSpan(fmt.Sprintf("expected %d fields, got %d", len(s.Fields), len(fields)))
+	}
+	value := VStruct(fields)
+	for _, field := range s.Fields {
+		fieldValue, ok := value.Field(field.Name)
+		if !ok {
+			return NewErrorSpan("missing field").WithPath(field.Name)
+		}
+		if err := e.encode(fieldValue, field.Type, module); err != nil {
+			return err.WithPath(field.Name)
+		}
+	}
+	return nil
+}
+
+func (e *schemaEncoder) encodeTuple(value Value, t *Tuple, module string) *ErrorSpan {
+	if err := expectKind(value, ValueKindList); err != nil {
+		return err
+	}
+	if len(value.List) != len(t.Fields) {
+		return NewErrorSpan(fmt.Sprintf("expected %d elements, got %d", len(t.Fields), len(value.List)))
+	}
+	for i := range t.Fields {
+		if err := e.encode(value.List[i], &t.Fields[i], module); err != nil {
+			return err.WithPathInt(i)
+		}
+	}
+	return nil
+}
+
+// variantIndex returns the discriminant of the variant at position in an enum's variants.
+func variantIndex(indices []int, position int) byte {
+	if indices == nil {
+		return byte(position)
+	}
+	return byte(indices[position])
+}
+
+func (e *schemaEncoder) encodeEnumSimple(value Value, enum *EnumSimple) *ErrorSpan {
+	if err := expectKind(value, ValueKindVariant); err != nil {
+		return err
+	}
+	for i, name := range enum.Variants {
+		if name == value.Variant.Name {
+			if len(value.Variant.Fields) != 0 {
+				return NewErrorSpan("variant of a simple enum has fields").WithPath(name)
+			}
+			e.buf = append(e.buf, variantIndex(enum.Indices, i))
+			return nil
+		}
+	}
+	return NewErrorSpan(fmt.Sprintf("unknown variant %s", value.Variant.Name))
+}
+
+func (e *schemaEncoder) encodeEnumComplex(value Value, enum *EnumComplex, module string) *ErrorSpan {
+	if err := expectKind(value, ValueKindVariant); err != nil {
+		return err
+	}
+	for i, variant := range enum.Variants {
+		if variant.Name == value.Variant.Name {
+			e.buf = append(e.buf, variantIndex(enum.Indices, i))
+			if err := e.encodeVariantFields(value.Variant.Fields, variant.Type, module); err != nil {
+				return err.WithPath(variant.Name)
+			}
+			return nil
+		}
+	}
+	return NewErrorSpan(fmt.Sprintf("unknown variant %s", value.Variant.Name))
+}
+
+// encodeVariantFields encodes the fields of a variant of a complex enum, see Variant.
+func (e *schemaEncoder) encodeVariantFields(fields []Field, schema *Type, module string) *ErrorSpan {
+	switch {
+	case schema == nil:
+		if len(fields) != 0 {
+			return NewErrorSpan(fmt.Sprintf("expected no fields, got %d", len(fields)))
+		}
+		return nil
+	case schema.Kind == KindStruct:
+		return e.encodeFields(fields, schema.Struct, module)
+	case schema.Kind == KindTuple:
+		list := make([]Value, len(fields))
+		for i, field := range fields {
+			list[i] = field.Value
+		}
+		return e.encodeTuple(VList(list), schema.Tuple, module)
+	default:
+		if len(fields) != 1 {
+			return NewErrorSpan(fmt.Sprintf("expected 1 field, got %d", len(fields)))
+		}
+		return e.encode(fields[0].Value, schema, module)
+	}
+}
+
+func isU8(schema *Type) bool {
+	return schema.Kind == KindRef && schema.Ref != nil && *schema.Ref == "u8"
+}
+
+func (e *schemaEncoder) encodeVec(value Value, v *Vec, module string) *ErrorSpan {
+	if isU8(v.Type) {
+		if err := expectKind(value, ValueKindBytes); err != nil {
+			return err
+		}
+		e.buf = append(e.buf, EncodeBytes(value.Bytes)...)
+		return nil
+	}
+
+	if err := expectKind(value, ValueKindList); err != nil {
+		return err
+	}
+	e.buf = append(e.buf, EncodeCompactU64(uint64(len(value.List)))...)
+	for i, elem := range value.List {
+		if err := e.encode(elem, v.Type, module); err != nil {
+			return err.WithPathInt(i)
+		}
+	}
+	return nil
+}
+
+func (e *schemaEncoder) encodeOption(value Value, o *Option, module string) *ErrorSpan {
+	if err := expectKind(value, ValueKindOption); err != nil {
+		return err
+	}
+	if value.Option == nil {
+		e.buf = append(e.buf, 0)
+		return nil
+	}
+	e.buf = append(e.buf, 1)
+	return e.encode(*value.Option, o.Type, module)
+}
+
+func (e *schemaEncoder) encodeArray(value Value, a *Array, module string) *ErrorSpan {
+	if isU8(a.Type) {
+		if err := expectKind(value, ValueKindBytes); err != nil {
+			return err
+		}
+		if len(value.Bytes) != a.Len {
+			return NewErrorSpan(fmt.Sprintf("expected %d bytes, got %d", a.Len, len(value.Bytes)))
+		}
+		e.buf = append(e.buf, value.Bytes...)
+		return nil
+	}
+
+	if err := expectKind(value, ValueKindList); err != nil {
+		return err
+	}
+	if len(value.List) != a.Len {
+		return NewErrorSpan(fmt.Sprintf("expected %d elements, got %d", a.Len, len(value.List)))
+	}
+	for i, elem := range value.List {
+		if err := e.encode(elem, a.Type, module); err != nil {
+			return err.WithPathInt(i)
+		}
+	}
+	return nil
+}
+
+// encodeBitFlags encodes a set from the struct of a boolean per flag that decodeBitFlags gives.
+// Bits no flag names decode to nothing, so can't be encoded.
+func (e *schemaEncoder) encodeBitFlags(value Value, bf *BitFlags) *ErrorSpan {
+	if err := expectKind(value, ValueKindStruct); err != nil {
+		return err
+	}
+
+	size := 0
+	for _, n := range []int{1, 2, 4, 8, 16, 32} {
+		if bf.BitLength <= n*8 {
+			size = n
+			break
+		}
+	}
+	if size == 0 {
+		return NewErrorSpan(fmt.Sprintf("unsupported bit length: %d", bf.BitLength))
+	}
+
+	rawValue := new(big.Int)
+	for _, field := range value.Fields {
+		if err := expectKind(field.Value, ValueKindBool); err != nil {
+			return err.WithPath(field.Name)
+		}
+		found := false
+		for _, flag := range bf.Flags {
+			if flag.Name == field.Name {
+				if field.Value.Bool {
+					rawValue.Or(rawValue, new(big.Int).SetUint64(flag.Value))
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return NewErrorSpan(fmt.Sprintf("unknown flag %s", field.Name))
+		}
+	}
+
+	encoded, err := EncodeInt(rawValue, size, false)
+	if err != nil {
+		return WrapError(err)
+	}
+	e.buf = append(e.buf, encoded...)
+	return nil
+}
+
+func (e *schemaEncoder) encodeResult(value Value, res *Result, module string) *ErrorSpan {
+	if err := expectKind(value, ValueKindVariant); err != nil {
+		return err
+	}
+
+	variant := value.Variant
+	var schema *Type
+	switch variant.Name {
+	case "Ok":
+		e.buf, schema = append(e.buf, 0), res.Ok
+	case "Err":
+		e.buf, schema = append(e.buf, 1), res.Err
+	default:
+		return NewErrorSpan(fmt.Sprintf("unknown variant %s, expected Ok or Err", variant.Name))
+	}
+	if len(variant.Fields) != 1 {
+		return NewErrorSpan(fmt.Sprintf("expected 1 field, got %d", len(variant.Fields))).WithPath(variant.Name)
+	}
+	if err := e.encode(variant.Fields[0].Value, schema, module); err != nil {
+		return err.WithPath(variant.Name)
+	}
+	return nil
+}
+
+func (e *schemaEncoder) encodeMap(value Value, m *Map, module string) *ErrorSpan {
+	if err := expectKind(value, ValueKindList); err != nil {
+		return err
+	}
+	e.buf = append(e.buf, EncodeCompactU64(uint64(len(value.List)))...)
+	for i, entry := range value.List {
+		if entry.Kind != ValueKindList || len(entry.List) != 2 {
+			return NewErrorSpan("expected a list of a key and a value").WithPathInt(i)
+		}
+		if err := e.encode(entry.List[0], m.Key, module); err != nil {
+			return err.WithPath("key").WithPathInt(i)
+		}
+		if err := e.encode(entry.List[1], m.Value, module); err != nil {
+			return err.WithPath("value").WithPathInt(i)
+		}
+	}
+	return nil
+}
+
+func (e *schemaEncoder) encodeOpaque(value Value, o *Opaque, module string) *ErrorSpan {
+	outer := e.buf
+	e.buf = nil
+	err := e.encode(value, o.Type, module)
+	inner := e.buf
+	e.buf = outer
+	if err != nil {
+		return err
+	}
+	e.buf = append(e.buf, EncodeBytes(inner)...)
+	return nil
+}
@@ -0,0 +1,68 @@
+package scale_test
+
+import (
+	"math/big"
+	. "submarine/scale"
+	"testing"
+)
+
+func TestEncodeWithSchemaErrors(t *testing.T) {
+	pair := &Type{Kind: KindStruct, Struct: &Struct{Fields: []NamedMember{
+		{Name: "a", Type: ref("u8")},
+		{Name: "b", Type: ref("u8")},
+	}}}
+	colors := &Type{Kind: KindEnumSimple, EnumSimple: &EnumSimple{Variants: []string{"Red", "Green"}}}
+	shapes := &Type{Kind: KindEnumComplex, EnumComplex: &EnumComplex{Variants: []NamedMember{
+		{Name: "Point", Type: nil},
+		{Name: "Circle", Type: ref("u8")},
+	}}}
+
+	tests := []struct {
+		name   string
+		value  Value
+		schema *Type
+	}{
+		{"wrong kind", VText("1"), ref("u8")},
+		{"int out of range", VIntFromInt64(256), ref("u8")},
+		{"int for compact", VIntFromInt64(1), ref("compact")},
+		{"negative compact", VCompact(big.NewInt(-1)), ref("compact")},
+		{"missing field", VStruct([]Field{VField("a", VIntFromInt64(1)), VField("c", VIntFromInt64(2))}), pair},
+		{"extra field", VStruct([]Field{VField("a", VIntFromInt64(1)), VField("b", VIntFromInt64(2)), VField("c", VIntFromInt64(3))}), pair},
+		{"tuple length", VList([]Value{VIntFromInt64(1)}), &Type{Kind: KindTuple, Tuple: &Tuple{Fields: []Type{*ref("u8"), *ref("u8")}}}},
+		{"unknown variant", VVariant("Blue", 2, nil), colors},
+		{"fields of a simple variant", VVariant("Red", 0, []Field{VField("", VIntFromInt64(1))}), colors},
+		{"fields of a unit variant", VVariant("Point", 0, []Field{VField("", VIntFromInt64(1))}), shapes},
+		{"missing variant field", VVariant("Circle", 1, []Field{}), shapes},
+		{"text for option", VText("x"), &Type{Kind: KindOption, Option: &Option{Type: ref("u8")}}},
+		{"array length", VBytes([]byte{1, 2}), &Type{Kind: KindArray, Array: &Array{Type: ref("u8"), Len: 3}}},
+		{"unknown flag", VStruct([]Field{VField("Other", VBool(true))}), &Type{Kind: KindBitFlags, BitFlags: &BitFlags{BitLength: 8, Flags: []BitFlag{{Name: "A", Value: 1}}}}},
+		{"result variant", VVariant("Maybe", 2, []Field{VField("", VIntFromInt64(1))}), &Type{Kind: KindResult, Result: &Result{Ok: ref("u8"), Err: ref("u8")}}},
+		{"map entry", VList([]Value{VIntFromInt64(1)}), &Type{Kind: KindMap, Map: &Map{Key: ref("u8"), Value: ref("u8")}}},
+		{"unresolved ref", VIntFromInt64(1), ref("Balance")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if encoded, err := EncodeWithSchema(tt.value, tt.schema, nil); err == nil {
+				t.Errorf("expected an error, got %x", encoded)
+			}
+		})
+	}
+}
+
+func TestEncodeWithSchemaFieldOrder(t *testing.T) {
+	// Struct fields are encoded in the order of the schema.
+	schema := &Type{Kind: KindStruct, Struct: &Struct{Fields: []NamedMember{
+		{Name: "a", Type: ref("u8")},
+		{Name: "b", Type: ref("u16")},
+	}}}
+	value := VStruct([]Field{VField("b", VIntFromInt64(2)), VField("a", VIntFromInt64(1))})
+
+	encoded, err := EncodeWithSchema(value, schema, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []byte{0x01, 0x02, 0x00}; string(encoded) != string(expected) {
+		t.Errorf("expected %x, got %x", expected, encoded)
+	}
+}
@@ -0,0 +1,148 @@
+package scale
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// MarshalJSON implements json.Marshaler with a lossless form, from which UnmarshalJSON gives back
+// the same value: an object with the kind of the value as its only key, e.g. {"int": "42"},
+// {"bytes": "0x0102"}, {"struct": [{"name": "a", "value": {"bool": true}}]},
+// {"variant": {"name": "Some", "index": 1, "fields": [...]}}, {"option": null} for None or
+// {"bitSequence": "101"}. Integers are strings, as JSON numbers lose precision in JavaScript.
+func (v Value) MarshalJSON() ([]byte, error) {
+	var payload any
+	switch v.Kind {
+	case ValueKindNull:
+		payload = nil
+	case ValueKindInt, ValueKindCompact:
+		if v.Int == nil {
+			return nil, fmt.Errorf("%s value without an integer", v.Kind)
+		}
+		payload = v.Int.String()
+	case ValueKindBool:
+		payload = v.Bool
+	case ValueKindBytes:
+		payload = "0x" + hex.EncodeToString(v.Bytes)
+	case ValueKindText:
+		payload = v.Text
+	case ValueKindList:
+		payload = v.List
+	case ValueKindStruct:
+		payload = v.Fields
+	case ValueKindVariant:
+		if v.Variant == nil {
+			return nil, fmt.Errorf("variant value without a variant")
+		}
+		payload = v.Variant
+	case ValueKindOption:
+		payload = v.Option
+	case ValueKindBitSequence:
+		bits := make([]byte, len(v.Bits))
+		for i, bit := range v.Bits {
+			bits[i] = '0'
+			if bit {
+				bits[i] = '1'
+			}
+		}
+		payload = string(bits)
+	default:
+		return nil, fmt.Errorf("unknown value kind %d", v.Kind)
+	}
+	return json.Marshal(map[string]any{v.Kind.String(): payload})
+}
+
+// UnmarshalJSON implements json.Unmarshaler for the form of MarshalJSON.
+func (v *Value) UnmarshalJSON(data []byte) error {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	if len(object) != 1 {
+		return fmt.Errorf("value must have a single kind, got %d keys", len(object))
+	}
+
+	for key, payload := range object {
+		kind := ValueKind(-1)
+		for i, name := range valueKindNames {
+			if name == key {
+				kind = ValueKind(i)
+			}
+		}
+		value, err := unmarshalValue(kind, payload)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		*v = value
+	}
+	return nil
+}
+
+func unmarshalValue(kind ValueKind, payload json.RawMessage) (Value, error) {
+	value := Value{Kind: kind}
+	switch kind {
+	case ValueKindNull:
+		if string(payload) != "null" {
+			return Value{}, fmt.Errorf("expected null")
+		}
+	case ValueKindInt, ValueKindCompact:
+		var text string
+		if err := json.Unmarshal(payload, &text); err != nil {
+			return Value{}, err
+		}
+		n, ok := new(big.Int).SetString(text, 10)
+		if !ok {
+			return Value{}, fmt.Errorf("invalid integer %q", text)
+		}
+		value.Int = n
+	case ValueKindBool:
+		return value, json.Unmarshal(payload, &value.Bool)
+	case ValueKindBytes:
+		var text string
+		if err := json.Unmarshal(payload, &text); err != nil {
+			return Value{}, err
+		}
+		digits, ok := strings.CutPrefix(text, "0x")
+		if !ok {
+			return Value{}, fmt.Errorf("bytes must start with 0x")
+		}
+		b, err := hex.DecodeString(digits)
+		if err != nil {
+			return Value{}, err
+		}
+		value.Bytes = b
+	case ValueKindText:
+		return value, json.Unmarshal(payload, &value.Text)
+	case ValueKindList:
+		return value, json.Unmarshal(payload, &value.List)
+	case ValueKindStruct:
+		return value, json.Unmarshal(payload, &value.Fields)
+	case ValueKindVariant:
+		if err := json.Unmarshal(payload, &value.Variant); err != nil {
+			return Value{}, err
+		}
+		if value.Variant == nil {
+			return Value{}, fmt.Errorf("expected a variant")
+		}
+	case ValueKindOption:
+		return value, json.Unmarshal(payload, &value.Option)
+	case ValueKindBitSequence:
+		var text string
+		if err := json.Unmarshal(payload, &text); err != nil {
+			return Value{}, err
+		}
+		value.Bits = make([]bool, len(text))
+		for i, c := range text {
+			if c != '0' && c != '1' {
+				return Value{}, fmt.Errorf("invalid bit %q", c)
+			}
+			value.Bits[i] = c == '1'
+		}
+	default:
+		return Value{}, fmt.Errorf("unknown value kind")
+	}
+	return value, nil
+}
@@ -0,0 +1,77 @@
+package scale_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	. "submarine/scale"
+	"testing"
+)
+
+func TestValueJSON(t *testing.T) {
+	u128Max, _ := new(big.Int).SetString("340282366920938463463374607431768211455", 10)
+
+	tests := []struct {
+		name     string
+		value    Value
+		expected string
+	}{
+		{"null", VNull(), `{"null":null}`},
+		{"int", VInt(u128Max), `{"int":"340282366920938463463374607431768211455"}`},
+		{"negative int", VIntFromInt64(-5), `{"int":"-5"}`},
+		{"compact", VCompact(big.NewInt(64)), `{"compact":"64"}`},
+		{"bool", VBool(true), `{"bool":true}`},
+		{"bytes", VBytes([]byte{0xde, 0xad}), `{"bytes":"0xdead"}`},
+		{"text", VText("hi"), `{"text":"hi"}`},
+		{"list", VList([]Value{VBool(false)}), `{"list":[{"bool":false}]}`},
+		{"struct", VStruct([]Field{VField("z", VIntFromInt64(1)), VField("a", VNull())}), `{"struct":[{"name":"z","value":{"int":"1"}},{"name":"a","value":{"null":null}}]}`},
+		{"simple variant", VVariant("Normal", 0, nil), `{"variant":{"name":"Normal","index":0,"fields":null}}`},
+		{"unit variant", VVariant("Unit", 3, []Field{}), `{"variant":{"name":"Unit","index":3,"fields":[]}}`},
+		{"tuple variant", VVariant("Pair", 1, []Field{VField("", VText("a"))}), `{"variant":{"name":"Pair","index":1,"fields":[{"name":"","value":{"text":"a"}}]}}`},
+		{"none", VNone(), `{"option":null}`},
+		{"some none", VSome(VNone()), `{"option":{"option":null}}`},
+		{"bit sequence", VBitSequence([]bool{true, false, true}), `{"bitSequence":"101"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, data)
+			}
+
+			var decoded Value
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, tt.value) {
+				t.Errorf("round trip: expected %+v, got %+v", tt.value, decoded)
+			}
+		})
+	}
+}
+
+func TestValueJSONErrors(t *testing.T) {
+	for _, data := range []string{
+		`[]`,
+		`{}`,
+		`{"int":"1","bool":true}`,
+		`{"float":1.5}`,
+		`{"int":1}`,
+		`{"int":"1.5"}`,
+		`{"bytes":"dead"}`,
+		`{"bytes":"0xzz"}`,
+		`{"null":1}`,
+		`{"variant":null}`,
+		`{"bitSequence":"102"}`,
+		`{"list":[{"int":"x"}]}`,
+	} {
+		var value Value
+		if err := json.Unmarshal([]byte(data), &value); err == nil {
+			t.Errorf("expected an error for %s, got %+v", data, value)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package scale
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrLimitExceeded is wrapped by the errors of decoding input that goes over a limit of its
+// DecodeOptions.
+var ErrLimitExceeded = errors.New("decode limit exceeded")
+
+// DecodeOptions limit the resources that decoding untrusted input may take. A zero field sets no
+// limit.
+type DecodeOptions struct {
+	// MaxLength is the largest number of elements of a collection: a Vec, a map, text, bytes or a
+	// bit sequence.
+	MaxLength int
+	// MaxAllocation is the most memory, in bytes, the values decoded from a reader may take in total,
+	// as estimated from the sizes of the collections allocated for them.
+	MaxAllocation int
+	// MaxDepth is the deepest that values may nest, e.g. in recursive types or nested calls.
+	MaxDepth int
+}
+
+// DefaultDecodeOptions are the options of NewReader, generous enough for metadata and blocks of
+// any chain. Services decoding input from users should set tighter ones with NewReaderWithOptions.
+var DefaultDecodeOptions = DecodeOptions{
+	MaxLength:     1 << 24,
+	MaxAllocation: 1 << 27,
+	MaxDepth:      256,
+}
+
+// limits keeps track of what was decoded from a reader against its options.
+type limits struct {
+	options   DecodeOptions
+	allocated int
+	depth     int
+}
+
+// DecodeLength decodes the compact length of a collection whose elements take at least minSize
+// bytes each, checking it against the limits of the reader and the bytes left.
+func DecodeLength(r *Reader, minSize int) (int, error) {
+	start := r.pos
+	length, ok, err := decodeCompactUint64(r)
+	if err != nil {
+		return 0, err
+	}
+	maxLength := math.MaxInt32
+	if r.limits != nil && r.limits.options.MaxLength > 0 {
+		maxLength = r.limits.options.MaxLength
+	}
+	if !ok || length > uint64(maxLength) {
+		// Decode the length again, only to report it.
+		r.pos = start
+		big, _ := DecodeCompact(r)
+		return 0, fmt.Errorf("%w: length %s over the maximum of %d", ErrLimitExceeded, big, maxLength)
+	}
+	n := int(length)
+	if minSize > 0 && n > r.Remaining()/minSize {
+		return 0, fmt.Errorf("length %d needs at least %d bytes, only %d left", n, n*minSize, r.Remaining())
+	}
+	return n, nil
+}
+
+// Allocate accounts for the allocation of count values of size bytes, failing if it would take the
+// values decoded from the reader over its MaxAllocation.
+func (r *Reader) Allocate(count, size int) error {
+	if r.limits == nil || r.limits.options.MaxAllocation <= 0 || size <= 0 {
+		return nil
+	}
+	left := r.limits.options.MaxAllocation - r.limits.allocated
+	if count > left/size {
+		return fmt.Errorf("%w: allocating %d values of %d bytes, over the maximum of %d bytes in total",
+			ErrLimitExceeded, count, size, r.limits.options.MaxAllocation)
+	}
+	r.limits.allocated += count * size
+	return nil
+}
+
+// Enter starts decoding a value nested in the current one, failing if it goes deeper than the
+// MaxDepth of the reader. Every successful Enter must be followed by a Leave.
+func (r *Reader) Enter() error {
+	if r.limits == nil {
+		return nil
+	}
+	if max := r.limits.options.MaxDepth; max > 0 && r.limits.depth >= max {
+		return fmt.Errorf("%w: values nested over the maximum depth of %d", ErrLimitExceeded, max)
+	}
+	r.limits.depth++
+	return nil
+}
+
+// Leave ends decoding a value started with Enter.
+func (r *Reader) Leave() {
+	if r.limits != nil {
+		r.limits.depth--
+	}
+}
@@ -0,0 +1,158 @@
+package scale_test
+
+import (
+	"errors"
+	"math/big"
+	"runtime"
+	"strings"
+	. "submarine/scale"
+	"testing"
+)
+
+func vecOf(elem *Type) *Type {
+	return &Type{Kind: KindVec, Vec: &Vec{Type: elem}}
+}
+
+func optionOf(elem *Type) *Type {
+	return &Type{Kind: KindOption, Option: &Option{Type: elem}}
+}
+
+func TestDecodeLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		schema  *Type
+		options DecodeOptions
+		err     string
+	}{
+		{
+			name:    "max length",
+			data:    []byte{5 << 2, 1, 2, 3, 4, 5},
+			schema:  ref("bytes"),
+			options: DecodeOptions{MaxLength: 4},
+			err:     "length 5 over the maximum of 4",
+		},
+		{
+			name: "length over 2^63",
+			// Big-integer mode compact of 8 bytes, 2^64 - 1.
+			data:    []byte{0x13, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			schema:  vecOf(ref("u8")),
+			options: DefaultDecodeOptions,
+			err:     "length 18446744073709551615 over the maximum",
+		},
+		{
+			name:    "max allocation",
+			data:    append([]byte{0x91, 0x01}, make([]byte, 100)...), // compact 100
+			schema:  ref("text"),
+			options: DecodeOptions{MaxAllocation: 99},
+			err:     "over the maximum of 99 bytes in total",
+		},
+		{
+			name:    "max allocation of values",
+			data:    append([]byte{0x91, 0x01}, make([]byte, 400)...),
+			schema:  vecOf(ref("u32")),
+			options: DecodeOptions{MaxAllocation: 1000},
+			err:     "allocating 100 values",
+		},
+		{
+			name:    "max depth",
+			data:    []byte{1, 1, 1, 7},
+			schema:  optionOf(optionOf(optionOf(ref("u8")))),
+			options: DecodeOptions{MaxDepth: 3},
+			err:     "nested over the maximum depth of 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errSpan := DecodeWithSchema(NewReaderWithOptions(tt.data, tt.options), tt.schema, nil)
+			if errSpan == nil || !strings.Contains(errSpan.Error(), tt.err) {
+				t.Fatalf("expected an error containing %q, got %v", tt.err, errSpan)
+			}
+			if !errors.Is(errSpan, ErrLimitExceeded) {
+				t.Errorf("expected ErrLimitExceeded, got %v", errSpan)
+			}
+		})
+	}
+
+	// Within the limits, the same inputs decode.
+	if _, errSpan := DecodeWithSchema(NewReaderWithOptions([]byte{1, 1, 1, 7}, DecodeOptions{MaxDepth: 4}), optionOf(optionOf(optionOf(ref("u8")))), nil); errSpan != nil {
+		t.Errorf("max depth 4: %v", errSpan)
+	}
+
+	// The limits apply to the generic and reflection-based decoders as well.
+	r := NewReaderWithOptions([]byte{2 << 2, 1, 2}, DecodeOptions{MaxLength: 1})
+	if _, err := DecodeVec(r, 1, DecodeU8); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("DecodeVec: expected ErrLimitExceeded, got %v", err)
+	}
+	var nested [][][]uint8
+	r = NewReaderWithOptions([]byte{1 << 2, 1 << 2, 0}, DecodeOptions{MaxDepth: 2})
+	if err := Decode(r, &nested); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Decode: expected ErrLimitExceeded, got %v", err)
+	}
+}
+
+func TestDecodeHugeLength(t *testing.T) {
+	// Vec<u32> claiming 2^22 - 1 elements, in 4 bytes.
+	data := []byte{0xfe, 0xff, 0xff, 0x00}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, errSpan := DecodeWithSchema(NewReader(data), vecOf(ref("u32")), nil)
+	runtime.ReadMemStats(&after)
+
+	if errSpan == nil || !strings.Contains(errSpan.Error(), "length 4194303 needs at least") {
+		t.Errorf("expected a length error, got %v", errSpan)
+	}
+	if bytes := after.TotalAlloc - before.TotalAlloc; bytes > 1<<20 {
+		t.Errorf("allocated %d bytes decoding %d bytes", bytes, len(data))
+	}
+}
+
+// fuzzSchema covers every kind of type that decodes without a resolver.
+var fuzzSchema = &Type{Kind: KindStruct, Struct: &Struct{Fields: []NamedMember{
+	{Name: "list", Type: vecOf(&Type{Kind: KindTuple, Tuple: &Tuple{Fields: []Type{*ref("u8"), *optionOf(ref("text"))}}})},
+	{Name: "map", Type: &Type{Kind: KindMap, Map: &Map{Key: ref("u32"), Value: ref("bytes")}}},
+	{Name: "bits", Type: ref("bitvec")},
+	{Name: "amount", Type: ref("compact")},
+	{Name: "array", Type: &Type{Kind: KindArray, Array: &Array{Len: 2, Type: ref("i128")}}},
+	{Name: "enum", Type: &Type{Kind: KindEnumComplex, EnumComplex: &EnumComplex{Variants: []NamedMember{
+		{Name: "Unit"},
+		{Name: "Nested", Type: vecOf(vecOf(ref("bool")))},
+	}}}},
+	{Name: "result", Type: &Type{Kind: KindResult, Result: &Result{Ok: ref("u64"), Err: &Type{Kind: KindOpaque, Opaque: &Opaque{Type: ref("u16")}}}}},
+}}}
+
+func FuzzDecodeWithSchema(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{4, 1, 1, 8, 'h', 'i', 4, 1, 0, 0, 0, 4, 9, 9, 0x13, 0xff, 0x00})
+	f.Add([]byte{0xfe, 0xff, 0xff, 0xff, 0x03})
+
+	options := DecodeOptions{MaxLength: 1 << 10, MaxAllocation: 1 << 20, MaxDepth: 32}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		value, errSpan := DecodeWithSchema(NewReaderWithOptions(data, options), fuzzSchema, nil)
+		if errSpan != nil {
+			return
+		}
+		if _, errSpan := EncodeWithSchema(value, fuzzSchema, nil); errSpan != nil {
+			t.Errorf("decoded value doesn't encode: %v", errSpan)
+		}
+	})
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	seed, _ := Marshal(account{Free: big.NewInt(1), Reserved: big.NewInt(2), Delta: big.NewInt(-1), Status: status{Idle: &struct{}{}}})
+	f.Add(seed)
+	f.Add([]byte{0xff})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var decoded account
+		r := NewReaderWithOptions(data, DecodeOptions{MaxLength: 1 << 10, MaxAllocation: 1 << 20, MaxDepth: 32})
+		if err := Decode(r, &decoded); err != nil {
+			return
+		}
+		if _, err := Marshal(decoded); err != nil {
+			t.Errorf("decoded value doesn't encode: %v", err)
+		}
+	})
+}
@@ -0,0 +1,843 @@
+package scale
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Decoder is implemented by types that decode themselves, like base.AddressId. Unmarshal calls
+// Decode on a pointer to the value.
+type Decoder interface {
+	Decode(r *Reader) error
+}
+
+// Encoder is implemented by types that encode themselves, like base.AddressId.
+type Encoder interface {
+	Encode() ([]byte, error)
+}
+
+// Enum is implemented by structs that hold a SCALE enum, like base.Address does: a pointer field
+// per variant, of which the one of the value is set and the others are nil. Variants are indexed
+// in field order unless tagged with an index, e.g. `scale:"Transfer,index=2"`, which the following
+// variants count on from; unit variants are pointers to struct{}.
+type Enum interface {
+	ScaleEnum()
+}
+
+var (
+	decoderType = reflect.TypeFor[Decoder]()
+	encoderType = reflect.TypeFor[Encoder]()
+	enumType    = reflect.TypeFor[Enum]()
+	bigIntType  = reflect.TypeFor[*big.Int]()
+)
+
+// Unmarshal decodes data into the value v points to, which must take all of data. The value is
+// decoded according to its Go type:
+//
+//   - bool, sized integers, and *big.Int as a u128, or as tagged with `scale:",i128"`, u256 or i256
+//   - integers and *big.Int tagged with `scale:",compact"` as Compact<T>
+//   - string as text, []byte as Vec<u8> and [N]byte as [u8; N]
+//   - slices as Vec<T> and arrays as [T; N]
+//   - pointers as Option<T>, *bool as the single-byte Option<bool>
+//   - structs as their exported fields in order, skipping those tagged `scale:"-"` and flattening
+//     embedded structs; structs implementing Enum as enums
+//   - types implementing Decoder with their Decode method
+func Unmarshal(data []byte, v any) error {
+	r := NewReader(data)
+	if err := Decode(r, v); err != nil {
+		return err
+	}
+	if r.Remaining() > 0 {
+		return fmt.Errorf("unmarshal: %d bytes left over", r.Remaining())
+	}
+	return nil
+}
+
+// Decode decodes a value from r into the value v points to, see Unmarshal.
+func Decode(r *Reader, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("unmarshal: expected a non-nil pointer, got %T", v)
+	}
+	return decodeReflect(r, rv.Elem(), fieldOptions{})
+}
+
+// Marshal encodes v, the inverse of Unmarshal. Like nested ones, a pointer passed to Marshal is
+// encoded as an Option.
+func Marshal(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return nil, fmt.Errorf("marshal: nil value")
+	}
+	// Make the value addressable, for Encode methods with pointer receivers.
+	addressable := reflect.New(rv.Type()).Elem()
+	addressable.Set(rv)
+
+	var buf bytes.Buffer
+	if err := encodeReflect(&buf, addressable, fieldOptions{}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalValue converts a Value, as decoded with a schema, into the value v points to. Struct
+// fields are matched by name, their tag name or else their Go name ignoring case and underscores,
+// e.g. FreeBalance for free_balance; fields of the Value without a Go field are ignored. Enum
+// variants are matched by name in the same way. Decode methods don't apply to Values, which are
+// converted according to the underlying Go type, e.g. [32]byte for base.AddressId.
+func UnmarshalValue(value Value, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("unmarshal: expected a non-nil pointer, got %T", v)
+	}
+	return fromValue(value, rv.Elem(), fieldOptions{})
+}
+
+// fieldOptions are the options of a `scale:"name,options..."` tag.
+type fieldOptions struct {
+	compact bool
+	// intSize and signed give the encoding of a *big.Int, u128 by default.
+	intSize int
+	signed  bool
+	// index is the variant index of a field of an Enum, -1 for its position.
+	index int
+}
+
+// structField is a field of a struct, or a variant of an Enum.
+type structField struct {
+	name    string
+	tagged  bool // name was given by a tag
+	index   []int
+	options fieldOptions
+}
+
+func parseTag(field reflect.StructField) (string, fieldOptions, error) {
+	options := fieldOptions{index: -1}
+	tag := field.Tag.Get("scale")
+	name, rest, _ := strings.Cut(tag, ",")
+	if rest == "" {
+		return name, options, nil
+	}
+	for _, option := range strings.Split(rest, ",") {
+		switch option {
+		case "compact":
+			options.compact = true
+		case "u128", "u256", "i128", "i256":
+			options.signed = option[0] == 'i'
+			options.intSize, _ = strconv.Atoi(option[1:])
+			options.intSize /= 8
+		default:
+			indexText, ok := strings.CutPrefix(option, "index=")
+			index, err := strconv.ParseUint(indexText, 10, 8)
+			if !ok || err != nil {
+				return "", options, fmt.Errorf("field %s: invalid scale tag option %q", field.Name, option)
+			}
+			options.index = int(index)
+		}
+	}
+	return name, options, nil
+}
+
+// structFields returns the fields of a struct that are encoded, in order.
+func structFields(t reflect.Type) ([]structField, error) {
+	var fields []structField
+	for i := range t.NumField() {
+		field := t.Field(i)
+		// Like encoding/json, embedded structs of unexported types still have their exported fields
+		// promoted.
+		promoted := field.Anonymous && field.Type.Kind() == reflect.Struct && !hasMethods(field.Type)
+		if (!field.IsExported() && !promoted) || field.Tag.Get("scale") == "-" {
+			continue
+		}
+		name, options, err := parseTag(field)
+		if err != nil {
+			return nil, err
+		}
+
+		if promoted && (name == "" || !field.IsExported()) {
+			embedded, err := structFields(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range embedded {
+				f.index = append([]int{i}, f.index...)
+				fields = append(fields, f)
+			}
+			continue
+		}
+
+		tagged := name != ""
+		if !tagged {
+			name = field.Name
+		}
+		fields = append(fields, structField{name: name, tagged: tagged, index: []int{i}, options: options})
+	}
+	return fields, nil
+}
+
+// hasMethods reports whether values of t decode, encode or are enums by their own methods.
+func hasMethods(t reflect.Type) bool {
+	ptr := reflect.PointerTo(t)
+	return ptr.Implements(decoderType) || ptr.Implements(encoderType) || ptr.Implements(enumType)
+}
+
+func isEnum(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && reflect.PointerTo(t).Implements(enumType)
+}
+
+// enumVariants returns the variants of an Enum, with their index in options.index.
+func enumVariants(t reflect.Type) ([]structField, error) {
+	fields, err := structFields(t)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[int]string)
+	next := 0
+	for i := range fields {
+		field := t.FieldByIndex(fields[i].index)
+		if field.Type.Kind() != reflect.Pointer {
+			return nil, fmt.Errorf("enum %s: variant %s must be a pointer", t, field.Name)
+		}
+		// Like Rust discriminants, an index defaults to the one of the previous variant plus one.
+		if fields[i].options.index < 0 {
+			fields[i].options.index = next
+		}
+		next = fields[i].options.index + 1
+		if other, ok := seen[fields[i].options.index]; ok {
+			return nil, fmt.Errorf("enum %s: variants %s and %s have index %d", t, other, field.Name, fields[i].options.index)
+		}
+		seen[fields[i].options.index] = field.Name
+	}
+	return fields, nil
+}
+
+// matchName reports whether the Value name matches a field, see UnmarshalValue.
+func (f *structField) matchName(name string) bool {
+	if f.tagged {
+		return f.name == name
+	}
+	normalize := func(s string) string { return strings.ToLower(strings.ReplaceAll(s, "_", "")) }
+	return normalize(f.name) == normalize(name)
+}
+
+func intSize(kind reflect.Kind) int {
+	switch kind {
+	case reflect.Uint8, reflect.Int8:
+		return 1
+	case reflect.Uint16, reflect.Int16:
+		return 2
+	case reflect.Uint32, reflect.Int32:
+		return 4
+	case reflect.Uint64, reflect.Int64:
+		return 8
+	default:
+		return 0
+	}
+}
+
+// minEncodedSize returns the fewest bytes a value of t is encoded in, as decodeReflect decodes
+// it, for DecodeLength to bound the length of slices of t by. Types decoding themselves may take
+// any number of bytes, so count for none.
+func minEncodedSize(t reflect.Type, options fieldOptions) int {
+	if t.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(decoderType) {
+		return 0
+	}
+	switch {
+	case options.compact:
+		return 1
+	case t == bigIntType && options.intSize > 0:
+		return options.intSize
+	case t == bigIntType:
+		return 16
+	case intSize(t.Kind()) > 0:
+		return intSize(t.Kind())
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Slice, reflect.Pointer:
+		// A byte, a length or an option flag.
+		return 1
+	case reflect.Array:
+		return t.Len() * minEncodedSize(t.Elem(), fieldOptions{})
+	case reflect.Struct:
+		if isEnum(t) {
+			return 1
+		}
+		fields, err := structFields(t)
+		if err != nil {
+			return 0
+		}
+		total := 0
+		for _, field := range fields {
+			total += minEncodedSize(t.FieldByIndex(field.index).Type, field.options)
+		}
+		return total
+	default:
+		return 0
+	}
+}
+
+func isSigned(kind reflect.Kind) bool {
+	return kind >= reflect.Int8 && kind <= reflect.Int64
+}
+
+func unsupported(t reflect.Type) error {
+	if t.Kind() == reflect.Int || t.Kind() == reflect.Uint {
+		return fmt.Errorf("unsupported type %s, use a sized integer", t)
+	}
+	return fmt.Errorf("unsupported type %s", t)
+}
+
+// setInt sets an integer or *big.Int to n, checking that it fits.
+func setInt(v reflect.Value, n *big.Int) error {
+	switch {
+	case v.Type() == bigIntType:
+		v.Set(reflect.ValueOf(new(big.Int).Set(n)))
+	case isSigned(v.Kind()):
+		if !n.IsInt64() || v.OverflowInt(n.Int64()) {
+			return fmt.Errorf("%s out of range for %s", n, v.Type())
+		}
+		v.SetInt(n.Int64())
+	case intSize(v.Kind()) > 0:
+		if !n.IsUint64() || v.OverflowUint(n.Uint64()) {
+			return fmt.Errorf("%s out of range for %s", n, v.Type())
+		}
+		v.SetUint(n.Uint64())
+	default:
+		return fmt.Errorf("cannot set %s to an integer", v.Type())
+	}
+	return nil
+}
+
+// getInt returns the value of an integer or *big.Int.
+func getInt(v reflect.Value) (*big.Int, error) {
+	switch {
+	case v.Type() == bigIntType:
+		if v.IsNil() {
+			return nil, fmt.Errorf("nil *big.Int")
+		}
+		return v.Interface().(*big.Int), nil
+	case isSigned(v.Kind()):
+		return big.NewInt(v.Int()), nil
+	case intSize(v.Kind()) > 0:
+		return new(big.Int).SetUint64(v.Uint()), nil
+	default:
+		return nil, fmt.Errorf("cannot encode %s as an integer", v.Type())
+	}
+}
+
+func decodeReflect(r *Reader, v reflect.Value, options fieldOptions) error {
+	if err := r.Enter(); err != nil {
+		return err
+	}
+	defer r.Leave()
+
+	t := v.Type()
+	if v.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(decoderType) {
+		return v.Addr().Interface().(Decoder).Decode(r)
+	}
+
+	if options.compact {
+		n, err := DecodeCompact(r)
+		if err != nil {
+			return err
+		}
+		return setInt(v, n)
+	}
+
+	switch {
+	case t == bigIntType:
+		size, signed := 16, false
+		if options.intSize > 0 {
+			size, signed = options.intSize, options.signed
+		}
+		b, err := r.ReadBytes(size)
+		if err != nil {
+			return err
+		}
+		n := new(big.Int).SetBytes(reverseBytes(b))
+		if signed && b[size-1]&0x80 != 0 {
+			n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(size*8)))
+		}
+		v.Set(reflect.ValueOf(n))
+		return nil
+	case intSize(t.Kind()) > 0:
+		b, err := r.ReadBytes(intSize(t.Kind()))
+		if err != nil {
+			return err
+		}
+		var n uint64
+		for i, c := range b {
+			n |= uint64(c) << (8 * i)
+		}
+		if isSigned(t.Kind()) {
+			// Sign-extend from the size of the integer.
+			shift := 64 - 8*len(b)
+			v.SetInt(int64(n<<shift) >> shift)
+		} else {
+			v.SetUint(n)
+		}
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		b, err := DecodeBool(r)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.String:
+		text, err := DecodeText(r)
+		if err != nil {
+			return err
+		}
+		v.SetString(text)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 && !hasMethods(t.Elem()) {
+			b, err := DecodeBytes(r)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(bytes.Clone(b))
+			return nil
+		}
+		length, err := DecodeLength(r, minEncodedSize(t.Elem(), fieldOptions{}))
+		if err != nil {
+			return fmt.Errorf("vec.len: %w", err)
+		}
+		if err := r.Allocate(length, int(t.Elem().Size())); err != nil {
+			return fmt.Errorf("vec: %w", err)
+		}
+		slice := reflect.MakeSlice(t, length, length)
+		for i := range slice.Len() {
+			if err := decodeReflect(r, slice.Index(i), fieldOptions{}); err != nil {
+				return fmt.Errorf("vec[%d]: %w", i, err)
+			}
+		}
+		v.Set(slice)
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && !hasMethods(t.Elem()) {
+			b, err := r.ReadBytes(t.Len())
+			if err != nil {
+				return err
+			}
+			reflect.Copy(v, reflect.ValueOf(b))
+			return nil
+		}
+		for i := range t.Len() {
+			if err := decodeReflect(r, v.Index(i), fieldOptions{}); err != nil {
+				return fmt.Errorf("array[%d]: %w", i, err)
+			}
+		}
+	case reflect.Pointer:
+		return decodeOption(r, v, options)
+	case reflect.Struct:
+		if isEnum(t) {
+			return decodeEnum(r, v)
+		}
+		fields, err := structFields(t)
+		if err != nil {
+			return err
+		}
+		for _, field := range fields {
+			if err := decodeReflect(r, v.FieldByIndex(field.index), field.options); err != nil {
+				return fmt.Errorf("%s: %w", field.name, err)
+			}
+		}
+	default:
+		return unsupported(t)
+	}
+	return nil
+}
+
+func decodeOption(r *Reader, v reflect.Value, options fieldOptions) error {
+	flag, err := r.ReadByte()
+	if err != nil {
+		return fmt.Errorf("option.flag: %w", err)
+	}
+
+	// Option<bool> takes a single byte: 0 for None, 1 for Some(true) and 2 for Some(false).
+	if v.Type().Elem().Kind() == reflect.Bool {
+		if flag > 2 {
+			return fmt.Errorf("option<bool>: invalid byte %d", flag)
+		}
+		v.SetZero()
+		if flag > 0 {
+			v.Set(reflect.New(v.Type().Elem()))
+			v.Elem().SetBool(flag == 1)
+		}
+		return nil
+	}
+
+	switch flag {
+	case 0:
+		v.SetZero()
+		return nil
+	case 1:
+		value := reflect.New(v.Type().Elem())
+		if err := decodeReflect(r, value.Elem(), options); err != nil {
+			return fmt.Errorf("option.value: %w", err)
+		}
+		v.Set(value)
+		return nil
+	default:
+		return fmt.Errorf("option.flag: invalid byte %d", flag)
+	}
+}
+
+func decodeEnum(r *Reader, v reflect.Value) error {
+	variants, err := enumVariants(v.Type())
+	if err != nil {
+		return err
+	}
+	index, err := r.ReadByte()
+	if err != nil {
+		return fmt.Errorf("index: %w", err)
+	}
+	for _, variant := range variants {
+		if variant.options.index != int(index) {
+			continue
+		}
+		v.SetZero()
+		field := v.FieldByIndex(variant.index)
+		value := reflect.New(field.Type().Elem())
+		options := variant.options
+		options.index = -1
+		if err := decodeReflect(r, value.Elem(), options); err != nil {
+			return fmt.Errorf("%s: %w", variant.name, err)
+		}
+		field.Set(value)
+		return nil
+	}
+	return fmt.Errorf("enum %s: unknown variant index %d", v.Type(), index)
+}
+
+func encodeReflect(buf *bytes.Buffer, v reflect.Value, options fieldOptions) error {
+	t := v.Type()
+	if v.Kind() != reflect.Pointer {
+		var encoder Encoder
+		if t.Implements(encoderType) {
+			encoder = v.Interface().(Encoder)
+		} else if v.CanAddr() && reflect.PointerTo(t).Implements(encoderType) {
+			encoder = v.Addr().Interface().(Encoder)
+		}
+		if encoder != nil {
+			b, err := encoder.Encode()
+			if err != nil {
+				return err
+			}
+			buf.Write(b)
+			return nil
+		}
+	}
+
+	if options.compact {
+		n, err := getInt(v)
+		if err != nil {
+			return err
+		}
+		if n.Sign() < 0 {
+			return fmt.Errorf("compact: negative integer %s", n)
+		}
+		buf.Write(EncodeCompact(n))
+		return nil
+	}
+
+	switch {
+	case t == bigIntType:
+		n, err := getInt(v)
+		if err != nil {
+			return err
+		}
+		size, signed := 16, false
+		if options.intSize > 0 {
+			size, signed = options.intSize, options.signed
+		}
+		b, err := EncodeInt(n, size, signed)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		return nil
+	case intSize(t.Kind()) > 0:
+		var n uint64
+		if isSigned(t.Kind()) {
+			n = uint64(v.Int())
+		} else {
+			n = v.Uint()
+		}
+		buf.Write(binary.LittleEndian.AppendUint64(nil, n)[:intSize(t.Kind())])
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		buf.Write(EncodeBool(v.Bool()))
+	case reflect.String:
+		buf.Write(EncodeBytes([]byte(v.String())))
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 && !hasMethods(t.Elem()) {
+			buf.Write(EncodeBytes(v.Bytes()))
+			return nil
+		}
+		buf.Write(EncodeCompactU64(uint64(v.Len())))
+		for i := range v.Len() {
+			if err := encodeReflect(buf, v.Index(i), fieldOptions{}); err != nil {
+				return fmt.Errorf("vec[%d]: %w", i, err)
+			}
+		}
+	case reflect.Array:
+		for i := range v.Len() {
+			if err := encodeReflect(buf, v.Index(i), fieldOptions{}); err != nil {
+				return fmt.Errorf("array[%d]: %w", i, err)
+			}
+		}
+	case reflect.Pointer:
+		switch {
+		case v.IsNil():
+			buf.WriteByte(0)
+		case t.Elem().Kind() == reflect.Bool:
+			if v.Elem().Bool() {
+				buf.WriteByte(1)
+			} else {
+				buf.WriteByte(2)
+			}
+		default:
+			buf.WriteByte(1)
+			if err := encodeReflect(buf, v.Elem(), options); err != nil {
+				return fmt.Errorf("option.value: %w", err)
+			}
+		}
+	case reflect.Struct:
+		if isEnum(t) {
+			return encodeEnum(buf, v)
+		}
+		fields, err := structFields(t)
+		if err != nil {
+			return err
+		}
+		for _, field := range fields {
+			if err := encodeReflect(buf, v.FieldByIndex(field.index), field.options); err != nil {
+				return fmt.Errorf("%s: %w", field.name, err)
+			}
+		}
+	default:
+		return unsupported(t)
+	}
+	return nil
+}
+
+func encodeEnum(buf *bytes.Buffer, v reflect.Value) error {
+	variants, err := enumVariants(v.Type())
+	if err != nil {
+		return err
+	}
+	var set *structField
+	for i, variant := range variants {
+		if v.FieldByIndex(variant.index).IsNil() {
+			continue
+		}
+		if set != nil {
+			return fmt.Errorf("enum %s: both %s and %s are set", v.Type(), set.name, variant.name)
+		}
+		set = &variants[i]
+	}
+	if set == nil {
+		return fmt.Errorf("enum %s: no variant is set", v.Type())
+	}
+
+	buf.WriteByte(byte(set.options.index))
+	options := set.options
+	options.index = -1
+	if err := encodeReflect(buf, v.FieldByIndex(set.index).Elem(), options); err != nil {
+		return fmt.Errorf("%s: %w", set.name, err)
+	}
+	return nil
+}
+
+func fromValue(value Value, v reflect.Value, options fieldOptions) error {
+	t := v.Type()
+	switch {
+	case t == bigIntType || intSize(t.Kind()) > 0:
+		if value.Kind != ValueKindInt && value.Kind != ValueKindCompact {
+			return fmt.Errorf("expected an integer for %s, got %s", t, value.Kind)
+		}
+		return setInt(v, value.Int)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if value.Kind != ValueKindBool {
+			return fmt.Errorf("expected a bool, got %s", value.Kind)
+		}
+		v.SetBool(value.Bool)
+	case reflect.String:
+		switch value.Kind {
+		case ValueKindText:
+			v.SetString(value.Text)
+		case ValueKindBytes:
+			v.SetString(string(value.Bytes))
+		default:
+			return fmt.Errorf("expected text, got %s", value.Kind)
+		}
+	case reflect.Slice, reflect.Array:
+		return fromListValue(value, v)
+	case reflect.Pointer:
+		switch {
+		case value.Kind == ValueKindNull || (value.Kind == ValueKindOption && value.Option == nil):
+			v.SetZero()
+		case value.Kind == ValueKindOption:
+			inner := reflect.New(t.Elem())
+			if err := fromValue(*value.Option, inner.Elem(), options); err != nil {
+				return fmt.Errorf("option.value: %w", err)
+			}
+			v.Set(inner)
+		default:
+			return fmt.Errorf("expected an option, got %s", value.Kind)
+		}
+	case reflect.Struct:
+		if isEnum(t) {
+			return fromVariantValue(value, v)
+		}
+		switch value.Kind {
+		case ValueKindStruct:
+			return fromFields(value.Fields, v)
+		case ValueKindList:
+			return fromTuple(value.List, v)
+		default:
+			return fmt.Errorf("expected a struct for %s, got %s", t, value.Kind)
+		}
+	default:
+		return unsupported(t)
+	}
+	return nil
+}
+
+func fromListValue(value Value, v reflect.Value) error {
+	t := v.Type()
+	var list []Value
+	switch value.Kind {
+	case ValueKindBytes:
+		if t.Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("expected a list for %s, got bytes", t)
+		}
+		if t.Kind() == reflect.Slice {
+			v.Set(reflect.ValueOf(bytes.Clone(value.Bytes)).Convert(t))
+			return nil
+		}
+		if len(value.Bytes) != t.Len() {
+			return fmt.Errorf("expected %d bytes, got %d", t.Len(), len(value.Bytes))
+		}
+		reflect.Copy(v, reflect.ValueOf(value.Bytes))
+		return nil
+	case ValueKindBitSequence:
+		list = make([]Value, len(value.Bits))
+		for i, bit := range value.Bits {
+			list[i] = VBool(bit)
+		}
+	case ValueKindList:
+		list = value.List
+	default:
+		return fmt.Errorf("expected a list for %s, got %s", t, value.Kind)
+	}
+
+	if t.Kind() == reflect.Slice {
+		v.Set(reflect.MakeSlice(t, len(list), len(list)))
+	} else if len(list) != t.Len() {
+		return fmt.Errorf("expected %d elements, got %d", t.Len(), len(list))
+	}
+	for i, elem := range list {
+		if err := fromValue(elem, v.Index(i), fieldOptions{}); err != nil {
+			return fmt.Errorf("[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func fromFields(values []Field, v reflect.Value) error {
+	fields, err := structFields(v.Type())
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		i := -1
+		for j := range values {
+			if field.matchName(values[j].Name) {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			return fmt.Errorf("%s: missing field", field.name)
+		}
+		if err := fromValue(values[i].Value, v.FieldByIndex(field.index), field.options); err != nil {
+			return fmt.Errorf("%s: %w", field.name, err)
+		}
+	}
+	return nil
+}
+
+func fromTuple(values []Value, v reflect.Value) error {
+	fields, err := structFields(v.Type())
+	if err != nil {
+		return err
+	}
+	if len(values) != len(fields) {
+		return fmt.Errorf("expected %d elements for %s, got %d", len(fields), v.Type(), len(values))
+	}
+	for i, field := range fields {
+		if err := fromValue(values[i], v.FieldByIndex(field.index), field.options); err != nil {
+			return fmt.Errorf("%s: %w", field.name, err)
+		}
+	}
+	return nil
+}
+
+func fromVariantValue(value Value, v reflect.Value) error {
+	if value.Kind != ValueKindVariant {
+		return fmt.Errorf("expected a variant for %s, got %s", v.Type(), value.Kind)
+	}
+	variants, err := enumVariants(v.Type())
+	if err != nil {
+		return err
+	}
+	for _, variant := range variants {
+		if !variant.matchName(value.Variant.Name) {
+			continue
+		}
+		v.SetZero()
+		field := v.FieldByIndex(variant.index)
+		payload := reflect.New(field.Type().Elem())
+		if err := fromVariantFields(value.Variant.Fields, payload.Elem()); err != nil {
+			return fmt.Errorf("%s: %w", variant.name, err)
+		}
+		field.Set(payload)
+		return nil
+	}
+	return fmt.Errorf("enum %s: unknown variant %s", v.Type(), value.Variant.Name)
+}
+
+// fromVariantFields converts the fields of a variant, see Variant, into the payload of a variant
+// of an Enum.
+func fromVariantFields(fields []Field, v reflect.Value) error {
+	switch {
+	case len(fields) == 0:
+		return nil
+	case fields[0].Name != "":
+		return fromFields(fields, v)
+	case len(fields) == 1:
+		return fromValue(fields[0].Value, v, fieldOptions{})
+	default:
+		values := make([]Value, len(fields))
+		for i, field := range fields {
+			values[i] = field.Value
+		}
+		return fromTuple(values, v)
+	}
+}
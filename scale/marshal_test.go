@@ -0,0 +1,224 @@
+package scale_test
+
+import (
+	"encoding/hex"
+	"math/big"
+	"reflect"
+	"strings"
+	"submarine/metadata/base"
+	. "submarine/scale"
+	"testing"
+)
+
+type status struct {
+	Idle   *struct{}
+	Active *uint8 `scale:",index=2"`
+	Moved  *moved `scale:"moved_to"`
+}
+
+func (status) ScaleEnum() {}
+
+type moved struct {
+	To    base.AddressId
+	Block uint32 `scale:",compact"`
+}
+
+type header struct {
+	Version uint8
+	Name    string
+}
+
+type account struct {
+	header
+	Nonce    uint32   `scale:",compact"`
+	Free     *big.Int `scale:",compact"`
+	Reserved *big.Int
+	Delta    *big.Int `scale:",i128"`
+	Memo     []byte
+	Tip      *uint16
+	Keep     *bool
+	Status   status
+	Pair     [2]int16
+	History  []int64
+	Ignored  string `scale:"-"`
+	internal int
+}
+
+func u8(n uint8) *uint8 { return &n }
+
+func TestMarshal(t *testing.T) {
+	alice := base.AddressId{0xd4, 0x35, 31: 0x7d}
+	keep := false
+	tip := uint16(0x0102)
+
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{"u32", uint32(0x01020304), "04030201"},
+		{"i16", int16(-2), "feff"},
+		{"bool", true, "01"},
+		{"text", "hi", "086869"},
+		{"bytes", []byte{1, 2}, "080102"},
+		{"byte array", [3]byte{1, 2, 3}, "010203"},
+		{"vec", []uint16{1, 2}, "0801000200"},
+		{"vec of empty structs", []struct{}{{}, {}, {}}, "0c"},
+		{"none", (*uint8)(nil), "00"},
+		{"some", u8(7), "0107"},
+		{"decoder", alice, hex.EncodeToString(alice[:])},
+		{"unit variant", status{Idle: &struct{}{}}, "00"},
+		{"tagged index", status{Active: u8(9)}, "0209"},
+		{"struct variant", status{Moved: &moved{To: alice, Block: 1}}, "03" + hex.EncodeToString(alice[:]) + "04"},
+		{
+			"struct",
+			account{
+				header:   header{Version: 1, Name: "a"},
+				Nonce:    64,
+				Free:     big.NewInt(1),
+				Reserved: big.NewInt(2),
+				Delta:    big.NewInt(-1),
+				Memo:     []byte{0xaa},
+				Tip:      &tip,
+				Keep:     &keep,
+				Status:   status{Active: u8(1)},
+				Pair:     [2]int16{1, -1},
+				History:  []int64{},
+			},
+			"01" + "0461" + "0101" + "04" + "02000000000000000000000000000000" + "ffffffffffffffffffffffffffffffff" +
+				"04aa" + "010201" + "02" + "0201" + "0100ffff" + "00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if got := hex.EncodeToString(data); got != tt.expected {
+				t.Errorf("marshal: expected %s, got %s", tt.expected, got)
+			}
+
+			decoded := reflect.New(reflect.TypeOf(tt.value))
+			if err := Unmarshal(data, decoded.Interface()); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(decoded.Elem().Interface(), tt.value) {
+				t.Errorf("unmarshal: expected %+v, got %+v", tt.value, decoded.Elem().Interface())
+			}
+		})
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		err   string
+	}{
+		{"platform int", 1, "use a sized integer"},
+		{"map", map[string]int{}, "unsupported type"},
+		{"no variant", status{}, "no variant is set"},
+		{"two variants", status{Idle: &struct{}{}, Active: u8(1)}, "both Idle and Active are set"},
+		{"negative compact", struct {
+			N int8 `scale:",compact"`
+		}{-1}, "N: compact: negative integer -1"},
+		{"u128 overflow", new(big.Int).Lsh(big.NewInt(1), 128), "out of range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Marshal(tt.value)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		target any
+		err    string
+	}{
+		{"left over", "0102", new(uint8), "1 bytes left over"},
+		{"short", "01", new(uint16), "out of bounds"},
+		{"unknown variant", "01", new(status), "unknown variant index 1"},
+		{"compact overflow", "0104", &struct {
+			N uint8 `scale:",compact"`
+		}{}, "N: 256 out of range for uint8"},
+		{"vec length", "0c01", new([]uint8), "length 3 needs at least 3 bytes, only 1 left"},
+		{"vec of structs length", "fdff", new([]header), "vec.len: length 16383 needs at least 32766 bytes, only 0 left"},
+		{"vec of arrays length", "0c0000", new([][2]uint32), "length 3 needs at least 24 bytes, only 2 left"},
+		{"option bool", "03", new(*bool), "invalid byte 3"},
+		{"not a pointer", "00", uint8(0), "expected a non-nil pointer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := hex.DecodeString(tt.data)
+			err := Unmarshal(data, tt.target)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalValue(t *testing.T) {
+	// AccountInfo { nonce: u32, data: AccountData { free: u128, misc_frozen: u128 } }, with a status
+	// enum and an Option<Vec<u8>>.
+	schema := &Type{Kind: KindStruct, Struct: &Struct{Fields: []NamedMember{
+		{Name: "nonce", Type: ref("u32")},
+		{Name: "data", Type: &Type{Kind: KindStruct, Struct: &Struct{Fields: []NamedMember{
+			{Name: "free", Type: ref("u128")},
+			{Name: "misc_frozen", Type: ref("u128")},
+		}}}},
+		{Name: "status", Type: &Type{Kind: KindEnumComplex, EnumComplex: &EnumComplex{Variants: []NamedMember{
+			{Name: "Idle"},
+			{Name: "Active", Type: ref("u8")},
+		}}}},
+		{Name: "memo", Type: &Type{Kind: KindOption, Option: &Option{Type: ref("bytes")}}},
+	}}}
+	data, _ := hex.DecodeString("05000000" + "0a000000000000000000000000000000" + "0b000000000000000000000000000000" + "0103" + "01080102")
+
+	value, errSpan := DecodeWithSchema(NewReader(data), schema, nil)
+	if errSpan != nil {
+		t.Fatalf("decode: %v", errSpan)
+	}
+
+	type accountData struct {
+		Free       *big.Int
+		MiscFrozen *big.Int
+	}
+	var info struct {
+		Nonce  uint32
+		Data   accountData
+		Status status
+		Memo   *[]byte
+	}
+	if err := UnmarshalValue(value, &info); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if info.Nonce != 5 || info.Data.Free.Int64() != 10 || info.Data.MiscFrozen.Int64() != 11 {
+		t.Errorf("unexpected fields %+v", info)
+	}
+	if info.Status.Active == nil || *info.Status.Active != 3 {
+		t.Errorf("unexpected status %+v", info.Status)
+	}
+	if info.Memo == nil || hex.EncodeToString(*info.Memo) != "0102" {
+		t.Errorf("unexpected memo %v", info.Memo)
+	}
+
+	var wrong struct{ Nonce bool }
+	if err := UnmarshalValue(value, &wrong); err == nil || err.Error() != "Nonce: expected a bool, got int" {
+		t.Errorf("expected a kind mismatch, got %v", err)
+	}
+	var missing struct{ Balance uint64 }
+	if err := UnmarshalValue(value, &missing); err == nil || err.Error() != "Balance: missing field" {
+		t.Errorf("expected a missing field, got %v", err)
+	}
+}
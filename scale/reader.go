@@ -6,13 +6,25 @@ import (
 
 // Reader helps to decode SCALE types from a byte slice.
 type Reader struct {
-	data []byte
-	pos  int
+	data   []byte
+	pos    int
+	limits *limits
 }
 
-// NewReader creates a new reader instance.
+// NewReader creates a new reader instance, with the DefaultDecodeOptions.
 func NewReader(data []byte) *Reader {
-	return &Reader{data: data, pos: 0}
+	return NewReaderWithOptions(data, DefaultDecodeOptions)
+}
+
+// NewReaderWithOptions creates a reader that enforces the limits of options on what is decoded
+// from it.
+func NewReaderWithOptions(data []byte, options DecodeOptions) *Reader {
+	return &Reader{data: data, pos: 0, limits: &limits{options: options}}
+}
+
+// sub returns a reader of data, nested in the value being decoded from r, which shares its limits.
+func (r *Reader) sub(data []byte) *Reader {
+	return &Reader{data: data, pos: 0, limits: r.limits}
 }
 
 // ReadByte reads a single byte and advances the position.
@@ -27,7 +39,7 @@ func (r *Reader) ReadByte() (byte, error) {
 
 // ReadBytes reads n bytes and advances the position.
 func (r *Reader) ReadBytes(n int) ([]byte, error) {
-	if r.pos+n > len(r.data) {
+	if n < 0 || n > len(r.data)-r.pos {
 		return nil, fmt.Errorf("reader: out of bounds for %d bytes", n)
 	}
 	bytes := r.data[r.pos : r.pos+n]
@@ -35,10 +47,29 @@ func (r *Reader) ReadBytes(n int) ([]byte, error) {
 	return bytes, nil
 }
 
+// Skip advances the position by n bytes.
+func (r *Reader) Skip(n int) error {
+	if n < 0 || n > len(r.data)-r.pos {
+		return fmt.Errorf("reader: out of bounds for %d bytes", n)
+	}
+	r.pos += n
+	return nil
+}
+
 func (r *Reader) Pos() int {
 	return r.pos
 }
 
+// Remaining returns the number of bytes left to read.
+func (r *Reader) Remaining() int {
+	return len(r.data) - r.pos
+}
+
+// BytesSince returns the bytes consumed between position start and the current position.
+func (r *Reader) BytesSince(start int) []byte {
+	return r.data[start:r.pos]
+}
+
 func reverseBytes(data []byte) []byte {
 	reversed := make([]byte, len(data))
 	for i, b := range data {
@@ -13,6 +13,10 @@ const (
 	KindArray       TypeKind = "array"
 	KindRef         TypeKind = "ref"
 	KindBitFlags    TypeKind = "bit_flags"
+	KindResult      TypeKind = "result"
+	KindMap         TypeKind = "map"
+	KindOpaque      TypeKind = "opaque"
+	KindGeneric     TypeKind = "generic"
 )
 
 type Type struct {
@@ -26,7 +30,12 @@ type Type struct {
 	Option      *Option
 	Array       *Array
 	Ref         *string
+	Args        []Type // type arguments of a Ref to a generic definition, e.g. u32 in Foo<u32>
 	BitFlags    *BitFlags
+	Result      *Result
+	Map         *Map
+	Opaque      *Opaque
+	Generic     *Generic
 }
 
 type Struct struct {
@@ -39,10 +48,15 @@ type Tuple struct {
 
 type EnumSimple struct {
 	Variants []string
+	// Indices are the discriminants of the variants, for enums with explicit or sparse ones.
+	// Nil means the variants are numbered from 0.
+	Indices []int
 }
 
 type EnumComplex struct {
 	Variants []NamedMember
+	// Indices are the discriminants of the variants, as for EnumSimple.
+	Indices []int
 }
 
 type NamedMember struct {
@@ -77,3 +91,27 @@ type BitFlag struct {
 	Name  string
 	Value uint64
 }
+
+// Result is Result<T, E>: a variant index, 0 for Ok and 1 for Err, followed by the value.
+type Result struct {
+	Ok  *Type
+	Err *Type
+}
+
+// Map is BTreeMap<K, V>, encoded as a vector of key-value pairs.
+type Map struct {
+	Key   *Type
+	Value *Type
+}
+
+// Opaque is WrapperOpaque<T>: T encoded behind a compact length prefix.
+type Opaque struct {
+	Type *Type
+}
+
+// Generic is a definition with type parameters, e.g. Pair<A, B> = (A, B). Refs to the parameters in
+// Type are substituted with the arguments of the Ref to the definition.
+type Generic struct {
+	Params []string
+	Type   *Type
+}
@@ -0,0 +1,392 @@
+package scale
+
+import (
+	"fmt"
+	"math"
+	. "submarine/errorspan"
+)
+
+// decodeCompactUint64 decodes a compact integer without allocating. It reports false for a value
+// over 64 bits, which is read all the same.
+func decodeCompactUint64(r *Reader) (uint64, bool, error) {
+	firstByte, err := r.ReadByte()
+	if err != nil {
+		return 0, false, err
+	}
+	switch firstByte & 0b11 {
+	case 0:
+		return uint64(firstByte >> 2), true, nil
+	case 1:
+		secondByte, err := r.ReadByte()
+		if err != nil {
+			return 0, false, fmt.Errorf("compact[1]: %w", err)
+		}
+		return uint64(firstByte>>2) | uint64(secondByte)<<6, true, nil
+	case 2:
+		bytes, err := r.ReadBytes(3)
+		if err != nil {
+			return 0, false, fmt.Errorf("compact[2]: %w", err)
+		}
+		return uint64(firstByte>>2) | uint64(bytes[0])<<6 | uint64(bytes[1])<<14 | uint64(bytes[2])<<22, true, nil
+	default:
+		bytes, err := r.ReadBytes(int(firstByte>>2) + 4)
+		if err != nil {
+			return 0, false, fmt.Errorf("compact[3]: %w", err)
+		}
+		var value uint64
+		for i, b := range bytes {
+			if i >= 8 {
+				if b != 0 {
+					return 0, false, nil
+				}
+				continue
+			}
+			value |= uint64(b) << (8 * i)
+		}
+		return value, true, nil
+	}
+}
+
+// SkipCompact advances the reader past a compact integer.
+func SkipCompact(r *Reader) error {
+	_, _, err := decodeCompactUint64(r)
+	return err
+}
+
+// SkipBytes advances the reader past a length-prefixed byte string: Vec<u8>, or text.
+func SkipBytes(r *Reader) error {
+	length, err := DecodeLength(r, 1)
+	if err != nil {
+		return fmt.Errorf("bytes.len: %w", err)
+	}
+	if err := r.Skip(length); err != nil {
+		return fmt.Errorf("bytes: %w", err)
+	}
+	return nil
+}
+
+// SkipBitSequence advances the reader past a bit sequence, see DecodeBitSequence.
+func SkipBitSequence(r *Reader) error {
+	numBits, err := DecodeLength(r, 0)
+	if err != nil {
+		return fmt.Errorf("bitvec.len: %w", err)
+	}
+	if err := r.Skip((numBits + 7) / 8); err != nil {
+		return fmt.Errorf("bitvec: %w", err)
+	}
+	return nil
+}
+
+// SkipWithSchema advances the reader past a value of schema without materializing it, for scanning
+// input for the few values worth decoding. It rejects the input DecodeWithSchema rejects, within
+// the same limits but MaxAllocation, as skipping allocates nothing. Values of fixed-width types are
+// skipped in one step, without nesting into them.
+func SkipWithSchema(r *Reader, schema *Type, resolver TypeResolver) *ErrorSpan {
+	return NewSchemaSkipper(resolver).Skip(r, schema, "")
+}
+
+// SchemaSkipper skips values like SkipWithSchema. It keeps the types it resolved and the sizes of
+// the fixed-width ones between calls, so reuse it to skip many values of the same schemas.
+type SchemaSkipper struct {
+	*schemaTypes
+	expanding expansions
+	sizes     map[sizeKey]int
+}
+
+// sizeKey identifies a type in a schema, with the module the names in it refer to.
+type sizeKey struct {
+	schema *Type
+	module string
+}
+
+// NewSchemaSkipper returns a skipper resolving named types with resolver, which may be nil for
+// schemas made of primitives only.
+func NewSchemaSkipper(resolver TypeResolver) *SchemaSkipper {
+	return &SchemaSkipper{
+		schemaTypes: newSchemaTypes(resolver),
+		expanding:   make(expansions),
+		sizes:       make(map[sizeKey]int),
+	}
+}
+
+// Skip advances the reader past a value of schema, whose refs are names in module.
+func (s *SchemaSkipper) Skip(r *Reader, schema *Type, module string) *ErrorSpan {
+	if schema == nil {
+		return nil
+	}
+	if size, ok := s.fixedSize(schema, module); ok {
+		if err := r.Skip(size); err != nil {
+			return WrapError(err)
+		}
+		return nil
+	}
+	if err := r.Enter(); err != nil {
+		return WrapError(err)
+	}
+	defer r.Leave()
+
+	switch schema.Kind {
+	case KindStruct:
+		for _, field := range schema.Struct.Fields {
+			if err := s.Skip(r, field.Type, module); err != nil {
+				return err.WithPath(field.Name)
+			}
+		}
+		return nil
+	case KindTuple:
+		for i := range schema.Tuple.Fields {
+			if err := s.Skip(r, &schema.Tuple.Fields[i], module); err != nil {
+				return err.WithPathInt(i)
+			}
+		}
+		return nil
+	case KindEnumSimple:
+		_, err := decodeEnumSimple(r, schema.EnumSimple)
+		return err
+	case KindEnumComplex:
+		e := schema.EnumComplex
+		index, err := DecodeU8(r)
+		if err != nil {
+			return WrapError(err).WithPath("index")
+		}
+		position, err2 := variantPosition(e.Indices, len(e.Variants), index)
+		if err2 != nil {
+			return err2.WithPath("index")
+		}
+		if err := s.Skip(r, e.Variants[position].Type, module); err != nil {
+			return err.WithPath(e.Variants[position].Name)
+		}
+		return nil
+	case KindVec:
+		return s.skipVec(r, schema.Vec, module)
+	case KindOption:
+		hasValue, err := DecodeBool(r)
+		if err != nil {
+			return WrapError(err).WithPath("flag")
+		}
+		if !hasValue {
+			return nil
+		}
+		return s.Skip(r, schema.Option.Type, module)
+	case KindArray:
+		for i := 0; i < schema.Array.Len; i++ {
+			if err := s.Skip(r, schema.Array.Type, module); err != nil {
+				return err.WithPathInt(i)
+			}
+		}
+		return nil
+	case KindRef:
+		if primitives[*schema.Ref] {
+			return skipRef(r, *schema.Ref)
+		}
+		return s.skipNamed(r, typeKey{module, *schema.Ref}, schema.Args)
+	case KindBitFlags:
+		// Bit flags of a supported length are fixed-width.
+		return NewErrorSpan(fmt.Sprintf("unsupported bit length: %d", schema.BitFlags.BitLength))
+	case KindResult:
+		index, err := DecodeU8(r)
+		if err != nil {
+			return WrapError(err).WithPath("index")
+		}
+		switch index {
+		case 0:
+			if err := s.Skip(r, schema.Result.Ok, module); err != nil {
+				return err.WithPath("Ok")
+			}
+		case 1:
+			if err := s.Skip(r, schema.Result.Err, module); err != nil {
+				return err.WithPath("Err")
+			}
+		default:
+			return NewErrorSpan(fmt.Sprintf("result index %d out of bounds (max 1)", index)).WithPath("index")
+		}
+		return nil
+	case KindMap:
+		length, err := DecodeLength(r, s.minSize(schema.Map.Key, module)+s.minSize(schema.Map.Value, module))
+		if err != nil {
+			return WrapError(err).WithPath("length")
+		}
+		for i := range length {
+			if err := s.Skip(r, schema.Map.Key, module); err != nil {
+				return err.WithPath("key").WithPathInt(i)
+			}
+			if err := s.Skip(r, schema.Map.Value, module); err != nil {
+				return err.WithPath("value").WithPathInt(i)
+			}
+		}
+		return nil
+	case KindOpaque:
+		data, err := DecodeBytes(r)
+		if err != nil {
+			return WrapError(err).WithPath("length")
+		}
+		inner := r.sub(data)
+		if err := s.Skip(inner, schema.Opaque.Type, module); err != nil {
+			return err
+		}
+		if inner.Remaining() != 0 {
+			return NewErrorSpan(fmt.Sprintf("%d bytes left after opaque value", inner.Remaining()))
+		}
+		return nil
+	case KindGeneric:
+		return NewErrorSpan(fmt.Sprintf("generic type with parameters %v needs type arguments", schema.Generic.Params))
+	case KindImport:
+		if err := s.skipNamed(r, typeKey{schema.Import.Module, schema.Import.Item}, nil); err != nil {
+			return err.WithPath(schema.Import.Module)
+		}
+		return nil
+	default:
+		return NewErrorSpan(fmt.Sprintf("unknown type kind: %s", schema.Kind))
+	}
+}
+
+func (s *SchemaSkipper) skipNamed(r *Reader, key typeKey, args []Type) *ErrorSpan {
+	schema, module, instance, err := s.lookup(key, args)
+	if err != nil {
+		return err
+	}
+
+	leave, err := s.expanding.enter(instance, r.Pos(), key.name)
+	if err != nil {
+		return err
+	}
+	defer leave()
+
+	if err := s.Skip(r, schema, module); err != nil {
+		return err.WithPath(key.name)
+	}
+	return nil
+}
+
+func (s *SchemaSkipper) skipVec(r *Reader, v *Vec, module string) *ErrorSpan {
+	// Vectors of fixed-width values, Vec<u8> among them, are skipped in one step.
+	if size, ok := s.fixedSize(v.Type, module); ok {
+		length, err := DecodeLength(r, size)
+		if err != nil {
+			return WrapError(err).WithPath("length")
+		}
+		if err := r.Skip(length * size); err != nil {
+			return WrapError(err)
+		}
+		return nil
+	}
+
+	length, err := DecodeLength(r, s.minSize(v.Type, module))
+	if err != nil {
+		return WrapError(err).WithPath("length")
+	}
+	for i := range length {
+		if err := s.Skip(r, v.Type, module); err != nil {
+			return err.WithPathInt(i)
+		}
+	}
+	return nil
+}
+
+// skipRef skips a primitive of variable width.
+func skipRef(r *Reader, refType string) *ErrorSpan {
+	var err error
+	switch refType {
+	case "bool":
+		_, err = DecodeBool(r)
+	case "text", "bytes":
+		err = SkipBytes(r)
+	case "compact":
+		err = SkipCompact(r)
+	case "bitvec":
+		err = SkipBitSequence(r)
+	default:
+		return NewErrorSpan(fmt.Sprintf("unknown primitive type: %s", refType))
+	}
+	if err != nil {
+		return WrapError(err)
+	}
+	return nil
+}
+
+// primitiveSizes are the widths of the primitives any bytes are a valid encoding of. Bools are
+// left out, to be checked when skipped.
+var primitiveSizes = map[string]int{
+	"u8": 1, "u16": 2, "u32": 4, "u64": 8, "u128": 16, "u256": 32,
+	"i8": 1, "i16": 2, "i32": 4, "i64": 8, "i128": 16, "i256": 32,
+	"empty": 0,
+}
+
+// fixedSize returns the encoded size of the values of schema if they all take the same number of
+// bytes, and any bytes of that length are one. Sizes are memoized, so a skipper computes each
+// once.
+func (s *SchemaSkipper) fixedSize(schema *Type, module string) (int, bool) {
+	if schema == nil {
+		return 0, true
+	}
+	key := sizeKey{schema, module}
+	if size, ok := s.sizes[key]; ok {
+		return size, size >= 0
+	}
+	// Variable until computed, which also ends the recursion of recursive types.
+	s.sizes[key] = -1
+	size, ok := s.computeFixedSize(schema, module)
+	if ok {
+		s.sizes[key] = size
+	}
+	return size, ok
+}
+
+func (s *SchemaSkipper) computeFixedSize(schema *Type, module string) (int, bool) {
+	switch schema.Kind {
+	case KindStruct:
+		total := 0
+		for _, field := range schema.Struct.Fields {
+			size, ok := s.fixedSize(field.Type, module)
+			if !ok {
+				return 0, false
+			}
+			total += size
+		}
+		return total, true
+	case KindTuple:
+		total := 0
+		for i := range schema.Tuple.Fields {
+			size, ok := s.fixedSize(&schema.Tuple.Fields[i], module)
+			if !ok {
+				return 0, false
+			}
+			total += size
+		}
+		return total, true
+	case KindArray:
+		size, ok := s.fixedSize(schema.Array.Type, module)
+		if !ok || schema.Array.Len < 0 || (size > 0 && schema.Array.Len > math.MaxInt32/size) {
+			return 0, false
+		}
+		return schema.Array.Len * size, true
+	case KindRef:
+		if size, ok := primitiveSizes[*schema.Ref]; ok {
+			return size, true
+		}
+		if primitives[*schema.Ref] {
+			return 0, false
+		}
+		return s.namedFixedSize(typeKey{module, *schema.Ref}, schema.Args)
+	case KindImport:
+		return s.namedFixedSize(typeKey{schema.Import.Module, schema.Import.Item}, nil)
+	case KindBitFlags:
+		for _, size := range []int{1, 2, 4, 8, 16, 32} {
+			if schema.BitFlags.BitLength <= size*8 {
+				return size, true
+			}
+		}
+		return 0, false
+	default:
+		return 0, false
+	}
+}
+
+func (s *SchemaSkipper) namedFixedSize(key typeKey, args []Type) (int, bool) {
+	schema, module, _, err := s.lookup(key, args)
+	if err != nil {
+		// Skip reports the error.
+		return 0, false
+	}
+	return s.fixedSize(schema, module)
+}
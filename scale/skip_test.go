@@ -0,0 +1,130 @@
+package scale_test
+
+import (
+	"errors"
+	"strings"
+	. "submarine/scale"
+	"testing"
+)
+
+func TestSkipWithSchema(t *testing.T) {
+	pair := &Type{Kind: KindTuple, Tuple: &Tuple{Fields: []Type{*ref("u32"), *ref("u64")}}}
+
+	tests := []struct {
+		name   string
+		data   []byte
+		schema *Type
+		err    string
+	}{
+		{name: "fixed-width struct", data: append([]byte{1, 2, 3, 4}, make([]byte, 8)...), schema: pair},
+		{name: "vec of fixed-width", data: append([]byte{2 << 2}, make([]byte, 24)...), schema: vecOf(pair)},
+		{name: "bytes", data: []byte{2 << 2, 'h', 'i'}, schema: ref("bytes")},
+		{name: "compact", data: []byte{0x13, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, schema: ref("compact")},
+		{name: "big compact", data: append([]byte{0xff}, bytes(0xff, 67)...), schema: ref("compact")},
+		{name: "option", data: []byte{2 << 2, 1, 1, 0}, schema: vecOf(optionOf(ref("bool")))},
+		{
+			name: "fuzz schema",
+			data: append(append([]byte{4, 1, 1, 2 << 2, 'h', 'i', 4, 1, 0, 0, 0, 1 << 2, 9, 0, 1 << 2}, make([]byte, 32)...),
+				0, 0, 1, 0, 0, 0, 0, 0, 0, 0),
+			schema: fuzzSchema,
+		},
+		{name: "short vec", data: []byte{2 << 2, 1, 2, 3}, schema: vecOf(ref("u16")), err: "length 2 needs at least 4 bytes, only 3 left"},
+		{name: "invalid bool", data: []byte{1, 2}, schema: optionOf(ref("bool")), err: "bool? 2"},
+		{name: "invalid variant", data: []byte{2}, schema: fuzzSchema.Struct.Fields[5].Type, err: "index: enum index 2 out of bounds (max 1)"},
+		{name: "opaque left over", data: []byte{3 << 2, 1, 0, 0}, schema: &Type{Kind: KindOpaque, Opaque: &Opaque{Type: ref("u16")}}, err: "1 bytes left after opaque value"},
+		{name: "truncated", data: []byte{1, 2, 3}, schema: pair, err: "out of bounds"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReader(tt.data)
+			errSpan := SkipWithSchema(r, tt.schema, nil)
+			if tt.err != "" {
+				if errSpan == nil || !strings.Contains(errSpan.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, errSpan)
+				}
+				return
+			}
+			if errSpan != nil {
+				t.Fatalf("skip: %v", errSpan)
+			}
+			if r.Remaining() != 0 {
+				t.Errorf("%d bytes left after skipping", r.Remaining())
+			}
+		})
+	}
+
+	// Skipping is bounded by the limits of the reader too.
+	r := NewReaderWithOptions([]byte{1, 1, 1, 7}, DecodeOptions{MaxDepth: 2})
+	if errSpan := SkipWithSchema(r, optionOf(optionOf(optionOf(ref("bool")))), nil); !errors.Is(errSpan, ErrLimitExceeded) {
+		t.Errorf("expected ErrLimitExceeded, got %v", errSpan)
+	}
+}
+
+// FuzzSkipWithSchema checks that skipping accepts the input decoding accepts, and ends at the same
+// position.
+func FuzzSkipWithSchema(f *testing.F) {
+	f.Add([]byte{4, 1, 1, 8, 'h', 'i', 4, 1, 0, 0, 0, 4, 9, 9, 0x13, 0xff, 0x00})
+	f.Add([]byte{0xfe, 0xff, 0xff, 0xff, 0x03})
+
+	options := DecodeOptions{MaxLength: 1 << 10, MaxDepth: 32}
+	skipper := NewSchemaSkipper(nil)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		decoder := NewReaderWithOptions(data, options)
+		_, decodeErr := DecodeWithSchema(decoder, fuzzSchema, nil)
+		r := NewReaderWithOptions(data, options)
+		skipErr := skipper.Skip(r, fuzzSchema, "")
+		if (decodeErr == nil) != (skipErr == nil) {
+			t.Fatalf("decode error %v, skip error %v", decodeErr, skipErr)
+		}
+		if decodeErr == nil && r.Pos() != decoder.Pos() {
+			t.Errorf("skipped to %d, decoded to %d", r.Pos(), decoder.Pos())
+		}
+	})
+}
+
+// benchmarkData is many records of a schema mixing fixed-width fields and collections, like the
+// events of a block.
+func benchmarkData() (*Type, []byte) {
+	record := &Type{Kind: KindStruct, Struct: &Struct{Fields: []NamedMember{
+		{Name: "who", Type: &Type{Kind: KindArray, Array: &Array{Len: 32, Type: ref("u8")}}},
+		{Name: "amounts", Type: vecOf(ref("u128"))},
+		{Name: "nonce", Type: ref("compact")},
+		{Name: "memo", Type: optionOf(ref("text"))},
+	}}}
+
+	var one []byte
+	one = append(one, bytes(0xaa, 32)...)
+	one = append(one, 4<<2)
+	one = append(one, make([]byte, 4*16)...)
+	one = append(one, 0x91, 0x01, 1, 5<<2, 'h', 'e', 'l', 'l', 'o')
+
+	data := []byte{0x01, 0x19} // compact 1600
+	for range 1600 {
+		data = append(data, one...)
+	}
+	return vecOf(record), data
+}
+
+func BenchmarkDecodeWithSchema(b *testing.B) {
+	schema, data := benchmarkData()
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for range b.N {
+		if _, errSpan := DecodeWithSchema(NewReader(data), schema, nil); errSpan != nil {
+			b.Fatal(errSpan)
+		}
+	}
+}
+
+func BenchmarkSkipWithSchema(b *testing.B) {
+	schema, data := benchmarkData()
+	skipper := NewSchemaSkipper(nil)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for range b.N {
+		if errSpan := skipper.Skip(NewReader(data), schema, ""); errSpan != nil {
+			b.Fatal(errSpan)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package scale
 
-import "math/big"
+import (
+	"fmt"
+	"math/big"
+	"unsafe"
+)
 
 type ValueKind int
 
@@ -12,16 +16,65 @@ const (
 	ValueKindText
 	ValueKindList
 	ValueKindStruct
+	ValueKindVariant
+	ValueKindOption
+	ValueKindCompact
+	ValueKindBitSequence
 )
 
+var valueKindNames = [...]string{"null", "int", "bool", "bytes", "text", "list", "struct", "variant", "option", "compact", "bitSequence"}
+
+func (k ValueKind) String() string {
+	if k < 0 || int(k) >= len(valueKindNames) {
+		return fmt.Sprintf("ValueKind(%d)", int(k))
+	}
+	return valueKindNames[k]
+}
+
 type Value struct {
-	Kind   ValueKind
-	Int    *big.Int
-	Bool   bool
-	Bytes  []byte
-	Text   string
-	List   []Value
-	Struct map[string]Value
+	Kind    ValueKind
+	Int     *big.Int // integers, compact or not
+	Bool    bool
+	Bytes   []byte
+	Text    string
+	List    []Value
+	Fields  []Field // struct fields, in order
+	Variant *Variant
+	Option  *Value // the value of Some, nil for None
+	Bits    []bool // bit sequences, least significant bit of the first byte first
+}
+
+// valueSize and fieldSize are the sizes of a Value and a Field, for Reader.Allocate.
+var (
+	valueSize = int(unsafe.Sizeof(Value{}))
+	fieldSize = int(unsafe.Sizeof(Field{}))
+)
+
+// Field is a field of a struct or of an enum variant. Unnamed fields, like those of tuple variants,
+// have an empty Name.
+type Field struct {
+	Name  string `json:"name"`
+	Value Value  `json:"value"`
+}
+
+// Variant is a value of an enum, or of a Result.
+type Variant struct {
+	Name  string `json:"name"`
+	Index uint8  `json:"index"`
+	// Fields are those of a variant of a complex enum: named for a struct variant, a field per
+	// element for a tuple variant, a single unnamed one otherwise, and none for a unit variant. They
+	// are nil for the variants of simple enums, whose variants have no fields at all.
+	Fields []Field `json:"fields"`
+}
+
+// Field returns the value of the struct field name.
+func (v Value) Field(name string) (Value, bool) {
+	for _, field := range v.Fields {
+		if field.Name == name {
+			return field.Value, true
+		}
+	}
+	return Value{}, false
 }
 
 // Constructors
@@ -74,9 +127,50 @@ func VList(list []Value) Value {
 	}
 }
 
-func VStruct(m map[string]Value) Value {
+func VStruct(fields []Field) Value {
 	return Value{
 		Kind:   ValueKindStruct,
-		Struct: m,
+		Fields: fields,
+	}
+}
+
+func VField(name string, value Value) Field {
+	return Field{
+		Name:  name,
+		Value: value,
+	}
+}
+
+func VVariant(name string, index uint8, fields []Field) Value {
+	return Value{
+		Kind:    ValueKindVariant,
+		Variant: &Variant{Name: name, Index: index, Fields: fields},
+	}
+}
+
+func VSome(value Value) Value {
+	return Value{
+		Kind:   ValueKindOption,
+		Option: &value,
+	}
+}
+
+func VNone() Value {
+	return Value{
+		Kind: ValueKindOption,
+	}
+}
+
+func VCompact(i *big.Int) Value {
+	return Value{
+		Kind: ValueKindCompact,
+		Int:  i,
+	}
+}
+
+func VBitSequence(bits []bool) Value {
+	return Value{
+		Kind: ValueKindBitSequence,
+		Bits: bits,
 	}
 }
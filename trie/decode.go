@@ -0,0 +1,160 @@
+package trie
+
+import (
+	"fmt"
+	"submarine/scale"
+)
+
+type nodeKind int
+
+const (
+	kindEmpty nodeKind = iota
+	kindLeaf
+	kindBranch
+)
+
+// node is a decoded trie node. Children are references: an inline node encoding if shorter than
+// 32 bytes, the hash of the node otherwise.
+type node struct {
+	kind    nodeKind
+	partial []byte
+	// hasValue is set for leaves and branches with a value. The value is either inline, or
+	// referenced by valueHash.
+	hasValue  bool
+	value     []byte
+	valueHash *[32]byte
+	children  [16][]byte
+}
+
+// decodeNode decodes a node encoded by the sp-trie node codec, in either state version.
+func decodeNode(data []byte) (*node, error) {
+	r := scale.NewReader(data)
+	first, err := r.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("node header: %w", err)
+	}
+
+	n := &node{}
+	var prefixBits int
+	hashedValue := false
+	switch {
+	case first == emptyNode:
+		if len(data) != 1 {
+			return nil, fmt.Errorf("empty node with %d trailing bytes", len(data)-1)
+		}
+		return &node{kind: kindEmpty}, nil
+	case first&0b1100_0000 == leafPrefix:
+		n.kind, n.hasValue, prefixBits = kindLeaf, true, leafPrefixBits
+	case first&0b1100_0000 == branchWithoutValuePrefix:
+		n.kind, prefixBits = kindBranch, branchPrefixBits
+	case first&0b1100_0000 == branchWithValuePrefix:
+		n.kind, n.hasValue, prefixBits = kindBranch, true, branchPrefixBits
+	case first&0b1110_0000 == leafWithHashedValuePrefix:
+		n.kind, n.hasValue, prefixBits = kindLeaf, true, leafWithHashedValuePrefixBits
+		hashedValue = true
+	case first&0b1111_0000 == branchWithHashedValuePrefix:
+		n.kind, n.hasValue, prefixBits = kindBranch, true, branchWithHashedValuePrefixBits
+		hashedValue = true
+	default:
+		return nil, fmt.Errorf("invalid node header 0x%02x", first)
+	}
+
+	nibbleCount, err := decodeNibbleCount(r, first, prefixBits)
+	if err != nil {
+		return nil, err
+	}
+	if n.partial, err = decodePartialKey(r, nibbleCount); err != nil {
+		return nil, err
+	}
+
+	var bitmap uint16
+	if n.kind == kindBranch {
+		b, err := r.ReadBytes(2)
+		if err != nil {
+			return nil, fmt.Errorf("branch bitmap: %w", err)
+		}
+		bitmap = uint16(b[0]) | uint16(b[1])<<8
+		if bitmap == 0 {
+			return nil, fmt.Errorf("branch without children")
+		}
+	}
+
+	if n.hasValue {
+		if hashedValue {
+			hash, err := r.ReadBytes(32)
+			if err != nil {
+				return nil, fmt.Errorf("value hash: %w", err)
+			}
+			n.valueHash = (*[32]byte)(hash)
+		} else {
+			if n.value, err = readLengthPrefixed(r); err != nil {
+				return nil, fmt.Errorf("value: %w", err)
+			}
+		}
+	}
+
+	for i := range n.children {
+		if bitmap&(1<<i) == 0 {
+			continue
+		}
+		child, err := readLengthPrefixed(r)
+		if err != nil {
+			return nil, fmt.Errorf("child %x: %w", i, err)
+		}
+		if len(child) > 32 {
+			return nil, fmt.Errorf("child %x: reference of %d bytes", i, len(child))
+		}
+		n.children[i] = child
+	}
+
+	if r.Remaining() != 0 {
+		return nil, fmt.Errorf("node with %d trailing bytes", r.Remaining())
+	}
+	return n, nil
+}
+
+// decodeNibbleCount reverses encodeHeader, reading the continuation bytes if the count doesn't
+// fit in the first byte.
+func decodeNibbleCount(r *scale.Reader, first byte, prefixBits int) (int, error) {
+	maxValue := 255 >> prefixBits
+	count := int(first) & maxValue
+	if count < maxValue {
+		return count, nil
+	}
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, fmt.Errorf("node header: %w", err)
+		}
+		count += int(b)
+		if b < 255 {
+			return count, nil
+		}
+	}
+}
+
+func decodePartialKey(r *scale.Reader, nibbleCount int) ([]byte, error) {
+	packed, err := r.ReadBytes((nibbleCount + 1) / 2)
+	if err != nil {
+		return nil, fmt.Errorf("partial key: %w", err)
+	}
+	out := nibbles(packed)
+	if nibbleCount%2 == 1 {
+		if out[0] != 0 {
+			return nil, fmt.Errorf("partial key: non-zero padding")
+		}
+		out = out[1:]
+	}
+	return out, nil
+}
+
+func readLengthPrefixed(r *scale.Reader) ([]byte, error) {
+	length, err := scale.DecodeCompact(r)
+	if err != nil {
+		return nil, err
+	}
+	if !length.IsUint64() || length.Uint64() > uint64(r.Remaining()) {
+		return nil, fmt.Errorf("length %s exceeds the %d bytes left", length, r.Remaining())
+	}
+	return r.ReadBytes(int(length.Uint64()))
+}
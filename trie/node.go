@@ -0,0 +1,166 @@
+// Package trie implements the base-16 Patricia-Merkle trie of Substrate, with Blake2-256 hashing
+// and the node codec of sp-trie, in both state versions.
+package trie
+
+import (
+	"fmt"
+	"submarine/crypto"
+	"submarine/scale"
+)
+
+// Layout is the trie state version. LayoutV1 stores values of HashedValueThreshold bytes or more
+// outside of their nodes, referenced by hash.
+type Layout int
+
+const (
+	LayoutV0 Layout = iota
+	LayoutV1
+)
+
+// HashedValueThreshold is the size from which LayoutV1 stores values by hash.
+const HashedValueThreshold = 33
+
+// EmptyRoot is the root of the empty trie, the hash of the empty node.
+var EmptyRoot = crypto.Blake2_256([]byte{emptyNode})
+
+// Node header prefixes, in the high bits of the first byte.
+const (
+	emptyNode                       = 0b0000_0000
+	leafPrefix                      = 0b01 << 6
+	branchWithoutValuePrefix        = 0b10 << 6
+	branchWithValuePrefix           = 0b11 << 6
+	leafWithHashedValuePrefix       = 0b001 << 5
+	branchWithHashedValuePrefix     = 0b0001 << 4
+	leafPrefixBits                  = 2
+	branchPrefixBits                = 2
+	leafWithHashedValuePrefixBits   = 3
+	branchWithHashedValuePrefixBits = 4
+)
+
+// nibbles returns the base-16 digits of key, most significant first.
+func nibbles(key []byte) []byte {
+	out := make([]byte, 0, len(key)*2)
+	for _, b := range key {
+		out = append(out, b>>4, b&0x0f)
+	}
+	return out
+}
+
+// encodeHeader writes a node header: the prefix and the number of nibbles of the partial key.
+// Counts that don't fit in the bits left by the prefix continue in the following bytes.
+func encodeHeader(prefix byte, prefixBits int, nibbleCount int) []byte {
+	maxValue := 255 >> prefixBits
+	if nibbleCount < maxValue {
+		return []byte{prefix | byte(nibbleCount)}
+	}
+
+	out := []byte{prefix | byte(maxValue)}
+	rem := nibbleCount - maxValue
+	for rem >= 255 {
+		out = append(out, 255)
+		rem -= 255
+	}
+	return append(out, byte(rem))
+}
+
+// encodePartialKey packs nibbles two per byte. An odd leading nibble gets a byte of its own.
+func encodePartialKey(partial []byte) []byte {
+	out := make([]byte, 0, (len(partial)+1)/2)
+	if len(partial)%2 == 1 {
+		out = append(out, partial[0])
+		partial = partial[1:]
+	}
+	for i := 0; i < len(partial); i += 2 {
+		out = append(out, partial[i]<<4|partial[i+1])
+	}
+	return out
+}
+
+// hashesValue reports whether the layout stores value by hash.
+func (l Layout) hashesValue(value []byte) bool {
+	return l == LayoutV1 && len(value) >= HashedValueThreshold
+}
+
+// The encoders below add every node and value they reference by hash to db, unless it is nil.
+type nodeDB map[[32]byte][]byte
+
+func (db nodeDB) add(data []byte) [32]byte {
+	hash := crypto.Blake2_256(data)
+	if db != nil {
+		db[hash] = data
+	}
+	return hash
+}
+
+func encodeLeaf(partial, value []byte, layout Layout, db nodeDB) []byte {
+	var out []byte
+	if layout.hashesValue(value) {
+		out = encodeHeader(leafWithHashedValuePrefix, leafWithHashedValuePrefixBits, len(partial))
+	} else {
+		out = encodeHeader(leafPrefix, leafPrefixBits, len(partial))
+	}
+	out = append(out, encodePartialKey(partial)...)
+	return appendValue(out, value, layout, db)
+}
+
+// encodeBranch encodes a branch from its already encoded children. value is nil for branches without one.
+func encodeBranch(partial []byte, value []byte, children *[16][]byte, layout Layout, db nodeDB) []byte {
+	var out []byte
+	switch {
+	case value == nil:
+		out = encodeHeader(branchWithoutValuePrefix, branchPrefixBits, len(partial))
+	case layout.hashesValue(value):
+		out = encodeHeader(branchWithHashedValuePrefix, branchWithHashedValuePrefixBits, len(partial))
+	default:
+		out = encodeHeader(branchWithValuePrefix, branchPrefixBits, len(partial))
+	}
+	out = append(out, encodePartialKey(partial)...)
+
+	var bitmap uint16
+	for i, child := range children {
+		if child != nil {
+			bitmap |= 1 << i
+		}
+	}
+	out = append(out, byte(bitmap), byte(bitmap>>8))
+
+	if value != nil {
+		out = appendValue(out, value, layout, db)
+	}
+
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+		// Children shorter than a hash are stored inline.
+		if len(child) < 32 {
+			out = append(out, scale.EncodeCompactU64(uint64(len(child)))...)
+			out = append(out, child...)
+		} else {
+			hash := db.add(child)
+			out = append(out, scale.EncodeCompactU64(32)...)
+			out = append(out, hash[:]...)
+		}
+	}
+	return out
+}
+
+func appendValue(out, value []byte, layout Layout, db nodeDB) []byte {
+	if layout.hashesValue(value) {
+		hash := db.add(value)
+		return append(out, hash[:]...)
+	}
+	out = append(out, scale.EncodeCompactU64(uint64(len(value)))...)
+	return append(out, value...)
+}
+
+func (l Layout) String() string {
+	switch l {
+	case LayoutV0:
+		return "V0"
+	case LayoutV1:
+		return "V1"
+	default:
+		return fmt.Sprintf("Layout(%d)", int(l))
+	}
+}
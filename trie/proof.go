@@ -0,0 +1,121 @@
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrIncompleteProof is returned when a proof lacks a node or value needed to look up a key.
+var ErrIncompleteProof = errors.New("incomplete proof")
+
+// VerifyProof looks up keys in the trie with the given root, using only the nodes of proof, as
+// returned by state_getReadProof. It returns the value of each key, in order, or nil if the proof
+// shows the key is absent. Both state versions are supported; values stored by hash must be part
+// of the proof.
+func VerifyProof(root [32]byte, proof [][]byte, keys [][]byte) ([][]byte, error) {
+	db := make(nodeDB, len(proof))
+	for _, data := range proof {
+		db.add(data)
+	}
+
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		value, err := lookup(db, root, key, nil)
+		if err != nil {
+			return nil, fmt.Errorf("key 0x%x: %w", key, err)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// Prove returns the proof of keys in the trie holding entries: the nodes and values needed to
+// look them up from the root, whether they are present or not.
+func Prove(entries []KeyValue, layout Layout, keys [][]byte) ([][]byte, error) {
+	db := make(nodeDB)
+	root := build(entries, layout, db)
+
+	used := make(nodeDB)
+	for _, key := range keys {
+		if _, err := lookup(db, root, key, used); err != nil {
+			return nil, fmt.Errorf("key 0x%x: %w", key, err)
+		}
+	}
+
+	proof := make([][]byte, 0, len(used))
+	for _, data := range used {
+		proof = append(proof, data)
+	}
+	slices.SortFunc(proof, bytes.Compare)
+	return proof, nil
+}
+
+// lookup returns the value of key in the trie with the given root, or nil if it is absent.
+// Entries of db read during the lookup are added to used, unless it is nil.
+func lookup(db nodeDB, root [32]byte, key []byte, used nodeDB) ([]byte, error) {
+	data, err := fetch(db, root, used)
+	if err != nil {
+		return nil, fmt.Errorf("root: %w", err)
+	}
+
+	remaining := nibbles(key)
+	for depth := 0; ; depth++ {
+		n, err := decodeNode(data)
+		if err != nil {
+			return nil, fmt.Errorf("node at depth %d: %w", depth, err)
+		}
+		if n.kind == kindEmpty {
+			if depth > 0 {
+				return nil, errors.New("empty node below the root")
+			}
+			return nil, nil
+		}
+		if !bytes.HasPrefix(remaining, n.partial) {
+			return nil, nil
+		}
+		remaining = remaining[len(n.partial):]
+
+		if len(remaining) == 0 {
+			if !n.hasValue {
+				return nil, nil
+			}
+			if n.valueHash != nil {
+				value, err := fetch(db, *n.valueHash, used)
+				if err != nil {
+					return nil, fmt.Errorf("value: %w", err)
+				}
+				return value, nil
+			}
+			return n.value, nil
+		}
+		if n.kind == kindLeaf {
+			return nil, nil
+		}
+
+		child := n.children[remaining[0]]
+		remaining = remaining[1:]
+		switch {
+		case child == nil:
+			return nil, nil
+		case len(child) == 32:
+			if data, err = fetch(db, [32]byte(child), used); err != nil {
+				return nil, fmt.Errorf("node at depth %d: %w", depth+1, err)
+			}
+		default:
+			data = child
+		}
+	}
+}
+
+func fetch(db nodeDB, hash [32]byte, used nodeDB) ([]byte, error) {
+	data, ok := db[hash]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing 0x%x", ErrIncompleteProof, hash)
+	}
+	if used != nil {
+		used[hash] = data
+	}
+	return data, nil
+}
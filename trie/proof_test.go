@@ -0,0 +1,86 @@
+package trie_test
+
+import (
+	"bytes"
+	"errors"
+	. "submarine/trie"
+	"testing"
+)
+
+func proofEntries() []KeyValue {
+	return []KeyValue{
+		{[]byte{0xaa}, []byte{0x01}},
+		{[]byte{0xaa, 0xbb}, []byte{0x02}},
+		{[]byte{0xaa, 0xbc}, bytes.Repeat([]byte{0x03}, 40)},
+		{[]byte{0x13, 0x14}, bytes.Repeat([]byte{0x04}, 100)},
+		{[]byte{0x48, 0x19}, []byte{}},
+	}
+}
+
+func TestVerifyProof(t *testing.T) {
+	entries := proofEntries()
+	keys := [][]byte{{0xaa}, {0xaa, 0xbc}, {0x13, 0x14}, {0x48, 0x19}, {0xaa, 0xbd}, {0x13}, {0xff}}
+	want := [][]byte{entries[0].Value, entries[2].Value, entries[3].Value, {}, nil, nil, nil}
+
+	for _, layout := range []Layout{LayoutV0, LayoutV1} {
+		t.Run(layout.String(), func(t *testing.T) {
+			root := Root(entries, layout)
+			proof, err := Prove(entries, layout, keys)
+			if err != nil {
+				t.Fatalf("prove: %v", err)
+			}
+
+			values, err := VerifyProof(root, proof, keys)
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			for i := range keys {
+				if !bytes.Equal(values[i], want[i]) || (values[i] == nil) != (want[i] == nil) {
+					t.Errorf("key %x = %x, want %x", keys[i], values[i], want[i])
+				}
+			}
+
+			// A proof for one key doesn't prove the others.
+			partial, _ := Prove(entries, layout, keys[:1])
+			if _, err := VerifyProof(root, partial, keys[1:3]); !errors.Is(err, ErrIncompleteProof) {
+				t.Errorf("partial proof: error = %v, want %v", err, ErrIncompleteProof)
+			}
+
+			// Proofs don't verify against another root.
+			other := Root(entries[1:], layout)
+			if _, err := VerifyProof(other, proof, keys); !errors.Is(err, ErrIncompleteProof) {
+				t.Errorf("other root: error = %v, want %v", err, ErrIncompleteProof)
+			}
+		})
+	}
+}
+
+func TestVerifyProofTampered(t *testing.T) {
+	entries := proofEntries()
+	keys := [][]byte{{0xaa, 0xbb}}
+	root := Root(entries, LayoutV1)
+	proof, _ := Prove(entries, LayoutV1, keys)
+
+	for i := range proof {
+		tampered := make([][]byte, len(proof))
+		copy(tampered, proof)
+		tampered[i] = bytes.Clone(proof[i])
+		tampered[i][len(tampered[i])-1] ^= 0x01
+
+		// The tampered node no longer hashes to its reference, so the lookup can't reach it.
+		if _, err := VerifyProof(root, tampered, keys); !errors.Is(err, ErrIncompleteProof) {
+			t.Errorf("tampered node %d: error = %v, want %v", i, err, ErrIncompleteProof)
+		}
+	}
+}
+
+func TestVerifyProofEmptyTrie(t *testing.T) {
+	proof, err := Prove(nil, LayoutV0, [][]byte{{0x01}})
+	if err != nil {
+		t.Fatalf("prove: %v", err)
+	}
+	values, err := VerifyProof(EmptyRoot, proof, [][]byte{{0x01}})
+	if err != nil || values[0] != nil {
+		t.Errorf("values = %x, error = %v", values, err)
+	}
+}
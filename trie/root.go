@@ -0,0 +1,92 @@
+package trie
+
+import (
+	"bytes"
+	"slices"
+	"submarine/scale"
+)
+
+// KeyValue is an entry of a trie.
+type KeyValue struct {
+	Key   []byte
+	Value []byte
+}
+
+// Root returns the root hash of the trie holding entries. Keys must be unique; the order of
+// entries doesn't matter.
+func Root(entries []KeyValue, layout Layout) [32]byte {
+	if len(entries) == 0 {
+		return EmptyRoot
+	}
+
+	return build(entries, layout, nil)
+}
+
+// build returns the root hash of the trie holding entries, adding its hashed nodes and values to db.
+func build(entries []KeyValue, layout Layout, db nodeDB) [32]byte {
+	if len(entries) == 0 {
+		return db.add([]byte{emptyNode})
+	}
+
+	sorted := slices.Clone(entries)
+	slices.SortFunc(sorted, func(a, b KeyValue) int { return bytes.Compare(a.Key, b.Key) })
+
+	nibbleEntries := make([]nibbleEntry, len(sorted))
+	for i, entry := range sorted {
+		nibbleEntries[i] = nibbleEntry{key: nibbles(entry.Key), value: entry.Value}
+	}
+	return db.add(buildNode(nibbleEntries, 0, layout, db))
+}
+
+// OrderedRoot returns the root of the trie mapping the compact-encoded index of each value to
+// the value, as used for the extrinsics root of a block.
+func OrderedRoot(values [][]byte, layout Layout) [32]byte {
+	entries := make([]KeyValue, len(values))
+	for i, value := range values {
+		entries[i] = KeyValue{Key: scale.EncodeCompactU64(uint64(i)), Value: value}
+	}
+	return Root(entries, layout)
+}
+
+type nibbleEntry struct {
+	key   []byte
+	value []byte
+}
+
+// buildNode encodes the node holding entries, which are sorted and share the first depth nibbles.
+func buildNode(entries []nibbleEntry, depth int, layout Layout, db nodeDB) []byte {
+	if len(entries) == 1 {
+		return encodeLeaf(entries[0].key[depth:], entries[0].value, layout, db)
+	}
+
+	// The partial key of the branch is the prefix shared by all entries. As entries are sorted,
+	// it is the prefix shared by the first and last.
+	first, last := entries[0].key, entries[len(entries)-1].key
+	end := depth
+	for end < len(first) && end < len(last) && first[end] == last[end] {
+		end++
+	}
+
+	var value []byte
+	if len(first) == end {
+		// The shortest key sorts first; if it ends at the branch, the branch holds its value.
+		value = entries[0].value
+		if value == nil {
+			value = []byte{}
+		}
+		entries = entries[1:]
+	}
+
+	var children [16][]byte
+	for start := 0; start < len(entries); {
+		nibble := entries[start].key[end]
+		stop := start + 1
+		for stop < len(entries) && entries[stop].key[end] == nibble {
+			stop++
+		}
+		children[nibble] = buildNode(entries[start:stop], end+1, layout, db)
+		start = stop
+	}
+
+	return encodeBranch(first[depth:end], value, &children, layout, db)
+}
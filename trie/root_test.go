@@ -0,0 +1,125 @@
+package trie_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"submarine/crypto"
+	. "submarine/trie"
+	"testing"
+)
+
+func TestEmptyRoot(t *testing.T) {
+	expected := "03170a2e7597b7b7e3d84c05391d139a62b157e78786d8c082f29dcf4c111314"
+	for _, layout := range []Layout{LayoutV0, LayoutV1} {
+		root := Root(nil, layout)
+		if got := hex.EncodeToString(root[:]); got != expected {
+			t.Errorf("%s: expected %s, got %s", layout, expected, got)
+		}
+		if root != EmptyRoot || OrderedRoot(nil, layout) != EmptyRoot {
+			t.Errorf("%s: root of no entries is not EmptyRoot", layout)
+		}
+	}
+}
+
+// The expected root nodes below are the sp-trie codec test vectors; the root is their hash.
+func TestRoot(t *testing.T) {
+	longValue := bytes.Repeat([]byte{0xcc}, 33)
+	longValueHash := crypto.Blake2_256(longValue)
+
+	tests := []struct {
+		name     string
+		entries  []KeyValue
+		layout   Layout
+		rootNode []byte
+	}{
+		{
+			name:    "single leaf",
+			entries: []KeyValue{{[]byte{0xaa}, []byte{0xbb}}},
+			layout:  LayoutV0,
+			// leaf with 2 nibbles, key, compact value length, value
+			rootNode: []byte{0x42, 0xaa, 0x04, 0xbb},
+		},
+		{
+			name:    "disjoint keys",
+			entries: []KeyValue{{[]byte{0x48, 0x19}, []byte{0xfe}}, {[]byte{0x13, 0x14}, []byte{0xff}}},
+			layout:  LayoutV0,
+			rootNode: []byte{
+				0x80,       // branch without value, no partial key
+				0x12, 0x00, // children at 1 and 4
+				0x14, 0x43, 0x03, 0x14, 0x04, 0xff, // inline leaf 0x1314 -> 0xff, 3 nibbles after the branch
+				0x14, 0x43, 0x08, 0x19, 0x04, 0xfe, // inline leaf 0x4819 -> 0xfe
+			},
+		},
+		{
+			name:    "branch with value",
+			entries: []KeyValue{{[]byte{0xaa}, []byte{0x01}}, {[]byte{0xaa, 0xbb}, []byte{0x02}}},
+			layout:  LayoutV0,
+			rootNode: []byte{
+				0xc2, 0xaa, // branch with value, 2 nibbles
+				0x00, 0x08, // child at 11
+				0x04, 0x01, // value
+				0x10, 0x41, 0x0b, 0x04, 0x02, // inline leaf with 1 nibble
+			},
+		},
+		{
+			name:     "short value is inline in V1",
+			entries:  []KeyValue{{[]byte{0xaa}, bytes.Repeat([]byte{0xcc}, 32)}},
+			layout:   LayoutV1,
+			rootNode: append([]byte{0x42, 0xaa, 0x80}, bytes.Repeat([]byte{0xcc}, 32)...),
+		},
+		{
+			name:     "long value is inline in V0",
+			entries:  []KeyValue{{[]byte{0xaa}, longValue}},
+			layout:   LayoutV0,
+			rootNode: append([]byte{0x42, 0xaa, 0x84}, longValue...),
+		},
+		{
+			name:    "long value is hashed in V1",
+			entries: []KeyValue{{[]byte{0xaa}, longValue}},
+			layout:  LayoutV1,
+			// leaf with hashed value, 2 nibbles, key, value hash
+			rootNode: append([]byte{0x22, 0xaa}, longValueHash[:]...),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := crypto.Blake2_256(tt.rootNode)
+			if root := Root(tt.entries, tt.layout); root != expected {
+				t.Errorf("expected %x, got %x", expected, root)
+			}
+		})
+	}
+}
+
+func TestRootLongPartialKey(t *testing.T) {
+	// A 64-nibble partial key overflows the 6 bits of the leaf header: 63 in the first byte,
+	// the remaining 1 in the next.
+	key := bytes.Repeat([]byte{0x12}, 32)
+	rootNode := append([]byte{0x7f, 0x01}, key...)
+	rootNode = append(rootNode, 0x04, 0x01)
+
+	expected := crypto.Blake2_256(rootNode)
+	if root := Root([]KeyValue{{key, []byte{0x01}}}, LayoutV0); root != expected {
+		t.Errorf("expected %x, got %x", expected, root)
+	}
+}
+
+func TestRootIgnoresOrder(t *testing.T) {
+	entries := []KeyValue{
+		{[]byte("alice"), []byte{1}},
+		{[]byte("bob"), []byte{2}},
+		{[]byte("al"), []byte{3}},
+		{[]byte("charlie"), bytes.Repeat([]byte{4}, 40)},
+	}
+	reversed := []KeyValue{entries[3], entries[2], entries[1], entries[0]}
+
+	for _, layout := range []Layout{LayoutV0, LayoutV1} {
+		if Root(entries, layout) != Root(reversed, layout) {
+			t.Errorf("%s: root depends on entry order", layout)
+		}
+	}
+	if Root(entries, LayoutV0) == Root(entries, LayoutV1) {
+		t.Error("V0 and V1 roots match despite a hashed value")
+	}
+}
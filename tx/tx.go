@@ -0,0 +1,85 @@
+// Package tx follows submitted extrinsics into blocks and reports their outcome.
+package tx
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	. "submarine/decoder/models"
+	v14_decoder "submarine/decoder/v14"
+	"submarine/metadata/generated/v14"
+	"submarine/rpc"
+)
+
+// ErrNotIncluded is returned when a watched extrinsic leaves the pool without being included in a block.
+var ErrNotIncluded = errors.New("extrinsic not included in a block")
+
+// Result is the outcome of an extrinsic included in a block.
+type Result struct {
+	BlockHash string
+	BlockExtrinsic
+}
+
+// FetchResult looks up extrinsic in the given block and reports its outcome from the block's events.
+func FetchResult(client *rpc.RPC, metadata *v14.Metadata, blockHash string, extrinsic []byte) (*Result, error) {
+	block, err := client.GetBlock(blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	index := -1
+	extrinsicHex := hex.EncodeToString(extrinsic)
+	for i, ext := range block.Block.Extrinsics {
+		if strings.EqualFold(strings.TrimPrefix(ext, "0x"), extrinsicHex) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("extrinsic not found in block %s", blockHash)
+	}
+
+	eventBytes, err := client.GetStorage(rpc.SYSTEM_EVENT_KEY, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := v14_decoder.DecodeBlock(metadata, block, eventBytes)
+	if err != nil {
+		return nil, fmt.Errorf("decode block %s: %w", blockHash, err)
+	}
+
+	result := &Result{BlockHash: blockHash, BlockExtrinsic: decoded.Extrinsics[index]}
+	if result.DispatchInfo == nil {
+		return nil, fmt.Errorf("no ExtrinsicSuccess or ExtrinsicFailed event for extrinsic %d in block %s", index, blockHash)
+	}
+	return result, nil
+}
+
+// SubmitAndWait submits extrinsic, waits until it is included in a block and returns its outcome.
+// The watch is ended once the block is known, without waiting for finality.
+func SubmitAndWait(client *rpc.RPC, metadata *v14.Metadata, extrinsic []byte) (*Result, error) {
+	watch, err := client.SubmitAndWatch(extrinsic)
+	if err != nil {
+		return nil, fmt.Errorf("submit extrinsic: %w", err)
+	}
+	return WaitForResult(client, metadata, watch, extrinsic)
+}
+
+// WaitForResult consumes the status updates of watch until the extrinsic is included in a block,
+// then fetches its outcome. It fails with ErrNotIncluded if the extrinsic is dropped, usurped or invalid.
+func WaitForResult(client *rpc.RPC, metadata *v14.Metadata, watch *rpc.TransactionWatch, extrinsic []byte) (*Result, error) {
+	for status := range watch.Status() {
+		switch status.Kind {
+		case rpc.TxStatusInBlock, rpc.TxStatusFinalized:
+			watch.Unsubscribe()
+			return FetchResult(client, metadata, status.Hash, extrinsic)
+		case rpc.TxStatusDropped, rpc.TxStatusUsurped, rpc.TxStatusInvalid:
+			return nil, fmt.Errorf("%w: %s", ErrNotIncluded, status.Kind)
+		}
+	}
+	if err := watch.Err(); err != nil {
+		return nil, fmt.Errorf("watch extrinsic: %w", err)
+	}
+	return nil, fmt.Errorf("watch extrinsic: subscription ended before inclusion")
+}
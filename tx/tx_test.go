@@ -0,0 +1,143 @@
+package tx_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"submarine/chain"
+	"submarine/decoder/v14/v14test"
+	"submarine/rpc"
+	"submarine/rpc/rpctest"
+	"submarine/trie"
+	. "submarine/tx"
+	"testing"
+)
+
+const blockHash = "0xb10c"
+
+var (
+	inherent  = []byte{0x10, 0x04, 0x00, 0x00, 0x00}
+	extrinsic = []byte{0x1c, 0x04, 0x00, 0x00, 0x0c, 0x01, 0x02, 0x03}
+	account   = make([]byte, 32)
+)
+
+// newNode returns a node serving a block whose second extrinsic is `extrinsic`, with the given events.
+func newNode(t *testing.T, events []byte, statuses ...any) *rpc.RPC {
+	t.Helper()
+	node := rpctest.NewNode()
+	t.Cleanup(node.Close)
+
+	root := chain.ExtrinsicsRoot([][]byte{inherent, extrinsic}, trie.LayoutV0)
+	var block rpc.SignedBlock
+	block.Block.Header.ExtrinsicsRoot = "0x" + hex.EncodeToString(root[:])
+	block.Block.Extrinsics = []string{"0x" + hex.EncodeToString(inherent), "0x" + hex.EncodeToString(extrinsic)}
+	node.HandleResult("chain_getBlock", block)
+	node.Handle("state_getStorage", func(params []json.RawMessage) (any, error) {
+		var key, hash string
+		json.Unmarshal(params[0], &key)
+		json.Unmarshal(params[1], &hash)
+		if key != rpc.SYSTEM_EVENT_KEY || hash != blockHash {
+			return nil, nil
+		}
+		return "0x" + hex.EncodeToString(events), nil
+	})
+	node.HandleResult("author_unwatchExtrinsic", true)
+	node.HandleSubscription("author_submitAndWatchExtrinsic", "author_extrinsicUpdate", func([]json.RawMessage) ([]any, error) {
+		return statuses, nil
+	})
+
+	client, err := rpc.NewRPC(node.URL())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+func successEvents() []byte {
+	return v14test.Events(
+		v14test.EventRecord(v14test.ApplyExtrinsic(0), v14test.SystemIndex, 0, v14test.DispatchInfo(1, 2, 2, 0)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(1), v14test.BalancesIndex, 7, account, v14test.U128(100)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(1), v14test.TransactionPaymentIndex, 0, account, v14test.U128(100), v14test.U128(0)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(1), v14test.SystemIndex, 0, v14test.DispatchInfo(10, 20, 0, 0)),
+		v14test.EventRecord(v14test.Finalization(), v14test.BalancesIndex, 7, account, v14test.U128(1)),
+	)
+}
+
+func failedEvents() []byte {
+	// DispatchError::Module { index: 5, error: [2, 0, 0, 0] }
+	dispatchError := []byte{3, 5, 2, 0, 0, 0}
+	return v14test.Events(
+		v14test.EventRecord(v14test.ApplyExtrinsic(0), v14test.SystemIndex, 0, v14test.DispatchInfo(1, 2, 2, 0)),
+		v14test.EventRecord(v14test.ApplyExtrinsic(1), v14test.SystemIndex, 1, dispatchError, v14test.DispatchInfo(10, 20, 0, 0)),
+	)
+}
+
+func TestFetchResultSuccess(t *testing.T) {
+	client := newNode(t, successEvents())
+
+	result, err := FetchResult(client, v14test.NewMetadata(), blockHash, extrinsic)
+	if err != nil {
+		t.Fatalf("fetch result: %v", err)
+	}
+	if !result.Success || result.DispatchError != nil {
+		t.Errorf("expected success, got %+v", result)
+	}
+	if result.Index != 1 || result.BlockHash != blockHash {
+		t.Errorf("got index %d in %s, want 1 in %s", result.Index, result.BlockHash, blockHash)
+	}
+
+	var names []string
+	for _, record := range result.Events {
+		names = append(names, record.Event.PalletName+"."+record.Event.EventName)
+	}
+	want := []string{"Balances.Withdraw", "TransactionPayment.TransactionFeePaid", "System.ExtrinsicSuccess"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("events = %v, want %v", names, want)
+	}
+}
+
+func TestFetchResultFailed(t *testing.T) {
+	client := newNode(t, failedEvents())
+
+	result, err := FetchResult(client, v14test.NewMetadata(), blockHash, extrinsic)
+	if err != nil {
+		t.Fatalf("fetch result: %v", err)
+	}
+	if result.Success {
+		t.Fatal("expected failure")
+	}
+	if result.DispatchError == nil || result.DispatchError.Error() != "Balances.InsufficientBalance" {
+		t.Errorf("dispatch error = %v, want Balances.InsufficientBalance", result.DispatchError)
+	}
+}
+
+func TestFetchResultNotInBlock(t *testing.T) {
+	client := newNode(t, successEvents())
+
+	if _, err := FetchResult(client, v14test.NewMetadata(), blockHash, []byte{0xff}); err == nil {
+		t.Fatal("expected an error for an extrinsic missing from the block")
+	}
+}
+
+func TestSubmitAndWait(t *testing.T) {
+	client := newNode(t, successEvents(), "ready", map[string]any{"broadcast": []string{"peer"}}, map[string]any{"inBlock": blockHash})
+
+	result, err := SubmitAndWait(client, v14test.NewMetadata(), extrinsic)
+	if err != nil {
+		t.Fatalf("submit and wait: %v", err)
+	}
+	if !result.Success || result.Index != 1 {
+		t.Errorf("got %+v, want success at index 1", result)
+	}
+}
+
+func TestSubmitAndWaitDropped(t *testing.T) {
+	client := newNode(t, successEvents(), "ready", "dropped")
+
+	_, err := SubmitAndWait(client, v14test.NewMetadata(), extrinsic)
+	if !errors.Is(err, ErrNotIncluded) {
+		t.Fatalf("expected ErrNotIncluded, got %v", err)
+	}
+}